@@ -3,7 +3,6 @@ package auth
 import (
 	"fmt"
 	"log/slog"
-	"strings"
 )
 
 type Auth struct {
@@ -23,6 +22,13 @@ func New(config Config, logger *slog.Logger) *Auth {
 	}
 }
 
+// RootUsername is the username of the root account from the config.
+func (a *Auth) RootUsername() string {
+	return a.cfg.RootUsername
+}
+
+// ValidateCredentials checks credentials against the root account from the
+// config, which is always an admin so there's someone to add the other GMs.
 func (a *Auth) ValidateCredentials(creds Credentials) (*Token, error) {
 	// Validate username
 	if creds.Username != a.cfg.RootUsername {
@@ -43,7 +49,7 @@ func (a *Auth) ValidateCredentials(creds Credentials) (*Token, error) {
 	}
 
 	// Generate JWT on successful validation with GM role
-	token, err := a.NewToken(creds.Username, RoleGM)
+	token, err := a.NewGMToken(creds.Username, true)
 	if err != nil {
 		a.logger.Error("failed to generate token", "error", err)
 		return nil, fmt.Errorf("failed to generate token: %w", err)
@@ -51,22 +57,3 @@ func (a *Auth) ValidateCredentials(creds Credentials) (*Token, error) {
 
 	return token, nil
 }
-
-func (a *Auth) GetJoinToken() string {
-	return a.cfg.JoinToken
-}
-
-func (a *Auth) ValidateJoinToken(joinToken string) (*Token, error) {
-	if !strings.EqualFold(joinToken, a.cfg.JoinToken) {
-		a.logger.Debug("invalid join token")
-		return nil, ErrInvalidJoinToken
-	}
-
-	token, err := a.NewToken("player", RolePlayer)
-	if err != nil {
-		a.logger.Error("failed to generate player token", "error", err)
-		return nil, fmt.Errorf("failed to generate player token: %w", err)
-	}
-
-	return token, nil
-}
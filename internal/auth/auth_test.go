@@ -19,7 +19,6 @@ func TestValidateCredentials(t *testing.T) {
 		TokenDuration:  time.Hour,
 		TokenIssuer:    "test-issuer",
 		TokenAudience:  "test-audience",
-		JoinToken:      "test-join-token",
 	}, slog.Default())
 
 	tests := []struct {
@@ -78,74 +77,13 @@ func TestValidateCredentials(t *testing.T) {
 			}
 
 			// Verify token is valid and contains correct claims
-			_, err = auth.ValidateToken(token.String())
+			validated, err := auth.ValidateToken(token.String())
 			if err != nil {
 				t.Errorf("Failed to validate generated token: %v", err)
 				return
 			}
-		})
-	}
-}
-
-func TestValidateJoinToken(t *testing.T) {
-	auth := New(Config{
-		TokenSecret:   "test-secret",
-		TokenDuration: time.Hour,
-		TokenIssuer:   "test-issuer",
-		TokenAudience: "test-audience",
-		JoinToken:     "test-join-token",
-	}, slog.Default())
-
-	tests := []struct {
-		name    string
-		token   string
-		wantErr error
-	}{
-		{
-			name:    "Valid join token",
-			token:   "test-join-token",
-			wantErr: nil,
-		},
-		{
-			name:    "Invalid join token",
-			token:   "wrong-token",
-			wantErr: ErrInvalidJoinToken,
-		},
-		{
-			name:    "Empty join token",
-			token:   "",
-			wantErr: ErrInvalidJoinToken,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			token, err := auth.ValidateJoinToken(tt.token)
-
-			if tt.wantErr != nil {
-				if err != tt.wantErr {
-					t.Errorf("ValidateJoinToken() error = %v, wantErr %v", err, tt.wantErr)
-				}
-				if token != nil {
-					t.Errorf("ValidateJoinToken() token = %v, want nil on error", token)
-				}
-				return
-			}
-
-			if err != nil {
-				t.Errorf("ValidateJoinToken() unexpected error: %v", err)
-				return
-			}
-
-			// Verify token is valid and contains correct claims
-			validatedToken, err := auth.ValidateToken(token.String())
-			if err != nil {
-				t.Errorf("Failed to validate generated token: %v", err)
-				return
-			}
-
-			if validatedToken.Role != RolePlayer {
-				t.Errorf("ValidateJoinToken() role = %v, want %v", validatedToken.Role, RolePlayer)
+			if validated.Role != RoleGM || !validated.Admin {
+				t.Errorf("expected the root account to get an admin GM token; got role %v, admin %v", validated.Role, validated.Admin)
 			}
 		})
 	}
@@ -3,11 +3,11 @@ package auth
 import "time"
 
 type Config struct {
-	RootUsername   string
-	HashedPassword string
-	TokenSecret    string
-	TokenDuration  time.Duration
-	TokenIssuer    string
-	TokenAudience  string
-	JoinToken      string
+	RootUsername      string
+	HashedPassword    string
+	TokenSecret       string
+	TokenDuration     time.Duration
+	TokenIssuer       string
+	TokenAudience     string
+	StreamURLDuration time.Duration
 }
@@ -5,4 +5,6 @@ import "errors"
 var (
 	ErrInvalidCredentials = errors.New("invalid credentials")
 	ErrInvalidJoinToken   = errors.New("invalid join token")
+	ErrInvalidSignature   = errors.New("invalid URL signature")
+	ErrSignatureExpired   = errors.New("URL signature expired")
 )
@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+)
+
+const joinSecretLength = 32
+
+// NewJoinSecret generates the secret half of a join token. Only its hash is
+// kept, so the secret can't be read back once it's handed out.
+func NewJoinSecret() (string, error) {
+	secret := make([]byte, joinSecretLength)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate join secret: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// HashJoinSecret hashes a join secret for storage. The secrets are random, so
+// a fast hash is enough.
+func HashJoinSecret(secret string) []byte {
+	hash := sha256.Sum256([]byte(secret))
+	return hash[:]
+}
+
+// JoinSecretMatches reports whether secret hashes to hash, taking the same
+// time whether or not it does.
+func JoinSecretMatches(secret string, hash []byte) bool {
+	return subtle.ConstantTimeCompare(HashJoinSecret(secret), hash) == 1
+}
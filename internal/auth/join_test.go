@@ -0,0 +1,27 @@
+package auth
+
+import "testing"
+
+func TestJoinSecret(t *testing.T) {
+	secret, err := NewJoinSecret()
+	if err != nil {
+		t.Fatalf("NewJoinSecret() error = %v", err)
+	}
+	other, err := NewJoinSecret()
+	if err != nil {
+		t.Fatalf("NewJoinSecret() error = %v", err)
+	}
+	if secret == other {
+		t.Errorf("expected different secrets; got %q twice", secret)
+	}
+
+	hash := HashJoinSecret(secret)
+	if !JoinSecretMatches(secret, hash) {
+		t.Errorf("expected %q to match its hash", secret)
+	}
+	for _, wrong := range []string{other, "", secret[:len(secret)-1]} {
+		if JoinSecretMatches(wrong, hash) {
+			t.Errorf("expected %q not to match", wrong)
+		}
+	}
+}
@@ -15,7 +15,13 @@ const (
 )
 
 type Claims struct {
-	Role Role `json:"role"`
+	Role  Role `json:"role"`
+	Admin bool `json:"admin,omitempty"`
+	// JoinTokenID is the join token a player redeemed for the token.
+	JoinTokenID string `json:"jtid,omitempty"`
+	// Name and Color are the display name and avatar color a player chose.
+	Name  string `json:"name,omitempty"`
+	Color string `json:"color,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -23,6 +29,25 @@ type Token struct {
 	token     string
 	ExpiresAt time.Time
 	Role      Role
+	// Admin is set for GMs who may manage user accounts.
+	Admin bool
+	// Subject is who the token was issued to: the GM's user ID, the root
+	// username for the root account, or the player's ID for players.
+	Subject string
+	// JoinTokenID is the join token a player redeemed for the token, so the
+	// token can be refused once the join token is revoked.
+	JoinTokenID string
+	// Name and Color are the display name and optional avatar color a player
+	// chose when joining.
+	Name  string
+	Color string
+}
+
+// Player identifies a player redeeming a join token.
+type Player struct {
+	ID    string
+	Name  string
+	Color string
 }
 
 func (a Token) String() string {
@@ -30,17 +55,35 @@ func (a Token) String() string {
 }
 
 func (a *Auth) NewToken(subject string, role Role) (*Token, error) {
+	return a.newToken(subject, Claims{Role: role})
+}
+
+// NewGMToken issues a GM token, which lets the GM manage user accounts if
+// admin is set.
+func (a *Auth) NewGMToken(subject string, admin bool) (*Token, error) {
+	return a.newToken(subject, Claims{Role: RoleGM, Admin: admin})
+}
+
+// NewPlayerToken issues a player token to a player who redeemed the given
+// join token. The token's subject is the player's ID.
+func (a *Auth) NewPlayerToken(joinTokenID string, player Player) (*Token, error) {
+	return a.newToken(player.ID, Claims{
+		Role:        RolePlayer,
+		JoinTokenID: joinTokenID,
+		Name:        player.Name,
+		Color:       player.Color,
+	})
+}
+
+func (a *Auth) newToken(subject string, claims Claims) (*Token, error) {
 	now := time.Now()
-	claims := Claims{
-		Role: role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			Issuer:    a.cfg.TokenIssuer,
-			Subject:   subject,
-			Audience:  jwt.ClaimStrings{a.cfg.TokenAudience},
-			ExpiresAt: jwt.NewNumericDate(now.Add(a.cfg.TokenDuration)),
-			NotBefore: jwt.NewNumericDate(now),
-			IssuedAt:  jwt.NewNumericDate(now),
-		},
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		Issuer:    a.cfg.TokenIssuer,
+		Subject:   subject,
+		Audience:  jwt.ClaimStrings{a.cfg.TokenAudience},
+		ExpiresAt: jwt.NewNumericDate(now.Add(a.cfg.TokenDuration)),
+		NotBefore: jwt.NewNumericDate(now),
+		IssuedAt:  jwt.NewNumericDate(now),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -49,11 +92,7 @@ func (a *Auth) NewToken(subject string, role Role) (*Token, error) {
 		return nil, fmt.Errorf("failed to sign token: %w", err)
 	}
 
-	return &Token{
-		token:     signedToken,
-		ExpiresAt: now.Add(a.cfg.TokenDuration),
-		Role:      role,
-	}, nil
+	return newTokenFromClaims(signedToken, &claims), nil
 }
 
 func (a *Auth) ValidateToken(token string) (*Token, error) {
@@ -80,9 +119,18 @@ func (a *Auth) ValidateToken(token string) (*Token, error) {
 		return nil, jwt.ErrTokenInvalidClaims
 	}
 
+	return newTokenFromClaims(token, claims), nil
+}
+
+func newTokenFromClaims(token string, claims *Claims) *Token {
 	return &Token{
-		token:     token,
-		ExpiresAt: claims.ExpiresAt.Time,
-		Role:      claims.Role,
-	}, nil
+		token:       token,
+		ExpiresAt:   claims.ExpiresAt.Time,
+		Role:        claims.Role,
+		Admin:       claims.Admin,
+		Subject:     claims.Subject,
+		JoinTokenID: claims.JoinTokenID,
+		Name:        claims.Name,
+		Color:       claims.Color,
+	}
 }
@@ -60,11 +60,64 @@ func TestTokenGeneration(t *testing.T) {
 				if claims.Claims.(*Claims).Subject != tt.subject {
 					t.Errorf("ValidateToken() subject = %v, want %v", claims.Claims.(*Claims).Subject, tt.subject)
 				}
+				if validatedToken.Subject != tt.subject {
+					t.Errorf("ValidateToken() token subject = %v, want %v", validatedToken.Subject, tt.subject)
+				}
 			}
 		})
 	}
 }
 
+func TestGMTokenAdmin(t *testing.T) {
+	auth := New(Config{
+		TokenSecret:   "test-secret",
+		TokenDuration: time.Hour,
+		TokenIssuer:   "test-issuer",
+		TokenAudience: "test-audience",
+	}, slog.Default())
+
+	for _, admin := range []bool{true, false} {
+		token, err := auth.NewGMToken("user-id", admin)
+		if err != nil {
+			t.Fatalf("NewGMToken() error = %v", err)
+		}
+
+		validated, err := auth.ValidateToken(token.String())
+		if err != nil {
+			t.Fatalf("ValidateToken() error = %v", err)
+		}
+		if validated.Role != RoleGM || validated.Admin != admin || validated.Subject != "user-id" {
+			t.Errorf("unexpected token %+v for admin %v", validated, admin)
+		}
+	}
+}
+
+func TestPlayerToken(t *testing.T) {
+	auth := New(Config{
+		TokenSecret:   "test-secret",
+		TokenDuration: time.Hour,
+		TokenIssuer:   "test-issuer",
+		TokenAudience: "test-audience",
+	}, slog.Default())
+
+	player := Player{ID: "player-id", Name: "Alice", Color: "#FF8A80"}
+	token, err := auth.NewPlayerToken("join-token-id", player)
+	if err != nil {
+		t.Fatalf("NewPlayerToken() error = %v", err)
+	}
+
+	validated, err := auth.ValidateToken(token.String())
+	if err != nil {
+		t.Fatalf("ValidateToken() error = %v", err)
+	}
+	if validated.Role != RolePlayer || validated.Admin || validated.JoinTokenID != "join-token-id" {
+		t.Errorf("unexpected player token %+v", validated)
+	}
+	if validated.Subject != player.ID || validated.Name != player.Name || validated.Color != player.Color {
+		t.Errorf("expected the player's identity in the token; got %+v", validated)
+	}
+}
+
 func TestTokenValidation(t *testing.T) {
 	testSecret := "test-secret"
 	auth := New(Config{
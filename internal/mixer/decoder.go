@@ -0,0 +1,109 @@
+package mixer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+)
+
+// decoderBuffer is how many frames a decoder reads ahead of the mix.
+const decoderBuffer = 50
+
+// decoder runs ffmpeg over a single track, turning it into raw PCM frames
+// for the mix loop.
+type decoder struct {
+	frames chan []byte
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func (m *Mixer) startDecoder(ctx context.Context, path string, position float64, repeating bool) (*decoder, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	cmd := exec.CommandContext(ctx, "ffmpeg", decoderArgs(path, position, repeating)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("couldn't open ffmpeg output: %w", err)
+	}
+
+	m.logger.Debug("starting track decoder", "command", cmd.String())
+	if err := cmd.Start(); err != nil {
+		cancel()
+		return nil, fmt.Errorf("couldn't start ffmpeg: %w", err)
+	}
+
+	d := &decoder{
+		frames: make(chan []byte, decoderBuffer),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go func() {
+		defer close(d.done)
+		d.read(ctx, stdout)
+		if err := cmd.Wait(); err != nil && ctx.Err() == nil {
+			m.logger.Error("track decoder exited", "path", path, "error", err, "ffmpegStderr", stderr.String())
+		}
+	}()
+
+	return d, nil
+}
+
+func (d *decoder) read(ctx context.Context, r io.Reader) {
+	defer close(d.frames)
+
+	for {
+		frame := make([]byte, frameBytes)
+		_, err := io.ReadFull(r, frame)
+		if errors.Is(err, io.EOF) {
+			return
+		}
+
+		// The end of the track is padded out to a whole frame with silence.
+		select {
+		case d.frames <- frame:
+		case <-ctx.Done():
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// next returns the decoder's next frame, or nil if it has fallen behind or
+// reached the end of the track.
+func (d *decoder) next() []byte {
+	select {
+	case frame := <-d.frames:
+		return frame
+	default:
+		return nil
+	}
+}
+
+func (d *decoder) stop() {
+	d.cancel()
+	<-d.done
+}
+
+func decoderArgs(path string, position float64, repeating bool) []string {
+	args := []string{"-hide_banner", "-loglevel", "error"}
+	if repeating {
+		args = append(args, "-stream_loop", "-1")
+	}
+	return append(args,
+		"-ss", formatSeconds(position),
+		"-i", path,
+		"-vn",
+		"-f", "s16le",
+		"-ar", strconv.Itoa(sampleRate),
+		"-ac", strconv.Itoa(channels),
+		"pipe:1",
+	)
+}
@@ -0,0 +1,131 @@
+package mixer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+// encoderBuffer is how many mixed frames can wait for a listener's encoder.
+const encoderBuffer = 50
+
+// slowListenerTimeout is how long a listener can stop reading before they're
+// disconnected. Until then their encoder stalls and misses whole frames of
+// the mix; encoded audio is never dropped, as that would corrupt the stream.
+const slowListenerTimeout = 5 * time.Second
+
+// encoder runs ffmpeg for a single listener, turning the mixed PCM into the
+// configured format. Each listener gets their own so every stream is one
+// continuous file with its own headers, whenever it joined.
+type encoder struct {
+	pcm    chan []byte
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// startEncoder starts an encoder that writes to out, closing it if ffmpeg
+// exits.
+func (m *Mixer) startEncoder(out chan<- []byte) (*encoder, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cmd := exec.CommandContext(ctx, "ffmpeg", m.encoderArgs()...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("couldn't open ffmpeg input: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("couldn't open ffmpeg output: %w", err)
+	}
+
+	m.logger.Debug("starting mix encoder", "command", cmd.String())
+	if err := cmd.Start(); err != nil {
+		cancel()
+		return nil, fmt.Errorf("couldn't start ffmpeg: %w", err)
+	}
+
+	e := &encoder{
+		pcm:    make(chan []byte, encoderBuffer),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go e.feed(ctx, stdin)
+	go func() {
+		defer close(e.done)
+		defer close(out)
+		if !pump(stdout, out) {
+			m.logger.Warn("disconnecting mix listener that stopped reading")
+			cancel()
+		}
+		if err := cmd.Wait(); err != nil && ctx.Err() == nil {
+			m.logger.Error("mix encoder exited", "error", err, "ffmpegStderr", stderr.String())
+		}
+	}()
+
+	return e, nil
+}
+
+func (e *encoder) feed(ctx context.Context, w io.WriteCloser) {
+	defer w.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case frame := <-e.pcm:
+			if _, err := w.Write(frame); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (e *encoder) stop() {
+	e.cancel()
+	<-e.done
+}
+
+// pump copies the encoded stream to out until it ends. It returns false if
+// the listener stopped reading.
+func pump(r io.Reader, out chan<- []byte) bool {
+	buf := make([]byte, chunkSize)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			chunk := make([]byte, n)
+			copy(chunk, buf[:n])
+			select {
+			case out <- chunk:
+			case <-time.After(slowListenerTimeout):
+				return false
+			}
+		}
+		if err != nil {
+			return true
+		}
+	}
+}
+
+func (m *Mixer) encoderArgs() []string {
+	f := formats[m.cfg.Format]
+	return []string{
+		"-hide_banner",
+		"-loglevel", "error",
+		"-f", "s16le",
+		"-ar", strconv.Itoa(sampleRate),
+		"-ac", strconv.Itoa(channels),
+		"-i", "pipe:0",
+		"-c:a", f.codec,
+		"-b:a", m.cfg.Bitrate,
+		"-flush_packets", "1",
+		"-f", f.muxer,
+		"pipe:1",
+	}
+}
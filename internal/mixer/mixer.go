@@ -0,0 +1,444 @@
+package mixer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/terrabitz/rpg-audio-streamer/internal/server"
+)
+
+const (
+	sampleRate = 44100
+	channels   = 2
+
+	// frameDuration is how much audio the mix loop renders at a time.
+	frameDuration = 20 * time.Millisecond
+	frameSamples  = sampleRate * int(frameDuration/time.Millisecond) / 1000
+	frameBytes    = frameSamples * channels * 2
+
+	// maxLag is how far the mix loop may fall behind the clock before it
+	// skips ahead rather than trying to catch up.
+	maxLag = 200 * time.Millisecond
+
+	// seekTolerance is how far a track's decoder may drift from the position
+	// the GM reports before it's restarted in the right place.
+	seekTolerance = time.Second
+
+	// lookupTimeout bounds how long the store gets to look up a track that's
+	// started playing.
+	lookupTimeout = 10 * time.Second
+)
+
+const chunkSize = 4096
+
+type Config struct {
+	Format  string
+	Bitrate string
+}
+
+type TrackGetter interface {
+	GetTrackByID(ctx context.Context, trackID uuid.UUID) (server.Track, error)
+	GetTrackTypeByID(ctx context.Context, id uuid.UUID) (server.TrackType, error)
+}
+
+// Mixer keeps an authoritative copy of what the GM is playing and mixes it
+// into a single continuous stream that any number of listeners can
+// subscribe to. Each playing track has its own ffmpeg decoder and each
+// listener its own encoder; volume and fades are applied while mixing, so
+// changing them never interrupts the stream.
+type Mixer struct {
+	cfg    Config
+	logger *slog.Logger
+	store  TrackGetter
+
+	mu          sync.Mutex
+	tracks      map[uuid.UUID]*track
+	subscribers map[*encoder]struct{}
+
+	// lookups tracks the track lookups running in the background.
+	lookups sync.WaitGroup
+}
+
+func New(cfg Config, logger *slog.Logger, store TrackGetter) (*Mixer, error) {
+	if _, ok := formats[cfg.Format]; !ok {
+		return nil, fmt.Errorf("unsupported mix format '%s'", cfg.Format)
+	}
+
+	return &Mixer{
+		cfg:         cfg,
+		logger:      logger,
+		store:       store,
+		tracks:      make(map[uuid.UUID]*track),
+		subscribers: make(map[*encoder]struct{}),
+	}, nil
+}
+
+type format struct {
+	contentType string
+	codec       string
+	muxer       string
+}
+
+var formats = map[string]format{
+	"mp3":  {contentType: "audio/mpeg", codec: "libmp3lame", muxer: "mp3"},
+	"opus": {contentType: "audio/ogg", codec: "libopus", muxer: "ogg"},
+}
+
+func (m *Mixer) ContentType() string {
+	return formats[m.cfg.Format].contentType
+}
+
+// HandleCommand applies a GM command routed through the hub to the mix.
+func (m *Mixer) HandleCommand(method string, payload json.RawMessage) {
+	now := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch method {
+	case "syncTrack":
+		state, err := decodeSyncTrack(payload)
+		if err != nil {
+			m.logger.Warn("couldn't decode syncTrack for mix", "error", err)
+			return
+		}
+		m.apply(state, now)
+	case "syncAll":
+		states, err := decodeSyncAll(payload)
+		if err != nil {
+			m.logger.Warn("couldn't decode syncAll for mix", "error", err)
+			return
+		}
+		m.applyAll(states, now)
+	}
+}
+
+// Subscribe registers a listener for the encoded mix. The returned function
+// must be called once the listener goes away.
+func (m *Mixer) Subscribe() (<-chan []byte, func()) {
+	out := make(chan []byte, 64)
+
+	enc, err := m.startEncoder(out)
+	if err != nil {
+		m.logger.Error("failed to start mix encoder", "error", err)
+		close(out)
+		return out, func() {}
+	}
+
+	m.mu.Lock()
+	m.subscribers[enc] = struct{}{}
+	m.mu.Unlock()
+
+	return out, func() {
+		m.mu.Lock()
+		delete(m.subscribers, enc)
+		m.mu.Unlock()
+
+		enc.stop()
+	}
+}
+
+// Run renders the mix in real time until ctx is done. Tracks are only
+// decoded while somebody is listening.
+func (m *Mixer) Run(ctx context.Context) {
+	voices := make(map[uuid.UUID]*voice)
+	defer func() {
+		for _, v := range voices {
+			v.stop()
+		}
+	}()
+
+	ticker := time.NewTicker(frameDuration)
+	defer ticker.Stop()
+
+	var next time.Time
+	for {
+		var now time.Time
+		select {
+		case <-ctx.Done():
+			return
+		case now = <-ticker.C:
+		}
+
+		m.mu.Lock()
+		listening := len(m.subscribers) > 0
+		if !listening {
+			m.pruneStopped()
+		}
+		m.mu.Unlock()
+
+		if !listening {
+			for id, v := range voices {
+				v.stop()
+				delete(voices, id)
+			}
+			next = time.Time{}
+			continue
+		}
+
+		if next.IsZero() || now.Sub(next) > maxLag {
+			next = now
+		}
+		for !next.After(now) {
+			m.publish(m.mixFrame(ctx, voices, next))
+			next = next.Add(frameDuration)
+		}
+	}
+}
+
+// pruneStopped drops stopped tracks straight away while nobody is listening
+// to them fade out.
+func (m *Mixer) pruneStopped() {
+	for id, t := range m.tracks {
+		if t.isStopping() {
+			delete(m.tracks, id)
+		}
+	}
+}
+
+// voice is the mix loop's view of a track: its decoder and where it's up to.
+type voice struct {
+	playedAt  time.Time
+	seq       int
+	repeating bool
+
+	info trackInfo
+
+	dec *decoder
+	// position is how far into the track the decoder's next frame is.
+	position float64
+	// gain is what the last frame ended at. Each frame ramps from there to
+	// the track's current gain, so volume changes and fades don't click.
+	gain float64
+}
+
+func (v *voice) stop() {
+	if v.dec != nil {
+		v.dec.stop()
+		v.dec = nil
+	}
+}
+
+// drift is how far the decoder is from where the track should be.
+func (v *voice) drift(position float64) time.Duration {
+	d := position - v.position
+	if v.repeating && v.info.duration > 0 {
+		d = math.Remainder(d, v.info.duration)
+	}
+	return time.Duration(math.Abs(d) * float64(time.Second))
+}
+
+type trackInfo struct {
+	path     string
+	duration float64
+	fadeIn   time.Duration
+	fadeOut  time.Duration
+}
+
+func (m *Mixer) trackInfo(ctx context.Context, id uuid.UUID) (trackInfo, error) {
+	storedTrack, err := m.store.GetTrackByID(ctx, id)
+	if err != nil {
+		return trackInfo{}, err
+	}
+
+	info := trackInfo{
+		path:     filepath.Join(storedTrack.Path, "index.m3u8"),
+		duration: storedTrack.Duration,
+	}
+
+	trackType, err := m.store.GetTrackTypeByID(ctx, storedTrack.TypeID)
+	if err != nil {
+		// The track still plays, just without fades.
+		m.logger.Warn("couldn't get track type for mix", "trackID", id, "typeID", storedTrack.TypeID, "error", err)
+		return info, nil
+	}
+	info.fadeIn = time.Duration(trackType.FadeInMs) * time.Millisecond
+	info.fadeOut = time.Duration(trackType.FadeOutMs) * time.Millisecond
+
+	return info, nil
+}
+
+// lookUp finds what the mix needs to know about a track the GM has just
+// played. It runs in the background so the store never holds up the GM's
+// commands or the mix loop; the track is silent until it's done.
+func (m *Mixer) lookUp(id uuid.UUID, playedAt time.Time) {
+	m.lookups.Add(1)
+	go func() {
+		defer m.lookups.Done()
+
+		ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+		defer cancel()
+		info, err := m.trackInfo(ctx, id)
+		if err != nil {
+			m.logger.Warn("skipping unknown track in mix", "trackID", id, "error", err)
+			return
+		}
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if t, ok := m.tracks[id]; ok && t.playedAt.Equal(playedAt) {
+			t.info = &info
+		}
+	}()
+}
+
+// mixFrame renders the frame of the mix that starts at now, starting and
+// stopping decoders to match the GM's tracks as it goes.
+func (m *Mixer) mixFrame(ctx context.Context, voices map[uuid.UUID]*voice, now time.Time) []byte {
+	m.mu.Lock()
+	tracks := make([]track, 0, len(m.tracks))
+	for _, t := range m.tracks {
+		tracks = append(tracks, *t)
+	}
+	m.mu.Unlock()
+
+	mix := make([]float64, frameSamples*channels)
+	active := make(map[uuid.UUID]bool, len(tracks))
+	for _, t := range tracks {
+		active[t.id] = true
+
+		v := voices[t.id]
+		if v != nil && !v.playedAt.Equal(t.playedAt) {
+			v.stop()
+			delete(voices, t.id)
+			v = nil
+		}
+		if v == nil {
+			if t.info == nil {
+				// The track is still being looked up, or isn't in the
+				// library, so there's nothing to fade out if it's stopped.
+				if t.isStopping() {
+					m.removeStopped(t)
+				}
+				continue
+			}
+			v = &voice{playedAt: t.playedAt, seq: t.seq, info: *t.info}
+			voices[t.id] = v
+		}
+
+		if !t.isStopping() && (v.dec == nil || v.seq != t.seq || v.repeating != t.isRepeating) {
+			// Only seeks need a new decoder; the periodic positions in
+			// syncAll messages just confirm the current one.
+			position := t.position(now)
+			if v.dec == nil || v.repeating != t.isRepeating || v.drift(position) > seekTolerance {
+				m.startVoice(ctx, v, t, position)
+			}
+			v.seq = t.seq
+		}
+
+		gain := t.volume / 100 * envelope(t, v.info, now.Add(frameDuration))
+		if v.dec != nil {
+			if frame := v.dec.next(); frame != nil {
+				addFrame(mix, frame, v.gain, gain)
+				v.advance()
+			}
+		}
+		v.gain = gain
+
+		if t.isStopping() && gain == 0 {
+			v.stop()
+			delete(voices, t.id)
+			m.removeStopped(t)
+		}
+	}
+
+	for id, v := range voices {
+		if !active[id] {
+			v.stop()
+			delete(voices, id)
+		}
+	}
+
+	return encodeFrame(mix)
+}
+
+func (m *Mixer) startVoice(ctx context.Context, v *voice, t track, position float64) {
+	v.stop()
+	v.repeating = t.isRepeating
+	v.position = startPosition(position, v.info.duration, t.isRepeating)
+
+	dec, err := m.startDecoder(ctx, v.info.path, v.position, v.repeating)
+	if err != nil {
+		m.logger.Error("failed to start track decoder", "trackID", t.id, "error", err)
+		return
+	}
+	v.dec = dec
+}
+
+// advance moves the voice on by the frame it just played.
+func (v *voice) advance() {
+	v.position += frameDuration.Seconds()
+	if v.repeating && v.info.duration > 0 {
+		v.position = math.Mod(v.position, v.info.duration)
+	}
+}
+
+// startPosition is where to start decoding a track that has been playing
+// for position seconds. Repeating tracks wrap around, since seeking past the
+// end of a looped input doesn't.
+func startPosition(position, duration float64, repeating bool) float64 {
+	if repeating && duration > 0 {
+		return math.Mod(position, duration)
+	}
+	return position
+}
+
+// removeStopped drops a track that has finished fading out, unless the GM
+// has played it again in the meantime.
+func (m *Mixer) removeStopped(t track) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if current, ok := m.tracks[t.id]; ok && current.isStopping() && current.playedAt.Equal(t.playedAt) {
+		delete(m.tracks, t.id)
+	}
+}
+
+// envelope is how far a track has faded in at the given time, from 0 to 1,
+// using its track type's fade durations.
+func envelope(t track, info trackInfo, at time.Time) float64 {
+	if !t.isStopping() {
+		return fadeLevel(at.Sub(t.playedAt), info.fadeIn)
+	}
+
+	// A track stopped while it was still fading in fades out from wherever
+	// it got to.
+	level := fadeLevel(t.stoppedAt.Sub(t.playedAt), info.fadeIn)
+	return level * (1 - fadeLevel(at.Sub(t.stoppedAt), info.fadeOut))
+}
+
+func fadeLevel(elapsed, fade time.Duration) float64 {
+	if elapsed >= fade {
+		return 1
+	}
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(elapsed) / float64(fade)
+}
+
+func (m *Mixer) publish(frame []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for enc := range m.subscribers {
+		// A listener that can't keep up loses audio rather than stalling
+		// everybody else.
+		select {
+		case enc.pcm <- frame:
+		default:
+		}
+	}
+}
+
+func formatSeconds(seconds float64) string {
+	return strconv.FormatFloat(seconds, 'f', 3, 64)
+}
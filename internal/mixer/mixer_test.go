@@ -0,0 +1,419 @@
+package mixer
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/terrabitz/rpg-audio-streamer/internal/server"
+)
+
+type mockTrackGetter struct {
+	tracks     map[uuid.UUID]server.Track
+	trackTypes map[uuid.UUID]server.TrackType
+}
+
+func (m *mockTrackGetter) GetTrackByID(ctx context.Context, trackID uuid.UUID) (server.Track, error) {
+	track, ok := m.tracks[trackID]
+	if !ok {
+		return server.Track{}, fmt.Errorf("track not found")
+	}
+	return track, nil
+}
+
+func (m *mockTrackGetter) GetTrackTypeByID(ctx context.Context, id uuid.UUID) (server.TrackType, error) {
+	trackType, ok := m.trackTypes[id]
+	if !ok {
+		return server.TrackType{}, fmt.Errorf("track type not found")
+	}
+	return trackType, nil
+}
+
+var testTrackType = server.TrackType{ID: uuid.New(), Name: "Ambience", FadeInMs: 1000, FadeOutMs: 500}
+
+func newTestMixer(t *testing.T, trackIDs ...uuid.UUID) *Mixer {
+	t.Helper()
+
+	store := &mockTrackGetter{
+		tracks:     make(map[uuid.UUID]server.Track),
+		trackTypes: map[uuid.UUID]server.TrackType{testTrackType.ID: testTrackType},
+	}
+	for _, id := range trackIDs {
+		store.tracks[id] = server.Track{ID: id, Path: "/uploads/" + id.String(), TypeID: testTrackType.ID, Duration: 60}
+	}
+
+	m, err := New(Config{Format: "mp3", Bitrate: "128k"}, slog.New(slog.NewTextHandler(io.Discard, nil)), store)
+	if err != nil {
+		t.Fatalf("failed to create mixer: %v", err)
+	}
+	return m
+}
+
+// handleCommand applies a command and waits for any track lookups it starts.
+func handleCommand(m *Mixer, method string, payload json.RawMessage) {
+	m.HandleCommand(method, payload)
+	m.lookups.Wait()
+}
+
+// fakeDecoder returns a decoder that has the given frames ready without
+// running ffmpeg.
+func fakeDecoder(frames ...[]byte) *decoder {
+	d := &decoder{
+		frames: make(chan []byte, len(frames)),
+		cancel: func() {},
+		done:   make(chan struct{}),
+	}
+	for _, frame := range frames {
+		d.frames <- frame
+	}
+	close(d.done)
+	return d
+}
+
+// constantFrame returns a frame where every sample has the given value.
+func constantFrame(sample int16) []byte {
+	frame := make([]byte, frameBytes)
+	for i := 0; i < len(frame); i += 2 {
+		binary.LittleEndian.PutUint16(frame[i:], uint16(sample))
+	}
+	return frame
+}
+
+func sampleAt(frame []byte, i int) int16 {
+	return int16(binary.LittleEndian.Uint16(frame[2*i:]))
+}
+
+func TestNewRejectsUnknownFormat(t *testing.T) {
+	_, err := New(Config{Format: "flac"}, slog.New(slog.NewTextHandler(io.Discard, nil)), &mockTrackGetter{})
+	if err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
+
+func TestHandleCommand(t *testing.T) {
+	trackA := uuid.New()
+	trackB := uuid.New()
+
+	t.Run("syncTrack starts and stops tracks", func(t *testing.T) {
+		m := newTestMixer(t, trackA)
+
+		handleCommand(m, "syncTrack", json.RawMessage(fmt.Sprintf(`{"fileID":%q,"isPlaying":true,"volume":50,"currentTime":10}`, trackA)))
+		if len(m.tracks) != 1 {
+			t.Fatalf("expected 1 track in mix; got %d", len(m.tracks))
+		}
+		tr := m.tracks[trackA]
+		if tr.volume != 50 || tr.offset != 10 || tr.isStopping() {
+			t.Errorf("unexpected track state: %+v", tr)
+		}
+
+		handleCommand(m, "syncTrack", json.RawMessage(fmt.Sprintf(`{"fileID":%q,"isPlaying":false}`, trackA)))
+		if !m.tracks[trackA].isStopping() {
+			t.Error("expected track to be fading out")
+		}
+	})
+
+	t.Run("updates for tracks that aren't playing are ignored", func(t *testing.T) {
+		m := newTestMixer(t, trackA)
+
+		handleCommand(m, "syncTrack", json.RawMessage(fmt.Sprintf(`{"fileID":%q,"volume":20}`, trackA)))
+		if len(m.tracks) != 0 {
+			t.Errorf("expected empty mix; got %d tracks", len(m.tracks))
+		}
+	})
+
+	t.Run("only position updates count as possible seeks", func(t *testing.T) {
+		m := newTestMixer(t, trackA)
+
+		handleCommand(m, "syncTrack", json.RawMessage(fmt.Sprintf(`{"fileID":%q,"isPlaying":true}`, trackA)))
+		handleCommand(m, "syncTrack", json.RawMessage(fmt.Sprintf(`{"fileID":%q,"volume":30}`, trackA)))
+		if seq := m.tracks[trackA].seq; seq != 0 {
+			t.Errorf("expected volume change to leave position alone; got seq %d", seq)
+		}
+
+		handleCommand(m, "syncTrack", json.RawMessage(fmt.Sprintf(`{"fileID":%q,"currentTime":42}`, trackA)))
+		if tr := m.tracks[trackA]; tr.seq != 1 || tr.offset != 42 {
+			t.Errorf("expected seek to move the track; got %+v", tr)
+		}
+	})
+
+	t.Run("syncAll replaces the mix", func(t *testing.T) {
+		m := newTestMixer(t, trackA, trackB)
+
+		handleCommand(m, "syncTrack", json.RawMessage(fmt.Sprintf(`{"fileID":%q,"isPlaying":true}`, trackA)))
+		handleCommand(m, "syncAll", json.RawMessage(fmt.Sprintf(`{"tracks":[{"fileID":%q,"isPlaying":true,"volume":80}]}`, trackB)))
+
+		if !m.tracks[trackA].isStopping() {
+			t.Error("expected track missing from syncAll to be fading out")
+		}
+		if tr := m.tracks[trackB]; tr == nil || tr.isStopping() || tr.volume != 80 {
+			t.Errorf("expected track from syncAll to be playing at volume 80; got %+v", tr)
+		}
+	})
+}
+
+func TestTrackInfo(t *testing.T) {
+	trackA := uuid.New()
+	m := newTestMixer(t, trackA)
+
+	info, err := m.trackInfo(context.Background(), trackA)
+	if err != nil {
+		t.Fatalf("trackInfo failed: %v", err)
+	}
+
+	want := trackInfo{
+		path:     "/uploads/" + trackA.String() + "/index.m3u8",
+		duration: 60,
+		fadeIn:   time.Second,
+		fadeOut:  500 * time.Millisecond,
+	}
+	if info != want {
+		t.Errorf("expected %+v; got %+v", want, info)
+	}
+}
+
+func TestLookUp(t *testing.T) {
+	trackA := uuid.New()
+	unknown := uuid.New()
+	m := newTestMixer(t, trackA)
+
+	handleCommand(m, "syncAll", json.RawMessage(fmt.Sprintf(`{"tracks":[{"fileID":%q,"isPlaying":true},{"fileID":%q,"isPlaying":true}]}`, trackA, unknown)))
+	if info := m.tracks[trackA].info; info == nil || info.duration != 60 || info.fadeIn != time.Second {
+		t.Errorf("expected the track to be looked up when it's played; got %+v", info)
+	}
+	if info := m.tracks[unknown].info; info != nil {
+		t.Errorf("expected no info for a track that isn't in the library; got %+v", info)
+	}
+
+	voices := make(map[uuid.UUID]*voice)
+	handleCommand(m, "syncAll", json.RawMessage(`{"tracks":[]}`))
+	m.mixFrame(context.Background(), voices, time.Now())
+	if _, ok := voices[trackA]; !ok {
+		t.Error("expected a voice for the track to fade out")
+	}
+	if _, ok := voices[unknown]; ok {
+		t.Error("expected no voice for a track that isn't in the library")
+	}
+	if _, ok := m.tracks[unknown]; ok {
+		t.Error("expected a stopped track that isn't in the library to be removed straight away")
+	}
+}
+
+func TestEnvelope(t *testing.T) {
+	start := time.Now()
+	info := trackInfo{fadeIn: time.Second, fadeOut: 2 * time.Second}
+
+	tests := []struct {
+		name  string
+		track track
+		info  trackInfo
+		at    time.Time
+		want  float64
+	}{
+		{
+			name:  "fading in",
+			track: track{playedAt: start},
+			info:  info,
+			at:    start.Add(250 * time.Millisecond),
+			want:  0.25,
+		},
+		{
+			name:  "faded in",
+			track: track{playedAt: start},
+			info:  info,
+			at:    start.Add(5 * time.Second),
+			want:  1,
+		},
+		{
+			name:  "no fade in",
+			track: track{playedAt: start},
+			at:    start,
+			want:  1,
+		},
+		{
+			name:  "fading out",
+			track: track{playedAt: start, stoppedAt: start.Add(5 * time.Second)},
+			info:  info,
+			at:    start.Add(5500 * time.Millisecond),
+			want:  0.75,
+		},
+		{
+			name:  "stopped while fading in",
+			track: track{playedAt: start, stoppedAt: start.Add(500 * time.Millisecond)},
+			info:  info,
+			at:    start.Add(1500 * time.Millisecond),
+			want:  0.25,
+		},
+		{
+			name:  "no fade out",
+			track: track{playedAt: start, stoppedAt: start.Add(5 * time.Second)},
+			at:    start.Add(5 * time.Second),
+			want:  0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := envelope(tt.track, tt.info, tt.at); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("expected %v; got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestStartPosition(t *testing.T) {
+	if got := startPosition(130, 60, true); got != 10 {
+		t.Errorf("expected repeating track to wrap to 10s; got %v", got)
+	}
+	if got := startPosition(130, 60, false); got != 130 {
+		t.Errorf("expected non-repeating track to start at 130s; got %v", got)
+	}
+	if got := startPosition(130, 0, true); got != 130 {
+		t.Errorf("expected track with unknown duration to start at 130s; got %v", got)
+	}
+}
+
+func TestVoiceDrift(t *testing.T) {
+	v := &voice{repeating: true, position: 1, info: trackInfo{duration: 60}}
+	if got := v.drift(60.5); got != 500*time.Millisecond {
+		t.Errorf("expected drift across the loop point to be 500ms; got %v", got)
+	}
+
+	v.repeating = false
+	if got := v.drift(60.5); got != 59500*time.Millisecond {
+		t.Errorf("expected drift of 59.5s; got %v", got)
+	}
+}
+
+func TestMixFrame(t *testing.T) {
+	trackA := uuid.New()
+	ctx := context.Background()
+
+	t.Run("volume changes don't restart the decoder", func(t *testing.T) {
+		m := newTestMixer(t, trackA)
+		handleCommand(m, "syncTrack", json.RawMessage(fmt.Sprintf(`{"fileID":%q,"isPlaying":true,"volume":50}`, trackA)))
+		tr := m.tracks[trackA]
+		tr.playedAt = tr.playedAt.Add(-time.Minute)
+
+		dec := fakeDecoder(constantFrame(1000), constantFrame(1000))
+		voices := map[uuid.UUID]*voice{
+			trackA: {playedAt: tr.playedAt, info: trackInfo{duration: 60}, dec: dec, gain: 0.5},
+		}
+		now := time.Now()
+
+		frame := m.mixFrame(ctx, voices, now)
+		if got := sampleAt(frame, len(frame)/2-1); got != 500 {
+			t.Errorf("expected last sample at half volume; got %d", got)
+		}
+
+		handleCommand(m, "syncTrack", json.RawMessage(fmt.Sprintf(`{"fileID":%q,"volume":100}`, trackA)))
+		frame = m.mixFrame(ctx, voices, now.Add(frameDuration))
+		if voices[trackA].dec != dec {
+			t.Fatal("expected volume change to keep the same decoder")
+		}
+		first, last := sampleAt(frame, 0), sampleAt(frame, len(frame)/2-1)
+		if first <= 500 || first >= 1000 || last != 1000 {
+			t.Errorf("expected volume to ramp from 500 to 1000; got %d to %d", first, last)
+		}
+	})
+
+	t.Run("small position updates don't restart the decoder", func(t *testing.T) {
+		m := newTestMixer(t, trackA)
+		handleCommand(m, "syncTrack", json.RawMessage(fmt.Sprintf(`{"fileID":%q,"isPlaying":true,"currentTime":10}`, trackA)))
+		tr := m.tracks[trackA]
+
+		dec := fakeDecoder(constantFrame(1000))
+		voices := map[uuid.UUID]*voice{
+			trackA: {playedAt: tr.playedAt, info: trackInfo{duration: 60}, dec: dec, position: 10.2},
+		}
+		m.mixFrame(ctx, voices, tr.startedAt)
+		if voices[trackA].dec != dec {
+			t.Fatal("expected position within tolerance to keep the same decoder")
+		}
+		if voices[trackA].seq != tr.seq {
+			t.Errorf("expected voice to catch up with the track's seq")
+		}
+	})
+
+	t.Run("stopped tracks are removed once faded out", func(t *testing.T) {
+		m := newTestMixer(t, trackA)
+		handleCommand(m, "syncTrack", json.RawMessage(fmt.Sprintf(`{"fileID":%q,"isPlaying":true}`, trackA)))
+		handleCommand(m, "syncTrack", json.RawMessage(fmt.Sprintf(`{"fileID":%q,"isPlaying":false}`, trackA)))
+		tr := m.tracks[trackA]
+
+		voices := map[uuid.UUID]*voice{
+			trackA: {playedAt: tr.playedAt, info: trackInfo{fadeOut: 500 * time.Millisecond}, dec: fakeDecoder()},
+		}
+		m.mixFrame(ctx, voices, tr.stoppedAt)
+		if _, ok := m.tracks[trackA]; !ok {
+			t.Fatal("expected track to stay in the mix while fading out")
+		}
+
+		m.mixFrame(ctx, voices, tr.stoppedAt.Add(500*time.Millisecond))
+		if _, ok := m.tracks[trackA]; ok {
+			t.Error("expected track to be removed after fading out")
+		}
+		if _, ok := voices[trackA]; ok {
+			t.Error("expected voice to be removed after fading out")
+		}
+	})
+}
+
+func TestMixing(t *testing.T) {
+	mix := make([]float64, frameSamples*channels)
+	addFrame(mix, constantFrame(20000), 1, 1)
+	addFrame(mix, constantFrame(20000), 1, 1)
+	addFrame(mix, constantFrame(-1000), 0, 1)
+
+	frame := encodeFrame(mix)
+	if got := sampleAt(frame, 0); got != math.MaxInt16 {
+		t.Errorf("expected loud mix to clip at %d; got %d", math.MaxInt16, got)
+	}
+
+	mix = make([]float64, frameSamples*channels)
+	addFrame(mix, constantFrame(-1000), 0, 1)
+	frame = encodeFrame(mix)
+	if got := sampleAt(frame, len(frame)/2-1); got != -1000 {
+		t.Errorf("expected gain ramp to end at full volume; got %d", got)
+	}
+	if got := sampleAt(frame, 0); got != -1 {
+		t.Errorf("expected gain ramp to start near silence; got %d", got)
+	}
+}
+
+func TestFFmpegArgs(t *testing.T) {
+	t.Run("decoder", func(t *testing.T) {
+		args := strings.Join(decoderArgs("/uploads/a/index.m3u8", 12.5, true), " ")
+		for _, want := range []string{
+			"-stream_loop -1",
+			"-ss 12.500 -i /uploads/a/index.m3u8",
+			"-f s16le -ar 44100 -ac 2 pipe:1",
+		} {
+			if !strings.Contains(args, want) {
+				t.Errorf("expected decoder args to contain %q; got %s", want, args)
+			}
+		}
+	})
+
+	t.Run("encoder", func(t *testing.T) {
+		m := newTestMixer(t)
+		args := strings.Join(m.encoderArgs(), " ")
+		for _, want := range []string{
+			"-f s16le -ar 44100 -ac 2 -i pipe:0",
+			"-c:a libmp3lame",
+			"-b:a 128k",
+			"-f mp3 pipe:1",
+		} {
+			if !strings.Contains(args, want) {
+				t.Errorf("expected encoder args to contain %q; got %s", want, args)
+			}
+		}
+	})
+}
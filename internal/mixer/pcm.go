@@ -0,0 +1,31 @@
+package mixer
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// addFrame adds a frame of signed 16-bit little-endian stereo samples to the
+// mix, scaled by a gain that moves linearly from `from` to `to` across the
+// frame.
+func addFrame(mix []float64, frame []byte, from, to float64) {
+	samples := len(mix) / channels
+	for i := range samples {
+		gain := from + (to-from)*float64(i+1)/float64(samples)
+		for c := range channels {
+			j := i*channels + c
+			mix[j] += float64(int16(binary.LittleEndian.Uint16(frame[2*j:]))) * gain
+		}
+	}
+}
+
+// encodeFrame turns the mix back into signed 16-bit little-endian samples,
+// clipping anything too loud to fit.
+func encodeFrame(mix []float64) []byte {
+	frame := make([]byte, 2*len(mix))
+	for i, sample := range mix {
+		sample = math.Max(math.MinInt16, math.Min(math.MaxInt16, math.Round(sample)))
+		binary.LittleEndian.PutUint16(frame[2*i:], uint16(int16(sample)))
+	}
+	return frame
+}
@@ -0,0 +1,125 @@
+package mixer
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TrackState mirrors the per-track state the GM client sends in syncTrack and
+// syncAll messages.
+type TrackState struct {
+	FileID      uuid.UUID `json:"fileID"`
+	IsPlaying   *bool     `json:"isPlaying"`
+	Volume      *float64  `json:"volume"`
+	IsRepeating *bool     `json:"isRepeating"`
+	CurrentTime *float64  `json:"currentTime"`
+}
+
+type track struct {
+	id          uuid.UUID
+	volume      float64
+	isRepeating bool
+
+	// playedAt is when the GM pressed play, which is where the fade in is
+	// measured from.
+	playedAt time.Time
+
+	// offset is the playback position at startedAt, which lets us work out
+	// the current position whenever the track's decoder has to be started.
+	offset    float64
+	startedAt time.Time
+
+	// seq counts the updates that may have moved the playback position, so
+	// the mix loop knows to check whether its decoder is still in the right
+	// place.
+	seq int
+
+	// stoppedAt is set while a stopped track is fading out of the mix.
+	stoppedAt time.Time
+
+	// info is set by lookUp once the track has been found in the library.
+	info *trackInfo
+}
+
+func (t *track) position(now time.Time) float64 {
+	return t.offset + now.Sub(t.startedAt).Seconds()
+}
+
+func (t *track) isStopping() bool {
+	return !t.stoppedAt.IsZero()
+}
+
+// apply updates the mix with a single track's state. Volume changes only
+// change the gain the mix loop applies to the track; nothing is restarted.
+func (m *Mixer) apply(state TrackState, now time.Time) {
+	t, exists := m.tracks[state.FileID]
+	playing := exists && !t.isStopping()
+
+	if state.IsPlaying != nil && !*state.IsPlaying {
+		if !playing {
+			return
+		}
+		t.offset = t.position(now)
+		t.startedAt = now
+		t.stoppedAt = now
+		return
+	}
+
+	if !playing {
+		if state.IsPlaying == nil {
+			// Updates for tracks that aren't playing (e.g. volume changes
+			// before hitting play) don't affect the mix.
+			return
+		}
+		t = &track{id: state.FileID, volume: 100, playedAt: now, startedAt: now}
+		m.tracks[state.FileID] = t
+		m.lookUp(t.id, t.playedAt)
+	}
+
+	if state.Volume != nil {
+		t.volume = *state.Volume
+	}
+	if state.IsRepeating != nil {
+		t.isRepeating = *state.IsRepeating
+	}
+	if state.CurrentTime != nil {
+		t.offset = *state.CurrentTime
+		t.startedAt = now
+		t.seq++
+	}
+}
+
+// applyAll replaces the whole mix with the playing tracks from a syncAll.
+func (m *Mixer) applyAll(states []TrackState, now time.Time) {
+	playing := make(map[uuid.UUID]bool)
+	for _, state := range states {
+		if state.IsPlaying == nil || !*state.IsPlaying {
+			continue
+		}
+		playing[state.FileID] = true
+		m.apply(state, now)
+	}
+
+	stopped := false
+	for id := range m.tracks {
+		if !playing[id] {
+			m.apply(TrackState{FileID: id, IsPlaying: &stopped}, now)
+		}
+	}
+}
+
+func decodeSyncTrack(payload json.RawMessage) (TrackState, error) {
+	var state TrackState
+	err := json.Unmarshal(payload, &state)
+	return state, err
+}
+
+func decodeSyncAll(payload json.RawMessage) ([]TrackState, error) {
+	var syncPayload struct {
+		Tracks []TrackState `json:"tracks"`
+	}
+	err := json.Unmarshal(payload, &syncPayload)
+	return syncPayload.Tracks, err
+}
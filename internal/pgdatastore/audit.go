@@ -0,0 +1,87 @@
+package pgdatastore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/terrabitz/rpg-audio-streamer/internal/pgdatastore/pgdb"
+	"github.com/terrabitz/rpg-audio-streamer/internal/server"
+)
+
+func (db *PGDatastore) SaveAuditEntry(ctx context.Context, entry server.AuditEntry) error {
+	params := pgdb.SaveAuditEntryParams{
+		CreatedAt:   dbTime(entry.CreatedAt),
+		Actor:       entry.Actor,
+		Action:      string(entry.Action),
+		TargetID:    entry.TargetID,
+		BeforeState: nullableJSON(entry.Before),
+		AfterState:  nullableJSON(entry.After),
+		Ip:          entry.IP,
+	}
+
+	if err := pgdb.New(db.DB).SaveAuditEntry(ctx, params); err != nil {
+		return fmt.Errorf("couldn't save audit entry to Postgres: %w", err)
+	}
+
+	return nil
+}
+
+func (db *PGDatastore) GetAuditEntries(ctx context.Context, filter server.AuditFilter, page server.AuditPage) ([]server.AuditEntry, error) {
+	params := pgdb.GetAuditEntriesParams{
+		Actor:         nullableString(filter.Actor),
+		Action:        nullableString(string(filter.Action)),
+		TargetID:      nullableString(filter.TargetID),
+		CreatedAfter:  nullableTime(filter.From),
+		CreatedBefore: nullableTime(filter.To),
+		Limit:         int32(page.Limit),
+	}
+	if page.BeforeID != 0 {
+		params.BeforeID = sql.NullInt64{Int64: page.BeforeID, Valid: true}
+	}
+
+	dbEntries, err := pgdb.New(db.DB).GetAuditEntries(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get audit entries: %w", err)
+	}
+
+	entries := make([]server.AuditEntry, 0, len(dbEntries))
+	for _, dbEntry := range dbEntries {
+		entries = append(entries, server.AuditEntry{
+			ID:        dbEntry.ID,
+			CreatedAt: dbEntry.CreatedAt.UTC(),
+			Actor:     dbEntry.Actor,
+			Action:    server.AuditAction(dbEntry.Action),
+			TargetID:  dbEntry.TargetID,
+			Before:    rawJSON(dbEntry.BeforeState),
+			After:     rawJSON(dbEntry.AfterState),
+			IP:        dbEntry.Ip,
+		})
+	}
+
+	return entries, nil
+}
+
+func nullableString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func nullableTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: dbTime(*t), Valid: true}
+}
+
+func nullableJSON(data json.RawMessage) sql.NullString {
+	return sql.NullString{String: string(data), Valid: len(data) > 0}
+}
+
+func rawJSON(s sql.NullString) json.RawMessage {
+	if !s.Valid {
+		return nil
+	}
+	return json.RawMessage(s.String)
+}
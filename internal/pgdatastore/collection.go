@@ -0,0 +1,111 @@
+package pgdatastore
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/terrabitz/rpg-audio-streamer/internal/pgdatastore/pgdb"
+	"github.com/terrabitz/rpg-audio-streamer/internal/server"
+)
+
+func (db *PGDatastore) GetCollections(ctx context.Context) ([]server.Collection, error) {
+	dbCollections, err := pgdb.New(db.DB).GetCollections(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get collections: %w", err)
+	}
+
+	var result []server.Collection
+	for _, dbCollection := range dbCollections {
+		result = append(result, convertDBCollection(dbCollection))
+	}
+	return result, nil
+}
+
+func (db *PGDatastore) SaveCollection(ctx context.Context, collection *server.Collection) error {
+	if err := pgdb.New(db.DB).SaveCollection(ctx, pgdb.SaveCollectionParams{
+		ID:        collection.ID,
+		ParentID:  nullableUUID(collection.ParentID),
+		Name:      collection.Name,
+		CreatedAt: dbTime(collection.CreatedAt),
+	}); err != nil {
+		return fmt.Errorf("couldn't save collection to Postgres: %w", wrapError(err))
+	}
+
+	return nil
+}
+
+func (db *PGDatastore) UpdateCollection(ctx context.Context, collection server.Collection) error {
+	if err := pgdb.New(db.DB).UpdateCollection(ctx, pgdb.UpdateCollectionParams{
+		Name:     collection.Name,
+		ParentID: nullableUUID(collection.ParentID),
+		ID:       collection.ID,
+	}); err != nil {
+		return fmt.Errorf("couldn't update collection in Postgres: %w", wrapError(err))
+	}
+
+	return nil
+}
+
+func (db *PGDatastore) DeleteCollection(ctx context.Context, id uuid.UUID) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("couldn't start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	queries := pgdb.New(db.DB).WithTx(tx)
+	if err := queries.DeleteCollectionTracks(ctx, id); err != nil {
+		return fmt.Errorf("couldn't remove tracks from collection: %w", err)
+	}
+
+	if err := queries.DeleteCollection(ctx, id); err != nil {
+		return fmt.Errorf("couldn't delete collection: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("couldn't commit collection deletion: %w", err)
+	}
+
+	return nil
+}
+
+// setTrackCollections replaces the collections a track is in.
+func setTrackCollections(ctx context.Context, queries *pgdb.Queries, trackID uuid.UUID, collectionIDs []uuid.UUID) error {
+	if err := queries.DeleteTrackCollections(ctx, trackID); err != nil {
+		return fmt.Errorf("couldn't clear track collections: %w", err)
+	}
+
+	for _, collectionID := range collectionIDs {
+		if err := queries.AddCollectionTrack(ctx, pgdb.AddCollectionTrackParams{
+			CollectionID: collectionID,
+			TrackID:      trackID,
+		}); err != nil {
+			return fmt.Errorf("couldn't add track to collection %s: %w", collectionID, wrapError(err))
+		}
+	}
+
+	return nil
+}
+
+func nullableUUID(id *uuid.UUID) uuid.NullUUID {
+	if id == nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: *id, Valid: true}
+}
+
+func convertDBCollection(dbCollection pgdb.GetCollectionsRow) server.Collection {
+	var parentID *uuid.UUID
+	if dbCollection.ParentID.Valid {
+		parentID = &dbCollection.ParentID.UUID
+	}
+
+	return server.Collection{
+		ID:         dbCollection.ID,
+		ParentID:   parentID,
+		Name:       dbCollection.Name,
+		CreatedAt:  dbCollection.CreatedAt.UTC(),
+		TrackCount: int(dbCollection.TrackCount),
+	}
+}
@@ -0,0 +1,43 @@
+package pgdatastore
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/terrabitz/rpg-audio-streamer/internal/server"
+)
+
+type PGDatastore struct {
+	*sql.DB
+}
+
+func New(url string) (*PGDatastore, error) {
+	db, err := sql.Open("pgx", url)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't open Postgres DB: %v", err)
+	}
+
+	return &PGDatastore{DB: db}, nil
+}
+
+// wrapError wraps a missing row in server.ErrNotFound and a violated unique or
+// foreign key in server.ErrConflict. Other errors are returned as they are.
+func wrapError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %w", server.ErrNotFound, err)
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case pgerrcode.UniqueViolation, pgerrcode.ForeignKeyViolation:
+			return fmt.Errorf("%w: %w", server.ErrConflict, err)
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,116 @@
+package pgdatastore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/terrabitz/rpg-audio-streamer/internal/pgdatastore/pgdb"
+	"github.com/terrabitz/rpg-audio-streamer/internal/server"
+)
+
+func (db *PGDatastore) SaveJoinToken(ctx context.Context, token server.JoinToken) error {
+	if err := pgdb.New(db.DB).SaveJoinToken(ctx, pgdb.SaveJoinTokenParams{
+		ID:         token.ID,
+		Label:      token.Label,
+		SecretHash: token.SecretHash,
+		CreatedAt:  dbTime(token.CreatedAt),
+		ExpiresAt:  nullableTime(token.ExpiresAt),
+		MaxUses:    int32(token.MaxUses),
+		Uses:       int32(token.Uses),
+		RevokedAt:  nullableTime(token.RevokedAt),
+	}); err != nil {
+		return fmt.Errorf("couldn't save join token to Postgres: %w", wrapError(err))
+	}
+
+	return nil
+}
+
+func (db *PGDatastore) GetJoinTokens(ctx context.Context) ([]server.JoinToken, error) {
+	dbTokens, err := pgdb.New(db.DB).GetJoinTokens(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get join tokens: %w", err)
+	}
+
+	tokens := make([]server.JoinToken, 0, len(dbTokens))
+	for _, dbToken := range dbTokens {
+		tokens = append(tokens, convertDBJoinToken(dbToken))
+	}
+
+	return tokens, nil
+}
+
+func (db *PGDatastore) GetJoinTokenByID(ctx context.Context, id uuid.UUID) (server.JoinToken, error) {
+	dbToken, err := pgdb.New(db.DB).GetJoinTokenByID(ctx, id)
+	if err != nil {
+		return server.JoinToken{}, fmt.Errorf("couldn't get join token by ID: %w", wrapError(err))
+	}
+
+	return convertDBJoinToken(dbToken), nil
+}
+
+func (db *PGDatastore) RotateJoinToken(ctx context.Context, id uuid.UUID, secretHash []byte) error {
+	rows, err := pgdb.New(db.DB).RotateJoinToken(ctx, pgdb.RotateJoinTokenParams{
+		SecretHash: secretHash,
+		ID:         id,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't rotate join token: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("couldn't rotate join token: %w", server.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (db *PGDatastore) RevokeJoinToken(ctx context.Context, id uuid.UUID, revokedAt time.Time) error {
+	rows, err := pgdb.New(db.DB).RevokeJoinToken(ctx, pgdb.RevokeJoinTokenParams{
+		RevokedAt: nullableTime(&revokedAt),
+		ID:        id,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't revoke join token: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("couldn't revoke join token: %w", server.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (db *PGDatastore) UseJoinToken(ctx context.Context, id uuid.UUID, secretHash []byte, now time.Time) (server.JoinToken, error) {
+	dbToken, err := pgdb.New(db.DB).UseJoinToken(ctx, pgdb.UseJoinTokenParams{
+		ID:         id,
+		SecretHash: secretHash,
+		Now:        nullableTime(&now),
+	})
+	if err != nil {
+		return server.JoinToken{}, fmt.Errorf("couldn't use join token: %w", wrapError(err))
+	}
+
+	return convertDBJoinToken(dbToken), nil
+}
+
+func convertDBJoinToken(dbToken pgdb.JoinToken) server.JoinToken {
+	return server.JoinToken{
+		ID:         dbToken.ID,
+		Label:      dbToken.Label,
+		SecretHash: dbToken.SecretHash,
+		CreatedAt:  dbToken.CreatedAt.UTC(),
+		ExpiresAt:  timePtr(dbToken.ExpiresAt),
+		MaxUses:    int(dbToken.MaxUses),
+		Uses:       int(dbToken.Uses),
+		RevokedAt:  timePtr(dbToken.RevokedAt),
+	}
+}
+
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	utc := t.Time.UTC()
+	return &utc
+}
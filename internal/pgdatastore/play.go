@@ -0,0 +1,93 @@
+package pgdatastore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/terrabitz/rpg-audio-streamer/internal/pgdatastore/pgdb"
+	"github.com/terrabitz/rpg-audio-streamer/internal/server"
+)
+
+func (db *PGDatastore) RecordPlay(ctx context.Context, trackID uuid.UUID, startedAt time.Time) error {
+	params := pgdb.SaveTrackPlayParams{
+		TrackID:   trackID,
+		StartedAt: dbTime(startedAt),
+	}
+
+	if err := pgdb.New(db.DB).SaveTrackPlay(ctx, params); err != nil {
+		return fmt.Errorf("couldn't save track play to Postgres: %w", wrapError(err))
+	}
+
+	return nil
+}
+
+func (db *PGDatastore) GetPlayedTrackIDs(ctx context.Context, from, to time.Time) ([]uuid.UUID, error) {
+	params := pgdb.GetPlayedTrackIDsParams{
+		StartedAfter:  dbTime(from),
+		StartedBefore: dbTime(to),
+	}
+
+	trackIDs, err := pgdb.New(db.DB).GetPlayedTrackIDs(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get played tracks: %w", err)
+	}
+
+	return trackIDs, nil
+}
+
+func (db *PGDatastore) FinishPlay(ctx context.Context, trackID uuid.UUID, startedAt time.Time, duration time.Duration) error {
+	params := pgdb.FinishTrackPlayParams{
+		Duration:  sql.NullFloat64{Float64: duration.Seconds(), Valid: true},
+		TrackID:   trackID,
+		StartedAt: dbTime(startedAt),
+	}
+
+	if err := pgdb.New(db.DB).FinishTrackPlay(ctx, params); err != nil {
+		return fmt.Errorf("couldn't save track play duration to Postgres: %w", err)
+	}
+
+	return nil
+}
+
+func (db *PGDatastore) GetRecentTracks(ctx context.Context, limit int) ([]server.TrackStats, error) {
+	rows, err := pgdb.New(db.DB).GetRecentTrackStats(ctx, int32(limit))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get recent tracks: %w", err)
+	}
+
+	stats := make([]server.TrackStats, 0, len(rows))
+	for _, row := range rows {
+		stats = append(stats, convertDBTrackStats(pgdb.GetPopularTrackStatsRow(row)))
+	}
+
+	return stats, nil
+}
+
+func (db *PGDatastore) GetPopularTracks(ctx context.Context, since time.Time, limit int) ([]server.TrackStats, error) {
+	rows, err := pgdb.New(db.DB).GetPopularTrackStats(ctx, pgdb.GetPopularTrackStatsParams{
+		StartedAfter: dbTime(since),
+		Limit:        int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get popular tracks: %w", err)
+	}
+
+	stats := make([]server.TrackStats, 0, len(rows))
+	for _, row := range rows {
+		stats = append(stats, convertDBTrackStats(row))
+	}
+
+	return stats, nil
+}
+
+func convertDBTrackStats(row pgdb.GetPopularTrackStatsRow) server.TrackStats {
+	return server.TrackStats{
+		TrackID:      row.TrackID,
+		PlayCount:    int(row.PlayCount),
+		TotalPlayed:  row.TotalDuration,
+		LastPlayedAt: row.LastPlayedAt.UTC(),
+	}
+}
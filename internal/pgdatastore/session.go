@@ -0,0 +1,74 @@
+package pgdatastore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/terrabitz/rpg-audio-streamer/internal/pgdatastore/pgdb"
+	"github.com/terrabitz/rpg-audio-streamer/internal/server"
+)
+
+func (db *PGDatastore) SaveSessionEvent(ctx context.Context, event server.SessionEvent) error {
+	if err := pgdb.New(db.DB).SaveSessionEvent(ctx, pgdb.SaveSessionEventParams{
+		SessionID: event.SessionID,
+		TableID:   event.TableID,
+		Seq:       event.Seq,
+		// Kept to the millisecond, as in SQLite.
+		CreatedAt: event.CreatedAt.UTC().Truncate(time.Millisecond),
+		Method:    event.Method,
+		SenderID:  event.SenderID,
+		Payload:   event.Payload,
+	}); err != nil {
+		return fmt.Errorf("couldn't save session event to Postgres: %w", wrapError(err))
+	}
+
+	return nil
+}
+
+func (db *PGDatastore) GetSessionEvents(ctx context.Context, sessionID uuid.UUID, page server.SessionEventPage) ([]server.SessionEvent, error) {
+	dbEvents, err := pgdb.New(db.DB).GetSessionEvents(ctx, pgdb.GetSessionEventsParams{
+		SessionID: sessionID,
+		AfterSeq:  page.AfterSeq,
+		Limit:     int32(page.Limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get session events: %w", err)
+	}
+
+	events := make([]server.SessionEvent, 0, len(dbEvents))
+	for _, dbEvent := range dbEvents {
+		events = append(events, server.SessionEvent{
+			SessionID: dbEvent.SessionID,
+			TableID:   dbEvent.TableID,
+			Seq:       dbEvent.Seq,
+			CreatedAt: dbEvent.CreatedAt.UTC(),
+			Method:    dbEvent.Method,
+			SenderID:  dbEvent.SenderID,
+			Payload:   dbEvent.Payload,
+		})
+	}
+
+	return events, nil
+}
+
+func (db *PGDatastore) GetSessions(ctx context.Context, tableID string) ([]server.Session, error) {
+	rows, err := pgdb.New(db.DB).GetSessions(ctx, tableID)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get sessions: %w", err)
+	}
+
+	sessions := make([]server.Session, 0, len(rows))
+	for _, row := range rows {
+		sessions = append(sessions, server.Session{
+			ID:          row.SessionID,
+			TableID:     tableID,
+			StartedAt:   row.StartedAt.UTC(),
+			LastEventAt: row.LastEventAt.UTC(),
+			EventCount:  row.EventCount,
+		})
+	}
+
+	return sessions, nil
+}
@@ -0,0 +1,470 @@
+package pgdatastore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/terrabitz/rpg-audio-streamer/internal/pgdatastore/pgdb"
+	"github.com/terrabitz/rpg-audio-streamer/internal/server"
+)
+
+func (db *PGDatastore) SaveTrack(ctx context.Context, track *server.Track) error {
+	metadata, err := encodeMetadata(track.Metadata)
+	if err != nil {
+		return err
+	}
+
+	dbTrack := pgdb.SaveTrackParams{
+		ID:        track.ID,
+		CreatedAt: dbTime(track.CreatedAt),
+		Name:      track.Name,
+		Path:      track.Path,
+		TypeID:    track.TypeID,
+		Duration:  track.Duration,
+		Metadata:  metadata,
+	}
+
+	if err := pgdb.New(db.DB).SaveTrack(ctx, dbTrack); err != nil {
+		return fmt.Errorf("couldn't save track to Postgres: %w", wrapError(err))
+	}
+
+	return nil
+}
+
+func (db *PGDatastore) SetTrackMedia(ctx context.Context, trackID uuid.UUID, duration float64, metadata map[string]string) error {
+	encoded, err := encodeMetadata(metadata)
+	if err != nil {
+		return err
+	}
+
+	params := pgdb.SetTrackMediaParams{
+		Duration: duration,
+		Metadata: encoded,
+		ID:       trackID,
+	}
+	if err := pgdb.New(db.DB).SetTrackMedia(ctx, params); err != nil {
+		return fmt.Errorf("couldn't update track media in Postgres: %w", wrapError(err))
+	}
+
+	return nil
+}
+
+func encodeMetadata(metadata map[string]string) (json.RawMessage, error) {
+	if metadata == nil {
+		return json.RawMessage("{}"), nil
+	}
+
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't encode track metadata: %w", err)
+	}
+	return encoded, nil
+}
+
+// dbTime rounds a time down to the second, which is all SQLite keeps, so
+// both stores hand back the same times.
+func dbTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Second)
+}
+
+func (db *PGDatastore) GetTracks(ctx context.Context, filter server.TrackFilter, page server.TrackPage) ([]server.Track, error) {
+	query, typeID, tag, minID, maxID := trackFilterParams(filter)
+	params := pgdb.GetTracksParams{
+		Query:        query,
+		TypeID:       typeID,
+		Tag:          tag,
+		MinID:        minID,
+		MaxID:        maxID,
+		CollectionID: nullableUUID(filter.CollectionID),
+		Favorite:     nullableBool(filter.Favorite),
+		SortBy:       string(page.Sort),
+		Descending:   page.Descending,
+		Limit:        int32(page.Limit),
+	}
+
+	if page.After != nil {
+		params.CursorID = uuid.NullUUID{UUID: page.After.ID, Valid: true}
+		params.CursorName = page.After.Name
+		params.CursorDuration = page.After.Duration
+		params.CursorTypeID = page.After.TypeID
+		params.CursorPosition = int32(page.After.Position)
+	}
+
+	dbTracks, err := pgdb.New(db.DB).GetTracks(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []server.Track
+	for _, dbTrack := range dbTracks {
+		track, parseErr := convertDBTrack(dbTrack)
+		if parseErr != nil {
+			return nil, parseErr
+		}
+		result = append(result, track)
+	}
+	return result, nil
+}
+
+func (db *PGDatastore) CountTracks(ctx context.Context, filter server.TrackFilter) (int, error) {
+	query, typeID, tag, minID, maxID := trackFilterParams(filter)
+	count, err := pgdb.New(db.DB).CountTracks(ctx, pgdb.CountTracksParams{
+		Query:        query,
+		TypeID:       typeID,
+		Tag:          tag,
+		MinID:        minID,
+		MaxID:        maxID,
+		CollectionID: nullableUUID(filter.CollectionID),
+		Favorite:     nullableBool(filter.Favorite),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("couldn't count tracks: %w", err)
+	}
+
+	return int(count), nil
+}
+
+// trackFilterParams converts a filter into query parameters. As in the SQLite
+// store, the creation date range is matched against the UUIDv7 track IDs
+// rather than created_at, as they compare chronologically and are indexed.
+func trackFilterParams(filter server.TrackFilter) (query sql.NullString, typeID uuid.NullUUID, tag sql.NullString, minID, maxID uuid.NullUUID) {
+	if q := tsQuery(filter.Query); q != "" {
+		query = sql.NullString{String: q, Valid: true}
+	}
+
+	if filter.Tag != "" {
+		tag = sql.NullString{String: filter.Tag, Valid: true}
+	}
+
+	typeID = nullableUUID(filter.TypeID)
+
+	if !filter.CreatedAfter.IsZero() {
+		minID = uuid.NullUUID{UUID: uuidV7Floor(filter.CreatedAfter), Valid: true}
+	}
+
+	if !filter.CreatedBefore.IsZero() {
+		maxID = uuid.NullUUID{UUID: uuidV7Floor(filter.CreatedBefore), Valid: true}
+	}
+
+	return query, typeID, tag, minID, maxID
+}
+
+func nullableBool(b *bool) sql.NullBool {
+	if b == nil {
+		return sql.NullBool{}
+	}
+	return sql.NullBool{Bool: *b, Valid: true}
+}
+
+// uuidV7Floor returns the smallest possible UUIDv7 created at t.
+func uuidV7Floor(t time.Time) uuid.UUID {
+	var id uuid.UUID
+	var ms [8]byte
+	binary.BigEndian.PutUint64(ms[:], uint64(t.UnixMilli()))
+	copy(id[:6], ms[2:])
+	return id
+}
+
+func (db *PGDatastore) GetTrackByID(ctx context.Context, trackID uuid.UUID) (server.Track, error) {
+	dbTrack, err := pgdb.New(db.DB).GetTrackByID(ctx, trackID)
+	if err != nil {
+		return server.Track{}, fmt.Errorf("couldn't get track by ID: %w", wrapError(err))
+	}
+
+	return convertDBTrack(dbTrack)
+}
+
+func (db *PGDatastore) DeleteTrack(ctx context.Context, trackID uuid.UUID) error {
+	return pgdb.New(db.DB).DeleteTrackByID(ctx, trackID)
+}
+
+func (db *PGDatastore) UpdateTrack(ctx context.Context, trackID uuid.UUID, update server.UpdateTrackRequest) (server.Track, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return server.Track{}, fmt.Errorf("couldn't start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	track, err := updateTrack(ctx, pgdb.New(db.DB).WithTx(tx), trackID, update)
+	if err != nil {
+		return server.Track{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return server.Track{}, fmt.Errorf("couldn't commit track update: %w", err)
+	}
+
+	return track, nil
+}
+
+func (db *PGDatastore) ModifyTracks(ctx context.Context, trackIDs []uuid.UUID, modify func(server.Track) server.UpdateTrackRequest) ([]server.Track, []server.Track, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("couldn't start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	queries := pgdb.New(db.DB).WithTx(tx)
+	before, err := lockTracks(ctx, queries, trackIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	after := make([]server.Track, 0, len(before))
+	for _, track := range before {
+		updated, err := updateTrack(ctx, queries, track.ID, modify(track))
+		if err != nil {
+			return nil, nil, fmt.Errorf("couldn't update track %s: %w", track.ID, err)
+		}
+		after = append(after, updated)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("couldn't commit track updates: %w", err)
+	}
+
+	return before, after, nil
+}
+
+func (db *PGDatastore) DeleteTracks(ctx context.Context, trackIDs []uuid.UUID) ([]server.Track, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	queries := pgdb.New(db.DB).WithTx(tx)
+	tracks, err := lockTracks(ctx, queries, trackIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, trackID := range trackIDs {
+		if err := queries.DeleteTrackByID(ctx, trackID); err != nil {
+			return nil, fmt.Errorf("couldn't delete track %s: %w", trackID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("couldn't commit track deletion: %w", err)
+	}
+
+	return tracks, nil
+}
+
+// lockTracks locks tracks for the rest of the transaction and reads them. It
+// fails with a *server.MissingTracksError if any don't exist.
+func lockTracks(ctx context.Context, queries *pgdb.Queries, trackIDs []uuid.UUID) ([]server.Track, error) {
+	var missing []uuid.UUID
+	for _, trackID := range trackIDs {
+		if _, err := queries.LockTrack(ctx, trackID); errors.Is(err, sql.ErrNoRows) {
+			missing = append(missing, trackID)
+		} else if err != nil {
+			return nil, fmt.Errorf("couldn't lock track %s: %w", trackID, err)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, &server.MissingTracksError{TrackIDs: missing}
+	}
+
+	tracks := make([]server.Track, 0, len(trackIDs))
+	for _, trackID := range trackIDs {
+		dbTrack, err := queries.GetTrackByID(ctx, trackID)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't get track %s: %w", trackID, wrapError(err))
+		}
+		track, err := convertDBTrack(dbTrack)
+		if err != nil {
+			return nil, err
+		}
+		tracks = append(tracks, track)
+	}
+
+	return tracks, nil
+}
+
+// updateTrack applies an update to a track within a transaction and returns
+// the updated track.
+func updateTrack(ctx context.Context, queries *pgdb.Queries, trackID uuid.UUID, update server.UpdateTrackRequest) (server.Track, error) {
+	params := pgdb.UpdateTrackParams{
+		ID:       trackID,
+		TypeID:   nullableUUID(update.TypeID),
+		Favorite: nullableBool(update.Favorite),
+	}
+
+	for _, field := range []struct {
+		value *string
+		param *sql.NullString
+	}{
+		{update.Name, &params.Name},
+		{update.Description, &params.Description},
+		{update.Artist, &params.Artist},
+		{update.SourceURL, &params.SourceUrl},
+		{update.License, &params.License},
+		{update.Attribution, &params.Attribution},
+	} {
+		if field.value != nil {
+			*field.param = sql.NullString{String: *field.value, Valid: true}
+		}
+	}
+
+	if _, err := queries.UpdateTrack(ctx, params); err != nil {
+		return server.Track{}, fmt.Errorf("couldn't update track in Postgres: %w", wrapError(err))
+	}
+
+	if update.Tags != nil {
+		if err := setTrackTags(ctx, queries, trackID, *update.Tags); err != nil {
+			return server.Track{}, err
+		}
+	}
+
+	if update.Collections != nil {
+		if err := setTrackCollections(ctx, queries, trackID, *update.Collections); err != nil {
+			return server.Track{}, err
+		}
+	}
+
+	dbTrack, err := queries.GetTrackByID(ctx, trackID)
+	if err != nil {
+		return server.Track{}, fmt.Errorf("couldn't get updated track: %w", wrapError(err))
+	}
+
+	return convertDBTrack(dbTrack)
+}
+
+func (db *PGDatastore) ReorderTracks(ctx context.Context, typeID uuid.UUID, trackIDs []uuid.UUID) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("couldn't start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	queries := pgdb.New(db.DB).WithTx(tx)
+	current, err := queries.GetTrackIDsByType(ctx, typeID)
+	if err != nil {
+		return fmt.Errorf("couldn't get tracks of type: %w", err)
+	}
+
+	remaining := make(map[uuid.UUID]bool, len(current))
+	for _, id := range current {
+		remaining[id] = true
+	}
+
+	if len(trackIDs) != len(remaining) {
+		return server.ErrTrackOrderMismatch
+	}
+
+	for position, trackID := range trackIDs {
+		if !remaining[trackID] {
+			return server.ErrTrackOrderMismatch
+		}
+		delete(remaining, trackID)
+
+		if err := queries.SetTrackPosition(ctx, pgdb.SetTrackPositionParams{
+			Position: int32(position),
+			ID:       trackID,
+		}); err != nil {
+			return fmt.Errorf("couldn't set track position: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("couldn't commit track order: %w", err)
+	}
+
+	return nil
+}
+
+func (db *PGDatastore) GetTags(ctx context.Context) ([]string, error) {
+	return pgdb.New(db.DB).GetTags(ctx)
+}
+
+// setTrackTags replaces a track's tags, creating any new ones and dropping
+// tags no track uses anymore.
+func setTrackTags(ctx context.Context, queries *pgdb.Queries, trackID uuid.UUID, tags []string) error {
+	if err := queries.DeleteTrackTags(ctx, trackID); err != nil {
+		return fmt.Errorf("couldn't clear track tags: %w", err)
+	}
+
+	for _, tag := range tags {
+		id, err := queries.UpsertTag(ctx, pgdb.UpsertTagParams{
+			ID:   uuid.New(),
+			Name: tag,
+		})
+		if err != nil {
+			return fmt.Errorf("couldn't save tag '%s': %w", tag, err)
+		}
+
+		if err := queries.AddTrackTag(ctx, pgdb.AddTrackTagParams{
+			TrackID: trackID,
+			TagID:   id,
+		}); err != nil {
+			return fmt.Errorf("couldn't tag track with '%s': %w", tag, wrapError(err))
+		}
+	}
+
+	if err := queries.DeleteUnusedTags(ctx); err != nil {
+		return fmt.Errorf("couldn't remove unused tags: %w", err)
+	}
+
+	return nil
+}
+
+// tsQuery turns free text into a tsquery matching every word as a prefix, the
+// same way the SQLite store matches its full-text index. Only letters and
+// numbers are kept, so user input can never be a tsquery syntax error.
+func tsQuery(text string) string {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	for i, word := range words {
+		words[i] = word + ":*"
+	}
+
+	return strings.Join(words, " & ")
+}
+
+func convertDBTrack(dbTrack pgdb.TrackDetail) (server.Track, error) {
+	var metadata map[string]string
+	if err := json.Unmarshal(dbTrack.Metadata, &metadata); err != nil {
+		return server.Track{}, fmt.Errorf("invalid Metadata: %w", err)
+	}
+
+	var tags []string
+	if err := json.Unmarshal(dbTrack.Tags, &tags); err != nil {
+		return server.Track{}, fmt.Errorf("invalid Tags: %w", err)
+	}
+
+	var collections []uuid.UUID
+	if err := json.Unmarshal(dbTrack.Collections, &collections); err != nil {
+		return server.Track{}, fmt.Errorf("invalid Collections: %w", err)
+	}
+
+	return server.Track{
+		ID:          dbTrack.ID,
+		CreatedAt:   dbTrack.CreatedAt,
+		Name:        dbTrack.Name,
+		Path:        dbTrack.Path,
+		TypeID:      dbTrack.TypeID,
+		Duration:    dbTrack.Duration,
+		Metadata:    metadata,
+		Tags:        tags,
+		Position:    int(dbTrack.Position),
+		Collections: collections,
+		Description: dbTrack.Description,
+		Artist:      dbTrack.Artist,
+		SourceURL:   dbTrack.SourceUrl,
+		License:     dbTrack.License,
+		Attribution: dbTrack.Attribution,
+		Favorite:    dbTrack.Favorite,
+	}, nil
+}
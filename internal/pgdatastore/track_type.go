@@ -0,0 +1,126 @@
+package pgdatastore
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/terrabitz/rpg-audio-streamer/internal/pgdatastore/pgdb"
+	"github.com/terrabitz/rpg-audio-streamer/internal/server"
+)
+
+func (db *PGDatastore) GetTrackTypes(ctx context.Context) ([]server.TrackType, error) {
+	dbTrackTypes, err := pgdb.New(db.DB).GetTrackTypes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []server.TrackType
+	for _, dbTrackType := range dbTrackTypes {
+		result = append(result, convertDBTrackType(dbTrackType))
+	}
+	return result, nil
+}
+
+func (db *PGDatastore) GetTrackTypeByID(ctx context.Context, id uuid.UUID) (server.TrackType, error) {
+	dbTrackType, err := pgdb.New(db.DB).GetTrackTypeByID(ctx, id)
+	if err != nil {
+		return server.TrackType{}, wrapError(err)
+	}
+
+	return convertDBTrackType(dbTrackType), nil
+}
+
+func (db *PGDatastore) SaveTrackType(ctx context.Context, trackType server.TrackType) error {
+	if err := pgdb.New(db.DB).SaveTrackType(ctx, pgdb.SaveTrackTypeParams{
+		ID:                       trackType.ID,
+		Name:                     trackType.Name,
+		Color:                    trackType.Color,
+		IsRepeating:              trackType.IsRepeating,
+		AllowSimultaneousPlay:    trackType.AllowSimultaneousPlay,
+		DefaultVolume:            int32(trackType.DefaultVolume),
+		FadeInMs:                 int32(trackType.FadeInMs),
+		FadeOutMs:                int32(trackType.FadeOutMs),
+		AlwaysStartFromBeginning: trackType.AlwaysStartFromBeginning,
+		MaxConcurrent:            int32(trackType.MaxConcurrent),
+		AutoStopOthers:           trackType.AutoStopOthers,
+	}); err != nil {
+		return fmt.Errorf("couldn't save track type to Postgres: %w", wrapError(err))
+	}
+
+	return nil
+}
+
+func (db *PGDatastore) UpdateTrackType(ctx context.Context, trackType server.TrackType) error {
+	if err := pgdb.New(db.DB).UpdateTrackType(ctx, pgdb.UpdateTrackTypeParams{
+		Name:                     trackType.Name,
+		Color:                    trackType.Color,
+		IsRepeating:              trackType.IsRepeating,
+		AllowSimultaneousPlay:    trackType.AllowSimultaneousPlay,
+		DefaultVolume:            int32(trackType.DefaultVolume),
+		FadeInMs:                 int32(trackType.FadeInMs),
+		FadeOutMs:                int32(trackType.FadeOutMs),
+		AlwaysStartFromBeginning: trackType.AlwaysStartFromBeginning,
+		MaxConcurrent:            int32(trackType.MaxConcurrent),
+		AutoStopOthers:           trackType.AutoStopOthers,
+		ID:                       trackType.ID,
+	}); err != nil {
+		return fmt.Errorf("couldn't update track type in Postgres: %w", wrapError(err))
+	}
+
+	return nil
+}
+
+func (db *PGDatastore) DeleteTrackType(ctx context.Context, id uuid.UUID, reassignTo *uuid.UUID) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("couldn't start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	queries := pgdb.New(db.DB).WithTx(tx)
+	if reassignTo != nil {
+		if err := queries.MoveTracksToType(ctx, pgdb.MoveTracksToTypeParams{
+			NewTypeID: *reassignTo,
+			OldTypeID: id,
+		}); err != nil {
+			return fmt.Errorf("couldn't reassign tracks: %w", wrapError(err))
+		}
+	}
+
+	// The foreign key would refuse the delete anyway, but checking first says
+	// why.
+	remaining, err := queries.GetTrackIDsByType(ctx, id)
+	if err != nil {
+		return fmt.Errorf("couldn't check for remaining tracks: %w", err)
+	}
+	if len(remaining) > 0 {
+		return server.ErrTrackTypeInUse
+	}
+
+	if err := queries.DeleteTrackType(ctx, id); err != nil {
+		return fmt.Errorf("couldn't delete track type: %w", wrapError(err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("couldn't commit track type deletion: %w", err)
+	}
+
+	return nil
+}
+
+func convertDBTrackType(dbTrackType pgdb.TrackType) server.TrackType {
+	return server.TrackType{
+		ID:                       dbTrackType.ID,
+		Name:                     dbTrackType.Name,
+		Color:                    dbTrackType.Color,
+		IsRepeating:              dbTrackType.IsRepeating,
+		AllowSimultaneousPlay:    dbTrackType.AllowSimultaneousPlay,
+		DefaultVolume:            int(dbTrackType.DefaultVolume),
+		FadeInMs:                 int(dbTrackType.FadeInMs),
+		FadeOutMs:                int(dbTrackType.FadeOutMs),
+		AlwaysStartFromBeginning: dbTrackType.AlwaysStartFromBeginning,
+		MaxConcurrent:            int(dbTrackType.MaxConcurrent),
+		AutoStopOthers:           dbTrackType.AutoStopOthers,
+	}
+}
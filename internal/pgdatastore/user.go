@@ -0,0 +1,87 @@
+package pgdatastore
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/terrabitz/rpg-audio-streamer/internal/pgdatastore/pgdb"
+	"github.com/terrabitz/rpg-audio-streamer/internal/server"
+)
+
+func (db *PGDatastore) SaveUser(ctx context.Context, user server.User) error {
+	if err := pgdb.New(db.DB).SaveUser(ctx, pgdb.SaveUserParams{
+		ID:           user.ID,
+		Username:     user.Username,
+		PasswordHash: user.PasswordHash,
+		Admin:        user.Admin,
+		CreatedAt:    dbTime(user.CreatedAt),
+	}); err != nil {
+		return fmt.Errorf("couldn't save user to Postgres: %w", wrapError(err))
+	}
+
+	return nil
+}
+
+func (db *PGDatastore) GetUsers(ctx context.Context) ([]server.User, error) {
+	dbUsers, err := pgdb.New(db.DB).GetUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get users: %w", err)
+	}
+
+	users := make([]server.User, 0, len(dbUsers))
+	for _, dbUser := range dbUsers {
+		users = append(users, convertDBUser(dbUser))
+	}
+
+	return users, nil
+}
+
+func (db *PGDatastore) GetUserByID(ctx context.Context, id uuid.UUID) (server.User, error) {
+	dbUser, err := pgdb.New(db.DB).GetUserByID(ctx, id)
+	if err != nil {
+		return server.User{}, fmt.Errorf("couldn't get user by ID: %w", wrapError(err))
+	}
+
+	return convertDBUser(dbUser), nil
+}
+
+func (db *PGDatastore) GetUserByUsername(ctx context.Context, username string) (server.User, error) {
+	dbUser, err := pgdb.New(db.DB).GetUserByUsername(ctx, username)
+	if err != nil {
+		return server.User{}, fmt.Errorf("couldn't get user by username: %w", wrapError(err))
+	}
+
+	return convertDBUser(dbUser), nil
+}
+
+func (db *PGDatastore) UpdateUser(ctx context.Context, user server.User) error {
+	if err := pgdb.New(db.DB).UpdateUser(ctx, pgdb.UpdateUserParams{
+		Username:     user.Username,
+		PasswordHash: user.PasswordHash,
+		Admin:        user.Admin,
+		ID:           user.ID,
+	}); err != nil {
+		return fmt.Errorf("couldn't update user in Postgres: %w", wrapError(err))
+	}
+
+	return nil
+}
+
+func (db *PGDatastore) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	if err := pgdb.New(db.DB).DeleteUser(ctx, id); err != nil {
+		return fmt.Errorf("couldn't delete user: %w", err)
+	}
+
+	return nil
+}
+
+func convertDBUser(dbUser pgdb.User) server.User {
+	return server.User{
+		ID:           dbUser.ID,
+		Username:     dbUser.Username,
+		PasswordHash: dbUser.PasswordHash,
+		Admin:        dbUser.Admin,
+		CreatedAt:    dbUser.CreatedAt.UTC(),
+	}
+}
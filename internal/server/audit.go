@@ -0,0 +1,178 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/terrabitz/rpg-audio-streamer/internal/auth"
+)
+
+// AuditLog is one page of the audit log, newest entries first.
+type AuditLog struct {
+	Entries []AuditEntry `json:"entries"`
+	// NextCursor requests older entries. It's empty on the last page.
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// audit records an action in the audit log. before and after are the target
+// as it was and as it is now, either of which may be nil; only the fields
+// that differ are kept. Failing to write the log doesn't fail the action, as
+// it has already happened by the time it's recorded.
+func (s *Server) audit(r *http.Request, actor string, action AuditAction, targetID string, before, after any) {
+	entry := AuditEntry{
+		CreatedAt: time.Now(),
+		Actor:     actor,
+		Action:    action,
+		TargetID:  targetID,
+		IP:        clientIP(r),
+	}
+
+	var err error
+	if entry.Before, entry.After, err = auditDiff(before, after); err != nil {
+		s.logger.Error("failed to diff audit states", "error", err, "action", action)
+	}
+
+	if err := s.store.SaveAuditEntry(r.Context(), entry); err != nil {
+		s.logger.Error("failed to save audit entry", "error", err, "action", action, "actor", actor)
+	}
+}
+
+// tokenActor names who a token was issued to, for the audit log.
+func tokenActor(token *auth.Token) string {
+	if token == nil {
+		return ""
+	}
+	if token.Subject != "" {
+		return token.Subject
+	}
+	return string(token.Role)
+}
+
+// clientIP is the address the request came from. Forwarding headers aren't
+// trusted, since the server doesn't know whether it's behind a proxy.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// auditDiff encodes before and after as JSON objects holding only the fields
+// that differ between them. A nil state stays empty, so created and deleted
+// targets are recorded in full on one side.
+func auditDiff(before, after any) (json.RawMessage, json.RawMessage, error) {
+	beforeFields, err := auditFields(before)
+	if err != nil {
+		return nil, nil, err
+	}
+	afterFields, err := auditFields(after)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if beforeFields != nil && afterFields != nil {
+		for key, value := range beforeFields {
+			if other, ok := afterFields[key]; ok && bytes.Equal(value, other) {
+				delete(beforeFields, key)
+				delete(afterFields, key)
+			}
+		}
+	}
+
+	beforeJSON, err := encodeAuditFields(beforeFields)
+	if err != nil {
+		return nil, nil, err
+	}
+	afterJSON, err := encodeAuditFields(afterFields)
+	if err != nil {
+		return nil, nil, err
+	}
+	return beforeJSON, afterJSON, nil
+}
+
+func auditFields(state any) (map[string]json.RawMessage, error) {
+	if state == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't encode audit state: %w", err)
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("audit state isn't a JSON object: %w", err)
+	}
+	return fields, nil
+}
+
+func encodeAuditFields(fields map[string]json.RawMessage) (json.RawMessage, error) {
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(fields)
+}
+
+func (s *Server) handleAudit(w http.ResponseWriter, r *http.Request, token *auth.Token) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := AuditFilter{
+		Actor:    query.Get("actor"),
+		Action:   AuditAction(query.Get("action")),
+		TargetID: query.Get("target"),
+	}
+
+	for param, dest := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if value := query.Get(param); value != "" {
+			t, err := parseDateParam(value)
+			if err != nil {
+				http.Error(w, "Invalid "+param+" date", http.StatusBadRequest)
+				return
+			}
+			*dest = &t
+		}
+	}
+
+	page := AuditPage{Limit: defaultPageSize}
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxPageSize {
+			http.Error(w, fmt.Sprintf("Invalid pagination: limit must be between 1 and %d", maxPageSize), http.StatusBadRequest)
+			return
+		}
+		page.Limit = limit
+	}
+	if cursor := query.Get("cursor"); cursor != "" {
+		beforeID, err := strconv.ParseInt(cursor, 10, 64)
+		if err != nil || beforeID < 1 {
+			http.Error(w, "Invalid pagination: malformed cursor", http.StatusBadRequest)
+			return
+		}
+		page.BeforeID = beforeID
+	}
+
+	entries, err := s.store.GetAuditEntries(r.Context(), filter, page)
+	if err != nil {
+		s.logger.Error("failed to get audit entries", "error", err)
+		http.Error(w, "Failed to retrieve audit log", http.StatusInternalServerError)
+		return
+	}
+
+	auditLog := AuditLog{Entries: entries}
+	if len(entries) == page.Limit {
+		auditLog.NextCursor = strconv.FormatInt(entries[len(entries)-1].ID, 10)
+	}
+
+	respondJSON(w, http.StatusOK, auditLog)
+}
@@ -0,0 +1,178 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/terrabitz/rpg-audio-streamer/internal/auth"
+)
+
+func TestAuditDiff(t *testing.T) {
+	before := map[string]any{"name": "Lute", "tags": []string{"calm"}, "volume": 80}
+	after := map[string]any{"name": "Lute", "tags": []string{"calm", "tavern"}, "volume": 80}
+
+	beforeJSON, afterJSON, err := auditDiff(before, after)
+	if err != nil {
+		t.Fatalf("failed to diff: %v", err)
+	}
+	if string(beforeJSON) != `{"tags":["calm"]}` {
+		t.Errorf("expected only changed fields before; got %s", beforeJSON)
+	}
+	if string(afterJSON) != `{"tags":["calm","tavern"]}` {
+		t.Errorf("expected only changed fields after; got %s", afterJSON)
+	}
+
+	beforeJSON, afterJSON, err = auditDiff(nil, map[string]any{"name": "Lute"})
+	if err != nil {
+		t.Fatalf("failed to diff: %v", err)
+	}
+	if beforeJSON != nil || string(afterJSON) != `{"name":"Lute"}` {
+		t.Errorf("expected a created target in full; got %s -> %s", beforeJSON, afterJSON)
+	}
+
+	if _, _, err := auditDiff("not an object", nil); err == nil {
+		t.Error("expected an error for a state that isn't an object")
+	}
+}
+
+func TestAuditHandlers(t *testing.T) {
+	ts := setupTestServer(t)
+	defer ts.cleanup(t)
+
+	store := ts.store.(*MockTrackStore)
+	token := &auth.Token{Role: auth.RoleGM, Subject: "gm"}
+
+	trackID := uuid.New()
+	store.tracks[trackID] = Track{ID: trackID, Name: "Lute", Tags: []string{"calm"}}
+
+	t.Run("track update", func(t *testing.T) {
+		body := `{"name":"Lute","tags":["calm","tavern"]}`
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/files/"+trackID.String(), strings.NewReader(body))
+		req.SetPathValue("trackID", trackID.String())
+		req.RemoteAddr = "192.0.2.1:5000"
+		rec := httptest.NewRecorder()
+		ts.handleFileUpdate(rec, req, token)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status OK; got %v: %s", rec.Code, rec.Body)
+		}
+
+		entry := store.audit[len(store.audit)-1]
+		if entry.Action != AuditTrackUpdate || entry.Actor != "gm" || entry.TargetID != trackID.String() || entry.IP != "192.0.2.1" {
+			t.Errorf("unexpected audit entry %+v", entry)
+		}
+		if string(entry.Before) != `{"tags":["calm"]}` || string(entry.After) != `{"tags":["calm","tavern"]}` {
+			t.Errorf("expected only the tags in the diff; got %s -> %s", entry.Before, entry.After)
+		}
+	})
+
+	t.Run("track type create", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/trackTypes", strings.NewReader(`{"name":"Weather","color":"#A5D6A7"}`))
+		rec := httptest.NewRecorder()
+		ts.handleTrackTypes(rec, req, token)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status OK; got %v: %s", rec.Code, rec.Body)
+		}
+
+		entry := store.audit[len(store.audit)-1]
+		if entry.Action != AuditTrackTypeCreate || entry.Before != nil || !bytes.Contains(entry.After, []byte(`"Weather"`)) {
+			t.Errorf("unexpected audit entry %+v", entry)
+		}
+	})
+
+	t.Run("logins", func(t *testing.T) {
+		for _, creds := range []loginRequest{
+			{Username: "testuser", Password: "testpass"},
+			{Username: "intruder", Password: "guess"},
+		} {
+			body, _ := json.Marshal(creds)
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/login", bytes.NewReader(body))
+			ts.handleLogin(httptest.NewRecorder(), req)
+		}
+
+		failed := store.audit[len(store.audit)-1]
+		if failed.Action != AuditLoginFailed || failed.Actor != "intruder" {
+			t.Errorf("expected a failed login by intruder; got %+v", failed)
+		}
+		if login := store.audit[len(store.audit)-2]; login.Action != AuditLogin {
+			t.Errorf("expected a login; got %+v", login)
+		}
+	})
+}
+
+func TestGetAuditLog(t *testing.T) {
+	ts := setupTestServer(t)
+	defer ts.cleanup(t)
+
+	store := ts.store.(*MockTrackStore)
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i, action := range []AuditAction{AuditLogin, AuditTrackCreate, AuditTrackUpdate, AuditTrackUpdate, AuditLogout} {
+		store.SaveAuditEntry(t.Context(), AuditEntry{
+			CreatedAt: start.Add(time.Duration(i) * time.Hour),
+			Actor:     "gm",
+			Action:    action,
+		})
+	}
+
+	get := func(t *testing.T, query string) (int, AuditLog) {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/audit?"+query, nil)
+		rec := httptest.NewRecorder()
+		ts.handleAudit(rec, req, &auth.Token{Role: auth.RoleGM})
+
+		var auditLog AuditLog
+		if rec.Code == http.StatusOK {
+			if err := json.NewDecoder(rec.Body).Decode(&auditLog); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+		}
+		return rec.Code, auditLog
+	}
+
+	ids := func(entries []AuditEntry) []int64 {
+		var ids []int64
+		for _, entry := range entries {
+			ids = append(ids, entry.ID)
+		}
+		return ids
+	}
+
+	t.Run("newest first with pages", func(t *testing.T) {
+		_, first := get(t, "limit=3")
+		if got := ids(first.Entries); !slices.Equal(got, []int64{5, 4, 3}) || first.NextCursor != "3" {
+			t.Fatalf("unexpected first page %v, cursor %q", got, first.NextCursor)
+		}
+
+		_, second := get(t, "limit=3&cursor="+first.NextCursor)
+		if got := ids(second.Entries); !slices.Equal(got, []int64{2, 1}) || second.NextCursor != "" {
+			t.Errorf("unexpected second page %v, cursor %q", got, second.NextCursor)
+		}
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		_, byAction := get(t, "action=track.update")
+		if got := ids(byAction.Entries); !slices.Equal(got, []int64{4, 3}) {
+			t.Errorf("expected the updates; got %v", got)
+		}
+
+		_, byTime := get(t, "from=2024-05-01T13:00:00Z&to=2024-05-01T15:00:00Z")
+		if got := ids(byTime.Entries); !slices.Equal(got, []int64{3, 2}) {
+			t.Errorf("expected entries in the range; got %v", got)
+		}
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		for _, query := range []string{"limit=0", "cursor=abc", "from=yesterday"} {
+			if code, _ := get(t, query); code != http.StatusBadRequest {
+				t.Errorf("expected BadRequest for %q; got %v", query, code)
+			}
+		}
+	})
+}
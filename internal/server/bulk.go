@@ -0,0 +1,244 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/terrabitz/rpg-audio-streamer/internal/auth"
+)
+
+const maxBulkTracks = 500
+
+// BulkAction is an operation applied to every track in a bulk request.
+type BulkAction string
+
+const (
+	BulkActionSetType              BulkAction = "setType"
+	BulkActionAddTags              BulkAction = "addTags"
+	BulkActionRemoveTags           BulkAction = "removeTags"
+	BulkActionAddToCollection      BulkAction = "addToCollection"
+	BulkActionRemoveFromCollection BulkAction = "removeFromCollection"
+	BulkActionDelete               BulkAction = "delete"
+)
+
+// BulkTrackRequest applies one action to a list of tracks. Which of the other
+// fields are needed depends on the action.
+type BulkTrackRequest struct {
+	TrackIDs     []uuid.UUID `json:"trackIDs"`
+	Action       BulkAction  `json:"action"`
+	TypeID       *uuid.UUID  `json:"typeID"`
+	Tags         []string    `json:"tags"`
+	CollectionID *uuid.UUID  `json:"collectionID"`
+}
+
+// BulkTrackResult is the outcome of a bulk action for one track.
+type BulkTrackResult struct {
+	TrackID uuid.UUID `json:"trackID"`
+	// Error says why the action can't be applied to the track.
+	Error string `json:"error,omitempty"`
+	// Track is the updated track. It's left out for deleted tracks and when
+	// the request was rejected.
+	Track *Track `json:"track,omitempty"`
+}
+
+// BulkTrackResponse lists a result for each requested track, in order. The
+// action is applied to every track or, if any of them fails, to none.
+type BulkTrackResponse struct {
+	Applied bool              `json:"applied"`
+	Results []BulkTrackResult `json:"results"`
+}
+
+func (s *Server) handleFilesBulk(w http.ResponseWriter, r *http.Request, token *auth.Token) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req BulkTrackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if len(req.TrackIDs) == 0 {
+		http.Error(w, "No tracks given", http.StatusBadRequest)
+		return
+	}
+	if len(req.TrackIDs) > maxBulkTracks {
+		http.Error(w, fmt.Sprintf("At most %d tracks can be changed at once", maxBulkTracks), http.StatusBadRequest)
+		return
+	}
+
+	if !s.checkBulkAction(w, r, &req) {
+		return
+	}
+
+	results := make([]BulkTrackResult, len(req.TrackIDs))
+	failed := false
+	for i, id := range req.TrackIDs {
+		results[i].TrackID = id
+
+		if slices.Contains(req.TrackIDs[:i], id) {
+			results[i].Error = "Track is listed more than once"
+			failed = true
+		}
+	}
+
+	if failed {
+		// Nothing is written, so the other tracks are only looked up to
+		// report any that are missing as well.
+		for i := range results {
+			if results[i].Error != "" {
+				continue
+			}
+			if _, err := s.store.GetTrackByID(r.Context(), results[i].TrackID); errors.Is(err, ErrNotFound) {
+				results[i].Error = "Track not found"
+			} else if err != nil {
+				s.logger.Error("failed to get track", "error", err, "trackID", results[i].TrackID)
+				http.Error(w, "Failed to get tracks", http.StatusInternalServerError)
+				return
+			}
+		}
+		respondJSON(w, http.StatusBadRequest, BulkTrackResponse{Results: results})
+		return
+	}
+
+	// The tracks are read, checked and written in one transaction, so edits
+	// made to them meanwhile aren't lost. The store's foreign keys check the
+	// type or collection again in that transaction, so one deleted since
+	// checkBulkAction fails the request with a conflict.
+	var before, after []Track
+	var err error
+	if req.Action == BulkActionDelete {
+		before, err = s.store.DeleteTracks(r.Context(), req.TrackIDs)
+	} else {
+		before, after, err = s.store.ModifyTracks(r.Context(), req.TrackIDs, func(track Track) UpdateTrackRequest {
+			return bulkUpdate(req, track)
+		})
+	}
+
+	var missing *MissingTracksError
+	if errors.As(err, &missing) {
+		for i := range results {
+			if slices.Contains(missing.TrackIDs, results[i].TrackID) {
+				results[i].Error = "Track not found"
+			}
+		}
+		respondJSON(w, http.StatusBadRequest, BulkTrackResponse{Results: results})
+		return
+	} else if err != nil {
+		s.respondStoreError(w, err, "Track not found", "Failed to update tracks")
+		return
+	}
+
+	if req.Action == BulkActionDelete {
+		// Files can't be removed transactionally, so a folder left behind
+		// doesn't fail the request once the tracks are gone.
+		for _, track := range before {
+			s.audit(r, tokenActor(token), AuditTrackDelete, track.ID.String(), track, nil)
+			if err := removeTrackMedia(track); err != nil {
+				s.logger.Error("failed to delete track folder", "trackID", track.ID, "error", err)
+			}
+		}
+	} else {
+		for i := range after {
+			results[i].Track = &after[i]
+			s.audit(r, tokenActor(token), AuditTrackUpdate, after[i].ID.String(), before[i], after[i])
+		}
+	}
+
+	respondJSON(w, http.StatusOK, BulkTrackResponse{Applied: true, Results: results})
+}
+
+// checkBulkAction validates the action of a bulk request and the fields it
+// needs, normalizing its tags. It writes an error response and returns false
+// if the request is invalid.
+func (s *Server) checkBulkAction(w http.ResponseWriter, r *http.Request, req *BulkTrackRequest) bool {
+	switch req.Action {
+	case BulkActionSetType:
+		if req.TypeID == nil {
+			http.Error(w, "typeID is required to set the track type", http.StatusBadRequest)
+			return false
+		}
+		if _, err := s.store.GetTrackTypeByID(r.Context(), *req.TypeID); errors.Is(err, ErrNotFound) {
+			http.Error(w, "Unknown track type "+req.TypeID.String(), http.StatusBadRequest)
+			return false
+		} else if err != nil {
+			s.logger.Error("failed to get track type", "error", err)
+			http.Error(w, "Failed to update tracks", http.StatusInternalServerError)
+			return false
+		}
+	case BulkActionAddTags, BulkActionRemoveTags:
+		tags, err := normalizeTags(req.Tags)
+		if err != nil {
+			http.Error(w, "Invalid tags: "+err.Error(), http.StatusBadRequest)
+			return false
+		}
+		if len(tags) == 0 {
+			http.Error(w, "tags are required to add or remove tags", http.StatusBadRequest)
+			return false
+		}
+		req.Tags = tags
+	case BulkActionAddToCollection, BulkActionRemoveFromCollection:
+		if req.CollectionID == nil {
+			http.Error(w, "collectionID is required to add or remove tracks from a collection", http.StatusBadRequest)
+			return false
+		}
+		collections, err := s.store.GetCollections(r.Context())
+		if err != nil {
+			s.logger.Error("failed to get collections", "error", err)
+			http.Error(w, "Failed to update tracks", http.StatusInternalServerError)
+			return false
+		}
+		if _, ok := findCollection(collections, *req.CollectionID); !ok {
+			http.Error(w, "Unknown collection "+req.CollectionID.String(), http.StatusBadRequest)
+			return false
+		}
+	case BulkActionDelete:
+	default:
+		http.Error(w, fmt.Sprintf("Unknown bulk action '%s'", req.Action), http.StatusBadRequest)
+		return false
+	}
+
+	return true
+}
+
+// bulkUpdate works out the update a bulk action makes to one track.
+func bulkUpdate(req BulkTrackRequest, track Track) UpdateTrackRequest {
+	update := UpdateTrackRequest{ID: track.ID}
+
+	switch req.Action {
+	case BulkActionSetType:
+		update.TypeID = req.TypeID
+	case BulkActionAddTags:
+		tags := slices.Clone(track.Tags)
+		for _, tag := range req.Tags {
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+		update.Tags = &tags
+	case BulkActionRemoveTags:
+		tags := slices.DeleteFunc(slices.Clone(track.Tags), func(tag string) bool {
+			return slices.Contains(req.Tags, tag)
+		})
+		update.Tags = &tags
+	case BulkActionAddToCollection:
+		collections := slices.Clone(track.Collections)
+		if !slices.Contains(collections, *req.CollectionID) {
+			collections = append(collections, *req.CollectionID)
+		}
+		update.Collections = &collections
+	case BulkActionRemoveFromCollection:
+		collections := slices.DeleteFunc(slices.Clone(track.Collections), func(id uuid.UUID) bool {
+			return id == *req.CollectionID
+		})
+		update.Collections = &collections
+	}
+
+	return update
+}
@@ -0,0 +1,195 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/terrabitz/rpg-audio-streamer/internal/auth"
+)
+
+func TestFilesBulk(t *testing.T) {
+	ts := setupTestServer(t)
+	defer ts.cleanup(t)
+
+	store := ts.store.(*MockTrackStore)
+	musicID := uuid.MustParse("1EC000A2-A7C9-11EE-A0E5-0242AC120003")
+	ambianceID := uuid.MustParse("1EC000A2-A7C9-11EE-A0E5-0242AC120002")
+
+	tavernID := uuid.New()
+	store.collections[tavernID] = Collection{ID: tavernID, Name: "Tavern"}
+
+	newTrack := func(name string, tags ...string) Track {
+		track := Track{
+			ID:     uuid.New(),
+			Name:   name,
+			Path:   filepath.Join(ts.tempDir, name),
+			TypeID: musicID,
+			Tags:   tags,
+		}
+		if err := os.MkdirAll(track.Path, 0755); err != nil {
+			t.Fatalf("failed to create track folder: %v", err)
+		}
+		store.tracks[track.ID] = track
+		return track
+	}
+	lute := newTrack("lute", "calm")
+	drums := newTrack("drums", "combat", "calm")
+
+	request := func(t *testing.T, body BulkTrackRequest) (*httptest.ResponseRecorder, BulkTrackResponse) {
+		t.Helper()
+
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("failed to encode request: %v", err)
+		}
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/files/bulk", bytes.NewReader(data))
+		rec := httptest.NewRecorder()
+		ts.handleFilesBulk(rec, req, &auth.Token{Role: auth.RoleGM})
+
+		var resp BulkTrackResponse
+		if rec.Header().Get("Content-Type") == "application/json" {
+			if err := json.NewDecoder(bytes.NewReader(rec.Body.Bytes())).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+		}
+		return rec, resp
+	}
+
+	t.Run("Invalid requests", func(t *testing.T) {
+		unknown := uuid.New()
+		tests := []struct {
+			name       string
+			body       BulkTrackRequest
+			wantStatus int
+		}{
+			{"no tracks", BulkTrackRequest{Action: BulkActionDelete}, http.StatusBadRequest},
+			{"unknown action", BulkTrackRequest{TrackIDs: []uuid.UUID{lute.ID}, Action: "rename"}, http.StatusBadRequest},
+			{"missing type", BulkTrackRequest{TrackIDs: []uuid.UUID{lute.ID}, Action: BulkActionSetType}, http.StatusBadRequest},
+			{"unknown type", BulkTrackRequest{TrackIDs: []uuid.UUID{lute.ID}, Action: BulkActionSetType, TypeID: &unknown}, http.StatusBadRequest},
+			{"no tags", BulkTrackRequest{TrackIDs: []uuid.UUID{lute.ID}, Action: BulkActionAddTags}, http.StatusBadRequest},
+			{"unknown collection", BulkTrackRequest{TrackIDs: []uuid.UUID{lute.ID}, Action: BulkActionAddToCollection, CollectionID: &unknown}, http.StatusBadRequest},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				rec, _ := request(t, tt.body)
+				if rec.Code != tt.wantStatus {
+					t.Errorf("expected status %v; got %v: %s", tt.wantStatus, rec.Code, rec.Body)
+				}
+			})
+		}
+	})
+
+	t.Run("Failed items roll back", func(t *testing.T) {
+		missing := uuid.New()
+		rec, resp := request(t, BulkTrackRequest{
+			TrackIDs: []uuid.UUID{lute.ID, missing, lute.ID},
+			Action:   BulkActionSetType,
+			TypeID:   &ambianceID,
+		})
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("expected status Bad Request; got %v: %s", rec.Code, rec.Body)
+		}
+
+		if resp.Applied || len(resp.Results) != 3 {
+			t.Fatalf("expected 3 unapplied results; got %+v", resp)
+		}
+		if resp.Results[0].Error != "" || resp.Results[1].Error == "" || resp.Results[2].Error == "" {
+			t.Errorf("expected the missing and repeated tracks to fail; got %+v", resp.Results)
+		}
+		if got := store.tracks[lute.ID].TypeID; got != musicID {
+			t.Errorf("expected no track to be changed; got type %v", got)
+		}
+	})
+
+	t.Run("Missing tracks are found in the transaction", func(t *testing.T) {
+		missing := uuid.New()
+		rec, resp := request(t, BulkTrackRequest{
+			TrackIDs: []uuid.UUID{lute.ID, missing},
+			Action:   BulkActionAddTags,
+			Tags:     []string{"lost"},
+		})
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("expected status Bad Request; got %v: %s", rec.Code, rec.Body)
+		}
+		if resp.Applied || resp.Results[0].Error != "" || resp.Results[1].Error != "Track not found" {
+			t.Errorf("expected only the missing track to fail; got %+v", resp.Results)
+		}
+		if slices.Contains(store.tracks[lute.ID].Tags, "lost") {
+			t.Error("expected no track to be changed")
+		}
+	})
+
+	t.Run("Tags", func(t *testing.T) {
+		rec, resp := request(t, BulkTrackRequest{
+			TrackIDs: []uuid.UUID{lute.ID, drums.ID},
+			Action:   BulkActionAddTags,
+			Tags:     []string{"Tavern", "calm"},
+		})
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status OK; got %v: %s", rec.Code, rec.Body)
+		}
+		if !resp.Applied || resp.Results[1].Track == nil {
+			t.Fatalf("expected updated tracks in the results; got %+v", resp)
+		}
+		if got := store.tracks[drums.ID].Tags; !slices.Equal(got, []string{"combat", "calm", "tavern"}) {
+			t.Errorf("expected tags to be added once; got %v", got)
+		}
+
+		request(t, BulkTrackRequest{
+			TrackIDs: []uuid.UUID{lute.ID, drums.ID},
+			Action:   BulkActionRemoveTags,
+			Tags:     []string{"calm"},
+		})
+		if got := store.tracks[lute.ID].Tags; !slices.Equal(got, []string{"tavern"}) {
+			t.Errorf("expected calm to be removed; got %v", got)
+		}
+	})
+
+	t.Run("Type and collection", func(t *testing.T) {
+		ids := []uuid.UUID{lute.ID, drums.ID}
+		request(t, BulkTrackRequest{TrackIDs: ids, Action: BulkActionSetType, TypeID: &ambianceID})
+		request(t, BulkTrackRequest{TrackIDs: ids, Action: BulkActionAddToCollection, CollectionID: &tavernID})
+
+		for _, id := range ids {
+			track := store.tracks[id]
+			if track.TypeID != ambianceID || !slices.Equal(track.Collections, []uuid.UUID{tavernID}) {
+				t.Errorf("expected track to be moved; got %+v", track)
+			}
+		}
+
+		request(t, BulkTrackRequest{TrackIDs: ids[:1], Action: BulkActionRemoveFromCollection, CollectionID: &tavernID})
+		if got := store.tracks[lute.ID].Collections; len(got) != 0 {
+			t.Errorf("expected track to be removed from the collection; got %v", got)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		rec, resp := request(t, BulkTrackRequest{
+			TrackIDs: []uuid.UUID{lute.ID, drums.ID},
+			Action:   BulkActionDelete,
+		})
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status OK; got %v: %s", rec.Code, rec.Body)
+		}
+		if !resp.Applied || resp.Results[0].Track != nil {
+			t.Errorf("expected deleted tracks to be left out of the results; got %+v", resp)
+		}
+
+		for _, track := range []Track{lute, drums} {
+			if _, ok := store.tracks[track.ID]; ok {
+				t.Errorf("expected track %s to be deleted", track.Name)
+			}
+			if _, err := os.Stat(track.Path); !os.IsNotExist(err) {
+				t.Errorf("expected folder of %s to be removed", track.Name)
+			}
+		}
+	})
+}
@@ -0,0 +1,260 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/terrabitz/rpg-audio-streamer/internal/auth"
+)
+
+const maxCollectionNameLength = 100
+
+type CollectionRequest struct {
+	Name string `json:"name"`
+	// ParentID nests the collection inside another; null puts it at the top
+	// level.
+	ParentID *uuid.UUID `json:"parentID"`
+}
+
+func (s *Server) handleCollections(w http.ResponseWriter, r *http.Request, token *auth.Token) {
+	switch r.Method {
+	case http.MethodGet:
+		s.listCollections(w, r)
+	case http.MethodPost:
+		s.createCollection(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleCollection(w http.ResponseWriter, r *http.Request, token *auth.Token) {
+	switch r.Method {
+	case http.MethodGet:
+		s.getCollection(w, r)
+	case http.MethodPut:
+		s.updateCollection(w, r)
+	case http.MethodDelete:
+		s.deleteCollection(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) listCollections(w http.ResponseWriter, r *http.Request) {
+	collections, err := s.store.GetCollections(r.Context())
+	if err != nil {
+		s.logger.Error("failed to get collections", "error", err)
+		http.Error(w, "Failed to retrieve collections", http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, collectionTree(collections, nil))
+}
+
+func (s *Server) getCollection(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("collectionID"))
+	if err != nil {
+		http.Error(w, "Invalid collection ID", http.StatusBadRequest)
+		return
+	}
+
+	collections, err := s.store.GetCollections(r.Context())
+	if err != nil {
+		s.logger.Error("failed to get collections", "error", err)
+		http.Error(w, "Failed to retrieve collections", http.StatusInternalServerError)
+		return
+	}
+
+	collection, ok := findCollection(collections, id)
+	if !ok {
+		http.Error(w, "Collection not found", http.StatusNotFound)
+		return
+	}
+	collection.Children = collectionTree(collections, &collection.ID)
+
+	respondJSON(w, http.StatusOK, collection)
+}
+
+func (s *Server) createCollection(w http.ResponseWriter, r *http.Request) {
+	var req CollectionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	collections, err := s.store.GetCollections(r.Context())
+	if err != nil {
+		s.logger.Error("failed to get collections", "error", err)
+		http.Error(w, "Failed to create collection", http.StatusInternalServerError)
+		return
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		s.logger.Error("failed to generate collection ID", "error", err)
+		http.Error(w, "Failed to create collection", http.StatusInternalServerError)
+		return
+	}
+
+	if !checkCollectionRequest(w, collections, id, &req) {
+		return
+	}
+
+	collection := Collection{
+		ID:        id,
+		ParentID:  req.ParentID,
+		Name:      req.Name,
+		CreatedAt: time.Now(),
+		Children:  []Collection{},
+	}
+
+	if err := s.store.SaveCollection(r.Context(), &collection); err != nil {
+		s.respondStoreError(w, err, "Parent collection not found", "Failed to create collection")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, collection)
+}
+
+func (s *Server) updateCollection(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("collectionID"))
+	if err != nil {
+		http.Error(w, "Invalid collection ID", http.StatusBadRequest)
+		return
+	}
+
+	var req CollectionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	collections, err := s.store.GetCollections(r.Context())
+	if err != nil {
+		s.logger.Error("failed to get collections", "error", err)
+		http.Error(w, "Failed to update collection", http.StatusInternalServerError)
+		return
+	}
+
+	collection, ok := findCollection(collections, id)
+	if !ok {
+		http.Error(w, "Collection not found", http.StatusNotFound)
+		return
+	}
+
+	if !checkCollectionRequest(w, collections, id, &req) {
+		return
+	}
+
+	collection.Name = req.Name
+	collection.ParentID = req.ParentID
+	if err := s.store.UpdateCollection(r.Context(), collection); err != nil {
+		s.respondStoreError(w, err, "Collection not found", "Failed to update collection")
+		return
+	}
+	collection.Children = collectionTree(collections, &collection.ID)
+
+	respondJSON(w, http.StatusOK, collection)
+}
+
+func (s *Server) deleteCollection(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("collectionID"))
+	if err != nil {
+		http.Error(w, "Invalid collection ID", http.StatusBadRequest)
+		return
+	}
+
+	collections, err := s.store.GetCollections(r.Context())
+	if err != nil {
+		s.logger.Error("failed to get collections", "error", err)
+		http.Error(w, "Failed to delete collection", http.StatusInternalServerError)
+		return
+	}
+
+	if _, ok := findCollection(collections, id); !ok {
+		http.Error(w, "Collection not found", http.StatusNotFound)
+		return
+	}
+
+	if err := s.store.DeleteCollection(r.Context(), id); err != nil {
+		s.respondStoreError(w, err, "Collection not found", "Failed to delete collection")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Collection deleted successfully"))
+}
+
+// checkCollectionRequest validates a new or changed collection against the
+// existing ones, normalizing its name. It writes an error response and returns
+// false if the request is invalid.
+func checkCollectionRequest(w http.ResponseWriter, collections []Collection, id uuid.UUID, req *CollectionRequest) bool {
+	req.Name = strings.Join(strings.Fields(req.Name), " ")
+	if req.Name == "" {
+		http.Error(w, "Collection name must not be empty", http.StatusBadRequest)
+		return false
+	}
+	if utf8.RuneCountInString(req.Name) > maxCollectionNameLength {
+		http.Error(w, fmt.Sprintf("Collection name must be at most %d characters", maxCollectionNameLength), http.StatusBadRequest)
+		return false
+	}
+
+	// Walk up from the new parent to make sure it exists and that the
+	// collection isn't being moved inside itself.
+	for parentID := req.ParentID; parentID != nil; {
+		if *parentID == id {
+			http.Error(w, "A collection can't be nested inside itself", http.StatusBadRequest)
+			return false
+		}
+
+		parent, ok := findCollection(collections, *parentID)
+		if !ok {
+			http.Error(w, "Parent collection not found", http.StatusBadRequest)
+			return false
+		}
+		parentID = parent.ParentID
+	}
+
+	for _, c := range collections {
+		if c.ID != id && sameParent(c.ParentID, req.ParentID) && strings.EqualFold(c.Name, req.Name) {
+			http.Error(w, "A collection with that name already exists here", http.StatusConflict)
+			return false
+		}
+	}
+
+	return true
+}
+
+// collectionTree returns the collections directly under parentID, with their
+// own children nested recursively. A nil parentID returns the top level.
+func collectionTree(collections []Collection, parentID *uuid.UUID) []Collection {
+	tree := []Collection{}
+	for _, c := range collections {
+		if sameParent(c.ParentID, parentID) {
+			c.Children = collectionTree(collections, &c.ID)
+			tree = append(tree, c)
+		}
+	}
+	return tree
+}
+
+func findCollection(collections []Collection, id uuid.UUID) (Collection, bool) {
+	for _, c := range collections {
+		if c.ID == id {
+			return c, true
+		}
+	}
+	return Collection{}, false
+}
+
+func sameParent(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
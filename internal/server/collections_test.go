@@ -0,0 +1,133 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/terrabitz/rpg-audio-streamer/internal/auth"
+)
+
+func TestCollections(t *testing.T) {
+	ts := setupTestServer(t)
+	defer ts.cleanup(t)
+
+	request := func(t *testing.T, method, target, body string, handler func(http.ResponseWriter, *http.Request, *auth.Token)) *httptest.ResponseRecorder {
+		t.Helper()
+
+		req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+		if id, ok := strings.CutPrefix(target, "/api/v1/collections/"); ok {
+			req.SetPathValue("collectionID", id)
+		}
+		rec := httptest.NewRecorder()
+		handler(rec, req, &auth.Token{Role: auth.RoleGM})
+		return rec
+	}
+
+	create := func(t *testing.T, body string, wantStatus int) Collection {
+		t.Helper()
+
+		rec := request(t, http.MethodPost, "/api/v1/collections", body, ts.handleCollections)
+		if rec.Code != wantStatus {
+			t.Fatalf("expected status %v; got %v: %s", wantStatus, rec.Code, rec.Body)
+		}
+
+		var collection Collection
+		if wantStatus == http.StatusOK {
+			if err := json.NewDecoder(rec.Body).Decode(&collection); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+		}
+		return collection
+	}
+
+	waterdeep := create(t, `{"name": "Waterdeep"}`, http.StatusOK)
+	portal := create(t, `{"name": "Yawning  Portal", "parentID": "`+waterdeep.ID.String()+`"}`, http.StatusOK)
+	if portal.Name != "Yawning Portal" {
+		t.Errorf("expected name to be normalized; got %q", portal.Name)
+	}
+
+	t.Run("Invalid collections", func(t *testing.T) {
+		create(t, `{"name": "yawning portal", "parentID": "`+waterdeep.ID.String()+`"}`, http.StatusConflict)
+		create(t, `{"name": "Docks", "parentID": "`+uuid.NewString()+`"}`, http.StatusBadRequest)
+		create(t, `{"name": "   "}`, http.StatusBadRequest)
+
+		rec := request(t, http.MethodPut, "/api/v1/collections/"+waterdeep.ID.String(),
+			`{"name": "Waterdeep", "parentID": "`+portal.ID.String()+`"}`, ts.handleCollection)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected moving a collection inside itself to fail; got %v", rec.Code)
+		}
+	})
+
+	t.Run("Tree", func(t *testing.T) {
+		rec := request(t, http.MethodGet, "/api/v1/collections", "", ts.handleCollections)
+
+		var tree []Collection
+		if err := json.NewDecoder(rec.Body).Decode(&tree); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+
+		if len(tree) != 1 || tree[0].ID != waterdeep.ID {
+			t.Fatalf("expected Waterdeep as the only top-level collection; got %+v", tree)
+		}
+		if len(tree[0].Children) != 1 || tree[0].Children[0].ID != portal.ID {
+			t.Errorf("expected Yawning Portal nested in Waterdeep; got %+v", tree[0].Children)
+		}
+	})
+
+	trackID := uuid.New()
+	ts.store.(*MockTrackStore).tracks[trackID] = Track{ID: trackID, Name: "Tavern Chatter"}
+
+	t.Run("Assign track", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/files/"+trackID.String(),
+			bytes.NewBufferString(`{"collections": ["`+uuid.NewString()+`"]}`))
+		req.SetPathValue("trackID", trackID.String())
+		rec := httptest.NewRecorder()
+		ts.handleFile(rec, req, &auth.Token{Role: auth.RoleGM})
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected unknown collection to be rejected; got %v", rec.Code)
+		}
+
+		req = httptest.NewRequest(http.MethodPut, "/api/v1/files/"+trackID.String(),
+			bytes.NewBufferString(`{"collections": ["`+portal.ID.String()+`"]}`))
+		req.SetPathValue("trackID", trackID.String())
+		rec = httptest.NewRecorder()
+		ts.handleFile(rec, req, &auth.Token{Role: auth.RoleGM})
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status OK; got %v", rec.Code)
+		}
+
+		// Loading the parent collection includes tracks in nested ones
+		req = httptest.NewRequest(http.MethodGet, "/api/v1/files?collection="+waterdeep.ID.String(), nil)
+		rec = httptest.NewRecorder()
+		ts.handleFiles(rec, req, &auth.Token{Role: auth.RoleGM})
+
+		var list TrackList
+		if err := json.NewDecoder(rec.Body).Decode(&list); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(list.Tracks) != 1 || list.Tracks[0].ID != trackID {
+			t.Errorf("expected the track in Waterdeep; got %+v", list.Tracks)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		rec := request(t, http.MethodDelete, "/api/v1/collections/"+waterdeep.ID.String(), "", ts.handleCollection)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status OK; got %v", rec.Code)
+		}
+
+		rec = request(t, http.MethodGet, "/api/v1/collections/"+portal.ID.String(), "", ts.handleCollection)
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("expected nested collection to be deleted; got %v", rec.Code)
+		}
+
+		if track := ts.store.(*MockTrackStore).tracks[trackID]; len(track.Collections) != 0 {
+			t.Errorf("expected track to be kept without collections; got %v", track.Collections)
+		}
+	})
+}
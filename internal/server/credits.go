@@ -0,0 +1,254 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/terrabitz/rpg-audio-streamer/internal/auth"
+)
+
+const (
+	maxDescriptionLength = 2000
+	maxArtistLength      = 200
+	maxAttributionLength = 500
+	maxLicenseLength     = 64
+	maxSourceURLLength   = 2048
+)
+
+// licensePattern matches SPDX license identifiers like CC-BY-4.0, including
+// custom LicenseRef- ones.
+var licensePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9.+-]*$`)
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `[`, `\[`, `]`, `\]`,
+	`(`, `\(`, `)`, `\)`, `#`, `\#`, `<`, `\<`, `>`, `\>`, `|`, `\|`,
+)
+
+// markdownURLEscaper keeps a URL from ending a Markdown link early.
+var markdownURLEscaper = strings.NewReplacer(`(`, `%28`, `)`, `%29`, ` `, `%20`)
+
+// checkTrackCredits validates and trims the credit fields of a track update.
+// It writes an error response and returns false if any are invalid.
+func checkTrackCredits(w http.ResponseWriter, req *UpdateTrackRequest) bool {
+	for _, field := range []struct {
+		name      string
+		value     *string
+		maxLength int
+	}{
+		{"Description", req.Description, maxDescriptionLength},
+		{"Artist", req.Artist, maxArtistLength},
+		{"Attribution", req.Attribution, maxAttributionLength},
+		{"License", req.License, maxLicenseLength},
+		{"Source URL", req.SourceURL, maxSourceURLLength},
+	} {
+		if field.value == nil {
+			continue
+		}
+		*field.value = strings.TrimSpace(*field.value)
+		if utf8.RuneCountInString(*field.value) > field.maxLength {
+			http.Error(w, fmt.Sprintf("%s must be at most %d characters", field.name, field.maxLength), http.StatusBadRequest)
+			return false
+		}
+	}
+
+	if req.License != nil && *req.License != "" && !licensePattern.MatchString(*req.License) {
+		http.Error(w, "License must be an SPDX identifier like CC-BY-4.0", http.StatusBadRequest)
+		return false
+	}
+
+	if req.SourceURL != nil && *req.SourceURL != "" {
+		u, err := url.Parse(*req.SourceURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			http.Error(w, "Source URL must be an http or https URL", http.StatusBadRequest)
+			return false
+		}
+	}
+
+	return true
+}
+
+// handleCredits lists the credits for either the tracks in a collection or
+// the ones played during a session, given as a time range.
+func (s *Server) handleCredits(w http.ResponseWriter, r *http.Request, token *auth.Token) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+
+	format := query.Get("format")
+	switch format {
+	case "":
+		format = "markdown"
+	case "markdown", "text":
+	default:
+		http.Error(w, "Format must be markdown or text", http.StatusBadRequest)
+		return
+	}
+
+	collectionIDStr := query.Get("collection")
+	fromStr := query.Get("from")
+	if (collectionIDStr == "") == (fromStr == "") {
+		http.Error(w, "Specify either a collection or a from date", http.StatusBadRequest)
+		return
+	}
+
+	var tracks []Track
+	if collectionIDStr != "" {
+		collectionID, err := uuid.Parse(collectionIDStr)
+		if err != nil {
+			http.Error(w, "Invalid collection ID", http.StatusBadRequest)
+			return
+		}
+
+		var ok bool
+		if tracks, ok = s.collectionTracks(w, r, collectionID); !ok {
+			return
+		}
+	} else {
+		from, err := parseDateParam(fromStr)
+		if err != nil {
+			http.Error(w, "Invalid from date", http.StatusBadRequest)
+			return
+		}
+
+		to := time.Now()
+		if toStr := query.Get("to"); toStr != "" {
+			if to, err = parseDateParam(toStr); err != nil {
+				http.Error(w, "Invalid to date", http.StatusBadRequest)
+				return
+			}
+		}
+
+		var ok bool
+		if tracks, ok = s.playedTracks(w, r, from, to); !ok {
+			return
+		}
+	}
+
+	var b strings.Builder
+	for _, track := range tracks {
+		if format == "markdown" {
+			b.WriteString("- " + markdownCredit(track) + "\n")
+		} else {
+			b.WriteString(textCredit(track) + "\n")
+		}
+	}
+
+	if format == "markdown" {
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	} else {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(b.String()))
+}
+
+// collectionTracks returns every track in a collection and the ones nested in
+// it, sorted by name. It writes an error response and returns false on
+// failure.
+func (s *Server) collectionTracks(w http.ResponseWriter, r *http.Request, collectionID uuid.UUID) ([]Track, bool) {
+	collections, err := s.store.GetCollections(r.Context())
+	if err != nil {
+		s.logger.Error("failed to get collections", "error", err)
+		http.Error(w, "Failed to get credits", http.StatusInternalServerError)
+		return nil, false
+	}
+	if _, ok := findCollection(collections, collectionID); !ok {
+		http.Error(w, "Collection not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	filter := TrackFilter{CollectionID: &collectionID}
+	page := TrackPage{Sort: TrackSortName, Limit: maxPageSize}
+
+	var tracks []Track
+	for {
+		batch, err := s.store.GetTracks(r.Context(), filter, page)
+		if err != nil {
+			s.logger.Error("failed to retrieve tracks", "error", err)
+			http.Error(w, "Failed to get credits", http.StatusInternalServerError)
+			return nil, false
+		}
+		tracks = append(tracks, batch...)
+		if len(batch) < page.Limit {
+			return tracks, true
+		}
+		page.After = &batch[len(batch)-1]
+	}
+}
+
+// playedTracks returns the tracks played between from and to, in the order
+// they were first played. It writes an error response and returns false on
+// failure.
+func (s *Server) playedTracks(w http.ResponseWriter, r *http.Request, from, to time.Time) ([]Track, bool) {
+	trackIDs, err := s.store.GetPlayedTrackIDs(r.Context(), from, to)
+	if err != nil {
+		s.logger.Error("failed to get played tracks", "error", err)
+		http.Error(w, "Failed to get credits", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	tracks := make([]Track, 0, len(trackIDs))
+	for _, id := range trackIDs {
+		track, err := s.store.GetTrackByID(r.Context(), id)
+		if errors.Is(err, ErrNotFound) {
+			// The track has been deleted since it was played.
+			continue
+		} else if err != nil {
+			s.logger.Error("failed to get track", "trackID", id, "error", err)
+			http.Error(w, "Failed to get credits", http.StatusInternalServerError)
+			return nil, false
+		}
+		tracks = append(tracks, track)
+	}
+
+	return tracks, true
+}
+
+// markdownCredit formats a track's credit line as Markdown. An attribution
+// is used as is, since licenses often ask for it word for word.
+func markdownCredit(track Track) string {
+	if track.Attribution != "" {
+		return track.Attribution
+	}
+
+	credit := markdownEscaper.Replace(track.Name)
+	if track.SourceURL != "" {
+		credit = "[" + credit + "](" + markdownURLEscaper.Replace(track.SourceURL) + ")"
+	}
+	if track.Artist != "" {
+		credit += " by " + markdownEscaper.Replace(track.Artist)
+	}
+	if track.License != "" {
+		credit += ", licensed under " + track.License
+	}
+	return credit
+}
+
+// textCredit formats a track's credit line as plain text.
+func textCredit(track Track) string {
+	if track.Attribution != "" {
+		return track.Attribution
+	}
+
+	credit := track.Name
+	if track.Artist != "" {
+		credit += " by " + track.Artist
+	}
+	if track.SourceURL != "" {
+		credit += " (" + track.SourceURL + ")"
+	}
+	if track.License != "" {
+		credit += ", licensed under " + track.License
+	}
+	return credit
+}
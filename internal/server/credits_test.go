@@ -0,0 +1,154 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/terrabitz/rpg-audio-streamer/internal/auth"
+)
+
+func TestUpdateTrackCredits(t *testing.T) {
+	ts := setupTestServer(t)
+	defer ts.cleanup(t)
+
+	trackID := uuid.New()
+	ts.store.(*MockTrackStore).tracks[trackID] = Track{ID: trackID, Name: "Heavy Rain"}
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"valid", `{"artist": " Kevin MacLeod ", "license": "CC-BY-4.0", "sourceURL": "https://incompetech.com"}`, http.StatusOK},
+		{"clears fields", `{"license": "", "sourceURL": ""}`, http.StatusOK},
+		{"bad license", `{"license": "CC BY 4.0"}`, http.StatusBadRequest},
+		{"relative URL", `{"sourceURL": "incompetech.com"}`, http.StatusBadRequest},
+		{"non-http URL", `{"sourceURL": "javascript:alert(1)"}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/files/"+trackID.String(), bytes.NewBufferString(tt.body))
+			req.SetPathValue("trackID", trackID.String())
+			rec := httptest.NewRecorder()
+
+			ts.handleFile(rec, req, &auth.Token{Role: auth.RoleGM})
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("expected status %v; got %v: %s", tt.wantStatus, rec.Code, rec.Body)
+			}
+		})
+	}
+
+	track := ts.store.(*MockTrackStore).tracks[trackID]
+	if track.Artist != "Kevin MacLeod" || track.License != "" || track.SourceURL != "" {
+		t.Errorf("expected trimmed artist and cleared license and URL; got %+v", track)
+	}
+}
+
+func TestCredits(t *testing.T) {
+	ts := setupTestServer(t)
+	defer ts.cleanup(t)
+
+	store := ts.store.(*MockTrackStore)
+	collectionID := uuid.New()
+	store.collections[collectionID] = Collection{ID: collectionID, Name: "Tavern"}
+
+	rain := Track{
+		ID:          uuid.New(),
+		Name:        "Heavy Rain",
+		Artist:      "Sound_Guy",
+		SourceURL:   "https://example.com/rain",
+		License:     "CC0-1.0",
+		Collections: []uuid.UUID{collectionID},
+	}
+	lute := Track{
+		ID:          uuid.New(),
+		Name:        "Lute Song",
+		Attribution: "\"Lute Song\" by Bard, CC BY 4.0",
+		Collections: []uuid.UUID{collectionID},
+	}
+	store.tracks[rain.ID] = rain
+	store.tracks[lute.ID] = lute
+
+	request := func(t *testing.T, target string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		rec := httptest.NewRecorder()
+		ts.handleCredits(rec, req, &auth.Token{Role: auth.RoleGM})
+		return rec
+	}
+
+	t.Run("Collection", func(t *testing.T) {
+		rec := request(t, "/api/v1/credits?collection="+collectionID.String())
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status OK; got %v: %s", rec.Code, rec.Body)
+		}
+
+		want := "- [Heavy Rain](https://example.com/rain) by Sound\\_Guy, licensed under CC0-1.0\n" +
+			"- \"Lute Song\" by Bard, CC BY 4.0\n"
+		if got := rec.Body.String(); got != want {
+			t.Errorf("expected credits\n%s\ngot\n%s", want, got)
+		}
+
+		rec = request(t, "/api/v1/credits?collection="+uuid.NewString())
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("expected unknown collection to be not found; got %v", rec.Code)
+		}
+	})
+
+	t.Run("Session", func(t *testing.T) {
+		from := time.Now().Add(-time.Minute)
+
+		play := func(id uuid.UUID, playing bool) {
+			payload, _ := json.Marshal(map[string]any{"fileID": id, "isPlaying": playing})
+			ts.HandleCommand("syncTrack", payload)
+		}
+		play(lute.ID, true)
+		play(lute.ID, true) // still the same play
+		play(rain.ID, true)
+		play(lute.ID, false)
+		play(lute.ID, true)
+		// Closing waits for the queued plays to be saved.
+		ts.Close()
+
+		if len(store.plays) != 3 {
+			t.Errorf("expected 3 plays to be recorded; got %d", len(store.plays))
+		}
+
+		rec := request(t, "/api/v1/credits?format=text&from="+from.Format(time.RFC3339))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status OK; got %v: %s", rec.Code, rec.Body)
+		}
+
+		want := "\"Lute Song\" by Bard, CC BY 4.0\n" +
+			"Heavy Rain by Sound_Guy (https://example.com/rain), licensed under CC0-1.0\n"
+		if got := rec.Body.String(); got != want {
+			t.Errorf("expected credits\n%s\ngot\n%s", want, got)
+		}
+
+		rec = request(t, "/api/v1/credits?format=text&from="+from.Format(time.RFC3339)+"&to="+from.Format(time.RFC3339))
+		if rec.Body.Len() != 0 {
+			t.Errorf("expected no credits for an empty range; got %q", rec.Body)
+		}
+	})
+
+	t.Run("Invalid requests", func(t *testing.T) {
+		for _, target := range []string{
+			"/api/v1/credits",
+			"/api/v1/credits?collection=" + collectionID.String() + "&from=2024-01-01",
+			"/api/v1/credits?from=yesterday",
+			"/api/v1/credits?from=2024-01-01&format=html",
+		} {
+			if rec := request(t, target); rec.Code != http.StatusBadRequest {
+				t.Errorf("expected %s to be rejected; got %v", target, rec.Code)
+			}
+		}
+	})
+}
@@ -1,14 +1,11 @@
 package server
 
 import (
-	"bytes"
 	"encoding/json"
-	"io"
+	"errors"
 	"net/http"
-	"os"
-	"os/exec"
 	"path/filepath"
-	"strings"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,26 +15,97 @@ import (
 func (s *Server) handleFiles(w http.ResponseWriter, r *http.Request, token *auth.Token) {
 	switch r.Method {
 	case http.MethodGet:
-		s.listFiles(w, r)
+		s.listFiles(w, r, TrackFilter{})
 	case http.MethodPost:
-		s.uploadFile(w, r)
+		s.uploadFile(w, r, token)
 	default:
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 	}
 }
 
-func (s *Server) listFiles(w http.ResponseWriter, r *http.Request) {
-	tracks, err := s.store.GetTracks(r.Context())
+// listFiles lists tracks matching the query parameters and the given filter.
+func (s *Server) listFiles(w http.ResponseWriter, r *http.Request, filter TrackFilter) {
+	query := r.URL.Query()
+	filter.Query = query.Get("q")
+	filter.Tag = normalizeTag(query.Get("tag"))
+
+	if typeIDStr := query.Get("type"); typeIDStr != "" {
+		typeID, err := uuid.Parse(typeIDStr)
+		if err != nil {
+			http.Error(w, "Invalid track type ID", http.StatusBadRequest)
+			return
+		}
+		filter.TypeID = &typeID
+	}
+
+	if collectionIDStr := query.Get("collection"); collectionIDStr != "" {
+		collectionID, err := uuid.Parse(collectionIDStr)
+		if err != nil {
+			http.Error(w, "Invalid collection ID", http.StatusBadRequest)
+			return
+		}
+		filter.CollectionID = &collectionID
+	}
+
+	if after := query.Get("createdAfter"); after != "" {
+		t, err := parseDateParam(after)
+		if err != nil {
+			http.Error(w, "Invalid createdAfter date", http.StatusBadRequest)
+			return
+		}
+		filter.CreatedAfter = t
+	}
+
+	if before := query.Get("createdBefore"); before != "" {
+		t, err := parseDateParam(before)
+		if err != nil {
+			http.Error(w, "Invalid createdBefore date", http.StatusBadRequest)
+			return
+		}
+		filter.CreatedBefore = t
+	}
+
+	page, err := parseTrackPage(query)
+	if err != nil {
+		http.Error(w, "Invalid pagination: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Fetch one extra track to find out whether there's another page
+	fetch := page
+	fetch.Limit++
+	tracks, err := s.store.GetTracks(r.Context(), filter, fetch)
 	if err != nil {
 		s.logger.Error("failed to retrieve tracks", "error", err)
 		http.Error(w, "Failed to list files", http.StatusInternalServerError)
 		return
 	}
 
-	respondJSON(w, http.StatusOK, tracks)
+	total, err := s.store.CountTracks(r.Context(), filter)
+	if err != nil {
+		s.logger.Error("failed to count tracks", "error", err)
+		http.Error(w, "Failed to list files", http.StatusInternalServerError)
+		return
+	}
+
+	list := TrackList{
+		Tracks: tracks,
+		Total:  total,
+	}
+
+	if len(tracks) > page.Limit {
+		list.Tracks = tracks[:page.Limit]
+		list.NextCursor = encodeTrackCursor(page, list.Tracks[page.Limit-1])
+	}
+
+	if list.Tracks == nil {
+		list.Tracks = []Track{}
+	}
+
+	respondJSON(w, http.StatusOK, list)
 }
 
-func (s *Server) uploadFile(w http.ResponseWriter, r *http.Request) {
+func (s *Server) uploadFile(w http.ResponseWriter, r *http.Request, token *auth.Token) {
 	err := r.ParseMultipartForm(10 << 20) // 10MB max
 	if err != nil {
 		s.logger.Error("failed to parse form", "error", err)
@@ -66,8 +134,12 @@ func (s *Server) uploadFile(w http.ResponseWriter, r *http.Request) {
 
 	// Validate track type exists
 	if _, err := s.store.GetTrackTypeByID(r.Context(), typeID); err != nil {
-		s.logger.Error("track type not found", "error", err)
-		http.Error(w, "Invalid track type", http.StatusBadRequest)
+		if errors.Is(err, ErrNotFound) {
+			http.Error(w, "Invalid track type", http.StatusBadRequest)
+			return
+		}
+		s.logger.Error("failed to get track type", "error", err)
+		http.Error(w, "Failed to save track information", http.StatusInternalServerError)
 		return
 	}
 
@@ -75,64 +147,17 @@ func (s *Server) uploadFile(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		s.logger.Error("failed to generate UUID", "error", err)
 		http.Error(w, "Failed to save track information", http.StatusInternalServerError)
-	}
-
-	tempDir := os.TempDir()
-	dstPath := filepath.Join(tempDir, id.String())
-	dstFile, err := os.Create(dstPath)
-	if err != nil {
-		s.logger.Error("failed to create file", "error", err, "path", dstPath)
-		http.Error(w, "Failed to save file", http.StatusInternalServerError)
-		return
-	}
-	defer dstFile.Close()
-
-	if _, err := io.Copy(dstFile, file); err != nil {
-		s.logger.Error("failed to write file", "error", err, "path", dstPath)
-		http.Error(w, "Failed to save file", http.StatusInternalServerError)
 		return
 	}
 
 	hlsDir := filepath.Join(s.cfg.UploadDir, id.String())
-	if err := os.MkdirAll(hlsDir, os.ModePerm); err != nil {
-		s.logger.Error("failed to create HLS directory", "error", err, "path", hlsDir)
-		http.Error(w, "Failed to save file", http.StatusInternalServerError)
-		return
-	}
-
-	cmd := exec.Command("ffmpeg",
-		"-i", dstPath,
-		"-v", "verbose",
-		"-c:a", "aac",
-		"-b:a", "128k",
-		"-ac", "2",
-		"-ar", "44100",
-		"-hls_time", "6",
-		"-hls_playlist_type", "event",
-		"-hls_segment_filename", hlsDir+"/segment_%03d.ts",
-		"-vn",
-		"-f", "hls",
-		filepath.Join(hlsDir, "index.m3u8"))
-	s.logger.Info("executing ffmpeg command", "command", cmd.String())
-	var out bytes.Buffer
-	var stderr bytes.Buffer
-	cmd.Stdout = &out
-	cmd.Stderr = &stderr
-	if err := cmd.Run(); err != nil {
-		s.logger.Error("failed to convert file to HLS",
-			"err", err,
-			"path", dstPath,
-			"ffmpegStderr", stderr.String(),
-		)
-
+	probe, err := s.ingestAudio(r.Context(), file, hlsDir)
+	if err != nil {
+		s.logger.Error("failed to convert file to HLS", "error", err, "filename", handler.Filename)
 		http.Error(w, "Failed to convert file", http.StatusInternalServerError)
 		return
 	}
 
-	if err := os.Remove(dstPath); err != nil {
-		s.logger.Warn("failed to remove original file", "error", err, "path", dstPath)
-	}
-
 	// Save track information to the datastore
 	track := Track{
 		ID:        id,
@@ -140,13 +165,15 @@ func (s *Server) uploadFile(w http.ResponseWriter, r *http.Request) {
 		Name:      name,
 		Path:      hlsDir,
 		TypeID:    typeID,
+		Duration:  probe.Duration,
+		Metadata:  probe.Tags,
 	}
 
 	if err := s.store.SaveTrack(r.Context(), &track); err != nil {
-		s.logger.Error("failed to save track information", "error", err)
-		http.Error(w, "Failed to save track information", http.StatusInternalServerError)
+		s.respondStoreError(w, err, "Track type not found", "Failed to save track information")
 		return
 	}
+	s.audit(r, tokenActor(token), AuditTrackCreate, id.String(), nil, track)
 
 	s.logger.Info("file uploaded and converted to HLS", "filename", handler.Filename)
 	w.WriteHeader(http.StatusOK)
@@ -158,13 +185,13 @@ func (s *Server) handleFile(w http.ResponseWriter, r *http.Request, token *auth.
 	case http.MethodDelete:
 		s.handleFileDelete(w, r, token)
 	case http.MethodPut:
-		s.handleFileUpdate(w, r)
+		s.handleFileUpdate(w, r, token)
 	default:
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 	}
 }
 
-func (s *Server) handleFileUpdate(w http.ResponseWriter, r *http.Request) {
+func (s *Server) handleFileUpdate(w http.ResponseWriter, r *http.Request, token *auth.Token) {
 	if r.Method != http.MethodPut {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
@@ -184,16 +211,90 @@ func (s *Server) handleFileUpdate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.Tags != nil {
+		tags, err := normalizeTags(*req.Tags)
+		if err != nil {
+			http.Error(w, "Invalid tags: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		req.Tags = &tags
+	}
+
+	if req.Collections != nil {
+		collections, err := s.store.GetCollections(r.Context())
+		if err != nil {
+			s.logger.Error("failed to get collections", "error", err)
+			http.Error(w, "Failed to update track", http.StatusInternalServerError)
+			return
+		}
+
+		var ids []uuid.UUID
+		for _, id := range *req.Collections {
+			if _, ok := findCollection(collections, id); !ok {
+				http.Error(w, "Unknown collection "+id.String(), http.StatusBadRequest)
+				return
+			}
+			if !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+		req.Collections = &ids
+	}
+
+	if !checkTrackCredits(w, &req) {
+		return
+	}
+
+	before, err := s.store.GetTrackByID(r.Context(), trackID)
+	if err != nil {
+		s.respondStoreError(w, err, "Track not found", "Failed to update track")
+		return
+	}
+
 	track, err := s.store.UpdateTrack(r.Context(), trackID, req)
 	if err != nil {
-		s.logger.Error("failed to update track", "error", err)
-		http.Error(w, "Failed to update track", http.StatusInternalServerError)
+		s.respondStoreError(w, err, "Track not found", "Failed to update track")
 		return
 	}
+	s.audit(r, tokenActor(token), AuditTrackUpdate, trackID.String(), before, track)
 
 	respondJSON(w, http.StatusOK, track)
 }
 
+func (s *Server) handleFileOrder(w http.ResponseWriter, r *http.Request, token *auth.Token) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var order TrackOrder
+	if err := json.NewDecoder(r.Body).Decode(&order); err != nil {
+		s.logger.Error("failed to decode track order", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if order.TrackIDs == nil {
+		order.TrackIDs = []uuid.UUID{}
+	}
+
+	if err := s.store.ReorderTracks(r.Context(), order.TypeID, order.TrackIDs); err != nil {
+		if errors.Is(err, ErrTrackOrderMismatch) {
+			http.Error(w, "Track order must list every track of the type exactly once", http.StatusConflict)
+			return
+		}
+		s.logger.Error("failed to reorder tracks", "error", err)
+		http.Error(w, "Failed to reorder tracks", http.StatusInternalServerError)
+		return
+	}
+
+	if err := s.hub.NotifyGMs("trackOrder", order); err != nil {
+		s.logger.Error("failed to broadcast track order", "error", err)
+	}
+
+	respondJSON(w, http.StatusOK, order)
+}
+
 func (s *Server) handleFileDelete(w http.ResponseWriter, r *http.Request, token *auth.Token) {
 	if r.Method != http.MethodDelete {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -210,17 +311,18 @@ func (s *Server) handleFileDelete(w http.ResponseWriter, r *http.Request, token
 	// Retrieve the track to get its folder path
 	track, err := s.store.GetTrackByID(r.Context(), trackID)
 	if err != nil {
-		http.Error(w, "Track not found", http.StatusNotFound)
+		s.respondStoreError(w, err, "Track not found", "Failed to remove track record")
 		return
 	}
 
 	// Remove the database record
 	if err := s.store.DeleteTrack(r.Context(), trackID); err != nil {
-		http.Error(w, "Failed to remove track record", http.StatusInternalServerError)
+		s.respondStoreError(w, err, "Track not found", "Failed to remove track record")
 		return
 	}
+	s.audit(r, tokenActor(token), AuditTrackDelete, trackID.String(), track, nil)
 
-	if err := os.RemoveAll(track.Path); err != nil {
+	if err := removeTrackMedia(track); err != nil {
 		http.Error(w, "Failed to delete folder", http.StatusInternalServerError)
 		return
 	}
@@ -229,12 +331,6 @@ func (s *Server) handleFileDelete(w http.ResponseWriter, r *http.Request, token
 	w.Write([]byte("Track deleted successfully"))
 }
 
-func (s *Server) streamDirectory(w http.ResponseWriter, r *http.Request, token *auth.Token) {
-	relativePath := strings.TrimPrefix(r.URL.Path, "/api/v1/stream/")
-	filePath := filepath.Join(s.cfg.UploadDir, relativePath)
-	http.ServeFile(w, r, filePath)
-}
-
 func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request, token *auth.Token) {
 	conn, err := s.upgrader.Upgrade(w, r, nil)
 	if err != nil {
@@ -248,6 +344,7 @@ func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request, token *
 type authStatusResponse struct {
 	Authenticated bool      `json:"authenticated"`
 	Role          auth.Role `json:"role,omitempty"`
+	Admin         bool      `json:"admin,omitempty"`
 }
 
 func (s *Server) handleAuthStatus(w http.ResponseWriter, r *http.Request) {
@@ -265,6 +362,7 @@ func (s *Server) handleAuthStatus(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, authStatusResponse{
 		Authenticated: true,
 		Role:          token.Role,
+		Admin:         token.Admin,
 	})
 }
 
@@ -274,6 +372,10 @@ func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if token, err := s.getToken(r); err == nil {
+		s.audit(r, tokenActor(token), AuditLogout, "", nil, nil)
+	}
+
 	// Always clear the cookie, regardless of authentication method
 	s.clearCookie(w, authCookieName)
 	w.WriteHeader(http.StatusOK)
@@ -307,9 +409,10 @@ func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
 		Password: req.Password,
 	}
 
-	token, err := s.auth.ValidateCredentials(creds)
+	token, err := s.login(r.Context(), creds)
 	if err != nil {
 		s.logger.Info("login failed", "username", req.Username)
+		s.audit(r, req.Username, AuditLoginFailed, "", nil, nil)
 		respondJSON(w, http.StatusUnauthorized, loginResponse{
 			Success: false,
 			Error:   "Invalid credentials",
@@ -317,6 +420,8 @@ func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	s.audit(r, tokenActor(token), AuditLogin, "", nil, nil)
+
 	// Set auth cookie
 	s.writeCookie(w, authCookieName, token.String(), token.ExpiresAt)
 
@@ -324,36 +429,3 @@ func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
 		Success: true,
 	})
 }
-
-type joinTokenResponse struct {
-	Token string `json:"token"`
-}
-
-func (s *Server) handleGetJoinToken(w http.ResponseWriter, r *http.Request, token *auth.Token) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	resp := joinTokenResponse{
-		Token: s.auth.GetJoinToken(),
-	}
-
-	respondJSON(w, http.StatusOK, resp)
-}
-
-func (s *Server) handleTrackTypes(w http.ResponseWriter, r *http.Request, token *auth.Token) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	trackTypes, err := s.store.GetTrackTypes(r.Context())
-	if err != nil {
-		s.logger.Error("failed to get track types", "error", err)
-		http.Error(w, "Failed to retrieve track types", http.StatusInternalServerError)
-		return
-	}
-
-	respondJSON(w, http.StatusOK, trackTypes)
-}
@@ -0,0 +1,367 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/terrabitz/rpg-audio-streamer/internal/auth"
+)
+
+const (
+	maxJoinTokenLabelLength = 100
+	maxPlayerNameLength     = 32
+)
+
+var (
+	errJoinTokenRevoked = errors.New("join token revoked")
+	errJoinTokenExpired = errors.New("join token expired")
+)
+
+// JoinTokenRequest creates a join token. A null expiresAt never expires, and
+// a maxUses of 0 allows any number of players to join.
+type JoinTokenRequest struct {
+	Label     string     `json:"label"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+	MaxUses   int        `json:"maxUses"`
+}
+
+// IssuedJoinToken is a join token along with the token players join with,
+// which can't be retrieved again.
+type IssuedJoinToken struct {
+	JoinToken
+	Token string `json:"token"`
+}
+
+// JoinRequest redeems a join token. The player's name and optional avatar
+// color are shown to the GM and other players.
+type JoinRequest struct {
+	Token string `json:"token"`
+	Name  string `json:"name"`
+	Color string `json:"color,omitempty"`
+}
+
+// JoinResponse holds the player token a join token was redeemed for. Players
+// send it as a bearer token, or in the token parameter for the WebSocket.
+type JoinResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	PlayerID  string    `json:"playerID"`
+}
+
+// handleJoin redeems a join token for a player token, counting a use of it.
+func (s *Server) handleJoin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req JoinRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	req.Name = strings.Join(strings.Fields(req.Name), " ")
+	switch {
+	case req.Name == "" || utf8.RuneCountInString(req.Name) > maxPlayerNameLength:
+		http.Error(w, fmt.Sprintf("Name must be between 1 and %d characters", maxPlayerNameLength), http.StatusBadRequest)
+		return
+	case req.Color != "" && !hexColorPattern.MatchString(req.Color):
+		http.Error(w, "Color must be a hex color like #A5D6A7", http.StatusBadRequest)
+		return
+	}
+
+	id, secret, ok := parseJoinToken(req.Token)
+	if !ok {
+		http.Error(w, "Invalid join token", http.StatusUnauthorized)
+		return
+	}
+
+	joinToken, err := s.store.GetJoinTokenByID(r.Context(), id)
+	if errors.Is(err, ErrNotFound) || (err == nil && !auth.JoinSecretMatches(secret, joinToken.SecretHash)) {
+		http.Error(w, "Invalid join token", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		s.logger.Error("failed to get join token", "error", err)
+		http.Error(w, "Failed to join", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	if _, err := s.store.UseJoinToken(r.Context(), id, joinToken.SecretHash, now); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			http.Error(w, unusableJoinTokenReason(joinToken, now), http.StatusUnauthorized)
+			return
+		}
+		s.logger.Error("failed to use join token", "error", err)
+		http.Error(w, "Failed to join", http.StatusInternalServerError)
+		return
+	}
+
+	playerID, err := uuid.NewV7()
+	if err != nil {
+		s.logger.Error("failed to generate player ID", "error", err)
+		http.Error(w, "Failed to join", http.StatusInternalServerError)
+		return
+	}
+
+	token, err := s.auth.NewPlayerToken(id.String(), auth.Player{
+		ID:    playerID.String(),
+		Name:  req.Name,
+		Color: strings.ToUpper(req.Color),
+	})
+	if err != nil {
+		s.logger.Error("failed to generate player token", "error", err)
+		http.Error(w, "Failed to join", http.StatusInternalServerError)
+		return
+	}
+	s.audit(r, tokenActor(token), AuditJoin, id.String(), nil, map[string]string{"name": token.Name})
+
+	respondJSON(w, http.StatusOK, JoinResponse{
+		Token:     token.String(),
+		ExpiresAt: token.ExpiresAt,
+		PlayerID:  token.Subject,
+	})
+}
+
+// unusableJoinTokenReason says why a join token with a matching secret can't
+// be redeemed. The token may have changed since it was read, so it falls
+// back to a generic reason.
+func unusableJoinTokenReason(token JoinToken, now time.Time) string {
+	switch {
+	case token.RevokedAt != nil:
+		return "Join token has been revoked"
+	case token.ExpiresAt != nil && !token.ExpiresAt.After(now):
+		return "Join token has expired"
+	case token.MaxUses > 0 && token.Uses >= token.MaxUses:
+		return "Join token has been used up"
+	default:
+		return "Invalid join token"
+	}
+}
+
+func (s *Server) handleJoinTokens(w http.ResponseWriter, r *http.Request, token *auth.Token) {
+	switch r.Method {
+	case http.MethodGet:
+		s.listJoinTokens(w, r)
+	case http.MethodPost:
+		s.createJoinToken(w, r, token)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) listJoinTokens(w http.ResponseWriter, r *http.Request) {
+	tokens, err := s.store.GetJoinTokens(r.Context())
+	if err != nil {
+		s.logger.Error("failed to get join tokens", "error", err)
+		http.Error(w, "Failed to retrieve join tokens", http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, tokens)
+}
+
+func (s *Server) createJoinToken(w http.ResponseWriter, r *http.Request, token *auth.Token) {
+	var req JoinTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	req.Label = strings.TrimSpace(req.Label)
+	switch {
+	case req.Label == "" || utf8.RuneCountInString(req.Label) > maxJoinTokenLabelLength:
+		http.Error(w, fmt.Sprintf("Label must be between 1 and %d characters", maxJoinTokenLabelLength), http.StatusBadRequest)
+		return
+	case req.ExpiresAt != nil && !req.ExpiresAt.After(now):
+		http.Error(w, "Expiry must be in the future", http.StatusBadRequest)
+		return
+	case req.MaxUses < 0:
+		http.Error(w, "Max uses can't be negative", http.StatusBadRequest)
+		return
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		s.logger.Error("failed to generate join token ID", "error", err)
+		http.Error(w, "Failed to create join token", http.StatusInternalServerError)
+		return
+	}
+
+	secret, err := auth.NewJoinSecret()
+	if err != nil {
+		s.logger.Error("failed to generate join secret", "error", err)
+		http.Error(w, "Failed to create join token", http.StatusInternalServerError)
+		return
+	}
+
+	joinToken := JoinToken{
+		ID:         id,
+		Label:      req.Label,
+		SecretHash: auth.HashJoinSecret(secret),
+		CreatedAt:  now,
+		MaxUses:    req.MaxUses,
+	}
+	if req.ExpiresAt != nil {
+		expiresAt := req.ExpiresAt.UTC().Truncate(time.Second)
+		joinToken.ExpiresAt = &expiresAt
+	}
+
+	if err := s.store.SaveJoinToken(r.Context(), joinToken); err != nil {
+		s.respondStoreError(w, err, "Join token not found", "Failed to create join token")
+		return
+	}
+	s.audit(r, tokenActor(token), AuditJoinTokenCreate, id.String(), nil, joinToken)
+
+	respondJSON(w, http.StatusOK, IssuedJoinToken{
+		JoinToken: joinToken,
+		Token:     formatJoinToken(id, secret),
+	})
+}
+
+// handleJoinTokenRotate replaces a join token's secret, so the old one stops
+// working for new players. Players who already joined with it stay.
+func (s *Server) handleJoinTokenRotate(w http.ResponseWriter, r *http.Request, token *auth.Token) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("joinTokenID"))
+	if err != nil {
+		http.Error(w, "Invalid join token ID", http.StatusBadRequest)
+		return
+	}
+
+	secret, err := auth.NewJoinSecret()
+	if err != nil {
+		s.logger.Error("failed to generate join secret", "error", err)
+		http.Error(w, "Failed to rotate join token", http.StatusInternalServerError)
+		return
+	}
+
+	if err := s.store.RotateJoinToken(r.Context(), id, auth.HashJoinSecret(secret)); err != nil {
+		s.respondStoreError(w, err, "Join token not found or revoked", "Failed to rotate join token")
+		return
+	}
+
+	joinToken, err := s.store.GetJoinTokenByID(r.Context(), id)
+	if err != nil {
+		s.respondStoreError(w, err, "Join token not found", "Failed to retrieve join token")
+		return
+	}
+	s.audit(r, tokenActor(token), AuditJoinTokenRotate, id.String(), nil, nil)
+
+	respondJSON(w, http.StatusOK, IssuedJoinToken{
+		JoinToken: joinToken,
+		Token:     formatJoinToken(id, secret),
+	})
+}
+
+// handleJoinTokenRevoke stops a join token from working, and signs out the
+// players who joined with it.
+func (s *Server) handleJoinTokenRevoke(w http.ResponseWriter, r *http.Request, token *auth.Token) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("joinTokenID"))
+	if err != nil {
+		http.Error(w, "Invalid join token ID", http.StatusBadRequest)
+		return
+	}
+
+	before, err := s.store.GetJoinTokenByID(r.Context(), id)
+	if err != nil {
+		s.respondStoreError(w, err, "Join token not found", "Failed to retrieve join token")
+		return
+	}
+
+	if err := s.store.RevokeJoinToken(r.Context(), id, time.Now().UTC().Truncate(time.Second)); err != nil {
+		s.respondStoreError(w, err, "Join token not found", "Failed to revoke join token")
+		return
+	}
+
+	joinToken, err := s.store.GetJoinTokenByID(r.Context(), id)
+	if err != nil {
+		s.respondStoreError(w, err, "Join token not found", "Failed to retrieve join token")
+		return
+	}
+	s.hub.DisconnectJoinToken(id.String())
+	if before.RevokedAt == nil {
+		s.audit(r, tokenActor(token), AuditJoinTokenRevoke, id.String(), before, joinToken)
+	}
+
+	respondJSON(w, http.StatusOK, joinToken)
+}
+
+// checkJoinToken refuses player tokens whose join token has been revoked.
+func (s *Server) checkJoinToken(ctx context.Context, token *auth.Token) error {
+	if token.JoinTokenID == "" {
+		return nil
+	}
+
+	id, err := uuid.Parse(token.JoinTokenID)
+	if err != nil {
+		return fmt.Errorf("invalid join token ID: %w", err)
+	}
+
+	joinToken, err := s.store.GetJoinTokenByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("couldn't get join token: %w", err)
+	}
+	if joinToken.RevokedAt != nil {
+		return errJoinTokenRevoked
+	}
+
+	return nil
+}
+
+// streamJoinToken gets a join token a stream client is using, as long as it
+// hasn't been revoked or expired. Streaming doesn't count as a use, as
+// clients reconnect whenever the stream drops.
+func (s *Server) streamJoinToken(ctx context.Context, id uuid.UUID) (JoinToken, error) {
+	joinToken, err := s.store.GetJoinTokenByID(ctx, id)
+	if err != nil {
+		return JoinToken{}, fmt.Errorf("couldn't get join token: %w", err)
+	}
+	switch {
+	case joinToken.RevokedAt != nil:
+		return JoinToken{}, errJoinTokenRevoked
+	case joinToken.ExpiresAt != nil && !joinToken.ExpiresAt.After(time.Now()):
+		return JoinToken{}, errJoinTokenExpired
+	}
+
+	return joinToken, nil
+}
+
+// formatJoinToken joins a join token's ID and secret into the token players
+// use. The ID lets the server find the hash to check the secret against.
+func formatJoinToken(id uuid.UUID, secret string) string {
+	return id.String() + "." + secret
+}
+
+func parseJoinToken(token string) (uuid.UUID, string, bool) {
+	rawID, secret, ok := strings.Cut(token, ".")
+	if !ok || secret == "" {
+		return uuid.UUID{}, "", false
+	}
+
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		return uuid.UUID{}, "", false
+	}
+
+	return id, secret, true
+}
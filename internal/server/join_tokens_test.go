@@ -0,0 +1,258 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/terrabitz/rpg-audio-streamer/internal/auth"
+)
+
+func TestJoinTokenHandlers(t *testing.T) {
+	ts := setupTestServer(t)
+	defer ts.cleanup(t)
+
+	store := ts.store.(*MockTrackStore)
+	hub := ts.hub.(*mockWSRegisterer)
+	gm := &auth.Token{Role: auth.RoleGM, Subject: "gm"}
+
+	create := func(t *testing.T, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/joinTokens", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		ts.handleJoinTokens(rec, req, gm)
+		return rec
+	}
+	join := func(t *testing.T, token string) *httptest.ResponseRecorder {
+		t.Helper()
+		body, _ := json.Marshal(JoinRequest{Token: token, Name: "Alice"})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/join", strings.NewReader(string(body)))
+		rec := httptest.NewRecorder()
+		ts.handleJoin(rec, req)
+		return rec
+	}
+	action := func(t *testing.T, handler AuthedHandlerFunc, id string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/joinTokens/"+id, nil)
+		req.SetPathValue("joinTokenID", id)
+		rec := httptest.NewRecorder()
+		handler(rec, req, gm)
+		return rec
+	}
+
+	var issued IssuedJoinToken
+	t.Run("create", func(t *testing.T) {
+		rec := create(t, `{"label":" Thursday group ","maxUses":2}`)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status OK; got %v: %s", rec.Code, rec.Body)
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&issued); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+
+		stored := store.joinTokens[issued.ID]
+		if stored.Label != "Thursday group" || stored.MaxUses != 2 || stored.ExpiresAt != nil {
+			t.Errorf("unexpected join token %+v", stored)
+		}
+		if !strings.HasPrefix(issued.Token, issued.ID.String()+".") {
+			t.Errorf("expected the token to start with its ID; got %q", issued.Token)
+		}
+		if entry := store.audit[len(store.audit)-1]; entry.Action != AuditJoinTokenCreate || entry.TargetID != issued.ID.String() {
+			t.Errorf("unexpected audit entry %+v", entry)
+		}
+
+		rec = httptest.NewRecorder()
+		ts.handleJoinTokens(rec, httptest.NewRequest(http.MethodGet, "/api/v1/joinTokens", nil), gm)
+		if strings.Contains(rec.Body.String(), issued.Token) || strings.Contains(rec.Body.String(), `"token"`) {
+			t.Errorf("expected listed tokens not to include the secret; got %s", rec.Body)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, body := range []string{
+			`{"label":"  "}`,
+			`{"label":"Past","expiresAt":"2000-01-01T00:00:00Z"}`,
+			`{"label":"Negative","maxUses":-1}`,
+		} {
+			if rec := create(t, body); rec.Code != http.StatusBadRequest {
+				t.Errorf("expected BadRequest for %s; got %v", body, rec.Code)
+			}
+		}
+	})
+
+	t.Run("join", func(t *testing.T) {
+		rec := join(t, issued.Token)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status OK; got %v: %s", rec.Code, rec.Body)
+		}
+		if store.joinTokens[issued.ID].Uses != 1 {
+			t.Errorf("expected the use to be counted; got %+v", store.joinTokens[issued.ID])
+		}
+		var joined JoinResponse
+		if err := json.NewDecoder(rec.Body).Decode(&joined); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if entry := store.audit[len(store.audit)-1]; entry.Action != AuditJoin || entry.TargetID != issued.ID.String() || entry.Actor != joined.PlayerID {
+			t.Errorf("unexpected audit entry %+v", entry)
+		}
+
+		wrongSecret := issued.ID.String() + ".wrong"
+		for _, token := range []string{"", "not-a-token", wrongSecret, strings.ToUpper(issued.Token)} {
+			if rec := join(t, token); rec.Code != http.StatusUnauthorized {
+				t.Errorf("expected Unauthorized for %q; got %v", token, rec.Code)
+			}
+		}
+		if store.joinTokens[issued.ID].Uses != 1 {
+			t.Errorf("expected failed joins not to be counted; got %+v", store.joinTokens[issued.ID])
+		}
+
+		if rec := join(t, issued.Token); rec.Code != http.StatusOK {
+			t.Fatalf("expected the second use to succeed; got %v", rec.Code)
+		}
+		if rec := join(t, issued.Token); rec.Code != http.StatusUnauthorized || !strings.Contains(rec.Body.String(), "used up") {
+			t.Errorf("expected the token to be used up; got %v: %s", rec.Code, rec.Body)
+		}
+	})
+
+	t.Run("identity", func(t *testing.T) {
+		var joinToken IssuedJoinToken
+		if err := json.NewDecoder(create(t, `{"label":"Names"}`).Body).Decode(&joinToken); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		joinAs := func(name, color string) *httptest.ResponseRecorder {
+			body, _ := json.Marshal(JoinRequest{Token: joinToken.Token, Name: name, Color: color})
+			rec := httptest.NewRecorder()
+			ts.handleJoin(rec, httptest.NewRequest(http.MethodPost, "/api/v1/join", strings.NewReader(string(body))))
+			return rec
+		}
+
+		for _, tt := range []struct{ name, color string }{
+			{"", ""},
+			{"   ", ""},
+			{strings.Repeat("a", maxPlayerNameLength+1), ""},
+			{"Alice", "red"},
+		} {
+			if rec := joinAs(tt.name, tt.color); rec.Code != http.StatusBadRequest {
+				t.Errorf("expected BadRequest for %q %q; got %v", tt.name, tt.color, rec.Code)
+			}
+		}
+		if store.joinTokens[joinToken.ID].Uses != 0 {
+			t.Errorf("expected invalid joins not to be counted; got %+v", store.joinTokens[joinToken.ID])
+		}
+
+		var first, second JoinResponse
+		for _, resp := range []*JoinResponse{&first, &second} {
+			rec := joinAs("  Sir   Robin ", "#ff8a80")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected status OK; got %v: %s", rec.Code, rec.Body)
+			}
+			if err := json.NewDecoder(rec.Body).Decode(resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+		}
+		if first.PlayerID == "" || first.PlayerID == second.PlayerID {
+			t.Errorf("expected each player to get their own ID; got %q and %q", first.PlayerID, second.PlayerID)
+		}
+
+		players := ts.auth.(*mockAuth).players
+		if player := players[len(players)-2]; player.Subject != first.PlayerID || player.Name != "Sir Robin" || player.Color != "#FF8A80" {
+			t.Errorf("expected the player's identity in the token; got %+v", player)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		expires := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+		var expiring IssuedJoinToken
+		if err := json.NewDecoder(create(t, `{"label":"Soon","expiresAt":"`+expires+`"}`).Body).Decode(&expiring); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+
+		stored := store.joinTokens[expiring.ID]
+		past := time.Now().Add(-time.Minute)
+		stored.ExpiresAt = &past
+		store.joinTokens[expiring.ID] = stored
+
+		if rec := join(t, expiring.Token); rec.Code != http.StatusUnauthorized || !strings.Contains(rec.Body.String(), "expired") {
+			t.Errorf("expected the token to have expired; got %v: %s", rec.Code, rec.Body)
+		}
+	})
+
+	t.Run("rotate", func(t *testing.T) {
+		rec := action(t, ts.handleJoinTokenRotate, issued.ID.String())
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status OK; got %v: %s", rec.Code, rec.Body)
+		}
+
+		var rotated IssuedJoinToken
+		if err := json.NewDecoder(rec.Body).Decode(&rotated); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if rotated.ID != issued.ID || rotated.Token == issued.Token || rotated.Uses != 0 || rotated.Label != issued.Label {
+			t.Errorf("unexpected rotated token %+v", rotated)
+		}
+
+		if rec := join(t, issued.Token); rec.Code != http.StatusUnauthorized {
+			t.Errorf("expected the old token to stop working; got %v", rec.Code)
+		}
+		if rec := join(t, rotated.Token); rec.Code != http.StatusOK {
+			t.Errorf("expected the new token to work; got %v", rec.Code)
+		}
+		issued = rotated
+	})
+
+	t.Run("revoke", func(t *testing.T) {
+		rec := action(t, ts.handleJoinTokenRevoke, issued.ID.String())
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status OK; got %v: %s", rec.Code, rec.Body)
+		}
+		if store.joinTokens[issued.ID].RevokedAt == nil {
+			t.Errorf("expected the token to be revoked")
+		}
+		if len(hub.disconnected) != 1 || hub.disconnected[0] != issued.ID.String() {
+			t.Errorf("expected the token's players to be disconnected; got %v", hub.disconnected)
+		}
+
+		if rec := join(t, issued.Token); rec.Code != http.StatusUnauthorized || !strings.Contains(rec.Body.String(), "revoked") {
+			t.Errorf("expected the token to be revoked; got %v: %s", rec.Code, rec.Body)
+		}
+		if rec := action(t, ts.handleJoinTokenRotate, issued.ID.String()); rec.Code != http.StatusNotFound {
+			t.Errorf("expected a revoked token not to be rotated; got %v", rec.Code)
+		}
+
+		player, _ := ts.auth.NewPlayerToken(issued.ID.String(), auth.Player{ID: "player", Name: "Alice"})
+		if err := ts.checkJoinToken(context.Background(), player); !errors.Is(err, errJoinTokenRevoked) {
+			t.Errorf("expected players who joined with it to be refused; got %v", err)
+		}
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		for _, handler := range []AuthedHandlerFunc{ts.handleJoinTokenRotate, ts.handleJoinTokenRevoke} {
+			if rec := action(t, handler, "01890a5d-ac96-774b-bcce-b302099a8057"); rec.Code != http.StatusNotFound {
+				t.Errorf("expected NotFound; got %v", rec.Code)
+			}
+			if rec := action(t, handler, "nope"); rec.Code != http.StatusBadRequest {
+				t.Errorf("expected BadRequest; got %v", rec.Code)
+			}
+		}
+	})
+}
+
+func TestCheckJoinToken(t *testing.T) {
+	ts := setupTestServer(t)
+	defer ts.cleanup(t)
+
+	ctx := context.Background()
+	if err := ts.checkJoinToken(ctx, &auth.Token{Role: auth.RoleGM}); err != nil {
+		t.Errorf("expected GM tokens to be allowed; got %v", err)
+	}
+
+	player, _ := ts.auth.NewPlayerToken("01890a5d-ac96-774b-bcce-b302099a8057", auth.Player{ID: "player", Name: "Alice"})
+	if err := ts.checkJoinToken(ctx, player); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected players of a missing join token to be refused; got %v", err)
+	}
+}
@@ -0,0 +1,355 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/google/uuid"
+	"github.com/terrabitz/rpg-audio-streamer/internal/auth"
+)
+
+const (
+	// mediaInfoFileName holds the duration and metadata of a version of a
+	// track's audio while it isn't the current one, so rolling back restores
+	// them too.
+	mediaInfoFileName = "media.json"
+
+	stagedMediaSuffix   = ".staged"
+	previousMediaSuffix = ".previous"
+)
+
+type mediaInfo struct {
+	Duration float64           `json:"duration"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+// TrackMediaChange tells clients that a track's audio was replaced, so they
+// reload its playlist.
+type TrackMediaChange struct {
+	TrackID uuid.UUID `json:"trackID"`
+}
+
+// ingestAudio converts an uploaded audio file to HLS in hlsDir and probes it
+// for metadata. The directory is removed again if the conversion fails.
+func (s *Server) ingestAudio(ctx context.Context, src io.Reader, hlsDir string) (audioProbe, error) {
+	dstFile, err := os.CreateTemp("", "rpg-audio-upload-*")
+	if err != nil {
+		return audioProbe{}, fmt.Errorf("couldn't create temporary file: %w", err)
+	}
+	dstPath := dstFile.Name()
+	defer os.Remove(dstPath)
+
+	_, err = io.Copy(dstFile, src)
+	dstFile.Close()
+	if err != nil {
+		return audioProbe{}, fmt.Errorf("couldn't write temporary file: %w", err)
+	}
+
+	if err := os.MkdirAll(hlsDir, os.ModePerm); err != nil {
+		return audioProbe{}, fmt.Errorf("couldn't create HLS directory: %w", err)
+	}
+
+	cmd := exec.Command("ffmpeg",
+		"-i", dstPath,
+		"-v", "verbose",
+		"-c:a", "aac",
+		"-b:a", "128k",
+		"-ac", "2",
+		"-ar", "44100",
+		"-hls_time", "6",
+		"-hls_playlist_type", "event",
+		"-hls_segment_filename", hlsDir+"/segment_%03d.ts",
+		"-vn",
+		"-f", "hls",
+		filepath.Join(hlsDir, playlistFileName))
+	s.logger.Info("executing ffmpeg command", "command", cmd.String())
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		os.RemoveAll(hlsDir)
+		return audioProbe{}, fmt.Errorf("ffmpeg failed: %w: %s", err, stderr.String())
+	}
+
+	// Metadata is a nice-to-have for search, so don't fail the upload over it
+	probe, err := probeAudio(ctx, dstPath)
+	if err != nil {
+		s.logger.Warn("failed to probe audio metadata", "error", err, "path", dstPath)
+	}
+
+	return probe, nil
+}
+
+func (s *Server) handleFileMedia(w http.ResponseWriter, r *http.Request, token *auth.Token) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	trackID, err := uuid.Parse(r.PathValue("trackID"))
+	if err != nil {
+		http.Error(w, "Invalid track ID", http.StatusBadRequest)
+		return
+	}
+
+	// The track is read once the change is claimed, so the details kept
+	// with the current audio are up to date.
+	unlock, ok := s.claimTrackMedia(trackID)
+	if !ok {
+		http.Error(w, "Track audio is already being changed", http.StatusConflict)
+		return
+	}
+	defer unlock()
+
+	track, err := s.store.GetTrackByID(r.Context(), trackID)
+	if err != nil {
+		s.respondStoreError(w, err, "Track not found", "Failed to replace audio")
+		return
+	}
+
+	if err := r.ParseMultipartForm(10 << 20); err != nil {
+		s.logger.Error("failed to parse form", "error", err)
+		http.Error(w, "Failed to parse form", http.StatusBadRequest)
+		return
+	}
+
+	file, handler, err := r.FormFile("files")
+	if err != nil {
+		s.logger.Error("failed to get file", "error", err)
+		http.Error(w, "Failed to retrieve file", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	// Convert next to the current audio, so the swap is a rename on the same
+	// filesystem.
+	stagedDir := track.Path + stagedMediaSuffix
+	if err := os.RemoveAll(stagedDir); err != nil {
+		s.logger.Error("failed to clear staged media", "error", err, "path", stagedDir)
+		http.Error(w, "Failed to convert file", http.StatusInternalServerError)
+		return
+	}
+
+	probe, err := s.ingestAudio(r.Context(), file, stagedDir)
+	if err != nil {
+		s.logger.Error("failed to convert file to HLS", "error", err, "filename", handler.Filename)
+		http.Error(w, "Failed to convert file", http.StatusInternalServerError)
+		return
+	}
+
+	// The old version is replaced first, so a failed swap leaves the previous
+	// version as it was.
+	if err := os.RemoveAll(track.Path + previousMediaSuffix); err != nil {
+		s.logger.Error("failed to remove previous media", "error", err, "trackID", trackID)
+		os.RemoveAll(stagedDir)
+		http.Error(w, "Failed to replace audio", http.StatusInternalServerError)
+		return
+	}
+
+	info := mediaInfo{Duration: probe.Duration, Metadata: probe.Tags}
+	discard := func() {
+		os.RemoveAll(stagedDir)
+	}
+	s.swapTrackMedia(w, r, tokenActor(token), AuditTrackReplaceMedia, track, stagedDir, info, discard)
+	s.logger.Info("track audio replaced", "trackID", trackID, "filename", handler.Filename)
+}
+
+// handleFileMediaRollback swaps a track's audio back to the version it had
+// before it was last replaced. Rolling back again returns to the newer one.
+func (s *Server) handleFileMediaRollback(w http.ResponseWriter, r *http.Request, token *auth.Token) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	trackID, err := uuid.Parse(r.PathValue("trackID"))
+	if err != nil {
+		http.Error(w, "Invalid track ID", http.StatusBadRequest)
+		return
+	}
+
+	// The track is read once the change is claimed, so the details kept
+	// with the current audio are up to date.
+	unlock, ok := s.claimTrackMedia(trackID)
+	if !ok {
+		http.Error(w, "Track audio is already being changed", http.StatusConflict)
+		return
+	}
+	defer unlock()
+
+	track, err := s.store.GetTrackByID(r.Context(), trackID)
+	if err != nil {
+		s.respondStoreError(w, err, "Track not found", "Failed to restore audio")
+		return
+	}
+
+	previousDir := track.Path + previousMediaSuffix
+	info, err := readMediaInfo(previousDir)
+	if errors.Is(err, os.ErrNotExist) {
+		http.Error(w, "Track has no previous audio", http.StatusNotFound)
+		return
+	} else if err != nil {
+		s.logger.Error("failed to read previous media info", "error", err, "trackID", trackID)
+		http.Error(w, "Failed to restore audio", http.StatusInternalServerError)
+		return
+	}
+
+	stagedDir := track.Path + stagedMediaSuffix
+	if err := os.RemoveAll(stagedDir); err != nil {
+		s.logger.Error("failed to clear staged media", "error", err, "path", stagedDir)
+		http.Error(w, "Failed to restore audio", http.StatusInternalServerError)
+		return
+	}
+	if err := os.Rename(previousDir, stagedDir); err != nil {
+		s.logger.Error("failed to stage previous media", "error", err, "trackID", trackID)
+		http.Error(w, "Failed to restore audio", http.StatusInternalServerError)
+		return
+	}
+
+	// The staged audio is the only copy of the previous version, so a failed
+	// swap puts it back rather than deleting it.
+	restore := func() {
+		if err := os.Rename(stagedDir, previousDir); err != nil {
+			s.logger.Error("failed to restore previous media", "error", err, "trackID", trackID, "path", stagedDir)
+		}
+	}
+	s.swapTrackMedia(w, r, tokenActor(token), AuditTrackRollbackMedia, track, stagedDir, info, restore)
+	s.logger.Info("track audio rolled back", "trackID", trackID)
+}
+
+// claimTrackMedia claims the right to change a track's audio files for the
+// whole of a replace or rollback, which share the track's staging directory.
+// It returns false if another change to the track is under way; otherwise
+// unlock must be called once the change is done.
+func (s *Server) claimTrackMedia(trackID uuid.UUID) (unlock func(), ok bool) {
+	s.changingMediaMu.Lock()
+	defer s.changingMediaMu.Unlock()
+
+	if s.changingMedia[trackID] {
+		return nil, false
+	}
+	s.changingMedia[trackID] = true
+
+	return func() {
+		s.changingMediaMu.Lock()
+		delete(s.changingMedia, trackID)
+		s.changingMediaMu.Unlock()
+	}, true
+}
+
+// swapTrackMedia makes stagedDir the track's audio and keeps the current
+// audio as its previous version, then records the new duration and metadata
+// and tells clients to reload the track. The swap is audited as action on
+// behalf of actor. If the swap fails, undo is called to deal with stagedDir.
+// It writes the response.
+func (s *Server) swapTrackMedia(w http.ResponseWriter, r *http.Request, actor string, action AuditAction, track Track, stagedDir string, info mediaInfo, undo func()) {
+	previousDir := track.Path + previousMediaSuffix
+	current := mediaInfo{Duration: track.Duration, Metadata: track.Metadata}
+	if err := writeMediaInfo(track.Path, current); err != nil {
+		s.logger.Error("failed to save current media info", "error", err, "trackID", track.ID)
+		undo()
+		http.Error(w, "Failed to replace audio", http.StatusInternalServerError)
+		return
+	}
+
+	if err := s.renameMedia(track.Path, stagedDir, previousDir); err != nil {
+		s.logger.Error("failed to swap track media", "error", err, "trackID", track.ID)
+		// The current version is still in place, and its info would be
+		// stale by the time it's next moved aside.
+		os.Remove(filepath.Join(track.Path, mediaInfoFileName))
+		undo()
+		http.Error(w, "Failed to replace audio", http.StatusInternalServerError)
+		return
+	}
+	// The info only matters while a version isn't current
+	os.Remove(filepath.Join(track.Path, mediaInfoFileName))
+
+	swapped := track
+	swapped.Duration, swapped.Metadata = info.Duration, info.Metadata
+	s.audit(r, actor, action, track.ID.String(), track, swapped)
+
+	if err := s.store.SetTrackMedia(r.Context(), track.ID, info.Duration, info.Metadata); err != nil {
+		// The audio has already been swapped, so carry on with stale details
+		// rather than failing the request.
+		s.logger.Error("failed to save track media info", "error", err, "trackID", track.ID)
+	}
+
+	if err := s.hub.NotifyAll("trackMedia", TrackMediaChange{TrackID: track.ID}); err != nil {
+		s.logger.Error("failed to broadcast track media change", "error", err)
+	}
+
+	updated, err := s.store.GetTrackByID(r.Context(), track.ID)
+	if err != nil {
+		s.logger.Error("failed to get track", "error", err, "trackID", track.ID)
+		http.Error(w, "Failed to retrieve track", http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, updated)
+}
+
+// renameMedia moves the directory at path to previous and stagedDir to path.
+// Readers open track files under mediaMu, so they only ever see one of the
+// two versions.
+func (s *Server) renameMedia(path, stagedDir, previous string) error {
+	s.mediaMu.Lock()
+	defer s.mediaMu.Unlock()
+
+	if err := os.Rename(path, previous); err != nil {
+		return fmt.Errorf("couldn't move current media aside: %w", err)
+	}
+	if err := os.Rename(stagedDir, path); err != nil {
+		if restoreErr := os.Rename(previous, path); restoreErr != nil {
+			return fmt.Errorf("couldn't move staged media into place: %w; and couldn't restore current media: %w", err, restoreErr)
+		}
+		return fmt.Errorf("couldn't move staged media into place: %w", err)
+	}
+
+	return nil
+}
+
+// openTrackFile opens one of the files of a track's current audio.
+func (s *Server) openTrackFile(track Track, name string) (*os.File, error) {
+	s.mediaMu.RLock()
+	defer s.mediaMu.RUnlock()
+
+	return os.Open(filepath.Join(track.Path, name))
+}
+
+// removeTrackMedia deletes a track's audio along with any previous version.
+func removeTrackMedia(track Track) error {
+	for _, dir := range []string{track.Path, track.Path + previousMediaSuffix, track.Path + stagedMediaSuffix} {
+		if err := os.RemoveAll(dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readMediaInfo(dir string) (mediaInfo, error) {
+	data, err := os.ReadFile(filepath.Join(dir, mediaInfoFileName))
+	if err != nil {
+		return mediaInfo{}, err
+	}
+
+	var info mediaInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return mediaInfo{}, fmt.Errorf("couldn't decode media info: %w", err)
+	}
+	return info, nil
+}
+
+func writeMediaInfo(dir string, info mediaInfo) error {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return fmt.Errorf("couldn't encode media info: %w", err)
+	}
+	return os.WriteFile(filepath.Join(dir, mediaInfoFileName), data, 0644)
+}
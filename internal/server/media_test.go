@@ -0,0 +1,177 @@
+package server
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/terrabitz/rpg-audio-streamer/internal/auth"
+)
+
+func TestReplaceTrackMedia(t *testing.T) {
+	ts := setupTestServer(t)
+	defer ts.cleanup(t)
+
+	trackID := uuid.New()
+	addTestTrack(t, ts, trackID)
+	store := ts.store.(*MockTrackStore)
+	track := store.tracks[trackID]
+	track.Duration = 8.5
+	store.tracks[trackID] = track
+
+	request := func(t *testing.T, method, target string, handler AuthedHandlerFunc) *httptest.ResponseRecorder {
+		t.Helper()
+
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		part, err := writer.CreateFormFile("files", "better.mp3")
+		if err != nil {
+			t.Fatalf("failed to create form file: %v", err)
+		}
+		part.Write([]byte("new audio"))
+		writer.Close()
+
+		req := httptest.NewRequest(method, target, body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		req.SetPathValue("trackID", trackID.String())
+		rec := httptest.NewRecorder()
+		handler(rec, req, &auth.Token{Role: auth.RoleGM})
+		return rec
+	}
+
+	playlist := func(t *testing.T) string {
+		t.Helper()
+
+		data, err := os.ReadFile(filepath.Join(track.Path, playlistFileName))
+		if err != nil {
+			t.Fatalf("failed to read playlist: %v", err)
+		}
+		return string(data)
+	}
+
+	previousDir := track.Path + previousMediaSuffix
+
+	t.Run("Replace", func(t *testing.T) {
+		rec := request(t, http.MethodPut, "/api/v1/files/"+trackID.String()+"/media", ts.handleFileMedia)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status OK; got %v: %s", rec.Code, rec.Body)
+		}
+
+		if playlist(t) == testPlaylist {
+			t.Error("expected the playlist to be replaced")
+		}
+		if _, err := os.Stat(filepath.Join(previousDir, playlistFileName)); err != nil {
+			t.Errorf("expected the previous audio to be kept: %v", err)
+		}
+		if got := store.tracks[trackID]; got.ID != trackID || got.Duration == 8.5 {
+			t.Errorf("expected the track to keep its ID with new media details; got %+v", got)
+		}
+
+		notifications := ts.hub.(*mockWSRegisterer).notifications
+		if len(notifications) != 1 || notifications[0].method != "trackMedia" || !notifications[0].toPlayers {
+			t.Fatalf("expected players to be told to reload the track; got %+v", notifications)
+		}
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		rec := request(t, http.MethodPost, "/api/v1/files/"+trackID.String()+"/media/rollback", ts.handleFileMediaRollback)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status OK; got %v: %s", rec.Code, rec.Body)
+		}
+
+		if playlist(t) != testPlaylist {
+			t.Error("expected the original playlist to be restored")
+		}
+		if got := store.tracks[trackID].Duration; got != 8.5 {
+			t.Errorf("expected the original duration to be restored; got %v", got)
+		}
+		if _, err := os.Stat(filepath.Join(track.Path, mediaInfoFileName)); !os.IsNotExist(err) {
+			t.Error("expected no media info in the current audio")
+		}
+
+		// Rolling back again returns to the replacement
+		request(t, http.MethodPost, "/api/v1/files/"+trackID.String()+"/media/rollback", ts.handleFileMediaRollback)
+		if playlist(t) == testPlaylist {
+			t.Error("expected the replacement to be restored")
+		}
+	})
+
+	t.Run("One change at a time", func(t *testing.T) {
+		unlock, ok := ts.claimTrackMedia(trackID)
+		if !ok {
+			t.Fatal("expected to claim the track's media")
+		}
+
+		for name, rec := range map[string]*httptest.ResponseRecorder{
+			"replace":  request(t, http.MethodPut, "/api/v1/files/"+trackID.String()+"/media", ts.handleFileMedia),
+			"rollback": request(t, http.MethodPost, "/api/v1/files/"+trackID.String()+"/media/rollback", ts.handleFileMediaRollback),
+		} {
+			if rec.Code != http.StatusConflict {
+				t.Errorf("expected %s during another change to conflict; got %v", name, rec.Code)
+			}
+		}
+
+		unlock()
+		rec := request(t, http.MethodPost, "/api/v1/files/"+trackID.String()+"/media/rollback", ts.handleFileMediaRollback)
+		if rec.Code != http.StatusOK {
+			t.Errorf("expected rollback once the change is done to succeed; got %v: %s", rec.Code, rec.Body)
+		}
+
+		// Put the replacement back for the tests that follow
+		request(t, http.MethodPost, "/api/v1/files/"+trackID.String()+"/media/rollback", ts.handleFileMediaRollback)
+	})
+
+	t.Run("Failed rollback keeps the previous version", func(t *testing.T) {
+		// A directory where the media info goes makes the swap fail
+		blocker := filepath.Join(track.Path, mediaInfoFileName)
+		if err := os.Mkdir(blocker, 0755); err != nil {
+			t.Fatalf("failed to block media info: %v", err)
+		}
+		defer os.Remove(blocker)
+
+		rec := request(t, http.MethodPost, "/api/v1/files/"+trackID.String()+"/media/rollback", ts.handleFileMediaRollback)
+		if rec.Code != http.StatusInternalServerError {
+			t.Fatalf("expected status Internal Server Error; got %v: %s", rec.Code, rec.Body)
+		}
+
+		if _, err := os.Stat(filepath.Join(previousDir, playlistFileName)); err != nil {
+			t.Errorf("expected the previous audio to be kept: %v", err)
+		}
+		if _, err := os.Stat(track.Path + stagedMediaSuffix); !os.IsNotExist(err) {
+			t.Error("expected nothing left staged")
+		}
+	})
+
+	t.Run("No previous version", func(t *testing.T) {
+		otherID := uuid.New()
+		addTestTrack(t, ts, otherID)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/files/"+otherID.String()+"/media/rollback", nil)
+		req.SetPathValue("trackID", otherID.String())
+		rec := httptest.NewRecorder()
+		ts.handleFileMediaRollback(rec, req, &auth.Token{Role: auth.RoleGM})
+
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("expected status Not Found; got %v", rec.Code)
+		}
+	})
+
+	t.Run("Delete removes every version", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/files/"+trackID.String(), nil)
+		req.SetPathValue("trackID", trackID.String())
+		rec := httptest.NewRecorder()
+		ts.handleFileDelete(rec, req, &auth.Token{Role: auth.RoleGM})
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status OK; got %v: %s", rec.Code, rec.Body)
+		}
+		if _, err := os.Stat(previousDir); !os.IsNotExist(err) {
+			t.Error("expected the previous audio to be deleted")
+		}
+	})
+}
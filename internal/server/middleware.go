@@ -23,6 +23,19 @@ func (s *Server) authMiddleware(next AuthedHandlerFunc) http.HandlerFunc {
 }
 
 func (s *Server) getToken(r *http.Request) (*auth.Token, error) {
+	token, err := s.readToken(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkJoinToken(r.Context(), token); err != nil {
+		return nil, err
+	}
+
+	return s.checkUser(r.Context(), token)
+}
+
+func (s *Server) readToken(r *http.Request) (*auth.Token, error) {
 	cookie, err := readCookie(r, authCookieName)
 	if err == nil {
 		return s.auth.ValidateToken(cookie)
@@ -30,12 +43,12 @@ func (s *Server) getToken(r *http.Request) (*auth.Token, error) {
 
 	// This is a hack for the WS endpoint, which can't use an Authorization header.
 	if tokenParam := r.FormValue("token"); tokenParam != "" {
-		return s.auth.ValidateJoinToken(tokenParam)
+		return s.auth.ValidateToken(tokenParam)
 	}
 
 	authHeaderToken, err := getAuthorizationBearerToken(r)
 	if err == nil {
-		return s.auth.ValidateJoinToken(authHeaderToken)
+		return s.auth.ValidateToken(authHeaderToken)
 	}
 
 	return nil, fmt.Errorf("No valid authentication method found")
@@ -64,3 +77,13 @@ func (s *Server) gmOnlyMiddleware(next AuthedHandlerFunc) http.HandlerFunc {
 		next(w, r, token)
 	})
 }
+
+func (s *Server) adminOnlyMiddleware(next AuthedHandlerFunc) http.HandlerFunc {
+	return s.gmOnlyMiddleware(func(w http.ResponseWriter, r *http.Request, token *auth.Token) {
+		if !token.Admin {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		next(w, r, token)
+	})
+}
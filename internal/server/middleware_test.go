@@ -75,7 +75,7 @@ func TestGMOnlyMiddleware(t *testing.T) {
 	}{
 		{
 			name:       "GM role",
-			token:      &auth.Token{Role: auth.RoleGM},
+			token:      &auth.Token{Role: auth.RoleGM, Subject: "testuser"},
 			wantStatus: http.StatusOK,
 		},
 		{
@@ -100,3 +100,49 @@ func TestGMOnlyMiddleware(t *testing.T) {
 		})
 	}
 }
+
+func TestAdminOnlyMiddleware(t *testing.T) {
+	ts := setupTestServer(t)
+	defer ts.cleanup(t)
+
+	mockHandler := func(w http.ResponseWriter, r *http.Request, token *auth.Token) {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	tests := []struct {
+		name       string
+		token      *auth.Token
+		wantStatus int
+	}{
+		{
+			name:       "Admin GM",
+			token:      &auth.Token{Role: auth.RoleGM, Admin: true, Subject: "testuser"},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "GM",
+			token:      &auth.Token{Role: auth.RoleGM, Subject: "testuser"},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "Player role",
+			token:      &auth.Token{Role: auth.RolePlayer, Admin: true},
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts.auth.(*mockAuth).token = tt.token
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			addAuthCookie(req, tt.token.String())
+			rec := httptest.NewRecorder()
+
+			ts.adminOnlyMiddleware(mockHandler)(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("want status %v; got %v", tt.wantStatus, rec.Code)
+			}
+		})
+	}
+}
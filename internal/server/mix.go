@@ -0,0 +1,194 @@
+package server
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/google/uuid"
+	"github.com/terrabitz/rpg-audio-streamer/internal/auth"
+)
+
+const mixPath = "/api/v1/mix"
+
+type MixStreamer interface {
+	Subscribe() (<-chan []byte, func())
+	ContentType() string
+}
+
+// joinTokenParam names the join token a signed mix stream URL was issued
+// for. Only its ID is in the URL; the secret never is.
+const joinTokenParam = "joinToken"
+
+// mixAuthMiddleware also lets stream clients like VLC in with a signed URL
+// from handleMixURL, since they can't redeem a join token for a player token.
+func (s *Server) mixAuthMiddleware(next AuthedHandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		sig, ok := urlSignatureFromQuery(query)
+		if !ok {
+			s.authMiddleware(next)(w, r)
+			return
+		}
+
+		token := &auth.Token{Role: auth.RolePlayer, Subject: "stream"}
+		var joinToken *JoinToken
+		if idStr := query.Get(joinTokenParam); idStr != "" {
+			id, err := uuid.Parse(idStr)
+			if err != nil {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			found, err := s.streamJoinToken(r.Context(), id)
+			if err != nil {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			joinToken = &found
+			token.JoinTokenID = id.String()
+		}
+
+		if err := s.auth.ValidateURLSignature(mixResource(joinToken), sig); err != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next(w, r, token)
+	}
+}
+
+// MixURLRequest asks for a signed mix stream URL. Token is a join token, for
+// stream clients that have nothing else to sign in with; without it the
+// usual credentials are used.
+type MixURLRequest struct {
+	Token string `json:"token,omitempty"`
+}
+
+// handleMixURL issues a short-lived signed URL for the mix stream. The join
+// token is sent in the body rather than in the stream URL, so its secret
+// doesn't end up in access logs.
+func (s *Server) handleMixURL(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req MixURLRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	joinToken, err := s.mixJoinToken(r, req.Token)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	sig := s.auth.SignURL(mixResource(joinToken))
+	query := urlSignatureQuery(sig)
+	if joinToken != nil {
+		query.Set(joinTokenParam, joinToken.ID.String())
+	}
+	mixURL := url.URL{
+		Path:     mixPath,
+		RawQuery: query.Encode(),
+	}
+
+	respondJSON(w, http.StatusOK, streamURLResponse{
+		URL:       mixURL.String(),
+		ExpiresAt: sig.ExpiresAt,
+	})
+}
+
+// mixJoinToken finds the join token a mix stream URL is for: the one given in
+// the request, or the one the caller joined with. GMs get URLs without one.
+func (s *Server) mixJoinToken(r *http.Request, rawToken string) (*JoinToken, error) {
+	if rawToken != "" {
+		id, secret, ok := parseJoinToken(rawToken)
+		if !ok {
+			return nil, auth.ErrInvalidJoinToken
+		}
+		joinToken, err := s.streamJoinToken(r.Context(), id)
+		if err != nil {
+			return nil, err
+		}
+		if !auth.JoinSecretMatches(secret, joinToken.SecretHash) {
+			return nil, auth.ErrInvalidJoinToken
+		}
+		return &joinToken, nil
+	}
+
+	token, err := s.getToken(r)
+	if err != nil {
+		return nil, err
+	}
+	if token.JoinTokenID == "" {
+		return nil, nil
+	}
+
+	id, err := uuid.Parse(token.JoinTokenID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid join token ID: %w", err)
+	}
+	joinToken, err := s.streamJoinToken(r.Context(), id)
+	if err != nil {
+		return nil, err
+	}
+	return &joinToken, nil
+}
+
+// mixResource is what a mix stream URL is signed for. URLs issued for a join
+// token are tied to its current secret, so rotating the join token stops
+// them working too.
+func mixResource(joinToken *JoinToken) string {
+	if joinToken == nil {
+		return "mix"
+	}
+	return "mix/" + joinToken.ID.String() + "/" + hex.EncodeToString(joinToken.SecretHash)
+}
+
+// handleMixStream serves the server-side mix of the table as one endless
+// Icecast-style HTTP stream, for clients that can't run the web player.
+func (s *Server) handleMixStream(w http.ResponseWriter, r *http.Request, token *auth.Token) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.mix == nil {
+		http.Error(w, "Mix stream not available", http.StatusNotFound)
+		return
+	}
+
+	stream, unsubscribe := s.mix.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", s.mix.ContentType())
+	w.Header().Set("Cache-Control", "no-cache, no-store")
+	w.Header().Set("icy-name", "Skald Bot table mix")
+	w.Header().Set("icy-pub", "0")
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case chunk, ok := <-stream:
+			if !ok {
+				return
+			}
+			if _, err := w.Write(chunk); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/terrabitz/rpg-audio-streamer/internal/auth"
+)
+
+type mockMixStreamer struct {
+	chunks [][]byte
+}
+
+func (m *mockMixStreamer) Subscribe() (<-chan []byte, func()) {
+	ch := make(chan []byte, len(m.chunks))
+	for _, chunk := range m.chunks {
+		ch <- chunk
+	}
+	close(ch)
+	return ch, func() {}
+}
+
+func (m *mockMixStreamer) ContentType() string {
+	return "audio/mpeg"
+}
+
+func TestHandleMixStream(t *testing.T) {
+	ts := setupTestServer(t)
+	defer ts.cleanup(t)
+
+	t.Run("mix not available", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/mix", nil)
+		rec := httptest.NewRecorder()
+
+		ts.handleMixStream(rec, req, &auth.Token{Role: auth.RolePlayer})
+
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("expected status NotFound; got %v", rec.Code)
+		}
+	})
+
+	t.Run("streams mix chunks", func(t *testing.T) {
+		ts.mix = &mockMixStreamer{chunks: [][]byte{[]byte("frame1"), []byte("frame2")}}
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/mix", nil)
+		rec := httptest.NewRecorder()
+
+		ts.handleMixStream(rec, req, &auth.Token{Role: auth.RolePlayer})
+
+		if got := rec.Header().Get("Content-Type"); got != "audio/mpeg" {
+			t.Errorf("expected Content-Type audio/mpeg; got %q", got)
+		}
+		if got := rec.Body.String(); got != "frame1frame2" {
+			t.Errorf("expected streamed frames; got %q", got)
+		}
+	})
+}
+
+func TestMixAuthMiddleware(t *testing.T) {
+	ts := setupTestServer(t)
+	defer ts.cleanup(t)
+
+	store := ts.store.(*MockTrackStore)
+	ts.mix = &mockMixStreamer{chunks: [][]byte{[]byte("frame")}}
+	handler := ts.mixAuthMiddleware(ts.handleMixStream)
+
+	rec := httptest.NewRecorder()
+	ts.handleJoinTokens(rec, httptest.NewRequest(http.MethodPost, "/api/v1/joinTokens", strings.NewReader(`{"label":"VLC","maxUses":1}`)), &auth.Token{Role: auth.RoleGM})
+	var issued IssuedJoinToken
+	if err := json.NewDecoder(rec.Body).Decode(&issued); err != nil {
+		t.Fatalf("failed to decode join token: %v", err)
+	}
+
+	mixURL := func(t *testing.T, req *http.Request) (int, string) {
+		t.Helper()
+
+		rec := httptest.NewRecorder()
+		ts.handleMixURL(rec, req)
+		if rec.Code != http.StatusOK {
+			return rec.Code, ""
+		}
+
+		var resp streamURLResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode mix URL: %v", err)
+		}
+		return rec.Code, resp.URL
+	}
+	joinTokenURL := func(t *testing.T, token string) (int, string) {
+		t.Helper()
+		body, _ := json.Marshal(MixURLRequest{Token: token})
+		return mixURL(t, httptest.NewRequest(http.MethodPost, "/api/v1/mix/url", bytes.NewReader(body)))
+	}
+
+	stream := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	code, signed := joinTokenURL(t, issued.Token)
+	if code != http.StatusOK {
+		t.Fatalf("expected the join token to get a mix URL; got %v", code)
+	}
+	if strings.Contains(signed, issued.Token[strings.Index(issued.Token, ".")+1:]) {
+		t.Errorf("expected the mix URL not to contain the join token's secret; got %s", signed)
+	}
+
+	for range 2 {
+		if rec := stream(signed); rec.Code != http.StatusOK || rec.Body.String() != "frame" {
+			t.Fatalf("expected the signed URL to open the stream; got %v: %s", rec.Code, rec.Body)
+		}
+	}
+	if uses := store.joinTokens[issued.ID].Uses; uses != 0 {
+		t.Errorf("expected streaming not to use up the join token; got %d uses", uses)
+	}
+
+	if rec := stream("/api/v1/mix?token=" + url.QueryEscape(issued.Token)); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected a join token in the stream URL to be refused; got %v", rec.Code)
+	}
+	if code, _ := joinTokenURL(t, issued.ID.String()+".wrong"); code != http.StatusUnauthorized {
+		t.Errorf("expected a wrong secret to be refused; got %v", code)
+	}
+	if rec := stream(strings.Replace(signed, "signature=", "signature=x", 1)); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected a tampered signature to be refused; got %v", rec.Code)
+	}
+
+	t.Run("GM", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/mix/url", nil)
+		req.AddCookie(&http.Cookie{Name: authCookieName, Value: ts.auth.(*mockAuth).token.String()})
+		code, gmURL := mixURL(t, req)
+		if code != http.StatusOK {
+			t.Fatalf("expected a GM to get a mix URL; got %v", code)
+		}
+		if rec := stream(gmURL); rec.Code != http.StatusOK {
+			t.Errorf("expected the GM's URL to open the stream; got %v", rec.Code)
+		}
+
+		if code, _ := mixURL(t, httptest.NewRequest(http.MethodPost, "/api/v1/mix/url", nil)); code != http.StatusUnauthorized {
+			t.Errorf("expected no credentials to be refused; got %v", code)
+		}
+	})
+
+	t.Run("Rotated", func(t *testing.T) {
+		joinToken := store.joinTokens[issued.ID]
+		joinToken.SecretHash = auth.HashJoinSecret("rotated")
+		store.joinTokens[issued.ID] = joinToken
+		if rec := stream(signed); rec.Code != http.StatusUnauthorized {
+			t.Errorf("expected a rotated join token's URL to be refused; got %v", rec.Code)
+		}
+	})
+
+	t.Run("Revoked", func(t *testing.T) {
+		code, signed := joinTokenURL(t, issued.ID.String()+".rotated")
+		if code != http.StatusOK {
+			t.Fatalf("expected the rotated join token to get a mix URL; got %v", code)
+		}
+
+		joinToken := store.joinTokens[issued.ID]
+		revokedAt := time.Now()
+		joinToken.RevokedAt = &revokedAt
+		store.joinTokens[issued.ID] = joinToken
+		if rec := stream(signed); rec.Code != http.StatusUnauthorized {
+			t.Errorf("expected a revoked join token's URL to be refused; got %v", rec.Code)
+		}
+	})
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 500
+)
+
+// TrackList is one page of a track listing.
+type TrackList struct {
+	Tracks []Track `json:"tracks"`
+	// Total counts every track matching the filter, across all pages.
+	Total int `json:"total"`
+	// NextCursor requests the following page. It's empty on the last page.
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// trackCursor is the position of the last track on a page. Clients get it as
+// an opaque token; it records the ordering it was made for so it can't be
+// replayed against a different sort.
+type trackCursor struct {
+	Sort       TrackSort `json:"s"`
+	Descending bool      `json:"d,omitempty"`
+	ID         uuid.UUID `json:"i"`
+	Name       string    `json:"n,omitempty"`
+	Duration   float64   `json:"l,omitempty"`
+	TypeID     uuid.UUID `json:"t,omitzero"`
+	Position   int       `json:"p,omitempty"`
+}
+
+// parseTrackPage reads the sort, order, limit and cursor query parameters.
+func parseTrackPage(query url.Values) (TrackPage, error) {
+	page := TrackPage{
+		Sort:  TrackSortCreated,
+		Limit: defaultPageSize,
+	}
+
+	switch sort := TrackSort(query.Get("sort")); sort {
+	case "":
+	case TrackSortCreated, TrackSortName, TrackSortDuration, TrackSortPosition:
+		page.Sort = sort
+	default:
+		return TrackPage{}, fmt.Errorf("unknown sort '%s'", sort)
+	}
+
+	switch order := query.Get("order"); order {
+	case "", "asc":
+	case "desc":
+		page.Descending = true
+	default:
+		return TrackPage{}, fmt.Errorf("order must be 'asc' or 'desc'")
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxPageSize {
+			return TrackPage{}, fmt.Errorf("limit must be between 1 and %d", maxPageSize)
+		}
+		page.Limit = limit
+	}
+
+	if cursorStr := query.Get("cursor"); cursorStr != "" {
+		after, err := decodeTrackCursor(cursorStr, page)
+		if err != nil {
+			return TrackPage{}, err
+		}
+		page.After = after
+	}
+
+	return page, nil
+}
+
+func encodeTrackCursor(page TrackPage, last Track) string {
+	cursor := trackCursor{
+		Sort:       page.Sort,
+		Descending: page.Descending,
+		ID:         last.ID,
+	}
+
+	switch page.Sort {
+	case TrackSortName:
+		cursor.Name = last.Name
+	case TrackSortDuration:
+		cursor.Duration = last.Duration
+	case TrackSortPosition:
+		cursor.TypeID = last.TypeID
+		cursor.Position = last.Position
+	}
+
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeTrackCursor(s string, page TrackPage) (*Track, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("malformed cursor")
+	}
+
+	var cursor trackCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, fmt.Errorf("malformed cursor")
+	}
+
+	if cursor.Sort != page.Sort || cursor.Descending != page.Descending {
+		return nil, fmt.Errorf("cursor belongs to a different sort order")
+	}
+
+	return &Track{
+		ID:       cursor.ID,
+		Name:     cursor.Name,
+		Duration: cursor.Duration,
+		TypeID:   cursor.TypeID,
+		Position: cursor.Position,
+	}, nil
+}
+
+// parseDateParam accepts either a full RFC 3339 timestamp or a plain date,
+// which is taken as midnight UTC.
+func parseDateParam(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	return time.Parse(time.DateOnly, value)
+}
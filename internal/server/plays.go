@@ -0,0 +1,248 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/terrabitz/rpg-audio-streamer/internal/auth"
+)
+
+const (
+	defaultStatsLimit = 20
+	maxStatsLimit     = 100
+
+	// playQueueSize is how many plays can wait to be saved before new ones
+	// are dropped, so a slow database doesn't hold up the GM's commands.
+	playQueueSize = 256
+)
+
+// playState is the part of the GM client's track state that says whether a
+// track is playing.
+type playState struct {
+	FileID    uuid.UUID `json:"fileID"`
+	IsPlaying *bool     `json:"isPlaying"`
+}
+
+// playChange is a track starting or stopping.
+type playChange struct {
+	trackID   uuid.UUID
+	startedAt time.Time
+	// stoppedAt is set if the track has stopped.
+	stoppedAt time.Time
+}
+
+// PlayedTrack is a track along with how it has been played.
+type PlayedTrack struct {
+	Track Track      `json:"track"`
+	Stats TrackStats `json:"stats"`
+}
+
+// HandleCommand follows the GM's syncTrack and syncAll commands to log when
+// each track starts playing and for how long. It's meant to be registered as
+// a hub command listener, so it only queues the plays and leaves saving them
+// to writePlays.
+func (s *Server) HandleCommand(method string, payload json.RawMessage) {
+	now := time.Now()
+
+	var changes []playChange
+	switch method {
+	case "syncTrack":
+		var state playState
+		if err := json.Unmarshal(payload, &state); err != nil {
+			s.logger.Warn("couldn't decode syncTrack for play log", "error", err)
+			return
+		}
+		if state.IsPlaying == nil {
+			return
+		}
+
+		s.playingMu.Lock()
+		if change, ok := s.setPlaying(state.FileID, *state.IsPlaying, now); ok {
+			changes = append(changes, change)
+		}
+		s.playingMu.Unlock()
+	case "syncAll":
+		var syncPayload struct {
+			Tracks []playState `json:"tracks"`
+		}
+		if err := json.Unmarshal(payload, &syncPayload); err != nil {
+			s.logger.Warn("couldn't decode syncAll for play log", "error", err)
+			return
+		}
+
+		// syncAll lists every track, so anything not playing in it has
+		// stopped.
+		playing := make(map[uuid.UUID]bool)
+		s.playingMu.Lock()
+		for _, state := range syncPayload.Tracks {
+			if state.IsPlaying == nil || !*state.IsPlaying {
+				continue
+			}
+			playing[state.FileID] = true
+			if change, ok := s.setPlaying(state.FileID, true, now); ok {
+				changes = append(changes, change)
+			}
+		}
+		for id := range s.playing {
+			if playing[id] {
+				continue
+			}
+			if change, ok := s.setPlaying(id, false, now); ok {
+				changes = append(changes, change)
+			}
+		}
+		s.playingMu.Unlock()
+	}
+
+	s.queuesMu.RLock()
+	defer s.queuesMu.RUnlock()
+	if s.queuesClosed {
+		return
+	}
+
+	for _, change := range changes {
+		select {
+		case s.playChanges <- change:
+		default:
+			s.logger.Error("play log queue is full, dropping play", "trackID", change.trackID)
+		}
+	}
+}
+
+// writePlays saves queued plays in the order they happened, so a play is
+// always recorded before it's finished.
+func (s *Server) writePlays() {
+	defer close(s.playsDone)
+
+	for change := range s.playChanges {
+		if !change.stoppedAt.IsZero() {
+			if err := s.store.FinishPlay(context.Background(), change.trackID, change.startedAt, change.stoppedAt.Sub(change.startedAt)); err != nil {
+				s.logger.Error("failed to record track play duration", "trackID", change.trackID, "error", err)
+			}
+			continue
+		}
+		if err := s.store.RecordPlay(context.Background(), change.trackID, change.startedAt); err != nil {
+			s.logger.Error("failed to record track play", "trackID", change.trackID, "error", err)
+		}
+	}
+}
+
+// setPlaying updates whether a track is playing, returning the change if it
+// has just started or stopped. The caller must hold playingMu.
+func (s *Server) setPlaying(trackID uuid.UUID, playing bool, now time.Time) (playChange, bool) {
+	startedAt, wasPlaying := s.playing[trackID]
+	if playing == wasPlaying {
+		return playChange{}, false
+	}
+
+	if !playing {
+		delete(s.playing, trackID)
+		return playChange{trackID: trackID, startedAt: startedAt, stoppedAt: now}, true
+	}
+
+	s.playing[trackID] = now
+	return playChange{trackID: trackID, startedAt: now}, true
+}
+
+func (s *Server) handleRecentFiles(w http.ResponseWriter, r *http.Request, token *auth.Token) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit, err := parseStatsLimit(r.URL.Query())
+	if err != nil {
+		http.Error(w, "Invalid pagination: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	stats, err := s.store.GetRecentTracks(r.Context(), limit)
+	if err != nil {
+		s.logger.Error("failed to get recent tracks", "error", err)
+		http.Error(w, "Failed to get recent tracks", http.StatusInternalServerError)
+		return
+	}
+
+	s.respondPlayedTracks(w, r, stats)
+}
+
+func (s *Server) handlePopularFiles(w http.ResponseWriter, r *http.Request, token *auth.Token) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	limit, err := parseStatsLimit(query)
+	if err != nil {
+		http.Error(w, "Invalid pagination: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var since time.Time
+	if sinceStr := query.Get("since"); sinceStr != "" {
+		if since, err = parseDateParam(sinceStr); err != nil {
+			http.Error(w, "Invalid since date", http.StatusBadRequest)
+			return
+		}
+	}
+
+	stats, err := s.store.GetPopularTracks(r.Context(), since, limit)
+	if err != nil {
+		s.logger.Error("failed to get popular tracks", "error", err)
+		http.Error(w, "Failed to get popular tracks", http.StatusInternalServerError)
+		return
+	}
+
+	s.respondPlayedTracks(w, r, stats)
+}
+
+func (s *Server) handleFavoriteFiles(w http.ResponseWriter, r *http.Request, token *auth.Token) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	favorite := true
+	s.listFiles(w, r, TrackFilter{Favorite: &favorite})
+}
+
+// respondPlayedTracks looks up the tracks the stats are for and writes them
+// out in the same order.
+func (s *Server) respondPlayedTracks(w http.ResponseWriter, r *http.Request, stats []TrackStats) {
+	played := make([]PlayedTrack, 0, len(stats))
+	for _, stat := range stats {
+		track, err := s.store.GetTrackByID(r.Context(), stat.TrackID)
+		if errors.Is(err, ErrNotFound) {
+			// The track has been deleted since it was played.
+			continue
+		} else if err != nil {
+			s.logger.Error("failed to get track", "trackID", stat.TrackID, "error", err)
+			http.Error(w, "Failed to get tracks", http.StatusInternalServerError)
+			return
+		}
+		played = append(played, PlayedTrack{Track: track, Stats: stat})
+	}
+
+	respondJSON(w, http.StatusOK, played)
+}
+
+func parseStatsLimit(query url.Values) (int, error) {
+	limitStr := query.Get("limit")
+	if limitStr == "" {
+		return defaultStatsLimit, nil
+	}
+
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit < 1 || limit > maxStatsLimit {
+		return 0, fmt.Errorf("limit must be between 1 and %d", maxStatsLimit)
+	}
+	return limit, nil
+}
@@ -0,0 +1,157 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/terrabitz/rpg-audio-streamer/internal/auth"
+)
+
+func TestPlayLog(t *testing.T) {
+	ts := setupTestServer(t)
+	defer ts.cleanup(t)
+
+	store := ts.store.(*MockTrackStore)
+	rain, lute := uuid.New(), uuid.New()
+
+	send := func(method string, payload any) {
+		data, _ := json.Marshal(payload)
+		ts.HandleCommand(method, data)
+	}
+
+	send("syncTrack", map[string]any{"fileID": rain, "isPlaying": true})
+	send("syncTrack", map[string]any{"fileID": rain, "volume": 50})
+	send("syncAll", map[string]any{"tracks": []map[string]any{
+		{"fileID": rain, "isPlaying": true},
+		{"fileID": lute, "isPlaying": true},
+	}})
+
+	time.Sleep(10 * time.Millisecond)
+	send("syncAll", map[string]any{"tracks": []map[string]any{
+		{"fileID": lute, "isPlaying": true},
+	}})
+	// Closing waits for the queued plays to be saved.
+	ts.Close()
+
+	if len(store.plays) != 2 {
+		t.Fatalf("expected a play for each track; got %+v", store.plays)
+	}
+	if store.plays[0].duration <= 0 {
+		t.Errorf("expected the stopped track's play to get a duration; got %v", store.plays[0].duration)
+	}
+	if store.plays[1].duration != 0 {
+		t.Errorf("expected the playing track's play to have no duration yet; got %v", store.plays[1].duration)
+	}
+}
+
+func TestPlayedFiles(t *testing.T) {
+	ts := setupTestServer(t)
+	defer ts.cleanup(t)
+
+	store := ts.store.(*MockTrackStore)
+	rain := Track{ID: uuid.New(), Name: "Heavy Rain"}
+	lute := Track{ID: uuid.New(), Name: "Lute Song"}
+	store.tracks[rain.ID] = rain
+	store.tracks[lute.ID] = lute
+
+	now := time.Now()
+	store.plays = []trackPlay{
+		{trackID: rain.ID, startedAt: now.Add(-72 * time.Hour), duration: time.Minute},
+		{trackID: rain.ID, startedAt: now.Add(-48 * time.Hour), duration: time.Minute},
+		{trackID: lute.ID, startedAt: now.Add(-time.Hour), duration: time.Minute},
+	}
+
+	request := func(t *testing.T, target string, handler func(http.ResponseWriter, *http.Request, *auth.Token)) []PlayedTrack {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		rec := httptest.NewRecorder()
+		handler(rec, req, &auth.Token{Role: auth.RoleGM})
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status OK; got %v: %s", rec.Code, rec.Body)
+		}
+
+		var played []PlayedTrack
+		if err := json.NewDecoder(rec.Body).Decode(&played); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return played
+	}
+
+	names := func(played []PlayedTrack) []string {
+		var names []string
+		for _, p := range played {
+			names = append(names, p.Track.Name)
+		}
+		return names
+	}
+
+	tests := []struct {
+		name    string
+		target  string
+		handler func(http.ResponseWriter, *http.Request, *auth.Token)
+		want    []string
+	}{
+		{"recent", "/api/v1/files/recent", ts.handleRecentFiles, []string{"Lute Song", "Heavy Rain"}},
+		{"recent limit", "/api/v1/files/recent?limit=1", ts.handleRecentFiles, []string{"Lute Song"}},
+		{"popular", "/api/v1/files/popular", ts.handlePopularFiles, []string{"Heavy Rain", "Lute Song"}},
+		{"popular since", "/api/v1/files/popular?since=" + now.Add(-24*time.Hour).Format(time.RFC3339), ts.handlePopularFiles, []string{"Lute Song"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := names(request(t, tt.target, tt.handler))
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v; got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("expected %v; got %v", tt.want, got)
+				}
+			}
+		})
+	}
+
+	t.Run("Stats", func(t *testing.T) {
+		played := request(t, "/api/v1/files/popular", ts.handlePopularFiles)
+		if stats := played[0].Stats; stats.PlayCount != 2 || stats.TotalPlayed != 120 {
+			t.Errorf("expected 2 plays totalling 120 seconds; got %+v", stats)
+		}
+	})
+
+	t.Run("Invalid limit", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/files/recent?limit=1000", nil)
+		rec := httptest.NewRecorder()
+		ts.handleRecentFiles(rec, req, &auth.Token{Role: auth.RoleGM})
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected status %v; got %v", http.StatusBadRequest, rec.Code)
+		}
+	})
+
+	t.Run("Favorites", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/files/"+lute.ID.String(), bytes.NewBufferString(`{"favorite": true}`))
+		req.SetPathValue("trackID", lute.ID.String())
+		rec := httptest.NewRecorder()
+		ts.handleFile(rec, req, &auth.Token{Role: auth.RoleGM})
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status OK; got %v: %s", rec.Code, rec.Body)
+		}
+
+		req = httptest.NewRequest(http.MethodGet, "/api/v1/files/favorites", nil)
+		rec = httptest.NewRecorder()
+		ts.handleFavoriteFiles(rec, req, &auth.Token{Role: auth.RoleGM})
+
+		var list TrackList
+		if err := json.NewDecoder(rec.Body).Decode(&list); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if list.Total != 1 || list.Tracks[0].ID != lute.ID {
+			t.Errorf("expected only Lute Song to be a favourite; got %+v", list.Tracks)
+		}
+	})
+}
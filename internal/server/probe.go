@@ -0,0 +1,82 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// probedTags are the container tags worth keeping for search and display.
+var probedTags = map[string]bool{
+	"title":     true,
+	"artist":    true,
+	"album":     true,
+	"genre":     true,
+	"comment":   true,
+	"composer":  true,
+	"copyright": true,
+}
+
+type audioProbe struct {
+	Duration float64
+	Tags     map[string]string
+}
+
+// probeAudio reads the duration and descriptive tags of an uploaded file with
+// ffprobe.
+func probeAudio(ctx context.Context, path string) (audioProbe, error) {
+	cmd := exec.CommandContext(ctx, "ffprobe",
+		"-v", "error",
+		"-show_entries", "format=duration:format_tags",
+		"-of", "json",
+		path)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return audioProbe{}, fmt.Errorf("ffprobe failed: %w: %s", err, stderr.String())
+	}
+
+	return parseProbe(stdout.Bytes())
+}
+
+func parseProbe(output []byte) (audioProbe, error) {
+	var result struct {
+		Format struct {
+			Duration string            `json:"duration"`
+			Tags     map[string]string `json:"tags"`
+		} `json:"format"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return audioProbe{}, fmt.Errorf("couldn't parse ffprobe output: %w", err)
+	}
+
+	var probe audioProbe
+	if result.Format.Duration != "" {
+		duration, err := strconv.ParseFloat(result.Format.Duration, 64)
+		if err != nil {
+			return audioProbe{}, fmt.Errorf("invalid duration '%s': %w", result.Format.Duration, err)
+		}
+		probe.Duration = duration
+	}
+
+	// Tag names vary in case between containers (e.g. TITLE in FLAC)
+	for key, value := range result.Format.Tags {
+		key = strings.ToLower(key)
+		value = strings.TrimSpace(value)
+		if !probedTags[key] || value == "" {
+			continue
+		}
+		if probe.Tags == nil {
+			probe.Tags = make(map[string]string)
+		}
+		probe.Tags[key] = value
+	}
+
+	return probe, nil
+}
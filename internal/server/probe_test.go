@@ -0,0 +1,38 @@
+package server
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestParseProbe(t *testing.T) {
+	output := []byte(`{
+		"format": {
+			"duration": "183.040000",
+			"tags": {
+				"TITLE": "Rainy Tavern",
+				"artist": "Some Bard",
+				"encoder": "Lavf60.3.100",
+				"comment": "  "
+			}
+		}
+	}`)
+
+	probe, err := parseProbe(output)
+	if err != nil {
+		t.Fatalf("parseProbe() error = %v", err)
+	}
+
+	if probe.Duration != 183.04 {
+		t.Errorf("expected duration 183.04; got %v", probe.Duration)
+	}
+
+	want := map[string]string{"title": "Rainy Tavern", "artist": "Some Bard"}
+	if !maps.Equal(probe.Tags, want) {
+		t.Errorf("expected tags %v; got %v", want, probe.Tags)
+	}
+
+	if _, err := parseProbe([]byte(`{"format": {"duration": "N/A"}}`)); err == nil {
+		t.Error("expected an error for an invalid duration")
+	}
+}
@@ -1,41 +1,119 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io/fs"
 	"log/slog"
 	"net/http"
 	"os"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 	"github.com/terrabitz/rpg-audio-streamer/internal/auth"
 	"github.com/terrabitz/rpg-audio-streamer/internal/middlewares"
+	"golang.org/x/sync/singleflight"
 )
 
 const (
 	authCookieName = "auth_token"
 	cookiePath     = "/"
+
+	// shutdownTimeout is how long requests in flight get to finish when the
+	// server stops. Mix streams never finish on their own, so they're cut
+	// off once it's up.
+	shutdownTimeout = 5 * time.Second
 )
 
 type Authenticator interface {
 	ValidateCredentials(creds auth.Credentials) (*auth.Token, error)
+	RootUsername() string
+	NewGMToken(subject string, admin bool) (*auth.Token, error)
 	ValidateToken(tokenStr string) (*auth.Token, error)
-	GetJoinToken() string
-	ValidateJoinToken(joinToken string) (*auth.Token, error)
+	NewPlayerToken(joinTokenID string, player auth.Player) (*auth.Token, error)
+	SignURL(resource string) auth.URLSignature
+	ValidateURLSignature(resource string, sig auth.URLSignature) error
 }
 
 type WSRegisterer interface {
 	Register(conn *websocket.Conn, token *auth.Token)
 }
 
+// TrackAccess reports which tracks players may stream, so they can't fetch
+// tracks the GM hasn't played yet.
+type TrackAccess interface {
+	IsTrackReleased(trackID uuid.UUID) bool
+}
+
+// Notifier pushes changes made over the API to connected clients.
+type Notifier interface {
+	NotifyGMs(method string, payload any) error
+	NotifyAll(method string, payload any) error
+}
+
+// ClientDisconnector drops connected clients who've lost access.
+type ClientDisconnector interface {
+	// DisconnectJoinToken drops the players who joined with the join token.
+	DisconnectJoinToken(joinTokenID string)
+}
+
+type Hub interface {
+	WSRegisterer
+	TrackAccess
+	Notifier
+	ClientDisconnector
+}
+
 type Server struct {
 	cfg      Config
 	logger   *slog.Logger
 	frontend fs.FS
-	hub      WSRegisterer
+	hub      Hub
 	upgrader websocket.Upgrader
 	auth     Authenticator
 	store    Store
+	mix      MixStreamer
+
+	// remuxes makes concurrent requests for the same track's audio file
+	// share one ffmpeg run.
+	remuxes singleflight.Group
+
+	// mediaMu guards track directories while their audio is being swapped
+	// for a replacement.
+	mediaMu sync.RWMutex
+
+	// changingMedia holds the tracks whose audio is being replaced or rolled
+	// back, so only one change to a track's files runs at a time.
+	changingMediaMu sync.Mutex
+	changingMedia   map[uuid.UUID]bool
+
+	// playing holds when each track the GM is playing started, to tell new
+	// plays apart from updates to ones already logged.
+	playingMu sync.Mutex
+	playing   map[uuid.UUID]time.Time
+
+	// Plays are queued on playChanges and saved by writePlays, which closes
+	// playsDone once the queue is closed and drained.
+	playChanges chan playChange
+	playsDone   chan struct{}
+
+	// session identifies this run of the table in the session log. Events
+	// are queued on sessionEvents and saved by writeSessionEvents, which
+	// closes sessionDone once the queue is closed and drained.
+	// sessionDropped counts events dropped since the last one queued.
+	session        uuid.UUID
+	sessionEvents  chan queuedSessionEvent
+	sessionDropped atomic.Int64
+	sessionDone    chan struct{}
+
+	// queuesMu guards closing the queues above; nothing is queued once
+	// queuesClosed is set.
+	queuesMu     sync.RWMutex
+	queuesClosed bool
 }
 
 type Config struct {
@@ -43,15 +121,33 @@ type Config struct {
 	UploadDir string
 	DevMode   bool
 	CORS      middlewares.CorsConfig
+	// TableID names the table in the session log, so its sessions can be
+	// told apart from another server's sharing the database.
+	TableID string
 }
 
-func New(cfg Config, logger *slog.Logger, auth Authenticator, store Store, hub WSRegisterer) (*Server, error) {
+func New(cfg Config, logger *slog.Logger, auth Authenticator, store Store, hub Hub, mix MixStreamer) (*Server, error) {
+	session, err := uuid.NewV7()
+	if err != nil {
+		return nil, fmt.Errorf("couldn't generate session ID: %w", err)
+	}
+
 	srv := &Server{
-		logger: logger,
-		cfg:    cfg,
-		hub:    hub,
-		auth:   auth,
-		store:  store,
+		logger:  logger,
+		cfg:     cfg,
+		hub:     hub,
+		auth:    auth,
+		store:   store,
+		mix:     mix,
+		playing: make(map[uuid.UUID]time.Time),
+		session: session,
+
+		changingMedia: make(map[uuid.UUID]bool),
+
+		sessionEvents: make(chan queuedSessionEvent, sessionQueueSize),
+		sessionDone:   make(chan struct{}),
+		playChanges:   make(chan playChange, playQueueSize),
+		playsDone:     make(chan struct{}),
 		upgrader: websocket.Upgrader{
 			ReadBufferSize:  1024,
 			WriteBufferSize: 1024,
@@ -61,10 +157,15 @@ func New(cfg Config, logger *slog.Logger, auth Authenticator, store Store, hub W
 		},
 	}
 
+	go srv.writeSessionEvents()
+	go srv.writePlays()
+
 	return srv, nil
 }
 
-func (s *Server) Start() error {
+// Start serves requests until ctx is done, then stops accepting new ones and
+// waits a short while for those in flight.
+func (s *Server) Start(ctx context.Context) error {
 	// Ensure upload directory exists
 	if err := os.MkdirAll(s.cfg.UploadDir, os.ModePerm); err != nil {
 		return fmt.Errorf("failed to create upload directory: %w", err)
@@ -84,6 +185,9 @@ func (s *Server) Start() error {
 	// NOTE, we want to register this separately, because we don't want to apply
 	// the same default middleware we apply to the API routes.
 	mux.HandleFunc("/api/v1/ws", s.authMiddleware(s.handleWebSocket))
+	// The mix stream needs to flush as it goes, which the logging middleware's
+	// response writer doesn't support.
+	mux.HandleFunc(mixPath, s.mixAuthMiddleware(s.handleMixStream))
 	mux.Handle("/", apiHandler)
 
 	srv := &http.Server{
@@ -91,11 +195,25 @@ func (s *Server) Start() error {
 		Handler: mux,
 	}
 
+	stopped := make(chan error, 1)
+	go func() {
+		<-ctx.Done()
+		s.logger.Info("shutting down server")
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		stopped <- srv.Shutdown(shutdownCtx)
+	}()
+
 	s.logger.Info("starting server",
 		slog.Int("port", s.cfg.Port),
 		slog.Bool("devMode", s.cfg.DevMode),
 	)
-	return srv.ListenAndServe()
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return <-stopped
 }
 
 func (s *Server) registerHandlers() *http.ServeMux {
@@ -103,15 +221,40 @@ func (s *Server) registerHandlers() *http.ServeMux {
 
 	// Public endpoints
 	mux.HandleFunc("/api/v1/login", s.handleLogin)
+	mux.HandleFunc("/api/v1/join", s.handleJoin)
 	mux.HandleFunc("/api/v1/auth/status", s.handleAuthStatus)
 	mux.HandleFunc("/api/v1/auth/logout", s.handleLogout)
+	// Accepts a join token as well as the usual credentials
+	mux.HandleFunc("/api/v1/mix/url", s.handleMixURL)
 
 	// Protected endpoints with role validation
 	mux.HandleFunc("/api/v1/files", s.gmOnlyMiddleware(s.handleFiles))
+	mux.HandleFunc("/api/v1/files/order", s.gmOnlyMiddleware(s.handleFileOrder))
+	mux.HandleFunc("/api/v1/files/bulk", s.gmOnlyMiddleware(s.handleFilesBulk))
+	mux.HandleFunc("/api/v1/files/recent", s.gmOnlyMiddleware(s.handleRecentFiles))
+	mux.HandleFunc("/api/v1/files/popular", s.gmOnlyMiddleware(s.handlePopularFiles))
+	mux.HandleFunc("/api/v1/files/favorites", s.gmOnlyMiddleware(s.handleFavoriteFiles))
 	mux.HandleFunc("/api/v1/files/{trackID}", s.gmOnlyMiddleware(s.handleFile))
-	mux.HandleFunc("/api/v1/joinToken", s.gmOnlyMiddleware(s.handleGetJoinToken))
-	mux.HandleFunc("/api/v1/stream/", s.authMiddleware(s.streamDirectory))
+	mux.HandleFunc("/api/v1/files/{trackID}/audio", s.streamAuthMiddleware(s.handleTrackAudio))
+	mux.HandleFunc("/api/v1/files/{trackID}/media", s.gmOnlyMiddleware(s.handleFileMedia))
+	mux.HandleFunc("/api/v1/files/{trackID}/media/rollback", s.gmOnlyMiddleware(s.handleFileMediaRollback))
+	mux.HandleFunc("/api/v1/joinTokens", s.gmOnlyMiddleware(s.handleJoinTokens))
+	mux.HandleFunc("/api/v1/joinTokens/{joinTokenID}/rotate", s.gmOnlyMiddleware(s.handleJoinTokenRotate))
+	mux.HandleFunc("/api/v1/joinTokens/{joinTokenID}/revoke", s.gmOnlyMiddleware(s.handleJoinTokenRevoke))
+	mux.HandleFunc("/api/v1/stream/{trackID}/{file}", s.streamAuthMiddleware(s.streamDirectory))
+	mux.HandleFunc("/api/v1/streamURL/{trackID}", s.authMiddleware(s.handleGetStreamURL))
+	mux.HandleFunc("/api/v1/prefetch", s.authMiddleware(s.handlePrefetchManifest))
 	mux.HandleFunc("/api/v1/trackTypes", s.authMiddleware(s.handleTrackTypes))
+	mux.HandleFunc("/api/v1/trackTypes/{typeID}", s.gmOnlyMiddleware(s.handleTrackType))
+	mux.HandleFunc("/api/v1/tags", s.gmOnlyMiddleware(s.handleTags))
+	mux.HandleFunc("/api/v1/collections", s.gmOnlyMiddleware(s.handleCollections))
+	mux.HandleFunc("/api/v1/collections/{collectionID}", s.gmOnlyMiddleware(s.handleCollection))
+	mux.HandleFunc("/api/v1/credits", s.gmOnlyMiddleware(s.handleCredits))
+	mux.HandleFunc("/api/v1/audit", s.gmOnlyMiddleware(s.handleAudit))
+	mux.HandleFunc("/api/v1/sessions", s.gmOnlyMiddleware(s.handleSessions))
+	mux.HandleFunc("/api/v1/sessions/{sessionID}/events", s.gmOnlyMiddleware(s.handleSessionEvents))
+	mux.HandleFunc("/api/v1/users", s.adminOnlyMiddleware(s.handleUsers))
+	mux.HandleFunc("/api/v1/users/{userID}", s.adminOnlyMiddleware(s.handleUser))
 
 	return mux
 }
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"mime/multipart"
@@ -11,6 +13,8 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
@@ -30,7 +34,7 @@ type mockAuth struct {
 	validUser     string
 	validPassword string
 	token         *auth.Token
-	joinToken     string
+	players       []*auth.Token
 }
 
 // Verify mockAuth implements Authenticator interface
@@ -43,6 +47,14 @@ func (m *mockAuth) ValidateCredentials(creds auth.Credentials) (*auth.Token, err
 	return nil, auth.ErrInvalidCredentials
 }
 
+func (m *mockAuth) RootUsername() string {
+	return m.validUser
+}
+
+func (m *mockAuth) NewGMToken(subject string, admin bool) (*auth.Token, error) {
+	return &auth.Token{Role: auth.RoleGM, Admin: admin, Subject: subject}, nil
+}
+
 func (m *mockAuth) ValidateToken(tokenStr string) (*auth.Token, error) {
 	if tokenStr == m.token.String() {
 		return m.token, nil
@@ -50,19 +62,48 @@ func (m *mockAuth) ValidateToken(tokenStr string) (*auth.Token, error) {
 	return nil, jwt.ErrTokenInvalidClaims
 }
 
-func (m *mockAuth) GetJoinToken() string {
-	return m.joinToken
+func (m *mockAuth) NewPlayerToken(joinTokenID string, player auth.Player) (*auth.Token, error) {
+	token := &auth.Token{
+		Role:        auth.RolePlayer,
+		Subject:     player.ID,
+		JoinTokenID: joinTokenID,
+		Name:        player.Name,
+		Color:       player.Color,
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}
+	m.players = append(m.players, token)
+	return token, nil
 }
 
-func (m *mockAuth) ValidateJoinToken(joinToken string) (*auth.Token, error) {
-	if joinToken == m.joinToken {
-		return m.token, nil
+func (m *mockAuth) SignURL(resource string) auth.URLSignature {
+	return auth.URLSignature{
+		ExpiresAt: time.Now().Add(time.Hour).Truncate(time.Second),
+		Signature: "signed:" + resource,
 	}
-	return nil, auth.ErrInvalidJoinToken
+}
+
+func (m *mockAuth) ValidateURLSignature(resource string, sig auth.URLSignature) error {
+	if time.Now().After(sig.ExpiresAt) {
+		return auth.ErrSignatureExpired
+	}
+	if sig.Signature != "signed:"+resource {
+		return auth.ErrInvalidSignature
+	}
+	return nil
 }
 
 type mockWSRegisterer struct {
-	t *testing.T
+	t             *testing.T
+	released      map[uuid.UUID]bool
+	notifications []notification
+	disconnected  []string
+}
+
+// notification is a message the server pushed to clients through the hub.
+type notification struct {
+	method    string
+	payload   any
+	toPlayers bool
 }
 
 func (m *mockWSRegisterer) Register(conn *websocket.Conn, token *auth.Token) {
@@ -75,6 +116,24 @@ func (m *mockWSRegisterer) Register(conn *websocket.Conn, token *auth.Token) {
 	}
 }
 
+func (m *mockWSRegisterer) IsTrackReleased(trackID uuid.UUID) bool {
+	return m.released[trackID]
+}
+
+func (m *mockWSRegisterer) NotifyGMs(method string, payload any) error {
+	m.notifications = append(m.notifications, notification{method: method, payload: payload})
+	return nil
+}
+
+func (m *mockWSRegisterer) NotifyAll(method string, payload any) error {
+	m.notifications = append(m.notifications, notification{method: method, payload: payload, toPlayers: true})
+	return nil
+}
+
+func (m *mockWSRegisterer) DisconnectJoinToken(joinTokenID string) {
+	m.disconnected = append(m.disconnected, joinTokenID)
+}
+
 func setupTestServer(t *testing.T) *testServer {
 	t.Helper()
 
@@ -87,19 +146,19 @@ func setupTestServer(t *testing.T) *testServer {
 	mockAuth := &mockAuth{
 		validUser:     "testuser",
 		validPassword: "testpass",
-		token:         &auth.Token{Role: auth.RoleGM},
-		joinToken:     "valid-join-token",
+		token:         &auth.Token{Role: auth.RoleGM, Subject: "testuser"},
 	}
 
 	mockTrackStore := NewMockTrackStore(t)
-	mockWSReg := &mockWSRegisterer{t: t}
+	mockWSReg := &mockWSRegisterer{t: t, released: make(map[uuid.UUID]bool)}
 
 	// Create test server
 	srv, err := New(Config{
 		Port:      8080,
 		UploadDir: tempDir,
 		CORS:      middlewares.CorsConfig{},
-	}, slog.New(slog.NewTextHandler(io.Discard, nil)), mockAuth, mockTrackStore, mockWSReg)
+		TableID:   "test-table",
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)), mockAuth, mockTrackStore, mockWSReg, nil)
 	if err != nil {
 		t.Fatalf("failed to create server: %v", err)
 	}
@@ -112,6 +171,7 @@ func setupTestServer(t *testing.T) *testServer {
 
 func (ts *testServer) cleanup(t *testing.T) {
 	t.Helper()
+	ts.Close()
 	if err := os.RemoveAll(ts.tempDir); err != nil {
 		t.Errorf("failed to cleanup temp dir: %v", err)
 	}
@@ -157,7 +217,7 @@ func TestUploadFile(t *testing.T) {
 
 		// Verify track metadata was saved
 		mockStore := ts.store.(*MockTrackStore)
-		tracks, err := mockStore.GetTracks(context.Background())
+		tracks, err := mockStore.GetTracks(context.Background(), TrackFilter{}, TrackPage{})
 		if err != nil {
 			t.Fatalf("failed to get tracks: %v", err)
 		}
@@ -202,10 +262,11 @@ func TestListFiles(t *testing.T) {
 			t.Errorf("expected status OK; got %v", rec.Code)
 		}
 
-		var files []Track
-		if err := json.NewDecoder(rec.Body).Decode(&files); err != nil {
+		var list TrackList
+		if err := json.NewDecoder(rec.Body).Decode(&list); err != nil {
 			t.Fatalf("failed to decode response: %v", err)
 		}
+		files := list.Tracks
 
 		if len(files) != 0 {
 			t.Errorf("expected empty list; got %d files", len(files))
@@ -249,10 +310,11 @@ func TestListFiles(t *testing.T) {
 			t.Errorf("expected status OK; got %v", rec.Code)
 		}
 
-		var files []Track
-		if err := json.NewDecoder(rec.Body).Decode(&files); err != nil {
+		var list TrackList
+		if err := json.NewDecoder(rec.Body).Decode(&list); err != nil {
 			t.Fatalf("failed to decode response: %v", err)
 		}
+		files := list.Tracks
 
 		if len(files) != len(testFiles) {
 			t.Errorf("expected %d files; got %d", len(testFiles), len(files))
@@ -273,6 +335,354 @@ func TestListFiles(t *testing.T) {
 	})
 }
 
+func TestListFilesFiltered(t *testing.T) {
+	ts := setupTestServer(t)
+	defer ts.cleanup(t)
+
+	ambianceID := uuid.MustParse("1EC000A2-A7C9-11EE-A0E5-0242AC120002")
+	musicID := uuid.MustParse("1EC000A2-A7C9-11EE-A0E5-0242AC120003")
+
+	store := ts.store.(*MockTrackStore)
+	for _, track := range []Track{
+		{ID: uuid.New(), Name: "Tavern Chatter", TypeID: ambianceID, Tags: []string{"town"}},
+		{ID: uuid.New(), Name: "Heavy Rain", TypeID: ambianceID, Tags: []string{"weather"}},
+		{ID: uuid.New(), Name: "Tavern Song", TypeID: musicID, Tags: []string{"town"}},
+	} {
+		store.tracks[track.ID] = track
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantNames  []string
+	}{
+		{
+			name:       "Search",
+			query:      "q=tavern",
+			wantStatus: http.StatusOK,
+			wantNames:  []string{"Tavern Chatter", "Tavern Song"},
+		},
+		{
+			name:       "Tag",
+			query:      "tag=Weather",
+			wantStatus: http.StatusOK,
+			wantNames:  []string{"Heavy Rain"},
+		},
+		{
+			name:       "Search and type",
+			query:      "q=tavern&type=" + musicID.String(),
+			wantStatus: http.StatusOK,
+			wantNames:  []string{"Tavern Song"},
+		},
+		{
+			name:       "Invalid type",
+			query:      "type=ambiance",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/files?"+tt.query, nil)
+			rec := httptest.NewRecorder()
+
+			ts.handleFiles(rec, req, &auth.Token{Role: auth.RoleGM})
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected status %v; got %v", tt.wantStatus, rec.Code)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var list TrackList
+			if err := json.NewDecoder(rec.Body).Decode(&list); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+
+			var names []string
+			for _, f := range list.Tracks {
+				names = append(names, f.Name)
+			}
+			slices.Sort(names)
+
+			if !slices.Equal(names, tt.wantNames) {
+				t.Errorf("expected %v; got %v", tt.wantNames, names)
+			}
+		})
+	}
+}
+
+func TestListFilesPaginated(t *testing.T) {
+	ts := setupTestServer(t)
+	defer ts.cleanup(t)
+
+	store := ts.store.(*MockTrackStore)
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i, track := range []Track{
+		{Name: "Tavern Chatter", Duration: 300},
+		{Name: "heavy Rain", Duration: 120},
+		{Name: "Battle Drums", Duration: 95.5},
+		{Name: "Dragon Roar", Duration: 4},
+		{Name: "Forest Night", Duration: 600},
+	} {
+		track.ID = uuid.New()
+		track.CreatedAt = start.AddDate(0, 0, i)
+		store.tracks[track.ID] = track
+	}
+
+	listAll := func(t *testing.T, query string) ([]string, int) {
+		t.Helper()
+
+		var names []string
+		var total int
+		cursor := ""
+		for pages := 0; ; pages++ {
+			if pages > len(store.tracks) {
+				t.Fatalf("pagination didn't terminate")
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/files?limit=2&"+query+"&cursor="+cursor, nil)
+			rec := httptest.NewRecorder()
+			ts.handleFiles(rec, req, &auth.Token{Role: auth.RoleGM})
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected status OK; got %v: %s", rec.Code, rec.Body)
+			}
+
+			var list TrackList
+			if err := json.NewDecoder(rec.Body).Decode(&list); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+
+			for _, track := range list.Tracks {
+				names = append(names, track.Name)
+			}
+			total = list.Total
+
+			if list.NextCursor == "" {
+				return names, total
+			}
+			cursor = list.NextCursor
+		}
+	}
+
+	tests := []struct {
+		name      string
+		query     string
+		wantNames []string
+	}{
+		{
+			name:      "By name",
+			query:     "sort=name",
+			wantNames: []string{"Battle Drums", "Dragon Roar", "Forest Night", "heavy Rain", "Tavern Chatter"},
+		},
+		{
+			name:      "By duration descending",
+			query:     "sort=duration&order=desc",
+			wantNames: []string{"Forest Night", "Tavern Chatter", "heavy Rain", "Battle Drums", "Dragon Roar"},
+		},
+		{
+			name:      "Date range",
+			query:     "sort=name&createdAfter=2025-03-02&createdBefore=2025-03-04T12:00:00Z",
+			wantNames: []string{"Battle Drums", "heavy Rain"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names, total := listAll(t, tt.query)
+
+			if !slices.Equal(names, tt.wantNames) {
+				t.Errorf("expected %v; got %v", tt.wantNames, names)
+			}
+			if total != len(tt.wantNames) {
+				t.Errorf("expected total %d; got %d", len(tt.wantNames), total)
+			}
+		})
+	}
+
+	t.Run("Invalid parameters", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/files?sort=name&limit=1", nil)
+		rec := httptest.NewRecorder()
+		ts.handleFiles(rec, req, &auth.Token{Role: auth.RoleGM})
+
+		var list TrackList
+		if err := json.NewDecoder(rec.Body).Decode(&list); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+
+		for _, query := range []string{
+			"sort=size",
+			"order=up",
+			"limit=0",
+			"limit=100000",
+			"cursor=not-a-cursor",
+			"sort=duration&cursor=" + list.NextCursor,
+			"createdAfter=yesterday",
+		} {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/files?"+query, nil)
+			rec := httptest.NewRecorder()
+			ts.handleFiles(rec, req, &auth.Token{Role: auth.RoleGM})
+
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("%s: expected status BadRequest; got %v", query, rec.Code)
+			}
+		}
+	})
+}
+
+func TestUpdateFileTags(t *testing.T) {
+	ts := setupTestServer(t)
+	defer ts.cleanup(t)
+
+	trackID := uuid.New()
+	ts.store.(*MockTrackStore).tracks[trackID] = Track{ID: trackID, Name: "Heavy Rain"}
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantTags   []string
+	}{
+		{
+			name:       "Normalizes tags",
+			body:       `{"tags": ["Rain", " storm  night ", "rain"]}`,
+			wantStatus: http.StatusOK,
+			wantTags:   []string{"rain", "storm night"},
+		},
+		{
+			name:       "Empty tag",
+			body:       `{"tags": ["rain", "  "]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Clears tags",
+			body:       `{"tags": []}`,
+			wantStatus: http.StatusOK,
+			wantTags:   []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/files/"+trackID.String(), bytes.NewBufferString(tt.body))
+			req.SetPathValue("trackID", trackID.String())
+			rec := httptest.NewRecorder()
+
+			ts.handleFile(rec, req, &auth.Token{Role: auth.RoleGM})
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected status %v; got %v", tt.wantStatus, rec.Code)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			got := ts.store.(*MockTrackStore).tracks[trackID].Tags
+			if !slices.Equal(got, tt.wantTags) {
+				t.Errorf("expected tags %v; got %v", tt.wantTags, got)
+			}
+		})
+	}
+}
+
+func TestReorderFiles(t *testing.T) {
+	ts := setupTestServer(t)
+	defer ts.cleanup(t)
+
+	ambianceID := uuid.MustParse("1EC000A2-A7C9-11EE-A0E5-0242AC120002")
+	musicID := uuid.MustParse("1EC000A2-A7C9-11EE-A0E5-0242AC120003")
+
+	store := ts.store.(*MockTrackStore)
+	rain := Track{ID: uuid.New(), Name: "Heavy Rain", TypeID: ambianceID, Position: 0}
+	tavern := Track{ID: uuid.New(), Name: "Tavern Chatter", TypeID: ambianceID, Position: 1}
+	forest := Track{ID: uuid.New(), Name: "Forest Night", TypeID: ambianceID, Position: 2}
+	song := Track{ID: uuid.New(), Name: "Tavern Song", TypeID: musicID, Position: 0}
+	for _, track := range []Track{rain, tavern, forest, song} {
+		store.tracks[track.ID] = track
+	}
+
+	orderBody := func(typeID uuid.UUID, tracks ...Track) string {
+		ids := make([]string, len(tracks))
+		for i, track := range tracks {
+			ids[i] = `"` + track.ID.String() + `"`
+		}
+		return `{"typeID": "` + typeID.String() + `", "trackIDs": [` + strings.Join(ids, ",") + `]}`
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{
+			name:       "Missing track",
+			body:       orderBody(ambianceID, forest, rain),
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:       "Track of another type",
+			body:       orderBody(ambianceID, forest, rain, tavern, song),
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:       "Duplicate track",
+			body:       orderBody(ambianceID, forest, rain, rain),
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:       "Invalid body",
+			body:       `{"trackIDs": ["not-a-uuid"]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Reorders",
+			body:       orderBody(ambianceID, forest, rain, tavern),
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/files/order", bytes.NewBufferString(tt.body))
+			rec := httptest.NewRecorder()
+
+			ts.handleFileOrder(rec, req, &auth.Token{Role: auth.RoleGM})
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected status %v; got %v", tt.wantStatus, rec.Code)
+			}
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/files?sort=position&type="+ambianceID.String(), nil)
+	rec := httptest.NewRecorder()
+	ts.handleFiles(rec, req, &auth.Token{Role: auth.RoleGM})
+
+	var list TrackList
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	var names []string
+	for _, track := range list.Tracks {
+		names = append(names, track.Name)
+	}
+	if want := []string{"Forest Night", "Heavy Rain", "Tavern Chatter"}; !slices.Equal(names, want) {
+		t.Errorf("expected order %v; got %v", want, names)
+	}
+
+	notifications := ts.hub.(*mockWSRegisterer).notifications
+	if len(notifications) != 1 || notifications[0].method != "trackOrder" {
+		t.Fatalf("expected a single trackOrder notification; got %v", notifications)
+	}
+	if order := notifications[0].payload.(TrackOrder); order.TypeID != ambianceID || len(order.TrackIDs) != 3 {
+		t.Errorf("unexpected notification payload %+v", order)
+	}
+}
+
 func TestDeleteFile(t *testing.T) {
 	ts := setupTestServer(t)
 	defer ts.cleanup(t)
@@ -335,6 +745,51 @@ func TestDeleteFile(t *testing.T) {
 	})
 }
 
+// failingStore fails every track lookup with err.
+type failingStore struct {
+	*MockTrackStore
+	err error
+}
+
+func (s failingStore) GetTrackByID(ctx context.Context, trackID uuid.UUID) (Track, error) {
+	return Track{}, s.err
+}
+
+func TestStoreErrorStatus(t *testing.T) {
+	ts := setupTestServer(t)
+	defer ts.cleanup(t)
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{"not found", fmt.Errorf("couldn't get track: %w", ErrNotFound), http.StatusNotFound},
+		{"conflict", fmt.Errorf("couldn't get track: %w", ErrConflict), http.StatusConflict},
+		{"broken database", errors.New("disk I/O error"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts.store = failingStore{MockTrackStore: ts.store.(*MockTrackStore), err: tt.err}
+			defer func() { ts.store = ts.store.(failingStore).MockTrackStore }()
+
+			trackID := uuid.New().String()
+			for _, method := range []string{http.MethodPut, http.MethodDelete} {
+				req := httptest.NewRequest(method, "/api/v1/files/"+trackID, strings.NewReader(`{}`))
+				req.SetPathValue("trackID", trackID)
+				rec := httptest.NewRecorder()
+
+				ts.handleFile(rec, req, &auth.Token{Role: auth.RoleGM})
+
+				if rec.Code != tt.wantStatus {
+					t.Errorf("expected status %v for %s; got %v", tt.wantStatus, method, rec.Code)
+				}
+			}
+		})
+	}
+}
+
 func TestHandleLogin(t *testing.T) {
 	ts := setupTestServer(t)
 	defer ts.cleanup(t)
@@ -535,40 +990,6 @@ func TestHandleLogout(t *testing.T) {
 	}
 }
 
-func TestHandleJoinToken(t *testing.T) {
-	ts := setupTestServer(t)
-	defer ts.cleanup(t)
-
-	req := httptest.NewRequest(http.MethodGet, "/api/v1/joinToken", nil)
-	addAuthCookie(req, ts.auth.(*mockAuth).token.String())
-	rec := httptest.NewRecorder()
-
-	ts.handleGetJoinToken(rec, req, &auth.Token{Role: auth.RoleGM})
-
-	if rec.Code != http.StatusOK {
-		t.Errorf("expected status OK; got %v", rec.Code)
-	}
-
-	var resp joinTokenResponse
-	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
-		t.Fatalf("failed to decode response: %v", err)
-	}
-
-	if resp.Token != ts.auth.(*mockAuth).joinToken {
-		t.Errorf("expected token %q; got %q", ts.auth.(*mockAuth).joinToken, resp.Token)
-	}
-
-	req = httptest.NewRequest(http.MethodPost, "/api/v1/joinToken", nil)
-	addAuthCookie(req, ts.auth.(*mockAuth).token.String())
-	rec = httptest.NewRecorder()
-
-	ts.handleGetJoinToken(rec, req, &auth.Token{Role: auth.RoleGM})
-
-	if rec.Code != http.StatusMethodNotAllowed {
-		t.Errorf("expected status MethodNotAllowed; got %v", rec.Code)
-	}
-}
-
 func TestTrackTypes(t *testing.T) {
 	ts := setupTestServer(t)
 	defer ts.cleanup(t)
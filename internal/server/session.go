@@ -0,0 +1,225 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/terrabitz/rpg-audio-streamer/internal/auth"
+)
+
+// currentSession stands in for the running session's ID in requests, since
+// clients have no other way to learn it.
+const currentSession = "current"
+
+// sessionQueueSize is how many events can wait to be saved before new ones
+// are dropped, so a slow database doesn't hold up the GM's commands.
+const sessionQueueSize = 1024
+
+// sessionGapMethod marks where events are missing from the session log,
+// because they were dropped or failed to save. Its payload is a SessionGap.
+const sessionGapMethod = "sessionGap"
+
+// SessionGap is the payload of a sessionGap event.
+type SessionGap struct {
+	// Missed counts the events missing at this point in the log.
+	Missed int64 `json:"missed"`
+}
+
+// queuedSessionEvent is an event waiting to be saved, along with how many
+// events were dropped just before it.
+type queuedSessionEvent struct {
+	event        SessionEvent
+	missedBefore int64
+}
+
+// SessionList is the sessions played at this server's table.
+type SessionList struct {
+	// Current is the session being played now, which is only listed once
+	// the GM has sent a command.
+	Current  uuid.UUID `json:"current"`
+	Sessions []Session `json:"sessions"`
+}
+
+// SessionLog is one page of a session's events, in the order they were sent.
+type SessionLog struct {
+	SessionID uuid.UUID      `json:"sessionID"`
+	Events    []SessionEvent `json:"events"`
+	// NextCursor requests later events. It's empty on the last page.
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// RecordSessionEvent appends a command the GM sent to the table to the
+// session log. It's meant to be registered as a hub command listener, so it
+// only queues the event and leaves saving it to writeSessionEvents. If the
+// queue is full the event is dropped, and the gap is logged in its place. Clock
+// pings and WebRTC signaling aren't logged, as they don't change what the
+// table hears.
+func (s *Server) RecordSessionEvent(method string, payload json.RawMessage, senderID string) {
+	if method == "ping" || strings.HasPrefix(method, "rtc") {
+		return
+	}
+
+	event := SessionEvent{
+		SessionID: s.session,
+		TableID:   s.cfg.TableID,
+		CreatedAt: time.Now(),
+		Method:    method,
+		SenderID:  senderID,
+		Payload:   payload,
+	}
+
+	s.queuesMu.RLock()
+	defer s.queuesMu.RUnlock()
+	if s.queuesClosed {
+		return
+	}
+
+	queued := queuedSessionEvent{event: event, missedBefore: s.sessionDropped.Swap(0)}
+	select {
+	case s.sessionEvents <- queued:
+	default:
+		s.sessionDropped.Add(queued.missedBefore + 1)
+		s.logger.Error("session log queue is full, dropping event", "method", method)
+	}
+}
+
+// writeSessionEvents saves queued events in the order they were recorded,
+// numbering them as it goes. Events that are dropped or fail to save don't
+// use up a number; a sessionGap event records how many are missing instead.
+func (s *Server) writeSessionEvents() {
+	defer close(s.sessionDone)
+
+	var seq, missed int64
+	for queued := range s.sessionEvents {
+		missed += queued.missedBefore
+		if missed > 0 && s.saveSessionGap(&seq, missed) {
+			missed = 0
+		}
+
+		if !s.saveSessionEvent(&seq, queued.event) {
+			missed++
+		}
+	}
+
+	// Events dropped after the last one queued
+	if missed += s.sessionDropped.Swap(0); missed > 0 {
+		s.saveSessionGap(&seq, missed)
+	}
+}
+
+// saveSessionEvent saves event as the one after seq, moving seq on if it's
+// saved.
+func (s *Server) saveSessionEvent(seq *int64, event SessionEvent) bool {
+	event.Seq = *seq + 1
+	if err := s.store.SaveSessionEvent(context.Background(), event); err != nil {
+		s.logger.Error("failed to save session event", "error", err, "method", event.Method)
+		return false
+	}
+	*seq = event.Seq
+	return true
+}
+
+func (s *Server) saveSessionGap(seq *int64, missed int64) bool {
+	payload, err := json.Marshal(SessionGap{Missed: missed})
+	if err != nil {
+		s.logger.Error("failed to encode session gap", "error", err)
+		return false
+	}
+
+	return s.saveSessionEvent(seq, SessionEvent{
+		SessionID: s.session,
+		TableID:   s.cfg.TableID,
+		CreatedAt: time.Now(),
+		Method:    sessionGapMethod,
+		Payload:   payload,
+	})
+}
+
+// Close stops logging the session and plays, waiting for queued events and
+// plays to be saved.
+func (s *Server) Close() {
+	s.queuesMu.Lock()
+	if s.queuesClosed {
+		s.queuesMu.Unlock()
+		return
+	}
+	s.queuesClosed = true
+	close(s.sessionEvents)
+	close(s.playChanges)
+	s.queuesMu.Unlock()
+
+	<-s.sessionDone
+	<-s.playsDone
+}
+
+func (s *Server) handleSessions(w http.ResponseWriter, r *http.Request, token *auth.Token) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sessions, err := s.store.GetSessions(r.Context(), s.cfg.TableID)
+	if err != nil {
+		s.logger.Error("failed to get sessions", "error", err)
+		http.Error(w, "Failed to retrieve sessions", http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, SessionList{Current: s.session, Sessions: sessions})
+}
+
+func (s *Server) handleSessionEvents(w http.ResponseWriter, r *http.Request, token *auth.Token) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sessionID := s.session
+	if idStr := r.PathValue("sessionID"); idStr != currentSession {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			http.Error(w, "Invalid session ID", http.StatusBadRequest)
+			return
+		}
+		sessionID = id
+	}
+
+	query := r.URL.Query()
+	page := SessionEventPage{Limit: defaultPageSize}
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxPageSize {
+			http.Error(w, fmt.Sprintf("Invalid pagination: limit must be between 1 and %d", maxPageSize), http.StatusBadRequest)
+			return
+		}
+		page.Limit = limit
+	}
+	if cursor := query.Get("cursor"); cursor != "" {
+		afterSeq, err := strconv.ParseInt(cursor, 10, 64)
+		if err != nil || afterSeq < 1 {
+			http.Error(w, "Invalid pagination: malformed cursor", http.StatusBadRequest)
+			return
+		}
+		page.AfterSeq = afterSeq
+	}
+
+	events, err := s.store.GetSessionEvents(r.Context(), sessionID, page)
+	if err != nil {
+		s.logger.Error("failed to get session events", "error", err)
+		http.Error(w, "Failed to retrieve session events", http.StatusInternalServerError)
+		return
+	}
+
+	sessionLog := SessionLog{SessionID: sessionID, Events: events}
+	if len(events) == page.Limit {
+		sessionLog.NextCursor = strconv.FormatInt(events[len(events)-1].Seq, 10)
+	}
+
+	respondJSON(w, http.StatusOK, sessionLog)
+}
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/terrabitz/rpg-audio-streamer/internal/auth"
+)
+
+func TestRecordSessionEvent(t *testing.T) {
+	ts := setupTestServer(t)
+	defer ts.cleanup(t)
+
+	for _, method := range []string{"syncAll", "ping", "rtcPublish", "syncTrack", "broadcast"} {
+		ts.RecordSessionEvent(method, json.RawMessage(`{}`), "gm-client")
+	}
+	// Closing waits for the queued events to be saved.
+	ts.Close()
+
+	get := func(t *testing.T, sessionID, query string) (int, SessionLog) {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/sessions/"+sessionID+"/events?"+query, nil)
+		req.SetPathValue("sessionID", sessionID)
+		rec := httptest.NewRecorder()
+		ts.handleSessionEvents(rec, req, &auth.Token{Role: auth.RoleGM})
+
+		var sessionLog SessionLog
+		if rec.Code == http.StatusOK {
+			if err := json.NewDecoder(rec.Body).Decode(&sessionLog); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+		}
+		return rec.Code, sessionLog
+	}
+
+	methods := func(events []SessionEvent) []string {
+		var methods []string
+		for _, event := range events {
+			methods = append(methods, event.Method)
+		}
+		return methods
+	}
+
+	t.Run("current session", func(t *testing.T) {
+		_, sessionLog := get(t, "current", "")
+		if got := methods(sessionLog.Events); !slices.Equal(got, []string{"syncAll", "syncTrack", "broadcast"}) {
+			t.Fatalf("expected the commands without pings or signaling; got %v", got)
+		}
+		if sessionLog.SessionID != ts.session {
+			t.Errorf("expected session %s; got %s", ts.session, sessionLog.SessionID)
+		}
+		for i, event := range sessionLog.Events {
+			if event.Seq != int64(i+1) || event.SenderID != "gm-client" || event.TableID != "test-table" {
+				t.Errorf("unexpected event %+v", event)
+			}
+		}
+	})
+
+	t.Run("pages", func(t *testing.T) {
+		_, first := get(t, ts.session.String(), "limit=2")
+		if got := methods(first.Events); !slices.Equal(got, []string{"syncAll", "syncTrack"}) || first.NextCursor != "2" {
+			t.Fatalf("unexpected first page %v, cursor %q", got, first.NextCursor)
+		}
+
+		_, second := get(t, ts.session.String(), "limit=2&cursor="+first.NextCursor)
+		if got := methods(second.Events); !slices.Equal(got, []string{"broadcast"}) || second.NextCursor != "" {
+			t.Errorf("unexpected second page %v, cursor %q", got, second.NextCursor)
+		}
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		for sessionID, query := range map[string]string{"tuesday": "", "current": "limit=0", ts.session.String(): "cursor=abc"} {
+			if code, _ := get(t, sessionID, query); code != http.StatusBadRequest {
+				t.Errorf("expected BadRequest for %s?%s; got %v", sessionID, query, code)
+			}
+		}
+	})
+}
+
+func TestSessionGap(t *testing.T) {
+	ts := setupTestServer(t)
+	defer ts.cleanup(t)
+
+	// Stand in for events dropped while the queue was full
+	ts.RecordSessionEvent("syncAll", json.RawMessage(`{}`), "gm-client")
+	ts.sessionDropped.Add(2)
+	ts.RecordSessionEvent("syncTrack", json.RawMessage(`{}`), "gm-client")
+	ts.sessionDropped.Add(1)
+	ts.Close()
+
+	store := ts.store.(*MockTrackStore)
+	var got []string
+	for i, event := range store.events {
+		if event.Seq != int64(i+1) {
+			t.Errorf("expected event %d to be numbered %d; got %d", i, i+1, event.Seq)
+		}
+
+		entry := event.Method
+		if event.Method == sessionGapMethod {
+			var gap SessionGap
+			if err := json.Unmarshal(event.Payload, &gap); err != nil {
+				t.Fatalf("failed to decode gap: %v", err)
+			}
+			entry += strconv.FormatInt(gap.Missed, 10)
+		}
+		got = append(got, entry)
+	}
+
+	if want := []string{"syncAll", "sessionGap2", "syncTrack", "sessionGap1"}; !slices.Equal(got, want) {
+		t.Errorf("expected %v; got %v", want, got)
+	}
+}
+
+func TestHandleSessions(t *testing.T) {
+	ts := setupTestServer(t)
+	defer ts.cleanup(t)
+
+	store := ts.store.(*MockTrackStore)
+	earlier := uuid.Must(uuid.NewV7())
+	start := time.Now().Add(-24 * time.Hour)
+	for i := range 2 {
+		store.events = append(store.events, SessionEvent{SessionID: earlier, TableID: "test-table", Seq: int64(i + 1), CreatedAt: start.Add(time.Duration(i) * time.Minute), Method: "syncTrack"})
+	}
+	store.events = append(store.events, SessionEvent{SessionID: uuid.Must(uuid.NewV7()), TableID: "other-table", Seq: 1, CreatedAt: start, Method: "syncTrack"})
+
+	ts.RecordSessionEvent("syncAll", json.RawMessage(`{}`), "gm-client")
+	ts.Close()
+
+	rec := httptest.NewRecorder()
+	ts.handleSessions(rec, httptest.NewRequest(http.MethodGet, "/api/v1/sessions", nil), &auth.Token{Role: auth.RoleGM})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status OK; got %v: %s", rec.Code, rec.Body)
+	}
+
+	var list SessionList
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if list.Current != ts.session || len(list.Sessions) != 2 {
+		t.Fatalf("expected this table's two sessions; got %+v", list)
+	}
+	if current, previous := list.Sessions[0], list.Sessions[1]; current.ID != ts.session || previous.ID != earlier || previous.EventCount != 2 {
+		t.Errorf("expected the sessions newest first; got %+v", list.Sessions)
+	}
+}
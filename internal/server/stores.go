@@ -2,6 +2,9 @@ package server
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,39 +13,392 @@ import (
 type Store interface {
 	TrackStore
 	TrackTypeStore
+	CollectionStore
+	PlayStore
+	AuditStore
+	SessionEventStore
+	UserStore
+	JoinTokenStore
 }
 
+// Stores wrap their errors in these, so handlers can tell a missing track or
+// a clashing change from a broken database.
+var (
+	// ErrNotFound means the thing asked for doesn't exist.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict means a change clashes with what's already stored, such as
+	// a duplicate name or a reference to something that doesn't exist.
+	ErrConflict = errors.New("conflict")
+)
+
 type Track struct {
-	ID        uuid.UUID `json:"id,omitempty"`
-	CreatedAt time.Time `json:"createdAt,omitempty"`
-	Name      string    `json:"name,omitempty"`
-	Path      string    `json:"path,omitempty"`
-	TypeID    uuid.UUID `json:"typeID,omitempty"`
+	ID        uuid.UUID         `json:"id,omitempty"`
+	CreatedAt time.Time         `json:"createdAt,omitempty"`
+	Name      string            `json:"name,omitempty"`
+	Path      string            `json:"path,omitempty"`
+	TypeID    uuid.UUID         `json:"typeID,omitempty"`
+	Duration  float64           `json:"duration,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	Tags      []string          `json:"tags,omitempty"`
+	// Position orders the track among the others of its type.
+	Position    int         `json:"position"`
+	Collections []uuid.UUID `json:"collections,omitempty"`
+	// The credit fields say where a track came from and how it may be used.
+	// License is an SPDX identifier such as CC-BY-4.0, and Attribution, if
+	// set, is the exact credit line the license asks for.
+	Description string `json:"description,omitempty"`
+	Artist      string `json:"artist,omitempty"`
+	SourceURL   string `json:"sourceURL,omitempty"`
+	License     string `json:"license,omitempty"`
+	Attribution string `json:"attribution,omitempty"`
+	Favorite    bool   `json:"favorite"`
 }
 
 type UpdateTrackRequest struct {
 	ID     uuid.UUID  `json:"id"`
 	Name   *string    `json:"name"`
 	TypeID *uuid.UUID `json:"typeID"`
+	// Tags replaces the track's tags when set.
+	Tags *[]string `json:"tags"`
+	// Collections replaces the collections the track is in when set.
+	Collections *[]uuid.UUID `json:"collections"`
+	Description *string      `json:"description"`
+	Artist      *string      `json:"artist"`
+	SourceURL   *string      `json:"sourceURL"`
+	License     *string      `json:"license"`
+	Attribution *string      `json:"attribution"`
+	Favorite    *bool        `json:"favorite"`
+}
+
+// TrackFilter narrows down a track listing. Zero values match everything.
+type TrackFilter struct {
+	// Query is free text matched against track names, tags and metadata.
+	Query  string
+	Tag    string
+	TypeID *uuid.UUID
+	// CollectionID matches tracks in the collection or any nested in it.
+	CollectionID *uuid.UUID
+	// Favorite matches only favourite tracks when true, or only the others
+	// when false.
+	Favorite *bool
+	// CreatedAfter and CreatedBefore bound the upload time, inclusive and
+	// exclusive respectively.
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+}
+
+// TrackSort is the field a track listing is ordered by.
+type TrackSort string
+
+const (
+	TrackSortCreated  TrackSort = "created"
+	TrackSortName     TrackSort = "name"
+	TrackSortDuration TrackSort = "duration"
+	// TrackSortPosition groups tracks by type, in their custom order.
+	TrackSortPosition TrackSort = "position"
+)
+
+// TrackPage selects one page of a sorted track listing. Ties are broken by
+// track ID, so every track has a stable position.
+type TrackPage struct {
+	Sort       TrackSort
+	Descending bool
+	// After continues the listing past this track, which is the last one of the
+	// previous page. Only the ID and the sort fields need to be set.
+	After *Track
+	Limit int
 }
 
 type TrackStore interface {
 	SaveTrack(ctx context.Context, track *Track) error
-	GetTracks(ctx context.Context) ([]Track, error)
+	GetTracks(ctx context.Context, filter TrackFilter, page TrackPage) ([]Track, error)
+	CountTracks(ctx context.Context, filter TrackFilter) (int, error)
 	GetTrackByID(ctx context.Context, trackID uuid.UUID) (Track, error)
 	DeleteTrack(ctx context.Context, trackID uuid.UUID) error
 	UpdateTrack(ctx context.Context, trackID uuid.UUID, update UpdateTrackRequest) (Track, error)
+	// ModifyTracks reads several tracks and writes the update modify works
+	// out for each of them in one transaction, so edits made to the tracks
+	// in the meantime aren't overwritten. It returns the tracks before and
+	// after. If any track is missing it fails with a *MissingTracksError, and
+	// if anything fails nothing is changed.
+	ModifyTracks(ctx context.Context, trackIDs []uuid.UUID, modify func(Track) UpdateTrackRequest) (before, after []Track, err error)
+	// SetTrackMedia records the duration and probed metadata of a track's
+	// audio after it's been replaced.
+	SetTrackMedia(ctx context.Context, trackID uuid.UUID, duration float64, metadata map[string]string) error
+	// DeleteTracks deletes several tracks in one transaction and returns them
+	// as they were. If any track is missing it fails with a
+	// *MissingTracksError and nothing is deleted.
+	DeleteTracks(ctx context.Context, trackIDs []uuid.UUID) ([]Track, error)
+	GetTags(ctx context.Context) ([]string, error)
+	// ReorderTracks atomically sets the order of a track type's tracks. It
+	// fails with ErrTrackOrderMismatch unless trackIDs holds every track of
+	// the type exactly once.
+	ReorderTracks(ctx context.Context, typeID uuid.UUID, trackIDs []uuid.UUID) error
+}
+
+// MissingTracksError lists the tracks an operation on several tracks
+// couldn't find.
+type MissingTracksError struct {
+	TrackIDs []uuid.UUID
 }
 
+func (e *MissingTracksError) Error() string {
+	return fmt.Sprintf("%d of the tracks %s", len(e.TrackIDs), ErrNotFound)
+}
+
+func (e *MissingTracksError) Unwrap() error {
+	return ErrNotFound
+}
+
+// TrackOrder is the full, ordered list of a track type's tracks.
+type TrackOrder struct {
+	TypeID   uuid.UUID   `json:"typeID"`
+	TrackIDs []uuid.UUID `json:"trackIDs"`
+}
+
+var ErrTrackOrderMismatch = errors.New("track order doesn't match the tracks of the type")
+
+// TrackType holds the playback rules shared by a kind of track. Clients
+// apply them, so GMs and players behave the same way.
 type TrackType struct {
 	ID                    uuid.UUID `json:"id,omitempty"`
 	Name                  string    `json:"name,omitempty"`
 	Color                 string    `json:"color,omitempty"`
 	IsRepeating           bool      `json:"isRepeating"`
 	AllowSimultaneousPlay bool      `json:"allowSimultaneousPlay"`
+	// DefaultVolume is the volume, from 0 to 100, new tracks of the type start
+	// at.
+	DefaultVolume int `json:"defaultVolume"`
+	FadeInMs      int `json:"fadeInMs"`
+	FadeOutMs     int `json:"fadeOutMs"`
+	// AlwaysStartFromBeginning rewinds a track whenever it's played, rather
+	// than resuming where it was stopped.
+	AlwaysStartFromBeginning bool `json:"alwaysStartFromBeginning"`
+	// MaxConcurrent limits how many tracks of the type play at once; starting
+	// another stops the one that has been playing longest. Zero is no limit.
+	MaxConcurrent int `json:"maxConcurrent"`
+	// AutoStopOthers stops tracks of every other type when a track of this
+	// type starts.
+	AutoStopOthers bool `json:"autoStopOthers"`
 }
 
 type TrackTypeStore interface {
 	GetTrackTypes(ctx context.Context) ([]TrackType, error)
 	GetTrackTypeByID(ctx context.Context, id uuid.UUID) (TrackType, error)
+	SaveTrackType(ctx context.Context, trackType TrackType) error
+	UpdateTrackType(ctx context.Context, trackType TrackType) error
+	// DeleteTrackType deletes a track type, first moving its tracks to
+	// reassignTo if set. It returns ErrTrackTypeInUse if tracks would be left
+	// without a type.
+	DeleteTrackType(ctx context.Context, id uuid.UUID, reassignTo *uuid.UUID) error
+}
+
+var ErrTrackTypeInUse = errors.New("track type is still used by tracks")
+
+// Collection groups tracks by where or when they're used, e.g. a location or
+// story arc. Collections nest, and a track can be in any number of them.
+type Collection struct {
+	ID        uuid.UUID  `json:"id"`
+	ParentID  *uuid.UUID `json:"parentID"`
+	Name      string     `json:"name"`
+	CreatedAt time.Time  `json:"createdAt"`
+	// TrackCount counts the tracks directly in the collection, not in the
+	// ones nested in it.
+	TrackCount int          `json:"trackCount"`
+	Children   []Collection `json:"children"`
+}
+
+type CollectionStore interface {
+	// GetCollections returns every collection as a flat list, without
+	// children filled in.
+	GetCollections(ctx context.Context) ([]Collection, error)
+	SaveCollection(ctx context.Context, collection *Collection) error
+	UpdateCollection(ctx context.Context, collection Collection) error
+	// DeleteCollection deletes a collection and everything nested in it. The
+	// tracks themselves are kept.
+	DeleteCollection(ctx context.Context, id uuid.UUID) error
+}
+
+type PlayStore interface {
+	// RecordPlay logs that a track started playing.
+	RecordPlay(ctx context.Context, trackID uuid.UUID, startedAt time.Time) error
+	// FinishPlay records how long the play that started at startedAt lasted.
+	FinishPlay(ctx context.Context, trackID uuid.UUID, startedAt time.Time, duration time.Duration) error
+	// GetPlayedTrackIDs returns the tracks started at or after from and
+	// before to, in the order they were first played.
+	GetPlayedTrackIDs(ctx context.Context, from, to time.Time) ([]uuid.UUID, error)
+	// GetRecentTracks returns stats for the tracks played most recently.
+	GetRecentTracks(ctx context.Context, limit int) ([]TrackStats, error)
+	// GetPopularTracks returns stats for the tracks played most often since
+	// the given time.
+	GetPopularTracks(ctx context.Context, since time.Time, limit int) ([]TrackStats, error)
+}
+
+// TrackStats sums up a track's plays.
+type TrackStats struct {
+	TrackID   uuid.UUID `json:"trackID"`
+	PlayCount int       `json:"playCount"`
+	// TotalPlayed is how many seconds the track has played for. Plays that
+	// are still going aren't counted yet.
+	TotalPlayed  float64   `json:"totalPlayed"`
+	LastPlayedAt time.Time `json:"lastPlayedAt"`
+}
+
+// AuditStore keeps an append-only record of changes to the library and of
+// sign-ins.
+type AuditStore interface {
+	SaveAuditEntry(ctx context.Context, entry AuditEntry) error
+	// GetAuditEntries returns the entries matching the filter, newest first.
+	GetAuditEntries(ctx context.Context, filter AuditFilter, page AuditPage) ([]AuditEntry, error)
+}
+
+type AuditAction string
+
+const (
+	AuditTrackCreate        AuditAction = "track.create"
+	AuditTrackUpdate        AuditAction = "track.update"
+	AuditTrackDelete        AuditAction = "track.delete"
+	AuditTrackReplaceMedia  AuditAction = "track.replaceMedia"
+	AuditTrackRollbackMedia AuditAction = "track.rollbackMedia"
+	AuditTrackTypeCreate    AuditAction = "trackType.create"
+	AuditTrackTypeUpdate    AuditAction = "trackType.update"
+	AuditTrackTypeDelete    AuditAction = "trackType.delete"
+	AuditLogin              AuditAction = "auth.login"
+	AuditLoginFailed        AuditAction = "auth.loginFailed"
+	AuditLogout             AuditAction = "auth.logout"
+	AuditJoin               AuditAction = "auth.join"
+	AuditUserCreate         AuditAction = "user.create"
+	AuditUserUpdate         AuditAction = "user.update"
+	AuditUserDelete         AuditAction = "user.delete"
+	AuditJoinTokenCreate    AuditAction = "joinToken.create"
+	AuditJoinTokenRotate    AuditAction = "joinToken.rotate"
+	AuditJoinTokenRevoke    AuditAction = "joinToken.revoke"
+)
+
+// AuditEntry records one action. Before and After are JSON objects holding
+// only the fields the action changed; either is empty when the target was
+// created or deleted, or when the action changes nothing.
+type AuditEntry struct {
+	ID        int64           `json:"id"`
+	CreatedAt time.Time       `json:"createdAt"`
+	Actor     string          `json:"actor"`
+	Action    AuditAction     `json:"action"`
+	TargetID  string          `json:"targetID,omitempty"`
+	Before    json.RawMessage `json:"before,omitempty"`
+	After     json.RawMessage `json:"after,omitempty"`
+	IP        string          `json:"ip,omitempty"`
+}
+
+// AuditFilter narrows down audit entries. Zero fields match everything.
+type AuditFilter struct {
+	Actor    string
+	Action   AuditAction
+	TargetID string
+	// From and To limit entries to those created at or after From and
+	// before To.
+	From *time.Time
+	To   *time.Time
+}
+
+// AuditPage selects a page of audit entries. BeforeID continues from the
+// last entry of the previous page; zero starts from the newest entry.
+type AuditPage struct {
+	BeforeID int64
+	Limit    int
+}
+
+// SessionEventStore keeps an append-only log of the commands the GM sent to
+// the table in each session, to replay or recap it later.
+type SessionEventStore interface {
+	SaveSessionEvent(ctx context.Context, event SessionEvent) error
+	// GetSessionEvents returns a session's events in the order they were
+	// sent.
+	GetSessionEvents(ctx context.Context, sessionID uuid.UUID, page SessionEventPage) ([]SessionEvent, error)
+	// GetSessions returns the sessions played at a table that have any
+	// events, the most recently started first.
+	GetSessions(ctx context.Context, tableID string) ([]Session, error)
+}
+
+// SessionEvent is one command the GM sent to the table. Seq counts up from 1
+// within each session, in the order the commands were sent.
+type SessionEvent struct {
+	SessionID uuid.UUID       `json:"sessionID"`
+	TableID   string          `json:"tableID"`
+	Seq       int64           `json:"seq"`
+	CreatedAt time.Time       `json:"createdAt"`
+	Method    string          `json:"method"`
+	SenderID  string          `json:"senderID"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+// Session sums up a session from its events.
+type Session struct {
+	ID          uuid.UUID `json:"id"`
+	TableID     string    `json:"tableID"`
+	StartedAt   time.Time `json:"startedAt"`
+	LastEventAt time.Time `json:"lastEventAt"`
+	EventCount  int64     `json:"eventCount"`
+}
+
+// SessionEventPage selects a page of session events. AfterSeq continues from
+// the last event of the previous page; zero starts from the first event.
+type SessionEventPage struct {
+	AfterSeq int64
+	Limit    int
+}
+
+// UserStore keeps the GM accounts. Usernames are unique regardless of case.
+type UserStore interface {
+	SaveUser(ctx context.Context, user User) error
+	// GetUsers returns every account, ordered by username.
+	GetUsers(ctx context.Context) ([]User, error)
+	GetUserByID(ctx context.Context, id uuid.UUID) (User, error)
+	GetUserByUsername(ctx context.Context, username string) (User, error)
+	UpdateUser(ctx context.Context, user User) error
+	DeleteUser(ctx context.Context, id uuid.UUID) error
+}
+
+// User is a GM account. Admins can manage the other accounts.
+type User struct {
+	ID       uuid.UUID `json:"id"`
+	Username string    `json:"username"`
+	// PasswordHash is the argon2id hash from auth.HashPassword.
+	PasswordHash string    `json:"-"`
+	Admin        bool      `json:"admin"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// JoinTokenStore keeps the tokens players join the table with.
+type JoinTokenStore interface {
+	SaveJoinToken(ctx context.Context, token JoinToken) error
+	// GetJoinTokens returns every join token, including revoked and expired
+	// ones, newest first.
+	GetJoinTokens(ctx context.Context) ([]JoinToken, error)
+	GetJoinTokenByID(ctx context.Context, id uuid.UUID) (JoinToken, error)
+	// RotateJoinToken replaces the token's secret and resets its uses. It
+	// returns ErrNotFound if the token doesn't exist or is revoked.
+	RotateJoinToken(ctx context.Context, id uuid.UUID, secretHash []byte) error
+	// RevokeJoinToken revokes the token at revokedAt, or leaves it as it is
+	// if it's already revoked.
+	RevokeJoinToken(ctx context.Context, id uuid.UUID, revokedAt time.Time) error
+	// UseJoinToken counts a use of the token and returns it, as long as its
+	// secret hash matches and it's neither revoked, expired at now nor used
+	// up. Otherwise it returns ErrNotFound.
+	UseJoinToken(ctx context.Context, id uuid.UUID, secretHash []byte, now time.Time) (JoinToken, error)
+}
+
+// JoinToken lets players join the table. Players redeem it for a player
+// token, which stops working once the join token is revoked.
+type JoinToken struct {
+	ID    uuid.UUID `json:"id"`
+	Label string    `json:"label"`
+	// SecretHash is the hash from auth.HashJoinSecret. The secret itself is
+	// only shown when the token is created or rotated.
+	SecretHash []byte     `json:"-"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	// MaxUses is how many times the token can be redeemed, or 0 for no limit.
+	MaxUses   int        `json:"maxUses"`
+	Uses      int        `json:"uses"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
 }
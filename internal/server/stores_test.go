@@ -1,16 +1,34 @@
 package server
 
 import (
+	"bytes"
+	"cmp"
 	"context"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type MockTrackStore struct {
-	tracks     map[uuid.UUID]Track
-	trackTypes map[uuid.UUID]TrackType
+	tracks      map[uuid.UUID]Track
+	trackTypes  map[uuid.UUID]TrackType
+	collections map[uuid.UUID]Collection
+	plays       []trackPlay
+	audit       []AuditEntry
+	events      []SessionEvent
+	users       map[uuid.UUID]User
+	joinTokens  map[uuid.UUID]JoinToken
+}
+
+type trackPlay struct {
+	trackID   uuid.UUID
+	startedAt time.Time
+	duration  time.Duration
 }
 
 func (m *MockTrackStore) SaveTrack(ctx context.Context, track *Track) error {
@@ -18,34 +36,160 @@ func (m *MockTrackStore) SaveTrack(ctx context.Context, track *Track) error {
 	return nil
 }
 
-func (m *MockTrackStore) GetTracks(ctx context.Context) ([]Track, error) {
+func (m *MockTrackStore) GetTracks(ctx context.Context, filter TrackFilter, page TrackPage) ([]Track, error) {
 	var result []Track
 	for _, t := range m.tracks {
-		result = append(result, t)
+		if m.matchesFilter(t, filter) {
+			result = append(result, t)
+		}
+	}
+
+	compare := func(a, b Track) int {
+		var c int
+		switch page.Sort {
+		case TrackSortName:
+			c = strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		case TrackSortDuration:
+			c = cmp.Compare(a.Duration, b.Duration)
+		case TrackSortPosition:
+			c = bytes.Compare(a.TypeID[:], b.TypeID[:])
+			if c == 0 {
+				c = cmp.Compare(a.Position, b.Position)
+			}
+		}
+		if c == 0 {
+			c = bytes.Compare(a.ID[:], b.ID[:])
+		}
+		if page.Descending {
+			c = -c
+		}
+		return c
+	}
+	slices.SortFunc(result, compare)
+
+	if page.After != nil {
+		result = slices.DeleteFunc(result, func(t Track) bool {
+			return compare(t, *page.After) <= 0
+		})
+	}
+
+	if page.Limit > 0 && len(result) > page.Limit {
+		result = result[:page.Limit]
 	}
 	return result, nil
 }
 
+func (m *MockTrackStore) CountTracks(ctx context.Context, filter TrackFilter) (int, error) {
+	count := 0
+	for _, t := range m.tracks {
+		if m.matchesFilter(t, filter) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *MockTrackStore) matchesFilter(t Track, filter TrackFilter) bool {
+	if filter.TypeID != nil && t.TypeID != *filter.TypeID {
+		return false
+	}
+	if filter.Favorite != nil && t.Favorite != *filter.Favorite {
+		return false
+	}
+	if filter.Tag != "" && !slices.Contains(t.Tags, filter.Tag) {
+		return false
+	}
+	if filter.Query != "" && !strings.Contains(strings.ToLower(t.Name+" "+strings.Join(t.Tags, " ")), strings.ToLower(filter.Query)) {
+		return false
+	}
+	if !filter.CreatedAfter.IsZero() && t.CreatedAt.Before(filter.CreatedAfter) {
+		return false
+	}
+	if !filter.CreatedBefore.IsZero() && !t.CreatedAt.Before(filter.CreatedBefore) {
+		return false
+	}
+	if filter.CollectionID != nil && !slices.ContainsFunc(t.Collections, func(id uuid.UUID) bool {
+		return m.isWithin(id, *filter.CollectionID)
+	}) {
+		return false
+	}
+	return true
+}
+
+// isWithin reports whether a collection is the ancestor or one of its
+// descendants.
+func (m *MockTrackStore) isWithin(id, ancestor uuid.UUID) bool {
+	for {
+		if id == ancestor {
+			return true
+		}
+		c, ok := m.collections[id]
+		if !ok || c.ParentID == nil {
+			return false
+		}
+		id = *c.ParentID
+	}
+}
+
 func (m *MockTrackStore) GetTrackByID(ctx context.Context, trackID uuid.UUID) (Track, error) {
 	track, ok := m.tracks[trackID]
 	if !ok {
-		return Track{}, fmt.Errorf("track not found")
+		return Track{}, fmt.Errorf("track %w", ErrNotFound)
 	}
 	return track, nil
 }
 
 func (m *MockTrackStore) DeleteTrack(ctx context.Context, trackID uuid.UUID) error {
 	if _, ok := m.tracks[trackID]; !ok {
-		return fmt.Errorf("track not found")
+		return fmt.Errorf("track %w", ErrNotFound)
 	}
 	delete(m.tracks, trackID)
 	return nil
 }
 
+func (m *MockTrackStore) SetTrackMedia(ctx context.Context, trackID uuid.UUID, duration float64, metadata map[string]string) error {
+	track, ok := m.tracks[trackID]
+	if !ok {
+		return fmt.Errorf("track %w", ErrNotFound)
+	}
+	track.Duration = duration
+	track.Metadata = metadata
+	m.tracks[trackID] = track
+	return nil
+}
+
+func (m *MockTrackStore) DeleteTracks(ctx context.Context, trackIDs []uuid.UUID) ([]Track, error) {
+	tracks, err := m.getTracks(trackIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range trackIDs {
+		delete(m.tracks, id)
+	}
+	return tracks, nil
+}
+
+func (m *MockTrackStore) getTracks(trackIDs []uuid.UUID) ([]Track, error) {
+	var missing []uuid.UUID
+	tracks := make([]Track, 0, len(trackIDs))
+	for _, id := range trackIDs {
+		track, ok := m.tracks[id]
+		if !ok {
+			missing = append(missing, id)
+			continue
+		}
+		tracks = append(tracks, track)
+	}
+	if len(missing) > 0 {
+		return nil, &MissingTracksError{TrackIDs: missing}
+	}
+	return tracks, nil
+}
+
 func (m *MockTrackStore) UpdateTrack(ctx context.Context, trackID uuid.UUID, update UpdateTrackRequest) (Track, error) {
 	track, ok := m.tracks[trackID]
 	if !ok {
-		return Track{}, fmt.Errorf("track not found")
+		return Track{}, fmt.Errorf("track %w", ErrNotFound)
 	}
 
 	if update.Name != nil {
@@ -56,10 +200,94 @@ func (m *MockTrackStore) UpdateTrack(ctx context.Context, trackID uuid.UUID, upd
 		track.TypeID = *update.TypeID
 	}
 
+	if update.Tags != nil {
+		track.Tags = *update.Tags
+	}
+
+	if update.Collections != nil {
+		track.Collections = *update.Collections
+	}
+
+	if update.Favorite != nil {
+		track.Favorite = *update.Favorite
+	}
+
+	for _, field := range []struct {
+		value *string
+		dest  *string
+	}{
+		{update.Description, &track.Description},
+		{update.Artist, &track.Artist},
+		{update.SourceURL, &track.SourceURL},
+		{update.License, &track.License},
+		{update.Attribution, &track.Attribution},
+	} {
+		if field.value != nil {
+			*field.dest = *field.value
+		}
+	}
+
 	m.tracks[trackID] = track
 	return track, nil
 }
 
+func (m *MockTrackStore) ModifyTracks(ctx context.Context, trackIDs []uuid.UUID, modify func(Track) UpdateTrackRequest) ([]Track, []Track, error) {
+	before, err := m.getTracks(trackIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	after := make([]Track, len(before))
+	for i, track := range before {
+		updated, err := m.UpdateTrack(ctx, track.ID, modify(track))
+		if err != nil {
+			return nil, nil, err
+		}
+		after[i] = updated
+	}
+	return before, after, nil
+}
+
+func (m *MockTrackStore) ReorderTracks(ctx context.Context, typeID uuid.UUID, trackIDs []uuid.UUID) error {
+	count := 0
+	for _, t := range m.tracks {
+		if t.TypeID == typeID {
+			count++
+		}
+	}
+
+	seen := make(map[uuid.UUID]bool)
+	for _, id := range trackIDs {
+		if t, ok := m.tracks[id]; !ok || t.TypeID != typeID || seen[id] {
+			return ErrTrackOrderMismatch
+		}
+		seen[id] = true
+	}
+	if len(seen) != count {
+		return ErrTrackOrderMismatch
+	}
+
+	for position, id := range trackIDs {
+		t := m.tracks[id]
+		t.Position = position
+		m.tracks[id] = t
+	}
+	return nil
+}
+
+func (m *MockTrackStore) GetTags(ctx context.Context) ([]string, error) {
+	var result []string
+	for _, t := range m.tracks {
+		for _, tag := range t.Tags {
+			if !slices.Contains(result, tag) {
+				result = append(result, tag)
+			}
+		}
+	}
+	slices.Sort(result)
+	return result, nil
+}
+
 func (m *MockTrackStore) GetTrackTypes(ctx context.Context) ([]TrackType, error) {
 	var result []TrackType
 	for _, t := range m.trackTypes {
@@ -71,17 +299,329 @@ func (m *MockTrackStore) GetTrackTypes(ctx context.Context) ([]TrackType, error)
 func (m *MockTrackStore) GetTrackTypeByID(ctx context.Context, id uuid.UUID) (TrackType, error) {
 	trackType, ok := m.trackTypes[id]
 	if !ok {
-		return TrackType{}, fmt.Errorf("track type not found")
+		return TrackType{}, fmt.Errorf("track type %w", ErrNotFound)
 	}
 	return trackType, nil
 }
 
+func (m *MockTrackStore) SaveTrackType(ctx context.Context, trackType TrackType) error {
+	m.trackTypes[trackType.ID] = trackType
+	return nil
+}
+
+func (m *MockTrackStore) UpdateTrackType(ctx context.Context, trackType TrackType) error {
+	if _, ok := m.trackTypes[trackType.ID]; !ok {
+		return fmt.Errorf("track type %w", ErrNotFound)
+	}
+	m.trackTypes[trackType.ID] = trackType
+	return nil
+}
+
+func (m *MockTrackStore) DeleteTrackType(ctx context.Context, id uuid.UUID, reassignTo *uuid.UUID) error {
+	for trackID, track := range m.tracks {
+		if track.TypeID != id {
+			continue
+		}
+		if reassignTo == nil {
+			return ErrTrackTypeInUse
+		}
+		track.TypeID = *reassignTo
+		m.tracks[trackID] = track
+	}
+
+	delete(m.trackTypes, id)
+	return nil
+}
+
+func (m *MockTrackStore) GetCollections(ctx context.Context) ([]Collection, error) {
+	var result []Collection
+	for _, c := range m.collections {
+		c.TrackCount = 0
+		for _, t := range m.tracks {
+			if slices.Contains(t.Collections, c.ID) {
+				c.TrackCount++
+			}
+		}
+		result = append(result, c)
+	}
+	slices.SortFunc(result, func(a, b Collection) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return result, nil
+}
+
+func (m *MockTrackStore) SaveCollection(ctx context.Context, collection *Collection) error {
+	m.collections[collection.ID] = *collection
+	return nil
+}
+
+func (m *MockTrackStore) UpdateCollection(ctx context.Context, collection Collection) error {
+	if _, ok := m.collections[collection.ID]; !ok {
+		return fmt.Errorf("collection %w", ErrNotFound)
+	}
+	m.collections[collection.ID] = collection
+	return nil
+}
+
+func (m *MockTrackStore) DeleteCollection(ctx context.Context, id uuid.UUID) error {
+	var deleted []uuid.UUID
+	for cID := range m.collections {
+		if m.isWithin(cID, id) {
+			deleted = append(deleted, cID)
+		}
+	}
+
+	for _, cID := range deleted {
+		delete(m.collections, cID)
+	}
+	for tID, t := range m.tracks {
+		t.Collections = slices.DeleteFunc(t.Collections, func(c uuid.UUID) bool {
+			return slices.Contains(deleted, c)
+		})
+		m.tracks[tID] = t
+	}
+	return nil
+}
+
+func (m *MockTrackStore) RecordPlay(ctx context.Context, trackID uuid.UUID, startedAt time.Time) error {
+	m.plays = append(m.plays, trackPlay{trackID: trackID, startedAt: startedAt})
+	return nil
+}
+
+func (m *MockTrackStore) GetPlayedTrackIDs(ctx context.Context, from, to time.Time) ([]uuid.UUID, error) {
+	var trackIDs []uuid.UUID
+	for _, play := range m.plays {
+		if play.startedAt.Before(from) || !play.startedAt.Before(to) || slices.Contains(trackIDs, play.trackID) {
+			continue
+		}
+		trackIDs = append(trackIDs, play.trackID)
+	}
+	return trackIDs, nil
+}
+
+func (m *MockTrackStore) FinishPlay(ctx context.Context, trackID uuid.UUID, startedAt time.Time, duration time.Duration) error {
+	for i, play := range m.plays {
+		if play.trackID == trackID && play.startedAt.Equal(startedAt) {
+			m.plays[i].duration = duration
+		}
+	}
+	return nil
+}
+
+func (m *MockTrackStore) GetRecentTracks(ctx context.Context, limit int) ([]TrackStats, error) {
+	stats := m.trackStats(time.Time{})
+	slices.SortFunc(stats, func(a, b TrackStats) int {
+		return b.LastPlayedAt.Compare(a.LastPlayedAt)
+	})
+	return stats[:min(limit, len(stats))], nil
+}
+
+func (m *MockTrackStore) GetPopularTracks(ctx context.Context, since time.Time, limit int) ([]TrackStats, error) {
+	stats := m.trackStats(since)
+	slices.SortFunc(stats, func(a, b TrackStats) int {
+		return cmp.Or(
+			cmp.Compare(b.PlayCount, a.PlayCount),
+			cmp.Compare(b.TotalPlayed, a.TotalPlayed),
+			b.LastPlayedAt.Compare(a.LastPlayedAt),
+		)
+	})
+	return stats[:min(limit, len(stats))], nil
+}
+
+func (m *MockTrackStore) trackStats(since time.Time) []TrackStats {
+	var stats []TrackStats
+	for _, play := range m.plays {
+		if play.startedAt.Before(since) {
+			continue
+		}
+		i := slices.IndexFunc(stats, func(s TrackStats) bool { return s.TrackID == play.trackID })
+		if i < 0 {
+			stats = append(stats, TrackStats{TrackID: play.trackID})
+			i = len(stats) - 1
+		}
+		stats[i].PlayCount++
+		stats[i].TotalPlayed += play.duration.Seconds()
+		if play.startedAt.After(stats[i].LastPlayedAt) {
+			stats[i].LastPlayedAt = play.startedAt
+		}
+	}
+	return stats
+}
+
+func (m *MockTrackStore) SaveAuditEntry(ctx context.Context, entry AuditEntry) error {
+	entry.ID = int64(len(m.audit) + 1)
+	m.audit = append(m.audit, entry)
+	return nil
+}
+
+func (m *MockTrackStore) GetAuditEntries(ctx context.Context, filter AuditFilter, page AuditPage) ([]AuditEntry, error) {
+	var entries []AuditEntry
+	for _, entry := range slices.Backward(m.audit) {
+		switch {
+		case filter.Actor != "" && entry.Actor != filter.Actor,
+			filter.Action != "" && entry.Action != filter.Action,
+			filter.TargetID != "" && entry.TargetID != filter.TargetID,
+			filter.From != nil && entry.CreatedAt.Before(*filter.From),
+			filter.To != nil && !entry.CreatedAt.Before(*filter.To),
+			page.BeforeID != 0 && entry.ID >= page.BeforeID:
+			continue
+		}
+		entries = append(entries, entry)
+		if len(entries) == page.Limit {
+			break
+		}
+	}
+	return entries, nil
+}
+
+func (m *MockTrackStore) SaveSessionEvent(ctx context.Context, event SessionEvent) error {
+	m.events = append(m.events, event)
+	return nil
+}
+
+func (m *MockTrackStore) GetSessionEvents(ctx context.Context, sessionID uuid.UUID, page SessionEventPage) ([]SessionEvent, error) {
+	var events []SessionEvent
+	for _, event := range m.events {
+		if event.SessionID != sessionID || event.Seq <= page.AfterSeq {
+			continue
+		}
+		events = append(events, event)
+		if len(events) == page.Limit {
+			break
+		}
+	}
+	return events, nil
+}
+
+func (m *MockTrackStore) GetSessions(ctx context.Context, tableID string) ([]Session, error) {
+	var sessions []Session
+	for _, event := range m.events {
+		if event.TableID != tableID {
+			continue
+		}
+		i := slices.IndexFunc(sessions, func(s Session) bool { return s.ID == event.SessionID })
+		if i == -1 {
+			sessions = append(sessions, Session{ID: event.SessionID, TableID: tableID, StartedAt: event.CreatedAt})
+			i = len(sessions) - 1
+		}
+		sessions[i].LastEventAt = event.CreatedAt
+		sessions[i].EventCount++
+	}
+	slices.Reverse(sessions)
+	return sessions, nil
+}
+
+func (m *MockTrackStore) SaveUser(ctx context.Context, user User) error {
+	if _, err := m.GetUserByUsername(ctx, user.Username); err == nil {
+		return fmt.Errorf("username %w", ErrConflict)
+	}
+	m.users[user.ID] = user
+	return nil
+}
+
+func (m *MockTrackStore) GetUsers(ctx context.Context) ([]User, error) {
+	users := slices.Collect(maps.Values(m.users))
+	slices.SortFunc(users, func(a, b User) int {
+		return strings.Compare(strings.ToLower(a.Username), strings.ToLower(b.Username))
+	})
+	return users, nil
+}
+
+func (m *MockTrackStore) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
+	user, ok := m.users[id]
+	if !ok {
+		return User{}, fmt.Errorf("user %w", ErrNotFound)
+	}
+	return user, nil
+}
+
+func (m *MockTrackStore) GetUserByUsername(ctx context.Context, username string) (User, error) {
+	for _, user := range m.users {
+		if strings.EqualFold(user.Username, username) {
+			return user, nil
+		}
+	}
+	return User{}, fmt.Errorf("user %w", ErrNotFound)
+}
+
+func (m *MockTrackStore) UpdateUser(ctx context.Context, user User) error {
+	if other, err := m.GetUserByUsername(ctx, user.Username); err == nil && other.ID != user.ID {
+		return fmt.Errorf("username %w", ErrConflict)
+	}
+	m.users[user.ID] = user
+	return nil
+}
+
+func (m *MockTrackStore) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	delete(m.users, id)
+	return nil
+}
+
+func (m *MockTrackStore) SaveJoinToken(ctx context.Context, token JoinToken) error {
+	m.joinTokens[token.ID] = token
+	return nil
+}
+
+func (m *MockTrackStore) GetJoinTokens(ctx context.Context) ([]JoinToken, error) {
+	tokens := slices.Collect(maps.Values(m.joinTokens))
+	slices.SortFunc(tokens, func(a, b JoinToken) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), bytes.Compare(b.ID[:], a.ID[:]))
+	})
+	return tokens, nil
+}
+
+func (m *MockTrackStore) GetJoinTokenByID(ctx context.Context, id uuid.UUID) (JoinToken, error) {
+	token, ok := m.joinTokens[id]
+	if !ok {
+		return JoinToken{}, fmt.Errorf("join token %w", ErrNotFound)
+	}
+	return token, nil
+}
+
+func (m *MockTrackStore) RotateJoinToken(ctx context.Context, id uuid.UUID, secretHash []byte) error {
+	token, ok := m.joinTokens[id]
+	if !ok || token.RevokedAt != nil {
+		return fmt.Errorf("join token %w", ErrNotFound)
+	}
+	token.SecretHash = secretHash
+	token.Uses = 0
+	m.joinTokens[id] = token
+	return nil
+}
+
+func (m *MockTrackStore) RevokeJoinToken(ctx context.Context, id uuid.UUID, revokedAt time.Time) error {
+	token, ok := m.joinTokens[id]
+	if !ok {
+		return fmt.Errorf("join token %w", ErrNotFound)
+	}
+	if token.RevokedAt == nil {
+		token.RevokedAt = &revokedAt
+	}
+	m.joinTokens[id] = token
+	return nil
+}
+
+func (m *MockTrackStore) UseJoinToken(ctx context.Context, id uuid.UUID, secretHash []byte, now time.Time) (JoinToken, error) {
+	token, ok := m.joinTokens[id]
+	if !ok || !bytes.Equal(token.SecretHash, secretHash) || token.RevokedAt != nil ||
+		(token.ExpiresAt != nil && !token.ExpiresAt.After(now)) ||
+		(token.MaxUses > 0 && token.Uses >= token.MaxUses) {
+		return JoinToken{}, fmt.Errorf("join token %w", ErrNotFound)
+	}
+	token.Uses++
+	m.joinTokens[id] = token
+	return token, nil
+}
+
 func NewMockTrackStore(t *testing.T) *MockTrackStore {
 	t.Helper()
 
 	store := &MockTrackStore{
-		tracks:     make(map[uuid.UUID]Track),
-		trackTypes: make(map[uuid.UUID]TrackType),
+		tracks:      make(map[uuid.UUID]Track),
+		trackTypes:  make(map[uuid.UUID]TrackType),
+		collections: make(map[uuid.UUID]Collection),
+		users:       make(map[uuid.UUID]User),
+		joinTokens:  make(map[uuid.UUID]JoinToken),
 	}
 
 	// Add default track types
@@ -0,0 +1,800 @@
+// Package storetest checks that a server.Store behaves the way the server
+// expects, so every database backend can be held to the same behaviour.
+package storetest
+
+import (
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/terrabitz/rpg-audio-streamer/internal/server"
+)
+
+// Run runs the conformance tests against stores created by newStore. Each
+// test gets a new store with every migration applied and nothing else in it.
+func Run(t *testing.T, newStore func(t *testing.T) server.Store) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, store server.Store)
+	}{
+		{"tracks", testTracks},
+		{"not found", testNotFound},
+		{"conflicts", testConflicts},
+		{"filters", testFilters},
+		{"pages", testPages},
+		{"updates", testUpdates},
+		{"order", testOrder},
+		{"track types", testTrackTypes},
+		{"collections", testCollections},
+		{"plays", testPlays},
+		{"audit", testAudit},
+		{"session events", testSessionEvents},
+		{"users", testUsers},
+		{"join tokens", testJoinTokens},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newStore(t))
+		})
+	}
+}
+
+// The default track types every store starts with.
+var (
+	ambianceTypeID = uuid.MustParse("1ec000a2-a7c9-11ee-a0e5-0242ac120002")
+	musicTypeID    = uuid.MustParse("1ec000a2-a7c9-11ee-a0e5-0242ac120003")
+)
+
+func saveTrack(t *testing.T, store server.Store, track server.Track) server.Track {
+	t.Helper()
+
+	if track.ID == uuid.Nil {
+		track.ID = uuid.Must(uuid.NewV7())
+	}
+	if track.CreatedAt.IsZero() {
+		track.CreatedAt = time.Now()
+	}
+	if track.TypeID == uuid.Nil {
+		track.TypeID = ambianceTypeID
+	}
+	if err := store.SaveTrack(t.Context(), &track); err != nil {
+		t.Fatalf("failed to save track: %v", err)
+	}
+	return track
+}
+
+// idAt returns a UUIDv7 created at the given time, for tracks uploaded in the
+// past.
+func idAt(createdAt time.Time) uuid.UUID {
+	id := uuid.Must(uuid.NewV7())
+	var ms [8]byte
+	binary.BigEndian.PutUint64(ms[:], uint64(createdAt.UnixMilli()))
+	copy(id[:6], ms[2:])
+	return id
+}
+
+func updateTrack(t *testing.T, store server.Store, trackID uuid.UUID, update server.UpdateTrackRequest) server.Track {
+	t.Helper()
+
+	track, err := store.UpdateTrack(t.Context(), trackID, update)
+	if err != nil {
+		t.Fatalf("failed to update track: %v", err)
+	}
+	return track
+}
+
+func getTracks(t *testing.T, store server.Store, filter server.TrackFilter, page server.TrackPage) []server.Track {
+	t.Helper()
+
+	if page.Sort == "" {
+		page.Sort = server.TrackSortCreated
+	}
+	if page.Limit == 0 {
+		page.Limit = 100
+	}
+	tracks, err := store.GetTracks(t.Context(), filter, page)
+	if err != nil {
+		t.Fatalf("failed to get tracks: %v", err)
+	}
+	return tracks
+}
+
+func names(tracks []server.Track) []string {
+	var names []string
+	for _, track := range tracks {
+		names = append(names, track.Name)
+	}
+	return names
+}
+
+func testTracks(t *testing.T, store server.Store) {
+	createdAt := time.Date(2024, 5, 1, 12, 30, 15, 500, time.UTC)
+	saved := saveTrack(t, store, server.Track{
+		CreatedAt: createdAt,
+		Name:      "Tavern Lute",
+		Path:      "tavern.mp3",
+		TypeID:    musicTypeID,
+		Duration:  93.5,
+		Metadata:  map[string]string{"artist": "Bard"},
+	})
+	second := saveTrack(t, store, server.Track{Name: "Crowd", TypeID: musicTypeID})
+
+	track, err := store.GetTrackByID(t.Context(), saved.ID)
+	if err != nil {
+		t.Fatalf("failed to get track: %v", err)
+	}
+	if track.Name != "Tavern Lute" || track.Path != "tavern.mp3" || track.TypeID != musicTypeID || track.Duration != 93.5 {
+		t.Errorf("unexpected track %+v", track)
+	}
+	if !track.CreatedAt.Equal(createdAt.Truncate(time.Second)) {
+		t.Errorf("expected the creation time to the second; got %v", track.CreatedAt)
+	}
+	if track.Metadata["artist"] != "Bard" || len(track.Tags) != 0 || len(track.Collections) != 0 || track.Favorite {
+		t.Errorf("unexpected track details %+v", track)
+	}
+
+	if second, _ := store.GetTrackByID(t.Context(), second.ID); second.Position != 1 {
+		t.Errorf("expected new tracks to go last; got position %d", second.Position)
+	}
+
+	if err := store.SetTrackMedia(t.Context(), saved.ID, 12, map[string]string{"codec": "opus"}); err != nil {
+		t.Fatalf("failed to set track media: %v", err)
+	}
+	track, _ = store.GetTrackByID(t.Context(), saved.ID)
+	if track.Duration != 12 || track.Metadata["codec"] != "opus" || track.Metadata["artist"] != "" {
+		t.Errorf("expected the media to be replaced; got %+v", track)
+	}
+
+	if err := store.DeleteTrack(t.Context(), saved.ID); err != nil {
+		t.Fatalf("failed to delete track: %v", err)
+	}
+	var missing *server.MissingTracksError
+	if _, err := store.DeleteTracks(t.Context(), []uuid.UUID{second.ID, saved.ID}); !errors.As(err, &missing) || !slices.Equal(missing.TrackIDs, []uuid.UUID{saved.ID}) {
+		t.Errorf("expected deleting a missing track to list it; got %v", err)
+	}
+	deleted, err := store.DeleteTracks(t.Context(), []uuid.UUID{second.ID})
+	if err != nil {
+		t.Fatalf("failed to delete tracks: %v", err)
+	}
+	if len(deleted) != 1 || deleted[0].Name != second.Name {
+		t.Errorf("expected the deleted track to be returned; got %+v", deleted)
+	}
+	if count, _ := store.CountTracks(t.Context(), server.TrackFilter{}); count != 0 {
+		t.Errorf("expected no tracks left; got %d", count)
+	}
+}
+
+func testNotFound(t *testing.T, store server.Store) {
+	missing := uuid.Must(uuid.NewV7())
+
+	if _, err := store.GetTrackByID(t.Context(), missing); !errors.Is(err, server.ErrNotFound) {
+		t.Errorf("expected a missing track not to be found; got %v", err)
+	}
+	if _, err := store.UpdateTrack(t.Context(), missing, server.UpdateTrackRequest{}); !errors.Is(err, server.ErrNotFound) {
+		t.Errorf("expected a missing track not to be found for an update; got %v", err)
+	}
+	if _, _, err := store.ModifyTracks(t.Context(), []uuid.UUID{missing}, keepTrack); !errors.Is(err, server.ErrNotFound) {
+		t.Errorf("expected a missing track not to be found for a bulk update; got %v", err)
+	}
+	if _, err := store.GetTrackTypeByID(t.Context(), missing); !errors.Is(err, server.ErrNotFound) {
+		t.Errorf("expected a missing track type not to be found; got %v", err)
+	}
+}
+
+func testConflicts(t *testing.T, store server.Store) {
+	track := saveTrack(t, store, server.Track{Name: "Lute"})
+	if err := store.SaveTrack(t.Context(), &track); !errors.Is(err, server.ErrConflict) {
+		t.Errorf("expected a conflict saving a track twice; got %v", err)
+	}
+
+	music := server.TrackType{ID: uuid.Must(uuid.NewV7()), Name: "MUSIC", Color: "#80DEEA"}
+	if err := store.SaveTrackType(t.Context(), music); !errors.Is(err, server.ErrConflict) {
+		t.Errorf("expected a conflict for a duplicate type name; got %v", err)
+	}
+
+	unknownID := uuid.Must(uuid.NewV7())
+	untyped := server.Track{ID: uuid.Must(uuid.NewV7()), Name: "Untyped", CreatedAt: time.Now(), TypeID: unknownID}
+	if err := store.SaveTrack(t.Context(), &untyped); !errors.Is(err, server.ErrConflict) {
+		t.Errorf("expected a conflict for an unknown track type; got %v", err)
+	}
+
+	collections := []uuid.UUID{unknownID}
+	if _, err := store.UpdateTrack(t.Context(), track.ID, server.UpdateTrackRequest{Collections: &collections}); !errors.Is(err, server.ErrConflict) {
+		t.Errorf("expected a conflict adding a track to an unknown collection; got %v", err)
+	}
+
+	orphan := server.Collection{ID: uuid.Must(uuid.NewV7()), ParentID: &unknownID, Name: "Orphan", CreatedAt: time.Now()}
+	if err := store.SaveCollection(t.Context(), &orphan); !errors.Is(err, server.ErrConflict) {
+		t.Errorf("expected a conflict for an unknown parent collection; got %v", err)
+	}
+}
+
+func testFilters(t *testing.T, store server.Store) {
+	favorite := true
+	start := time.Now().Add(-time.Hour)
+	lute := saveTrack(t, store, server.Track{ID: idAt(start), Name: "Tavern Lute", TypeID: musicTypeID})
+	saveTrack(t, store, server.Track{
+		ID:       idAt(start.Add(30 * time.Minute)),
+		Name:     "Storm at Sea",
+		Metadata: map[string]string{"album": "Crashing Waves"},
+	})
+	saveTrack(t, store, server.Track{Name: "Dragon Roar"})
+
+	tags := []string{"Calm", "indoors"}
+	updateTrack(t, store, lute.ID, server.UpdateTrackRequest{Tags: &tags, Favorite: &favorite})
+
+	notFavorite := false
+	tests := []struct {
+		name     string
+		filter   server.TrackFilter
+		expected []string
+	}{
+		{"everything", server.TrackFilter{}, []string{"Tavern Lute", "Storm at Sea", "Dragon Roar"}},
+		{"name prefix", server.TrackFilter{Query: "tav"}, []string{"Tavern Lute"}},
+		{"tag", server.TrackFilter{Query: "indoors"}, []string{"Tavern Lute"}},
+		{"metadata", server.TrackFilter{Query: "waves"}, []string{"Storm at Sea"}},
+		{"every word", server.TrackFilter{Query: "storm sea"}, []string{"Storm at Sea"}},
+		{"punctuation", server.TrackFilter{Query: `"storm!" (sea`}, []string{"Storm at Sea"}},
+		{"tag without case", server.TrackFilter{Tag: "calm"}, []string{"Tavern Lute"}},
+		{"type", server.TrackFilter{TypeID: &musicTypeID}, []string{"Tavern Lute"}},
+		{"favourites", server.TrackFilter{Favorite: &favorite}, []string{"Tavern Lute"}},
+		{"not favourites", server.TrackFilter{Favorite: &notFavorite}, []string{"Storm at Sea", "Dragon Roar"}},
+		{"created range", server.TrackFilter{CreatedAfter: start.Add(time.Minute), CreatedBefore: time.Now().Add(-time.Minute)}, []string{"Storm at Sea"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := names(getTracks(t, store, tt.filter, server.TrackPage{})); !slices.Equal(got, tt.expected) {
+				t.Errorf("expected %v; got %v", tt.expected, got)
+			}
+
+			count, err := store.CountTracks(t.Context(), tt.filter)
+			if err != nil {
+				t.Fatalf("failed to count tracks: %v", err)
+			}
+			if count != len(tt.expected) {
+				t.Errorf("expected a count of %d; got %d", len(tt.expected), count)
+			}
+		})
+	}
+
+	if got, _ := store.GetTags(t.Context()); !slices.Equal(got, []string{"Calm", "indoors"}) {
+		t.Errorf("unexpected tags %v", got)
+	}
+}
+
+func testPages(t *testing.T, store server.Store) {
+	for i, name := range []string{"banana", "Cherry", "apple", "Apple pie"} {
+		saveTrack(t, store, server.Track{Name: name, Duration: float64(i % 2)})
+	}
+
+	tests := []struct {
+		name     string
+		page     server.TrackPage
+		expected []string
+	}{
+		{"created", server.TrackPage{Sort: server.TrackSortCreated}, []string{"banana", "Cherry", "apple", "Apple pie"}},
+		{"name", server.TrackPage{Sort: server.TrackSortName}, []string{"apple", "Apple pie", "banana", "Cherry"}},
+		{"name descending", server.TrackPage{Sort: server.TrackSortName, Descending: true}, []string{"Cherry", "banana", "Apple pie", "apple"}},
+		{"duration", server.TrackPage{Sort: server.TrackSortDuration}, []string{"banana", "apple", "Cherry", "Apple pie"}},
+		{"position", server.TrackPage{Sort: server.TrackSortPosition, Descending: true}, []string{"Apple pie", "apple", "Cherry", "banana"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			page := tt.page
+			page.Limit = 3
+			for {
+				tracks := getTracks(t, store, server.TrackFilter{}, page)
+				got = append(got, names(tracks)...)
+				if len(tracks) < page.Limit {
+					break
+				}
+				page.After = &tracks[len(tracks)-1]
+			}
+
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("expected %v; got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func testUpdates(t *testing.T, store server.Store) {
+	lute := saveTrack(t, store, server.Track{Name: "Lute"})
+	drums := saveTrack(t, store, server.Track{Name: "Drums"})
+	saveTrack(t, store, server.Track{Name: "Flute", TypeID: musicTypeID})
+
+	name, artist, favorite := "Tavern Lute", "Bard", true
+	tags := []string{"calm", "tavern"}
+	track := updateTrack(t, store, lute.ID, server.UpdateTrackRequest{
+		Name:     &name,
+		TypeID:   &musicTypeID,
+		Tags:     &tags,
+		Artist:   &artist,
+		Favorite: &favorite,
+	})
+	if track.Name != name || track.Artist != artist || !track.Favorite || !slices.Equal(track.Tags, tags) {
+		t.Errorf("unexpected updated track %+v", track)
+	}
+	if track.TypeID != musicTypeID || track.Position != 1 {
+		t.Errorf("expected the track to go last in its new type; got %+v", track)
+	}
+
+	// Fields left out are kept.
+	track = updateTrack(t, store, lute.ID, server.UpdateTrackRequest{})
+	if track.Name != name || track.Artist != artist || !slices.Equal(track.Tags, tags) {
+		t.Errorf("expected an empty update to change nothing; got %+v", track)
+	}
+
+	tags = []string{"Tavern"}
+	updateTrack(t, store, lute.ID, server.UpdateTrackRequest{Tags: &tags})
+	if got, _ := store.GetTags(t.Context()); !slices.Equal(got, []string{"tavern"}) {
+		t.Errorf("expected tags to be reused and unused ones dropped; got %v", got)
+	}
+
+	renamed := "Loud Drums"
+	rename := func(track server.Track) server.UpdateTrackRequest {
+		return server.UpdateTrackRequest{Name: &renamed}
+	}
+	_, _, err := store.ModifyTracks(t.Context(), []uuid.UUID{drums.ID, uuid.Must(uuid.NewV7())}, rename)
+	if err == nil {
+		t.Fatal("expected an error updating a missing track")
+	}
+	if track, _ := store.GetTrackByID(t.Context(), drums.ID); track.Name != "Drums" {
+		t.Errorf("expected a failed bulk update to change nothing; got %q", track.Name)
+	}
+
+	before, after, err := store.ModifyTracks(t.Context(), []uuid.UUID{drums.ID}, rename)
+	if err != nil || len(after) != 1 || after[0].Name != renamed || before[0].Name != "Drums" {
+		t.Errorf("unexpected bulk update result %+v, %+v, %v", before, after, err)
+	}
+
+	// The update is worked out from the track as it is in the transaction.
+	_, after, err = store.ModifyTracks(t.Context(), []uuid.UUID{drums.ID}, func(track server.Track) server.UpdateTrackRequest {
+		name := track.Name + "!"
+		return server.UpdateTrackRequest{Name: &name}
+	})
+	if err != nil || after[0].Name != renamed+"!" {
+		t.Errorf("expected the update to build on the stored track; got %+v, %v", after, err)
+	}
+}
+
+// keepTrack is a ModifyTracks update that changes nothing.
+func keepTrack(server.Track) server.UpdateTrackRequest {
+	return server.UpdateTrackRequest{}
+}
+
+func testOrder(t *testing.T, store server.Store) {
+	var ids []uuid.UUID
+	for _, name := range []string{"first", "second", "third"} {
+		ids = append(ids, saveTrack(t, store, server.Track{Name: name}).ID)
+	}
+
+	for _, order := range [][]uuid.UUID{ids[:2], {ids[0], ids[1], ids[1]}, {ids[0], ids[1], uuid.New()}} {
+		if err := store.ReorderTracks(t.Context(), ambianceTypeID, order); !errors.Is(err, server.ErrTrackOrderMismatch) {
+			t.Errorf("expected an order mismatch for %v; got %v", order, err)
+		}
+	}
+
+	if err := store.ReorderTracks(t.Context(), ambianceTypeID, []uuid.UUID{ids[2], ids[0], ids[1]}); err != nil {
+		t.Fatalf("failed to reorder tracks: %v", err)
+	}
+	got := names(getTracks(t, store, server.TrackFilter{}, server.TrackPage{Sort: server.TrackSortPosition}))
+	if expected := []string{"third", "first", "second"}; !slices.Equal(got, expected) {
+		t.Errorf("expected %v; got %v", expected, got)
+	}
+}
+
+func testTrackTypes(t *testing.T, store server.Store) {
+	weather := server.TrackType{
+		ID:            uuid.Must(uuid.NewV7()),
+		Name:          "Weather",
+		Color:         "#A5D6A7",
+		IsRepeating:   true,
+		DefaultVolume: 80,
+		FadeInMs:      500,
+		MaxConcurrent: 2,
+	}
+	if err := store.SaveTrackType(t.Context(), weather); err != nil {
+		t.Fatalf("failed to save track type: %v", err)
+	}
+
+	weather.AutoStopOthers = true
+	weather.FadeOutMs = 250
+	if err := store.UpdateTrackType(t.Context(), weather); err != nil {
+		t.Fatalf("failed to update track type: %v", err)
+	}
+	if got, err := store.GetTrackTypeByID(t.Context(), weather.ID); err != nil || got != weather {
+		t.Errorf("expected %+v; got %+v, %v", weather, got, err)
+	}
+
+	trackTypes, err := store.GetTrackTypes(t.Context())
+	if err != nil {
+		t.Fatalf("failed to get track types: %v", err)
+	}
+	if len(trackTypes) != 4 || !slices.Contains(trackTypes, weather) {
+		t.Errorf("expected the default types and Weather; got %+v", trackTypes)
+	}
+
+	saveTrack(t, store, server.Track{Name: "Music", TypeID: musicTypeID})
+	rain := saveTrack(t, store, server.Track{Name: "Rain", TypeID: weather.ID})
+	if err := store.DeleteTrackType(t.Context(), weather.ID, nil); !errors.Is(err, server.ErrTrackTypeInUse) {
+		t.Errorf("expected the type to be in use; got %v", err)
+	}
+
+	if err := store.DeleteTrackType(t.Context(), weather.ID, &musicTypeID); err != nil {
+		t.Fatalf("failed to delete track type: %v", err)
+	}
+	if track, _ := store.GetTrackByID(t.Context(), rain.ID); track.TypeID != musicTypeID || track.Position != 1 {
+		t.Errorf("expected the track to be moved to the end of Music; got %+v", track)
+	}
+	if _, err := store.GetTrackTypeByID(t.Context(), weather.ID); !errors.Is(err, server.ErrNotFound) {
+		t.Errorf("expected the type to be deleted; got %v", err)
+	}
+}
+
+func testCollections(t *testing.T, store server.Store) {
+	town := server.Collection{ID: uuid.Must(uuid.NewV7()), Name: "Town", CreatedAt: time.Now()}
+	tavern := server.Collection{ID: uuid.Must(uuid.NewV7()), ParentID: &town.ID, Name: "tavern", CreatedAt: time.Now()}
+	for _, collection := range []*server.Collection{&town, &tavern} {
+		if err := store.SaveCollection(t.Context(), collection); err != nil {
+			t.Fatalf("failed to save collection: %v", err)
+		}
+	}
+
+	lute := saveTrack(t, store, server.Track{Name: "Lute"})
+	saveTrack(t, store, server.Track{Name: "Wind"})
+	collections := []uuid.UUID{tavern.ID}
+	track := updateTrack(t, store, lute.ID, server.UpdateTrackRequest{Collections: &collections})
+	if !slices.Equal(track.Collections, collections) {
+		t.Errorf("expected the track in the tavern; got %v", track.Collections)
+	}
+
+	if got := names(getTracks(t, store, server.TrackFilter{CollectionID: &town.ID}, server.TrackPage{})); !slices.Equal(got, []string{"Lute"}) {
+		t.Errorf("expected tracks in nested collections; got %v", got)
+	}
+
+	tavern.Name = "Inn"
+	if err := store.UpdateCollection(t.Context(), tavern); err != nil {
+		t.Fatalf("failed to update collection: %v", err)
+	}
+
+	got, err := store.GetCollections(t.Context())
+	if err != nil {
+		t.Fatalf("failed to get collections: %v", err)
+	}
+	if len(got) != 2 || got[0].Name != "Inn" || got[0].TrackCount != 1 || *got[0].ParentID != town.ID || got[1].TrackCount != 0 {
+		t.Errorf("unexpected collections %+v", got)
+	}
+
+	if err := store.DeleteCollection(t.Context(), town.ID); err != nil {
+		t.Fatalf("failed to delete collection: %v", err)
+	}
+	if got, _ := store.GetCollections(t.Context()); len(got) != 0 {
+		t.Errorf("expected nested collections to be deleted; got %+v", got)
+	}
+	if track, err := store.GetTrackByID(t.Context(), lute.ID); err != nil || len(track.Collections) != 0 {
+		t.Errorf("expected the track to be kept outside any collection; got %+v, %v", track, err)
+	}
+}
+
+func testPlays(t *testing.T, store server.Store) {
+	lute := saveTrack(t, store, server.Track{Name: "Lute"})
+	drums := saveTrack(t, store, server.Track{Name: "Drums"})
+
+	start := time.Now().UTC().Truncate(time.Second).Add(-time.Hour)
+	plays := []struct {
+		trackID  uuid.UUID
+		offset   time.Duration
+		duration time.Duration
+	}{
+		{drums.ID, 0, 30 * time.Second},
+		{lute.ID, time.Minute, 90 * time.Second},
+		{lute.ID, 2 * time.Minute, 0},
+	}
+	for _, play := range plays {
+		startedAt := start.Add(play.offset)
+		if err := store.RecordPlay(t.Context(), play.trackID, startedAt); err != nil {
+			t.Fatalf("failed to record play: %v", err)
+		}
+		if play.duration > 0 {
+			if err := store.FinishPlay(t.Context(), play.trackID, startedAt, play.duration); err != nil {
+				t.Fatalf("failed to finish play: %v", err)
+			}
+		}
+	}
+
+	played, err := store.GetPlayedTrackIDs(t.Context(), start, start.Add(2*time.Minute))
+	if err != nil {
+		t.Fatalf("failed to get played tracks: %v", err)
+	}
+	if !slices.Equal(played, []uuid.UUID{drums.ID, lute.ID}) {
+		t.Errorf("expected the tracks in the order first played; got %v", played)
+	}
+
+	recent, err := store.GetRecentTracks(t.Context(), 10)
+	if err != nil {
+		t.Fatalf("failed to get recent tracks: %v", err)
+	}
+	if len(recent) != 2 || recent[0].TrackID != lute.ID {
+		t.Fatalf("expected the lute played last; got %+v", recent)
+	}
+	expected := server.TrackStats{TrackID: lute.ID, PlayCount: 2, TotalPlayed: 90, LastPlayedAt: start.Add(2 * time.Minute)}
+	if recent[0] != expected {
+		t.Errorf("expected %+v; got %+v", expected, recent[0])
+	}
+
+	popular, err := store.GetPopularTracks(t.Context(), start.Add(30*time.Second), 1)
+	if err != nil {
+		t.Fatalf("failed to get popular tracks: %v", err)
+	}
+	if len(popular) != 1 || popular[0].TrackID != lute.ID || popular[0].PlayCount != 2 {
+		t.Errorf("expected only the lute; got %+v", popular)
+	}
+}
+
+func testAudit(t *testing.T, store server.Store) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i, action := range []server.AuditAction{server.AuditLogin, server.AuditTrackUpdate, server.AuditTrackUpdate, server.AuditLogout} {
+		entry := server.AuditEntry{
+			CreatedAt: start.Add(time.Duration(i) * time.Hour),
+			Actor:     "gm",
+			Action:    action,
+			IP:        "192.0.2.1",
+		}
+		if action == server.AuditTrackUpdate {
+			entry.TargetID = "lute"
+			entry.Before = []byte(`{"tags":["calm"]}`)
+			entry.After = []byte(`{"tags":["calm","tavern"]}`)
+		}
+		if err := store.SaveAuditEntry(t.Context(), entry); err != nil {
+			t.Fatalf("failed to save audit entry: %v", err)
+		}
+	}
+
+	getEntries := func(t *testing.T, filter server.AuditFilter, page server.AuditPage) []server.AuditEntry {
+		t.Helper()
+
+		entries, err := store.GetAuditEntries(t.Context(), filter, page)
+		if err != nil {
+			t.Fatalf("failed to get audit entries: %v", err)
+		}
+		return entries
+	}
+
+	all := getEntries(t, server.AuditFilter{}, server.AuditPage{Limit: 10})
+	if len(all) != 4 || all[0].Action != server.AuditLogout || all[3].Action != server.AuditLogin {
+		t.Fatalf("expected every entry newest first; got %+v", all)
+	}
+	if !all[0].CreatedAt.Equal(start.Add(3*time.Hour)) || all[0].IP != "192.0.2.1" || all[0].Before != nil {
+		t.Errorf("unexpected entry %+v", all[0])
+	}
+	if update := all[1]; update.TargetID != "lute" || string(update.Before) != `{"tags":["calm"]}` || string(update.After) != `{"tags":["calm","tavern"]}` {
+		t.Errorf("expected the states back as written; got %+v", update)
+	}
+
+	page := getEntries(t, server.AuditFilter{}, server.AuditPage{BeforeID: all[1].ID, Limit: 1})
+	if len(page) != 1 || page[0].ID != all[2].ID {
+		t.Errorf("expected the page after %d; got %+v", all[1].ID, page)
+	}
+
+	from, to := start.Add(time.Hour), start.Add(3*time.Hour)
+	filtered := getEntries(t, server.AuditFilter{Actor: "gm", Action: server.AuditTrackUpdate, TargetID: "lute", From: &from, To: &to}, server.AuditPage{Limit: 10})
+	if len(filtered) != 2 {
+		t.Errorf("expected both updates; got %+v", filtered)
+	}
+}
+
+func testSessionEvents(t *testing.T, store server.Store) {
+	session, other := uuid.Must(uuid.NewV7()), uuid.Must(uuid.NewV7())
+	start := time.Date(2024, 5, 1, 19, 30, 0, 250_000_000, time.UTC)
+	for i, method := range []string{"syncAll", "syncTrack", "broadcast"} {
+		event := server.SessionEvent{
+			SessionID: session,
+			TableID:   "tavern",
+			Seq:       int64(i + 1),
+			CreatedAt: start.Add(time.Duration(i) * 1500 * time.Millisecond),
+			Method:    method,
+			SenderID:  "gm",
+			Payload:   []byte(`{"fileID": "lute", "isPlaying": true}`),
+		}
+		if err := store.SaveSessionEvent(t.Context(), event); err != nil {
+			t.Fatalf("failed to save session event: %v", err)
+		}
+	}
+	if err := store.SaveSessionEvent(t.Context(), server.SessionEvent{SessionID: other, TableID: "tavern", Seq: 1, CreatedAt: start.Add(-time.Hour), Method: "syncAll", Payload: []byte(`{}`)}); err != nil {
+		t.Fatalf("failed to save session event: %v", err)
+	}
+
+	duplicate := server.SessionEvent{SessionID: session, Seq: 2, CreatedAt: start, Method: "syncAll", Payload: []byte(`{}`)}
+	if err := store.SaveSessionEvent(t.Context(), duplicate); !errors.Is(err, server.ErrConflict) {
+		t.Errorf("expected a conflict reusing a sequence number; got %v", err)
+	}
+
+	events, err := store.GetSessionEvents(t.Context(), session, server.SessionEventPage{Limit: 10})
+	if err != nil {
+		t.Fatalf("failed to get session events: %v", err)
+	}
+	if len(events) != 3 || events[0].Method != "syncAll" || events[2].Method != "broadcast" {
+		t.Fatalf("expected the session's events in order; got %+v", events)
+	}
+	if second := events[1]; second.Seq != 2 || second.SessionID != session || second.TableID != "tavern" || second.SenderID != "gm" || !second.CreatedAt.Equal(start.Add(1500*time.Millisecond)) {
+		t.Errorf("unexpected event %+v", second)
+	}
+	if string(events[0].Payload) != `{"fileID": "lute", "isPlaying": true}` {
+		t.Errorf("expected the payload back as written; got %s", events[0].Payload)
+	}
+
+	page, err := store.GetSessionEvents(t.Context(), session, server.SessionEventPage{AfterSeq: 1, Limit: 1})
+	if err != nil {
+		t.Fatalf("failed to get session events: %v", err)
+	}
+	if len(page) != 1 || page[0].Seq != 2 {
+		t.Errorf("expected the event after 1; got %+v", page)
+	}
+
+	sessions, err := store.GetSessions(t.Context(), "tavern")
+	if err != nil {
+		t.Fatalf("failed to get sessions: %v", err)
+	}
+	want := []server.Session{
+		{ID: session, TableID: "tavern", StartedAt: start, LastEventAt: start.Add(3 * time.Second), EventCount: 3},
+		{ID: other, TableID: "tavern", StartedAt: start.Add(-time.Hour), LastEventAt: start.Add(-time.Hour), EventCount: 1},
+	}
+	if len(sessions) != len(want) {
+		t.Fatalf("expected %d sessions; got %+v", len(want), sessions)
+	}
+	for i := range want {
+		if got := sessions[i]; got.ID != want[i].ID || got.TableID != want[i].TableID || !got.StartedAt.Equal(want[i].StartedAt) || !got.LastEventAt.Equal(want[i].LastEventAt) || got.EventCount != want[i].EventCount {
+			t.Errorf("expected session %+v; got %+v", want[i], got)
+		}
+	}
+
+	if none, err := store.GetSessions(t.Context(), "elsewhere"); err != nil || len(none) != 0 {
+		t.Errorf("expected no sessions at another table; got %+v, %v", none, err)
+	}
+}
+
+func testUsers(t *testing.T, store server.Store) {
+	createdAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	brenna := server.User{ID: uuid.Must(uuid.NewV7()), Username: "Brenna", PasswordHash: "$argon2id$brenna", CreatedAt: createdAt}
+	aldric := server.User{ID: uuid.Must(uuid.NewV7()), Username: "aldric", PasswordHash: "$argon2id$aldric", Admin: true, CreatedAt: createdAt}
+	for _, user := range []server.User{brenna, aldric} {
+		if err := store.SaveUser(t.Context(), user); err != nil {
+			t.Fatalf("failed to save user: %v", err)
+		}
+	}
+
+	taken := server.User{ID: uuid.Must(uuid.NewV7()), Username: "BRENNA", PasswordHash: "$argon2id$other", CreatedAt: createdAt}
+	if err := store.SaveUser(t.Context(), taken); !errors.Is(err, server.ErrConflict) {
+		t.Errorf("expected a conflict for a username differing only in case; got %v", err)
+	}
+
+	users, err := store.GetUsers(t.Context())
+	if err != nil {
+		t.Fatalf("failed to get users: %v", err)
+	}
+	if len(users) != 2 || users[0] != aldric || users[1] != brenna {
+		t.Fatalf("expected users ordered by username; got %+v", users)
+	}
+
+	found, err := store.GetUserByUsername(t.Context(), "brenna")
+	if err != nil {
+		t.Fatalf("failed to get user by username: %v", err)
+	}
+	if found != brenna {
+		t.Errorf("expected %+v regardless of case; got %+v", brenna, found)
+	}
+
+	brenna.Username = "Brenna the Bold"
+	brenna.PasswordHash = "$argon2id$new"
+	brenna.Admin = true
+	if err := store.UpdateUser(t.Context(), brenna); err != nil {
+		t.Fatalf("failed to update user: %v", err)
+	}
+	if found, err := store.GetUserByID(t.Context(), brenna.ID); err != nil || found != brenna {
+		t.Errorf("expected %+v; got %+v, %v", brenna, found, err)
+	}
+
+	brenna.Username = "Aldric"
+	if err := store.UpdateUser(t.Context(), brenna); !errors.Is(err, server.ErrConflict) {
+		t.Errorf("expected a conflict renaming to a taken username; got %v", err)
+	}
+
+	if err := store.DeleteUser(t.Context(), aldric.ID); err != nil {
+		t.Fatalf("failed to delete user: %v", err)
+	}
+	if _, err := store.GetUserByID(t.Context(), aldric.ID); !errors.Is(err, server.ErrNotFound) {
+		t.Errorf("expected a deleted user not to be found; got %v", err)
+	}
+	if _, err := store.GetUserByUsername(t.Context(), "nobody"); !errors.Is(err, server.ErrNotFound) {
+		t.Errorf("expected an unknown username not to be found; got %v", err)
+	}
+}
+
+func testJoinTokens(t *testing.T, store server.Store) {
+	createdAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	expiresAt := createdAt.Add(24 * time.Hour)
+	open := server.JoinToken{ID: uuid.Must(uuid.NewV7()), Label: "Open table", SecretHash: []byte("open"), CreatedAt: createdAt}
+	limited := server.JoinToken{ID: uuid.Must(uuid.NewV7()), Label: "One-shot", SecretHash: []byte("limited"), CreatedAt: createdAt.Add(time.Hour), ExpiresAt: &expiresAt, MaxUses: 1}
+	for _, token := range []server.JoinToken{open, limited} {
+		if err := store.SaveJoinToken(t.Context(), token); err != nil {
+			t.Fatalf("failed to save join token: %v", err)
+		}
+	}
+
+	tokens, err := store.GetJoinTokens(t.Context())
+	if err != nil {
+		t.Fatalf("failed to get join tokens: %v", err)
+	}
+	if !reflect.DeepEqual(tokens, []server.JoinToken{limited, open}) {
+		t.Fatalf("expected join tokens newest first; got %+v", tokens)
+	}
+
+	now := createdAt.Add(2 * time.Hour)
+	if _, err := store.UseJoinToken(t.Context(), limited.ID, []byte("wrong"), now); !errors.Is(err, server.ErrNotFound) {
+		t.Errorf("expected a wrong secret hash not to be used; got %v", err)
+	}
+	used, err := store.UseJoinToken(t.Context(), limited.ID, limited.SecretHash, now)
+	if err != nil {
+		t.Fatalf("failed to use join token: %v", err)
+	}
+	if used.Uses != 1 {
+		t.Errorf("expected 1 use; got %+v", used)
+	}
+	if _, err := store.UseJoinToken(t.Context(), limited.ID, limited.SecretHash, now); !errors.Is(err, server.ErrNotFound) {
+		t.Errorf("expected a used up token not to be used; got %v", err)
+	}
+	if _, err := store.UseJoinToken(t.Context(), open.ID, open.SecretHash, expiresAt.Add(time.Hour)); err != nil {
+		t.Errorf("expected a token without an expiry to be used; got %v", err)
+	}
+
+	if err := store.RotateJoinToken(t.Context(), limited.ID, []byte("rotated")); err != nil {
+		t.Fatalf("failed to rotate join token: %v", err)
+	}
+	if _, err := store.UseJoinToken(t.Context(), limited.ID, limited.SecretHash, now); !errors.Is(err, server.ErrNotFound) {
+		t.Errorf("expected the old secret to stop working; got %v", err)
+	}
+	if _, err := store.UseJoinToken(t.Context(), limited.ID, []byte("rotated"), expiresAt); !errors.Is(err, server.ErrNotFound) {
+		t.Errorf("expected an expired token not to be used; got %v", err)
+	}
+	if _, err := store.UseJoinToken(t.Context(), limited.ID, []byte("rotated"), now); err != nil {
+		t.Errorf("expected rotating to reset the uses; got %v", err)
+	}
+
+	revokedAt := now.Add(time.Minute)
+	if err := store.RevokeJoinToken(t.Context(), open.ID, revokedAt); err != nil {
+		t.Fatalf("failed to revoke join token: %v", err)
+	}
+	if err := store.RevokeJoinToken(t.Context(), open.ID, revokedAt.Add(time.Hour)); err != nil {
+		t.Fatalf("failed to revoke join token again: %v", err)
+	}
+	found, err := store.GetJoinTokenByID(t.Context(), open.ID)
+	if err != nil {
+		t.Fatalf("failed to get join token: %v", err)
+	}
+	if found.RevokedAt == nil || !found.RevokedAt.Equal(revokedAt) || found.Uses != 1 {
+		t.Errorf("expected the token to stay revoked at %v; got %+v", revokedAt, found)
+	}
+	if _, err := store.UseJoinToken(t.Context(), open.ID, open.SecretHash, now); !errors.Is(err, server.ErrNotFound) {
+		t.Errorf("expected a revoked token not to be used; got %v", err)
+	}
+	if err := store.RotateJoinToken(t.Context(), open.ID, []byte("rotated")); !errors.Is(err, server.ErrNotFound) {
+		t.Errorf("expected a revoked token not to be rotated; got %v", err)
+	}
+
+	missing := uuid.Must(uuid.NewV7())
+	if _, err := store.GetJoinTokenByID(t.Context(), missing); !errors.Is(err, server.ErrNotFound) {
+		t.Errorf("expected an unknown join token not to be found; got %v", err)
+	}
+	if err := store.RevokeJoinToken(t.Context(), missing, revokedAt); !errors.Is(err, server.ErrNotFound) {
+		t.Errorf("expected revoking an unknown join token to fail; got %v", err)
+	}
+}
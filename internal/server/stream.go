@@ -0,0 +1,453 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/terrabitz/rpg-audio-streamer/internal/auth"
+)
+
+const (
+	streamPathPrefix = "/api/v1/stream/"
+	playlistFileName = "index.m3u8"
+	audioFileName    = "audio.m4a"
+
+	expiresParam   = "expires"
+	signatureParam = "signature"
+	// versionParam changes whenever a track's audio is replaced, so cached
+	// segments of the old audio are never reused.
+	versionParam = "v"
+)
+
+// streamFilePattern matches the only files ffmpeg produces for a track, so
+// nothing else that ends up in a track directory is ever served.
+var streamFilePattern = regexp.MustCompile(`^(index\.m3u8|segment_\d{3,}\.ts)$`)
+
+// streamAuthMiddleware lets requests through either with a valid URL
+// signature for the requested track, or with the usual credentials. Signed
+// requests get a token with the role the signature was issued to, so players'
+// URLs still only work for tracks the GM has released.
+func (s *Server) streamAuthMiddleware(next AuthedHandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sig, ok := urlSignatureFromQuery(r.URL.Query())
+		if !ok {
+			s.authMiddleware(next)(w, r)
+			return
+		}
+
+		trackID := r.PathValue("trackID")
+		for _, role := range []auth.Role{auth.RolePlayer, auth.RoleGM} {
+			if err := s.auth.ValidateURLSignature(streamResource(trackID, role), sig); err == nil {
+				next(w, r, &auth.Token{Role: role, Subject: signedURLSubject})
+				return
+			}
+		}
+
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	}
+}
+
+// signedURLSubject stands in for the subject of requests authenticated by a
+// URL signature alone.
+const signedURLSubject = "signed-url"
+
+// streamResource is what a track's stream URLs are signed for. URLs issued to
+// GMs are signed separately, since only GMs may hear tracks that haven't been
+// released.
+func streamResource(trackID string, role auth.Role) string {
+	if role == auth.RoleGM {
+		return trackID + "/gm"
+	}
+	return trackID
+}
+
+func (s *Server) streamDirectory(w http.ResponseWriter, r *http.Request, token *auth.Token) {
+	fileName := r.PathValue("file")
+	if !streamFilePattern.MatchString(fileName) {
+		http.Error(w, "File not found", http.StatusNotFound)
+		return
+	}
+
+	trackID, err := uuid.Parse(r.PathValue("trackID"))
+	if err != nil {
+		http.Error(w, "Invalid track ID", http.StatusBadRequest)
+		return
+	}
+
+	track, err := s.store.GetTrackByID(r.Context(), trackID)
+	if err != nil {
+		s.respondStoreError(w, err, "Track not found", "Failed to get track")
+		return
+	}
+
+	if !s.canHear(token, trackID) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	if fileName == playlistFileName {
+		s.serveSignedPlaylist(w, r, track, token)
+		return
+	}
+
+	segment, err := s.openTrackFile(track, fileName)
+	if err != nil {
+		http.Error(w, "File not found", http.StatusNotFound)
+		return
+	}
+	defer segment.Close()
+
+	info, err := segment.Stat()
+	if err != nil {
+		http.Error(w, "Failed to read segment", http.StatusInternalServerError)
+		return
+	}
+
+	// Segments never change once written, and their URLs change with the
+	// audio's version, so let players keep what they've prefetched.
+	w.Header().Set("Cache-Control", "private, max-age=3600, immutable")
+	http.ServeContent(w, r, fileName, info.ModTime(), segment)
+}
+
+func (s *Server) canHear(token *auth.Token, trackID uuid.UUID) bool {
+	return token.Role == auth.RoleGM || s.hub.IsTrackReleased(trackID)
+}
+
+// serveSignedPlaylist rewrites every segment URI in the playlist so that it
+// carries a URL signature, letting players fetch segments without sending
+// their credentials on every request.
+func (s *Server) serveSignedPlaylist(w http.ResponseWriter, r *http.Request, track Track, token *auth.Token) {
+	playlist, err := s.openTrackFile(track, playlistFileName)
+	if err != nil {
+		http.Error(w, "File not found", http.StatusNotFound)
+		return
+	}
+	defer playlist.Close()
+
+	version, err := mediaVersion(playlist)
+	if err != nil {
+		s.logger.Error("failed to read playlist", "error", err, "trackID", track.ID)
+		http.Error(w, "Failed to read playlist", http.StatusInternalServerError)
+		return
+	}
+
+	// Reuse an incoming signature rather than minting a new one; otherwise a
+	// signed URL could be refreshed indefinitely by re-fetching the playlist.
+	sig, ok := urlSignatureFromQuery(r.URL.Query())
+	if !ok {
+		sig = s.auth.SignURL(streamResource(track.ID.String(), token.Role))
+	}
+	query := urlSignatureQuery(sig)
+	query.Set(versionParam, version)
+	segmentQuery := query.Encode()
+
+	var out strings.Builder
+	scanner := bufio.NewScanner(playlist)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line != "" && !strings.HasPrefix(line, "#") {
+			line += "?" + segmentQuery
+		}
+		out.WriteString(line)
+		out.WriteString("\n")
+	}
+	if err := scanner.Err(); err != nil {
+		s.logger.Error("failed to read playlist", "error", err, "trackID", track.ID)
+		http.Error(w, "Failed to read playlist", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.apple.mpegurl")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(out.String()))
+}
+
+// handleTrackAudio serves a track as a single seekable file for clients that
+// can't play HLS. The file is remuxed from the HLS segments on first request
+// and cached next to them.
+func (s *Server) handleTrackAudio(w http.ResponseWriter, r *http.Request, token *auth.Token) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	trackID, err := uuid.Parse(r.PathValue("trackID"))
+	if err != nil {
+		http.Error(w, "Invalid track ID", http.StatusBadRequest)
+		return
+	}
+
+	track, err := s.store.GetTrackByID(r.Context(), trackID)
+	if err != nil {
+		s.respondStoreError(w, err, "Track not found", "Failed to get track")
+		return
+	}
+
+	if !s.canHear(token, trackID) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	if err := s.ensureRemuxedAudio(track); err != nil {
+		s.logger.Error("failed to remux track audio", "error", err, "trackID", trackID)
+		http.Error(w, "Failed to prepare audio", http.StatusInternalServerError)
+		return
+	}
+
+	audio, err := s.openTrackFile(track, audioFileName)
+	if err != nil {
+		http.Error(w, "File not found", http.StatusNotFound)
+		return
+	}
+	defer audio.Close()
+
+	info, err := audio.Stat()
+	if err != nil {
+		http.Error(w, "Failed to read audio", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "audio/mp4")
+	http.ServeContent(w, r, audioFileName, info.ModTime(), audio)
+}
+
+// ensureRemuxedAudio makes sure the track's audio has been remuxed into a
+// single file. Cached files are found without taking any lock; otherwise
+// requests for the same track share one ffmpeg run, and other tracks and
+// HLS reads carry on while it runs.
+func (s *Server) ensureRemuxedAudio(track Track) error {
+	audioPath := filepath.Join(track.Path, audioFileName)
+	if _, err := os.Stat(audioPath); err == nil {
+		return nil
+	}
+
+	_, err, _ := s.remuxes.Do(track.ID.String(), func() (any, error) {
+		return nil, s.remuxAudio(track)
+	})
+	return err
+}
+
+func (s *Server) remuxAudio(track Track) error {
+	audioPath := filepath.Join(track.Path, audioFileName)
+	if _, err := os.Stat(audioPath); err == nil {
+		return nil
+	}
+
+	// The track's media may be replaced while ffmpeg runs, so the directory
+	// is checked again before the result is moved into place.
+	before, err := os.Stat(track.Path)
+	if err != nil {
+		return fmt.Errorf("couldn't read track media: %w", err)
+	}
+
+	tmpPath := audioPath + ".tmp"
+	cmd := exec.Command("ffmpeg",
+		"-v", "error",
+		"-y",
+		"-i", filepath.Join(track.Path, playlistFileName),
+		"-vn",
+		"-c:a", "copy",
+		"-bsf:a", "aac_adtstoasc",
+		"-movflags", "+faststart",
+		"-f", "mp4",
+		tmpPath)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("ffmpeg failed: %w: %s", err, stderr.String())
+	}
+
+	s.mediaMu.RLock()
+	defer s.mediaMu.RUnlock()
+
+	after, err := os.Stat(track.Path)
+	if err != nil || !os.SameFile(before, after) {
+		// The unfinished file moved with the old media and goes when it does.
+		return errors.New("track media was replaced while remuxing")
+	}
+	if err := os.Rename(tmpPath, audioPath); err != nil {
+		return fmt.Errorf("couldn't move remuxed audio into place: %w", err)
+	}
+
+	return nil
+}
+
+const (
+	defaultPrefetchSegments = 2
+	maxPrefetchSegments     = 10
+)
+
+type prefetchManifestEntry struct {
+	TrackID     uuid.UUID `json:"trackID"`
+	PlaylistURL string    `json:"playlistURL"`
+	SegmentURLs []string  `json:"segmentURLs"`
+}
+
+// handlePrefetchManifest lists signed URLs for the playlist and first few
+// segments of each requested track, so players can warm their caches before
+// the GM hits play. Tracks the requester isn't allowed to hear are left out.
+func (s *Server) handlePrefetchManifest(w http.ResponseWriter, r *http.Request, token *auth.Token) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	segmentCount := defaultPrefetchSegments
+	if segmentsParam := r.URL.Query().Get("segments"); segmentsParam != "" {
+		n, err := strconv.Atoi(segmentsParam)
+		if err != nil || n < 0 || n > maxPrefetchSegments {
+			http.Error(w, "Invalid segment count", http.StatusBadRequest)
+			return
+		}
+		segmentCount = n
+	}
+
+	manifest := []prefetchManifestEntry{}
+	for _, trackIDString := range r.URL.Query()["trackID"] {
+		trackID, err := uuid.Parse(trackIDString)
+		if err != nil {
+			http.Error(w, "Invalid track ID", http.StatusBadRequest)
+			return
+		}
+
+		if !s.canHear(token, trackID) {
+			continue
+		}
+
+		track, err := s.store.GetTrackByID(r.Context(), trackID)
+		if err != nil {
+			continue
+		}
+
+		segments, version, err := s.playlistSegments(track, segmentCount)
+		if err != nil {
+			s.logger.Warn("failed to read playlist for prefetch", "error", err, "trackID", trackID)
+			continue
+		}
+
+		query := urlSignatureQuery(s.auth.SignURL(streamResource(trackID.String(), token.Role)))
+		entry := prefetchManifestEntry{
+			TrackID:     trackID,
+			PlaylistURL: streamPathPrefix + trackID.String() + "/" + playlistFileName + "?" + query.Encode(),
+			SegmentURLs: []string{},
+		}
+		// Matches the segment URLs in the signed playlist, so players hit
+		// what was prefetched
+		query.Set(versionParam, version)
+		for _, segment := range segments {
+			entry.SegmentURLs = append(entry.SegmentURLs, streamPathPrefix+trackID.String()+"/"+segment+"?"+query.Encode())
+		}
+		manifest = append(manifest, entry)
+	}
+
+	respondJSON(w, http.StatusOK, manifest)
+}
+
+// playlistSegments returns up to limit segment URIs from a track's HLS
+// playlist, along with the version of its audio.
+func (s *Server) playlistSegments(track Track, limit int) ([]string, string, error) {
+	playlist, err := s.openTrackFile(track, playlistFileName)
+	if err != nil {
+		return nil, "", err
+	}
+	defer playlist.Close()
+
+	version, err := mediaVersion(playlist)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var segments []string
+	scanner := bufio.NewScanner(playlist)
+	for scanner.Scan() && len(segments) < limit {
+		line := scanner.Text()
+		if line != "" && !strings.HasPrefix(line, "#") {
+			segments = append(segments, line)
+		}
+	}
+
+	return segments, version, scanner.Err()
+}
+
+// mediaVersion identifies a version of a track's audio by when its playlist
+// was written. Each replacement writes a new playlist.
+func mediaVersion(playlist *os.File) (string, error) {
+	info, err := playlist.Stat()
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(info.ModTime().UnixNano(), 36), nil
+}
+
+type streamURLResponse struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func (s *Server) handleGetStreamURL(w http.ResponseWriter, r *http.Request, token *auth.Token) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	trackID, err := uuid.Parse(r.PathValue("trackID"))
+	if err != nil {
+		http.Error(w, "Invalid track ID", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := s.store.GetTrackByID(r.Context(), trackID); err != nil {
+		s.respondStoreError(w, err, "Track not found", "Failed to get track")
+		return
+	}
+
+	if !s.canHear(token, trackID) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	sig := s.auth.SignURL(streamResource(trackID.String(), token.Role))
+	streamURL := url.URL{
+		Path:     streamPathPrefix + trackID.String() + "/" + playlistFileName,
+		RawQuery: urlSignatureQuery(sig).Encode(),
+	}
+
+	respondJSON(w, http.StatusOK, streamURLResponse{
+		URL:       streamURL.String(),
+		ExpiresAt: sig.ExpiresAt,
+	})
+}
+
+func urlSignatureFromQuery(query url.Values) (auth.URLSignature, bool) {
+	signature := query.Get(signatureParam)
+	expires, err := strconv.ParseInt(query.Get(expiresParam), 10, 64)
+	if signature == "" || err != nil {
+		return auth.URLSignature{}, false
+	}
+
+	return auth.URLSignature{
+		ExpiresAt: time.Unix(expires, 0),
+		Signature: signature,
+	}, true
+}
+
+func urlSignatureQuery(sig auth.URLSignature) url.Values {
+	return url.Values{
+		expiresParam:   {strconv.FormatInt(sig.ExpiresAt.Unix(), 10)},
+		signatureParam: {sig.Signature},
+	}
+}
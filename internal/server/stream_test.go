@@ -0,0 +1,465 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/terrabitz/rpg-audio-streamer/internal/auth"
+)
+
+const testPlaylist = `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-TARGETDURATION:6
+#EXT-X-PLAYLIST-TYPE:EVENT
+#EXTINF:6.000000,
+segment_000.ts
+#EXTINF:2.500000,
+segment_001.ts
+#EXT-X-ENDLIST
+`
+
+func addTestTrack(t *testing.T, ts *testServer, trackID uuid.UUID) {
+	t.Helper()
+
+	trackDir := filepath.Join(ts.tempDir, trackID.String())
+	if err := os.MkdirAll(trackDir, os.ModePerm); err != nil {
+		t.Fatalf("failed to create track dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(trackDir, playlistFileName), []byte(testPlaylist), 0644); err != nil {
+		t.Fatalf("failed to write playlist: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(trackDir, "segment_000.ts"), []byte("segment data"), 0644); err != nil {
+		t.Fatalf("failed to write segment: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(trackDir, "notes.txt"), []byte("not media"), 0644); err != nil {
+		t.Fatalf("failed to write non-media file: %v", err)
+	}
+
+	ts.store.(*MockTrackStore).tracks[trackID] = Track{
+		ID:   trackID,
+		Name: "Test Track",
+		Path: trackDir,
+	}
+}
+
+func TestStreamPlaylistSigning(t *testing.T) {
+	ts := setupTestServer(t)
+	defer ts.cleanup(t)
+
+	trackID := uuid.New()
+	addTestTrack(t, ts, trackID)
+
+	req := httptest.NewRequest(http.MethodGet, streamPathPrefix+trackID.String()+"/"+playlistFileName, nil)
+	addAuthCookie(req, ts.auth.(*mockAuth).token.String())
+	rec := httptest.NewRecorder()
+
+	ts.registerHandlers().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status OK; got %v", rec.Code)
+	}
+
+	var segments []string
+	for _, line := range strings.Split(rec.Body.String(), "\n") {
+		if line != "" && !strings.HasPrefix(line, "#") {
+			segments = append(segments, line)
+		}
+	}
+	if len(segments) != 2 {
+		t.Fatalf("expected 2 segments; got %d", len(segments))
+	}
+
+	for _, segment := range segments {
+		segmentURL, err := url.Parse(segment)
+		if err != nil {
+			t.Fatalf("failed to parse segment URI %q: %v", segment, err)
+		}
+		if got := segmentURL.Query().Get(signatureParam); got != "signed:"+streamResource(trackID.String(), auth.RoleGM) {
+			t.Errorf("expected segment %q to be signed for the GM to hear the track; got signature %q", segment, got)
+		}
+	}
+}
+
+func TestStreamSignedSegment(t *testing.T) {
+	ts := setupTestServer(t)
+	defer ts.cleanup(t)
+
+	trackID := uuid.New()
+	addTestTrack(t, ts, trackID)
+	segmentPath := streamPathPrefix + trackID.String() + "/segment_000.ts"
+
+	tests := []struct {
+		name       string
+		resource   string
+		withCookie bool
+		released   bool
+		wantStatus int
+	}{
+		{
+			name:       "GM signature",
+			resource:   streamResource(trackID.String(), auth.RoleGM),
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "player signature for a released track",
+			resource:   trackID.String(),
+			released:   true,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "player signature for an unreleased track",
+			resource:   trackID.String(),
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "signature for another track",
+			resource:   uuid.NewString(),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "no credentials",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "cookie without signature",
+			withCookie: true,
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts.hub.(*mockWSRegisterer).released[trackID] = tt.released
+
+			target := segmentPath
+			if tt.resource != "" {
+				target += "?" + urlSignatureQuery(ts.auth.SignURL(tt.resource)).Encode()
+			}
+
+			req := httptest.NewRequest(http.MethodGet, target, nil)
+			if tt.withCookie {
+				addAuthCookie(req, ts.auth.(*mockAuth).token.String())
+			}
+			rec := httptest.NewRecorder()
+
+			ts.registerHandlers().ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("expected status %v; got %v", tt.wantStatus, rec.Code)
+			}
+		})
+	}
+}
+
+func TestGetStreamURL(t *testing.T) {
+	ts := setupTestServer(t)
+	defer ts.cleanup(t)
+
+	trackID := uuid.New()
+	addTestTrack(t, ts, trackID)
+	ts.hub.(*mockWSRegisterer).released[trackID] = true
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/streamURL/"+trackID.String(), nil)
+	req.SetPathValue("trackID", trackID.String())
+	rec := httptest.NewRecorder()
+
+	ts.handleGetStreamURL(rec, req, &auth.Token{Role: auth.RolePlayer})
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status OK; got %v", rec.Code)
+	}
+
+	var resp streamURLResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	streamURL, err := url.Parse(resp.URL)
+	if err != nil {
+		t.Fatalf("failed to parse stream URL: %v", err)
+	}
+	if want := streamPathPrefix + trackID.String() + "/" + playlistFileName; streamURL.Path != want {
+		t.Errorf("expected path %q; got %q", want, streamURL.Path)
+	}
+	if _, ok := urlSignatureFromQuery(streamURL.Query()); !ok {
+		t.Errorf("expected stream URL %q to carry a signature", resp.URL)
+	}
+
+	missingID := uuid.NewString()
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/streamURL/"+missingID, nil)
+	req.SetPathValue("trackID", missingID)
+	rec = httptest.NewRecorder()
+
+	ts.handleGetStreamURL(rec, req, &auth.Token{Role: auth.RolePlayer})
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected status NotFound; got %v", rec.Code)
+	}
+}
+
+func TestStreamAuthorization(t *testing.T) {
+	ts := setupTestServer(t)
+	defer ts.cleanup(t)
+
+	releasedID := uuid.New()
+	addTestTrack(t, ts, releasedID)
+	ts.hub.(*mockWSRegisterer).released[releasedID] = true
+
+	unreleasedID := uuid.New()
+	addTestTrack(t, ts, unreleasedID)
+
+	tests := []struct {
+		name       string
+		role       auth.Role
+		path       string
+		wantStatus int
+	}{
+		{
+			name:       "player streams released track",
+			role:       auth.RolePlayer,
+			path:       releasedID.String() + "/segment_000.ts",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "player streams unreleased track",
+			role:       auth.RolePlayer,
+			path:       unreleasedID.String() + "/segment_000.ts",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "GM streams unreleased track",
+			role:       auth.RoleGM,
+			path:       unreleasedID.String() + "/segment_000.ts",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "unknown track",
+			role:       auth.RoleGM,
+			path:       uuid.NewString() + "/segment_000.ts",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "invalid track ID",
+			role:       auth.RoleGM,
+			path:       "not-a-uuid/segment_000.ts",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "non-media file",
+			role:       auth.RoleGM,
+			path:       releasedID.String() + "/notes.txt",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "path traversal",
+			role:       auth.RoleGM,
+			path:       releasedID.String() + "/..%2f..%2f" + unreleasedID.String() + "%2fsegment_000.ts",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts.auth.(*mockAuth).token = &auth.Token{Role: tt.role, Subject: "testuser"}
+
+			req := httptest.NewRequest(http.MethodGet, streamPathPrefix+tt.path, nil)
+			addAuthCookie(req, ts.auth.(*mockAuth).token.String())
+			rec := httptest.NewRecorder()
+
+			ts.registerHandlers().ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("expected status %v; got %v", tt.wantStatus, rec.Code)
+			}
+		})
+	}
+}
+
+func TestTrackAudio(t *testing.T) {
+	ts := setupTestServer(t)
+	defer ts.cleanup(t)
+
+	trackID := uuid.New()
+	addTestTrack(t, ts, trackID)
+	ts.hub.(*mockWSRegisterer).released[trackID] = true
+
+	// Pre-populate the cache so the test doesn't depend on ffmpeg.
+	audio := []byte("0123456789")
+	audioPath := filepath.Join(ts.tempDir, trackID.String(), audioFileName)
+	if err := os.WriteFile(audioPath, audio, 0644); err != nil {
+		t.Fatalf("failed to write cached audio: %v", err)
+	}
+
+	t.Run("full file", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/files/"+trackID.String()+"/audio", nil)
+		addAuthCookie(req, ts.auth.(*mockAuth).token.String())
+		rec := httptest.NewRecorder()
+
+		ts.registerHandlers().ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status OK; got %v", rec.Code)
+		}
+		if got := rec.Header().Get("Content-Type"); got != "audio/mp4" {
+			t.Errorf("expected Content-Type audio/mp4; got %q", got)
+		}
+		if got := rec.Header().Get("Accept-Ranges"); got != "bytes" {
+			t.Errorf("expected Accept-Ranges bytes; got %q", got)
+		}
+		if rec.Body.String() != string(audio) {
+			t.Errorf("expected full audio body; got %q", rec.Body.String())
+		}
+	})
+
+	t.Run("range request", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/files/"+trackID.String()+"/audio", nil)
+		req.Header.Set("Range", "bytes=2-5")
+		addAuthCookie(req, ts.auth.(*mockAuth).token.String())
+		rec := httptest.NewRecorder()
+
+		ts.registerHandlers().ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusPartialContent {
+			t.Fatalf("expected status PartialContent; got %v", rec.Code)
+		}
+		if got := rec.Header().Get("Content-Range"); got != "bytes 2-5/10" {
+			t.Errorf("expected Content-Range bytes 2-5/10; got %q", got)
+		}
+		if rec.Body.String() != "2345" {
+			t.Errorf("expected partial body %q; got %q", "2345", rec.Body.String())
+		}
+	})
+
+	t.Run("unknown track", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/files/"+uuid.NewString()+"/audio", nil)
+		addAuthCookie(req, ts.auth.(*mockAuth).token.String())
+		rec := httptest.NewRecorder()
+
+		ts.registerHandlers().ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("expected status NotFound; got %v", rec.Code)
+		}
+	})
+}
+
+func TestRemuxedAudio(t *testing.T) {
+	ts := setupTestServer(t)
+	defer ts.cleanup(t)
+
+	trackID := uuid.New()
+	addTestTrack(t, ts, trackID)
+	track := ts.store.(*MockTrackStore).tracks[trackID]
+
+	t.Run("concurrent requests share one remux", func(t *testing.T) {
+		var wg sync.WaitGroup
+		errs := make(chan error, 5)
+		for range 5 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- ts.ensureRemuxedAudio(track)
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			if err != nil {
+				t.Errorf("expected remux to succeed; got %v", err)
+			}
+		}
+		if _, err := os.Stat(filepath.Join(track.Path, audioFileName)); err != nil {
+			t.Errorf("expected remuxed audio to be cached: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(track.Path, audioFileName+".tmp")); !os.IsNotExist(err) {
+			t.Errorf("expected temporary file to be gone; got %v", err)
+		}
+	})
+
+	t.Run("cached audio doesn't wait for a media swap", func(t *testing.T) {
+		ts.mediaMu.Lock()
+		defer ts.mediaMu.Unlock()
+
+		done := make(chan error, 1)
+		go func() { done <- ts.ensureRemuxedAudio(track) }()
+
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("expected cached audio to be found; got %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Error("expected cached audio to be found without taking the media lock")
+		}
+	})
+}
+
+func TestPrefetchManifest(t *testing.T) {
+	ts := setupTestServer(t)
+	defer ts.cleanup(t)
+
+	releasedID := uuid.New()
+	addTestTrack(t, ts, releasedID)
+	ts.hub.(*mockWSRegisterer).released[releasedID] = true
+
+	unreleasedID := uuid.New()
+	addTestTrack(t, ts, unreleasedID)
+
+	query := url.Values{"trackID": {releasedID.String(), unreleasedID.String()}, "segments": {"1"}}
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/prefetch?"+query.Encode(), nil)
+	rec := httptest.NewRecorder()
+
+	ts.handlePrefetchManifest(rec, req, &auth.Token{Role: auth.RolePlayer})
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status OK; got %v", rec.Code)
+	}
+
+	var manifest []prefetchManifestEntry
+	if err := json.NewDecoder(rec.Body).Decode(&manifest); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if len(manifest) != 1 {
+		t.Fatalf("expected only the released track in the manifest; got %d entries", len(manifest))
+	}
+	if manifest[0].TrackID != releasedID {
+		t.Errorf("expected track %s; got %s", releasedID, manifest[0].TrackID)
+	}
+	if len(manifest[0].SegmentURLs) != 1 {
+		t.Fatalf("expected 1 segment URL; got %d", len(manifest[0].SegmentURLs))
+	}
+
+	segmentURL, err := url.Parse(manifest[0].SegmentURLs[0])
+	if err != nil {
+		t.Fatalf("failed to parse segment URL: %v", err)
+	}
+	if want := streamPathPrefix + releasedID.String() + "/segment_000.ts"; segmentURL.Path != want {
+		t.Errorf("expected segment path %q; got %q", want, segmentURL.Path)
+	}
+	if _, ok := urlSignatureFromQuery(segmentURL.Query()); !ok {
+		t.Error("expected segment URL to be signed")
+	}
+	if segmentURL.Query().Get(versionParam) == "" {
+		t.Error("expected segment URL to carry the audio version")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/prefetch?segments=100", nil)
+	rec = httptest.NewRecorder()
+
+	ts.handlePrefetchManifest(rec, req, &auth.Token{Role: auth.RolePlayer})
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status BadRequest; got %v", rec.Code)
+	}
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/terrabitz/rpg-audio-streamer/internal/auth"
+)
+
+const maxTagLength = 64
+
+func (s *Server) handleTags(w http.ResponseWriter, r *http.Request, token *auth.Token) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	tags, err := s.store.GetTags(r.Context())
+	if err != nil {
+		s.logger.Error("failed to get tags", "error", err)
+		http.Error(w, "Failed to retrieve tags", http.StatusInternalServerError)
+		return
+	}
+
+	if tags == nil {
+		tags = []string{}
+	}
+
+	respondJSON(w, http.StatusOK, tags)
+}
+
+// normalizeTag puts a tag in the canonical form it's stored and matched in.
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.Join(strings.Fields(tag), " "))
+}
+
+// normalizeTags normalizes and de-duplicates the tags for a track, rejecting
+// any that are empty or too long.
+func normalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	result := make([]string, 0, len(tags))
+
+	for _, tag := range tags {
+		tag = normalizeTag(tag)
+		if tag == "" {
+			return nil, fmt.Errorf("tags must not be empty")
+		}
+		if utf8.RuneCountInString(tag) > maxTagLength {
+			return nil, fmt.Errorf("tags must be at most %d characters", maxTagLength)
+		}
+
+		if !seen[tag] {
+			seen[tag] = true
+			result = append(result, tag)
+		}
+	}
+
+	return result, nil
+}
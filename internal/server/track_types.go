@@ -0,0 +1,328 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/terrabitz/rpg-audio-streamer/internal/auth"
+)
+
+const (
+	maxTrackTypeNameLength = 50
+	maxFadeMs              = 60_000
+)
+
+var hexColorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// TrackTypeRequest creates or updates a track type. The behaviour settings
+// are optional: omitted ones take their defaults on creation and are left
+// unchanged on update.
+type TrackTypeRequest struct {
+	Name                     string `json:"name"`
+	Color                    string `json:"color"`
+	IsRepeating              *bool  `json:"isRepeating"`
+	AllowSimultaneousPlay    *bool  `json:"allowSimultaneousPlay"`
+	DefaultVolume            *int   `json:"defaultVolume"`
+	FadeInMs                 *int   `json:"fadeInMs"`
+	FadeOutMs                *int   `json:"fadeOutMs"`
+	AlwaysStartFromBeginning *bool  `json:"alwaysStartFromBeginning"`
+	MaxConcurrent            *int   `json:"maxConcurrent"`
+	AutoStopOthers           *bool  `json:"autoStopOthers"`
+}
+
+// apply copies the request onto a track type.
+func (req TrackTypeRequest) apply(trackType *TrackType) {
+	trackType.Name = req.Name
+	trackType.Color = req.Color
+	if req.IsRepeating != nil {
+		trackType.IsRepeating = *req.IsRepeating
+	}
+	if req.AllowSimultaneousPlay != nil {
+		if *req.AllowSimultaneousPlay && !trackType.AllowSimultaneousPlay {
+			// The limit of one came from layering being off, so it goes
+			// with it unless the request sets another.
+			trackType.MaxConcurrent = 0
+		}
+		trackType.AllowSimultaneousPlay = *req.AllowSimultaneousPlay
+	}
+	if req.DefaultVolume != nil {
+		trackType.DefaultVolume = *req.DefaultVolume
+	}
+	if req.FadeInMs != nil {
+		trackType.FadeInMs = *req.FadeInMs
+	}
+	if req.FadeOutMs != nil {
+		trackType.FadeOutMs = *req.FadeOutMs
+	}
+	if req.AlwaysStartFromBeginning != nil {
+		trackType.AlwaysStartFromBeginning = *req.AlwaysStartFromBeginning
+	}
+	if req.MaxConcurrent != nil {
+		trackType.MaxConcurrent = *req.MaxConcurrent
+	}
+	if req.AutoStopOthers != nil {
+		trackType.AutoStopOthers = *req.AutoStopOthers
+	}
+
+	// allowSimultaneousPlay predates maxConcurrent; turning it off is the same
+	// as a limit of one.
+	if !trackType.AllowSimultaneousPlay {
+		trackType.MaxConcurrent = 1
+	}
+}
+
+func (s *Server) handleTrackTypes(w http.ResponseWriter, r *http.Request, token *auth.Token) {
+	switch r.Method {
+	case http.MethodGet:
+		s.listTrackTypes(w, r)
+	case http.MethodPost:
+		// Players need to read track types, so only creating them is limited
+		// to GMs here rather than in the middleware.
+		if token.Role != auth.RoleGM {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		s.createTrackType(w, r, token)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleTrackType(w http.ResponseWriter, r *http.Request, token *auth.Token) {
+	switch r.Method {
+	case http.MethodPut:
+		s.updateTrackType(w, r, token)
+	case http.MethodDelete:
+		s.deleteTrackType(w, r, token)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) listTrackTypes(w http.ResponseWriter, r *http.Request) {
+	trackTypes, err := s.store.GetTrackTypes(r.Context())
+	if err != nil {
+		s.logger.Error("failed to get track types", "error", err)
+		http.Error(w, "Failed to retrieve track types", http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, trackTypes)
+}
+
+func (s *Server) createTrackType(w http.ResponseWriter, r *http.Request, token *auth.Token) {
+	var req TrackTypeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	trackTypes, err := s.store.GetTrackTypes(r.Context())
+	if err != nil {
+		s.logger.Error("failed to get track types", "error", err)
+		http.Error(w, "Failed to create track type", http.StatusInternalServerError)
+		return
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		s.logger.Error("failed to generate track type ID", "error", err)
+		http.Error(w, "Failed to create track type", http.StatusInternalServerError)
+		return
+	}
+
+	if !checkTrackTypeRequest(w, trackTypes, id, &req) {
+		return
+	}
+
+	trackType := TrackType{
+		ID:            id,
+		DefaultVolume: 100,
+		FadeInMs:      2000,
+		FadeOutMs:     2000,
+	}
+	req.apply(&trackType)
+
+	if err := s.store.SaveTrackType(r.Context(), trackType); err != nil {
+		s.respondStoreError(w, err, "Track type not found", "Failed to create track type")
+		return
+	}
+	s.audit(r, tokenActor(token), AuditTrackTypeCreate, id.String(), nil, trackType)
+	s.broadcastTrackTypes(r.Context())
+
+	respondJSON(w, http.StatusOK, trackType)
+}
+
+func (s *Server) updateTrackType(w http.ResponseWriter, r *http.Request, token *auth.Token) {
+	id, err := uuid.Parse(r.PathValue("typeID"))
+	if err != nil {
+		http.Error(w, "Invalid track type ID", http.StatusBadRequest)
+		return
+	}
+
+	var req TrackTypeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	trackTypes, err := s.store.GetTrackTypes(r.Context())
+	if err != nil {
+		s.logger.Error("failed to get track types", "error", err)
+		http.Error(w, "Failed to update track type", http.StatusInternalServerError)
+		return
+	}
+
+	trackType, ok := findTrackType(trackTypes, id)
+	if !ok {
+		http.Error(w, "Track type not found", http.StatusNotFound)
+		return
+	}
+
+	if !checkTrackTypeRequest(w, trackTypes, id, &req) {
+		return
+	}
+
+	before := trackType
+	req.apply(&trackType)
+	if err := s.store.UpdateTrackType(r.Context(), trackType); err != nil {
+		s.respondStoreError(w, err, "Track type not found", "Failed to update track type")
+		return
+	}
+	s.audit(r, tokenActor(token), AuditTrackTypeUpdate, id.String(), before, trackType)
+	s.broadcastTrackTypes(r.Context())
+
+	respondJSON(w, http.StatusOK, trackType)
+}
+
+func (s *Server) deleteTrackType(w http.ResponseWriter, r *http.Request, token *auth.Token) {
+	id, err := uuid.Parse(r.PathValue("typeID"))
+	if err != nil {
+		http.Error(w, "Invalid track type ID", http.StatusBadRequest)
+		return
+	}
+
+	trackTypes, err := s.store.GetTrackTypes(r.Context())
+	if err != nil {
+		s.logger.Error("failed to get track types", "error", err)
+		http.Error(w, "Failed to delete track type", http.StatusInternalServerError)
+		return
+	}
+
+	trackType, ok := findTrackType(trackTypes, id)
+	if !ok {
+		http.Error(w, "Track type not found", http.StatusNotFound)
+		return
+	}
+
+	var reassignTo *uuid.UUID
+	if reassignStr := r.URL.Query().Get("reassignTo"); reassignStr != "" {
+		target, err := uuid.Parse(reassignStr)
+		if err != nil {
+			http.Error(w, "Invalid reassignTo track type ID", http.StatusBadRequest)
+			return
+		}
+		if target == id {
+			http.Error(w, "Tracks can't be reassigned to the type being deleted", http.StatusBadRequest)
+			return
+		}
+		if _, ok := findTrackType(trackTypes, target); !ok {
+			http.Error(w, "Track type to reassign tracks to not found", http.StatusBadRequest)
+			return
+		}
+		reassignTo = &target
+	}
+
+	if err := s.store.DeleteTrackType(r.Context(), id, reassignTo); err != nil {
+		if errors.Is(err, ErrTrackTypeInUse) {
+			http.Error(w, "Track type is still used by tracks; set reassignTo to move them to another type", http.StatusConflict)
+			return
+		}
+		s.respondStoreError(w, err, "Track type not found", "Failed to delete track type")
+		return
+	}
+	var after any
+	if reassignTo != nil {
+		after = map[string]any{"reassignTo": reassignTo}
+	}
+	s.audit(r, tokenActor(token), AuditTrackTypeDelete, id.String(), trackType, after)
+	s.broadcastTrackTypes(r.Context())
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Track type deleted successfully"))
+}
+
+// broadcastTrackTypes sends every client the current track types, so changes
+// to their playback rules apply straight away.
+func (s *Server) broadcastTrackTypes(ctx context.Context) {
+	trackTypes, err := s.store.GetTrackTypes(ctx)
+	if err != nil {
+		s.logger.Error("failed to get track types", "error", err)
+		return
+	}
+
+	if err := s.hub.NotifyAll("trackTypes", trackTypes); err != nil {
+		s.logger.Error("failed to broadcast track types", "error", err)
+	}
+}
+
+// checkTrackTypeRequest validates a new or changed track type against the
+// existing ones, normalizing its name and color. It writes an error response
+// and returns false if the request is invalid.
+func checkTrackTypeRequest(w http.ResponseWriter, trackTypes []TrackType, id uuid.UUID, req *TrackTypeRequest) bool {
+	req.Name = strings.Join(strings.Fields(req.Name), " ")
+	if req.Name == "" {
+		http.Error(w, "Track type name must not be empty", http.StatusBadRequest)
+		return false
+	}
+	if utf8.RuneCountInString(req.Name) > maxTrackTypeNameLength {
+		http.Error(w, fmt.Sprintf("Track type name must be at most %d characters", maxTrackTypeNameLength), http.StatusBadRequest)
+		return false
+	}
+
+	if !hexColorPattern.MatchString(req.Color) {
+		http.Error(w, "Color must be a hex color like #A5D6A7", http.StatusBadRequest)
+		return false
+	}
+	req.Color = strings.ToUpper(req.Color)
+
+	if req.DefaultVolume != nil && (*req.DefaultVolume < 0 || *req.DefaultVolume > 100) {
+		http.Error(w, "Default volume must be between 0 and 100", http.StatusBadRequest)
+		return false
+	}
+	for _, fade := range []*int{req.FadeInMs, req.FadeOutMs} {
+		if fade != nil && (*fade < 0 || *fade > maxFadeMs) {
+			http.Error(w, fmt.Sprintf("Fade durations must be between 0 and %d ms", maxFadeMs), http.StatusBadRequest)
+			return false
+		}
+	}
+	if req.MaxConcurrent != nil && *req.MaxConcurrent < 0 {
+		http.Error(w, "Max concurrent tracks must not be negative", http.StatusBadRequest)
+		return false
+	}
+
+	for _, t := range trackTypes {
+		if t.ID != id && strings.EqualFold(t.Name, req.Name) {
+			http.Error(w, "A track type with that name already exists", http.StatusConflict)
+			return false
+		}
+	}
+
+	return true
+}
+
+func findTrackType(trackTypes []TrackType, id uuid.UUID) (TrackType, bool) {
+	for _, t := range trackTypes {
+		if t.ID == id {
+			return t, true
+		}
+	}
+	return TrackType{}, false
+}
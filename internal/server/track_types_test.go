@@ -0,0 +1,188 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/terrabitz/rpg-audio-streamer/internal/auth"
+)
+
+func TestTrackTypeCRUD(t *testing.T) {
+	ts := setupTestServer(t)
+	defer ts.cleanup(t)
+
+	musicID := uuid.MustParse("1EC000A2-A7C9-11EE-A0E5-0242AC120003")
+
+	request := func(t *testing.T, method, target, body string, role auth.Role) *httptest.ResponseRecorder {
+		t.Helper()
+
+		req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+		rec := httptest.NewRecorder()
+		if path, _, _ := strings.Cut(target, "?"); strings.HasPrefix(path, "/api/v1/trackTypes/") {
+			req.SetPathValue("typeID", strings.TrimPrefix(path, "/api/v1/trackTypes/"))
+			ts.handleTrackType(rec, req, &auth.Token{Role: role})
+		} else {
+			ts.handleTrackTypes(rec, req, &auth.Token{Role: role})
+		}
+		return rec
+	}
+
+	rec := request(t, http.MethodPost, "/api/v1/trackTypes", `{"name": " Combat  Music ", "color": "#ff8a80", "isRepeating": true}`, auth.RoleGM)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status OK; got %v: %s", rec.Code, rec.Body)
+	}
+
+	var combat TrackType
+	if err := json.NewDecoder(rec.Body).Decode(&combat); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if combat.Name != "Combat Music" || combat.Color != "#FF8A80" {
+		t.Errorf("expected name and color to be normalized; got %q %q", combat.Name, combat.Color)
+	}
+
+	t.Run("Invalid track types", func(t *testing.T) {
+		tests := []struct {
+			name       string
+			role       auth.Role
+			body       string
+			wantStatus int
+		}{
+			{"player", auth.RolePlayer, `{"name": "Voice", "color": "#FFFFFF"}`, http.StatusUnauthorized},
+			{"bad color", auth.RoleGM, `{"name": "Voice", "color": "red"}`, http.StatusBadRequest},
+			{"empty name", auth.RoleGM, `{"name": " ", "color": "#FFFFFF"}`, http.StatusBadRequest},
+			{"duplicate name", auth.RoleGM, `{"name": "music", "color": "#FFFFFF"}`, http.StatusConflict},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				rec := request(t, http.MethodPost, "/api/v1/trackTypes", tt.body, tt.role)
+				if rec.Code != tt.wantStatus {
+					t.Errorf("expected status %v; got %v", tt.wantStatus, rec.Code)
+				}
+			})
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		rec := request(t, http.MethodPut, "/api/v1/trackTypes/"+combat.ID.String(),
+			`{"name": "Music", "color": "#FF8A80"}`, auth.RoleGM)
+		if rec.Code != http.StatusConflict {
+			t.Errorf("expected renaming to an existing name to conflict; got %v", rec.Code)
+		}
+
+		rec = request(t, http.MethodPut, "/api/v1/trackTypes/"+combat.ID.String(),
+			`{"name": "Battle Music", "color": "#FF8A80", "isRepeating": true}`, auth.RoleGM)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status OK; got %v: %s", rec.Code, rec.Body)
+		}
+		if got := ts.store.(*MockTrackStore).trackTypes[combat.ID].Name; got != "Battle Music" {
+			t.Errorf("expected track type to be renamed; got %q", got)
+		}
+	})
+
+	t.Run("Behaviour settings", func(t *testing.T) {
+		target := "/api/v1/trackTypes/" + combat.ID.String()
+
+		rec := request(t, http.MethodPut, target, `{"name": "Battle Music", "color": "#FF8A80", "defaultVolume": 150}`, auth.RoleGM)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected out of range volume to be rejected; got %v", rec.Code)
+		}
+
+		rec = request(t, http.MethodPut, target,
+			`{"name": "Battle Music", "color": "#FF8A80", "fadeInMs": 500, "maxConcurrent": 3, "autoStopOthers": true}`, auth.RoleGM)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status OK; got %v: %s", rec.Code, rec.Body)
+		}
+
+		got := ts.store.(*MockTrackStore).trackTypes[combat.ID]
+		want := TrackType{
+			ID:             combat.ID,
+			Name:           "Battle Music",
+			Color:          "#FF8A80",
+			DefaultVolume:  100,
+			FadeInMs:       500,
+			FadeOutMs:      2000,
+			MaxConcurrent:  1, // allowSimultaneousPlay is off
+			AutoStopOthers: true,
+			// Omitted settings are left as they were
+			IsRepeating: true,
+		}
+		if got != want {
+			t.Errorf("expected %+v; got %+v", want, got)
+		}
+
+		notifications := ts.hub.(*mockWSRegisterer).notifications
+		last := notifications[len(notifications)-1]
+		if last.method != "trackTypes" || !last.toPlayers {
+			t.Errorf("expected track types to be broadcast to everyone; got %+v", last)
+		}
+	})
+
+	t.Run("Layering toggle", func(t *testing.T) {
+		target := "/api/v1/trackTypes/" + combat.ID.String()
+		trackType := func(t *testing.T, body string) TrackType {
+			t.Helper()
+
+			rec := request(t, http.MethodPut, target, body, auth.RoleGM)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected status OK; got %v: %s", rec.Code, rec.Body)
+			}
+			return ts.store.(*MockTrackStore).trackTypes[combat.ID]
+		}
+
+		got := trackType(t, `{"name": "Battle Music", "color": "#FF8A80", "allowSimultaneousPlay": true}`)
+		if !got.AllowSimultaneousPlay || got.MaxConcurrent != 0 {
+			t.Errorf("expected turning layering on to lift the limit; got %+v", got)
+		}
+
+		got = trackType(t, `{"name": "Battle Music", "color": "#FF8A80", "maxConcurrent": 2}`)
+		if !got.AllowSimultaneousPlay || got.MaxConcurrent != 2 {
+			t.Errorf("expected omitting allowSimultaneousPlay to keep layering on; got %+v", got)
+		}
+
+		got = trackType(t, `{"name": "Battle Music", "color": "#FF8A80", "allowSimultaneousPlay": false}`)
+		if got.AllowSimultaneousPlay || got.MaxConcurrent != 1 {
+			t.Errorf("expected turning layering off to limit the type to one track; got %+v", got)
+		}
+
+		got = trackType(t, `{"name": "Battle Music", "color": "#FF8A80", "allowSimultaneousPlay": true, "maxConcurrent": 3}`)
+		if !got.AllowSimultaneousPlay || got.MaxConcurrent != 3 {
+			t.Errorf("expected the limit given with layering to be kept; got %+v", got)
+		}
+	})
+
+	trackID := uuid.New()
+	ts.store.(*MockTrackStore).tracks[trackID] = Track{ID: trackID, Name: "Boss Fight", TypeID: combat.ID}
+
+	t.Run("Delete", func(t *testing.T) {
+		target := "/api/v1/trackTypes/" + combat.ID.String()
+
+		rec := request(t, http.MethodDelete, target, "", auth.RoleGM)
+		if rec.Code != http.StatusConflict {
+			t.Errorf("expected deleting a type in use to conflict; got %v", rec.Code)
+		}
+
+		rec = request(t, http.MethodDelete, target+"?reassignTo="+combat.ID.String(), "", auth.RoleGM)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected reassigning to the deleted type to fail; got %v", rec.Code)
+		}
+
+		rec = request(t, http.MethodDelete, target+"?reassignTo="+musicID.String(), "", auth.RoleGM)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status OK; got %v: %s", rec.Code, rec.Body)
+		}
+
+		store := ts.store.(*MockTrackStore)
+		if _, ok := store.trackTypes[combat.ID]; ok {
+			t.Error("expected track type to be deleted")
+		}
+		if got := store.tracks[trackID].TypeID; got != musicID {
+			t.Errorf("expected track to be moved to Music; got %v", got)
+		}
+	})
+}
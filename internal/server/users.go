@@ -0,0 +1,274 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/terrabitz/rpg-audio-streamer/internal/auth"
+)
+
+const (
+	maxUsernameLength = 50
+	minPasswordLength = 8
+)
+
+// UserRequest creates or updates a GM account. Omitted fields are left
+// unchanged on update; creating an account needs a username and password.
+type UserRequest struct {
+	Username *string `json:"username"`
+	Password *string `json:"password"`
+	Admin    *bool   `json:"admin"`
+}
+
+// login checks credentials against the root account from the config, then
+// the GM accounts. Tokens for accounts are issued to the account's ID, so
+// they still name the same GM after a rename.
+func (s *Server) login(ctx context.Context, creds auth.Credentials) (*auth.Token, error) {
+	token, err := s.auth.ValidateCredentials(creds)
+	if !errors.Is(err, auth.ErrInvalidCredentials) {
+		return token, err
+	}
+
+	user, err := s.store.GetUserByUsername(ctx, creds.Username)
+	if errors.Is(err, ErrNotFound) {
+		return nil, auth.ErrInvalidCredentials
+	}
+	if err != nil {
+		s.logger.Error("failed to get user", "error", err)
+		return nil, fmt.Errorf("couldn't get user: %w", err)
+	}
+
+	valid, err := auth.VerifyPassword(creds.Password, user.PasswordHash)
+	if err != nil {
+		s.logger.Error("failed to verify password", "error", err, "userID", user.ID)
+		return nil, fmt.Errorf("failed to verify password: %w", err)
+	}
+	if !valid {
+		return nil, auth.ErrInvalidCredentials
+	}
+
+	return s.auth.NewGMToken(user.ID.String(), user.Admin)
+}
+
+// checkUser refuses GM tokens whose account has been deleted, and takes the
+// admin flag from the account rather than the token, so demoting a GM takes
+// effect straight away. The root account isn't stored, so its tokens are
+// returned as they are. Any other subject, such as a root username that has
+// since been changed, is refused.
+func (s *Server) checkUser(ctx context.Context, token *auth.Token) (*auth.Token, error) {
+	if token.Role != auth.RoleGM || token.Subject == s.auth.RootUsername() {
+		return token, nil
+	}
+
+	id, err := uuid.Parse(token.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("GM token for unknown account '%s'", token.Subject)
+	}
+
+	user, err := s.store.GetUserByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get user: %w", err)
+	}
+
+	checked := *token
+	checked.Admin = user.Admin
+	return &checked, nil
+}
+
+func (s *Server) handleUsers(w http.ResponseWriter, r *http.Request, token *auth.Token) {
+	switch r.Method {
+	case http.MethodGet:
+		s.listUsers(w, r)
+	case http.MethodPost:
+		s.createUser(w, r, token)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleUser(w http.ResponseWriter, r *http.Request, token *auth.Token) {
+	id, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	user, err := s.store.GetUserByID(r.Context(), id)
+	if err != nil {
+		s.respondStoreError(w, err, "User not found", "Failed to retrieve user")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		respondJSON(w, http.StatusOK, user)
+	case http.MethodPut:
+		s.updateUser(w, r, token, user)
+	case http.MethodDelete:
+		if err := s.store.DeleteUser(r.Context(), id); err != nil {
+			s.respondStoreError(w, err, "User not found", "Failed to delete user")
+			return
+		}
+		s.audit(r, tokenActor(token), AuditUserDelete, id.String(), user, nil)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) listUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := s.store.GetUsers(r.Context())
+	if err != nil {
+		s.logger.Error("failed to get users", "error", err)
+		http.Error(w, "Failed to retrieve users", http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, users)
+}
+
+func (s *Server) createUser(w http.ResponseWriter, r *http.Request, token *auth.Token) {
+	var req UserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Username == nil || req.Password == nil {
+		http.Error(w, "A username and password are required", http.StatusBadRequest)
+		return
+	}
+
+	user, err := NewUser(*req.Username, *req.Password, req.Admin != nil && *req.Admin, s.auth.RootUsername())
+	var invalid invalidUserError
+	if errors.As(err, &invalid) {
+		http.Error(w, invalid.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		s.logger.Error("failed to create user", "error", err)
+		http.Error(w, "Failed to create user", http.StatusInternalServerError)
+		return
+	}
+
+	if err := s.store.SaveUser(r.Context(), user); err != nil {
+		s.respondStoreError(w, err, "User not found", "Failed to create user")
+		return
+	}
+	s.audit(r, tokenActor(token), AuditUserCreate, user.ID.String(), nil, user)
+
+	respondJSON(w, http.StatusOK, user)
+}
+
+func (s *Server) updateUser(w http.ResponseWriter, r *http.Request, token *auth.Token, user User) {
+	var req UserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	before := user
+	if !s.applyUserRequest(w, req, &user) {
+		return
+	}
+
+	if err := s.store.UpdateUser(r.Context(), user); err != nil {
+		s.respondStoreError(w, err, "User not found", "Failed to update user")
+		return
+	}
+	s.audit(r, tokenActor(token), AuditUserUpdate, user.ID.String(), before, user)
+
+	respondJSON(w, http.StatusOK, user)
+}
+
+// NewUser creates a GM account, checking the username and hashing the
+// password. The username can't be the root account's, which would shadow the
+// account when signing in.
+func NewUser(username, password string, admin bool, rootUsername string) (User, error) {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return User{}, fmt.Errorf("couldn't generate user ID: %w", err)
+	}
+
+	user := User{ID: id, Admin: admin, CreatedAt: time.Now().UTC().Truncate(time.Second)}
+	if user.Username, err = checkUsername(username, rootUsername); err != nil {
+		return User{}, err
+	}
+	if err := checkPassword(password); err != nil {
+		return User{}, err
+	}
+	if user.PasswordHash, err = auth.HashPassword(password); err != nil {
+		return User{}, fmt.Errorf("couldn't hash password: %w", err)
+	}
+
+	return user, nil
+}
+
+// applyUserRequest checks the request and copies it onto the user, hashing
+// any new password. It responds with an error and returns false if the
+// request is invalid.
+func (s *Server) applyUserRequest(w http.ResponseWriter, req UserRequest, user *User) bool {
+	if req.Username != nil {
+		username, err := checkUsername(*req.Username, s.auth.RootUsername())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return false
+		}
+		user.Username = username
+	}
+
+	if req.Password != nil {
+		if err := checkPassword(*req.Password); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return false
+		}
+
+		hash, err := auth.HashPassword(*req.Password)
+		if err != nil {
+			s.logger.Error("failed to hash password", "error", err)
+			http.Error(w, "Failed to save user", http.StatusInternalServerError)
+			return false
+		}
+		user.PasswordHash = hash
+	}
+
+	if req.Admin != nil {
+		user.Admin = *req.Admin
+	}
+
+	return true
+}
+
+// invalidUserError says why a username or password isn't allowed, in a form
+// fit to show whoever chose it.
+type invalidUserError string
+
+func (e invalidUserError) Error() string {
+	return string(e)
+}
+
+// checkUsername returns the username without surrounding space, or an
+// invalidUserError if it isn't allowed.
+func checkUsername(username, rootUsername string) (string, error) {
+	username = strings.TrimSpace(username)
+	if username == "" || utf8.RuneCountInString(username) > maxUsernameLength {
+		return "", invalidUserError(fmt.Sprintf("Username must be between 1 and %d characters", maxUsernameLength))
+	}
+	if strings.EqualFold(username, rootUsername) {
+		return "", invalidUserError("Username is taken by the root account")
+	}
+	return username, nil
+}
+
+func checkPassword(password string) error {
+	if utf8.RuneCountInString(password) < minPasswordLength {
+		return invalidUserError(fmt.Sprintf("Password must be at least %d characters", minPasswordLength))
+	}
+	return nil
+}
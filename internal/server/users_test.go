@@ -0,0 +1,206 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/terrabitz/rpg-audio-streamer/internal/auth"
+)
+
+func TestUserHandlers(t *testing.T) {
+	ts := setupTestServer(t)
+	defer ts.cleanup(t)
+
+	store := ts.store.(*MockTrackStore)
+	admin := &auth.Token{Role: auth.RoleGM, Admin: true, Subject: "admin"}
+
+	request := func(t *testing.T, method, userID, body string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		target := "/api/v1/users"
+		if userID != "" {
+			target += "/" + userID
+		}
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		if userID == "" {
+			ts.handleUsers(rec, req, admin)
+		} else {
+			req.SetPathValue("userID", userID)
+			ts.handleUser(rec, req, admin)
+		}
+		return rec
+	}
+
+	var user User
+	t.Run("create", func(t *testing.T) {
+		rec := request(t, http.MethodPost, "", `{"username":" Brenna ","password":"dragon-hoard"}`)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status OK; got %v: %s", rec.Code, rec.Body)
+		}
+		if strings.Contains(rec.Body.String(), "argon2id") {
+			t.Errorf("expected the password hash not to be returned; got %s", rec.Body)
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&user); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+
+		stored := store.users[user.ID]
+		if stored.Username != "Brenna" || stored.Admin {
+			t.Errorf("unexpected user %+v", stored)
+		}
+		if valid, err := auth.VerifyPassword("dragon-hoard", stored.PasswordHash); err != nil || !valid {
+			t.Errorf("expected the password to be hashed; got %q", stored.PasswordHash)
+		}
+		if entry := store.audit[len(store.audit)-1]; entry.Action != AuditUserCreate || bytes.Contains(entry.After, []byte("argon2id")) {
+			t.Errorf("unexpected audit entry %+v", entry)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, body := range []string{
+			`{"username":"Corin"}`,
+			`{"username":"  ","password":"dragon-hoard"}`,
+			`{"username":"Corin","password":"short"}`,
+			`{"username":"TestUser","password":"dragon-hoard"}`,
+		} {
+			if rec := request(t, http.MethodPost, "", body); rec.Code != http.StatusBadRequest {
+				t.Errorf("expected BadRequest for %s; got %v", body, rec.Code)
+			}
+		}
+
+		if rec := request(t, http.MethodPost, "", `{"username":"BRENNA","password":"dragon-hoard"}`); rec.Code != http.StatusConflict {
+			t.Errorf("expected Conflict for a taken username; got %v", rec.Code)
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		rec := request(t, http.MethodPut, user.ID.String(), `{"admin":true,"password":"new-dragon-hoard"}`)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status OK; got %v: %s", rec.Code, rec.Body)
+		}
+
+		stored := store.users[user.ID]
+		if stored.Username != "Brenna" || !stored.Admin {
+			t.Errorf("expected only the given fields to change; got %+v", stored)
+		}
+		if valid, _ := auth.VerifyPassword("new-dragon-hoard", stored.PasswordHash); !valid {
+			t.Error("expected the new password to be set")
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		store.users[uuid.New()] = User{Username: "aldric"}
+
+		var users []User
+		rec := request(t, http.MethodGet, "", "")
+		if err := json.NewDecoder(rec.Body).Decode(&users); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(users) != 2 || users[0].Username != "aldric" || users[1].Username != "Brenna" {
+			t.Errorf("expected users ordered by username; got %+v", users)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		if rec := request(t, http.MethodDelete, user.ID.String(), ""); rec.Code != http.StatusNoContent {
+			t.Fatalf("expected status NoContent; got %v", rec.Code)
+		}
+		if rec := request(t, http.MethodGet, user.ID.String(), ""); rec.Code != http.StatusNotFound {
+			t.Errorf("expected NotFound after deleting; got %v", rec.Code)
+		}
+	})
+}
+
+func TestUserLogin(t *testing.T) {
+	ts := setupTestServer(t)
+	defer ts.cleanup(t)
+
+	hash, err := auth.HashPassword("dragon-hoard")
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+	user := User{ID: uuid.New(), Username: "Brenna", PasswordHash: hash}
+	ts.store.(*MockTrackStore).users[user.ID] = user
+
+	tests := []struct {
+		name     string
+		username string
+		password string
+		subject  string
+	}{
+		{"root account", "testuser", "testpass", "testuser"},
+		{"user account", "brenna", "dragon-hoard", user.ID.String()},
+		{"wrong password", "Brenna", "testpass", "-"},
+		{"unknown user", "Corin", "dragon-hoard", "-"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := ts.login(t.Context(), auth.Credentials{Username: tt.username, Password: tt.password})
+			if tt.subject == "-" {
+				if err != auth.ErrInvalidCredentials {
+					t.Errorf("expected invalid credentials; got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to log in: %v", err)
+			}
+			if token.Subject != tt.subject || token.Role != auth.RoleGM {
+				t.Errorf("expected a GM token for %q; got %+v", tt.subject, token)
+			}
+		})
+	}
+}
+
+func TestUserTokens(t *testing.T) {
+	ts := setupTestServer(t)
+	defer ts.cleanup(t)
+
+	store := ts.store.(*MockTrackStore)
+	user := User{ID: uuid.New(), Username: "Brenna", Admin: true}
+	store.users[user.ID] = user
+
+	handler := ts.adminOnlyMiddleware(func(w http.ResponseWriter, r *http.Request, token *auth.Token) {
+		w.WriteHeader(http.StatusOK)
+	})
+	request := func(token *auth.Token) int {
+		ts.auth.(*mockAuth).token = token
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+		addAuthCookie(req, token.String())
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec.Code
+	}
+	token := &auth.Token{Role: auth.RoleGM, Admin: true, Subject: user.ID.String()}
+
+	if code := request(token); code != http.StatusOK {
+		t.Fatalf("expected an admin to be allowed; got %v", code)
+	}
+
+	user.Admin = false
+	store.users[user.ID] = user
+	if code := request(token); code != http.StatusForbidden {
+		t.Errorf("expected a demoted admin to be refused; got %v", code)
+	}
+
+	delete(store.users, user.ID)
+	if code := request(token); code != http.StatusUnauthorized {
+		t.Errorf("expected a deleted account to be refused; got %v", code)
+	}
+
+	if code := request(&auth.Token{Role: auth.RoleGM, Admin: true, Subject: "testuser"}); code != http.StatusOK {
+		t.Errorf("expected the root account to be allowed; got %v", code)
+	}
+
+	// Issued before the root account was renamed
+	if code := request(&auth.Token{Role: auth.RoleGM, Admin: true, Subject: "admin"}); code != http.StatusUnauthorized {
+		t.Errorf("expected a former root username to be refused; got %v", code)
+	}
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 )
 
@@ -10,3 +11,18 @@ func respondJSON(w http.ResponseWriter, status int, payload any) {
 	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(payload)
 }
+
+// respondStoreError responds to an error from the store: 404 with notFound if
+// something it needed doesn't exist, 409 if the change clashes with what's
+// stored, and otherwise 500 with failed, logging the error.
+func (s *Server) respondStoreError(w http.ResponseWriter, err error, notFound, failed string) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		http.Error(w, notFound, http.StatusNotFound)
+	case errors.Is(err, ErrConflict):
+		http.Error(w, failed+": it conflicts with existing data", http.StatusConflict)
+	default:
+		s.logger.Error("datastore error", "error", err)
+		http.Error(w, failed, http.StatusInternalServerError)
+	}
+}
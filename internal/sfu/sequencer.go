@@ -0,0 +1,38 @@
+package sfu
+
+import "github.com/pion/rtp"
+
+// opusFrameTicks is one 20ms Opus frame at the 48kHz RTP clock, used as the
+// gap between the last packet of one publisher and the first of the next.
+const opusFrameTicks = 960
+
+// sequencer keeps the forwarded stream's sequence numbers and timestamps
+// continuous when the publisher changes (e.g. the GM reloads the page), so
+// subscribers see one uninterrupted stream instead of a jump to new random
+// values that their jitter buffers would treat as loss.
+type sequencer struct {
+	started   bool
+	source    uint32
+	lastSeq   uint16
+	lastTS    uint32
+	seqOffset uint16
+	tsOffset  uint32
+}
+
+func (s *sequencer) rewrite(pkt *rtp.Packet) {
+	switch {
+	case !s.started:
+		s.started = true
+		s.source = pkt.SSRC
+	case pkt.SSRC != s.source:
+		s.source = pkt.SSRC
+		s.seqOffset = s.lastSeq + 1 - pkt.SequenceNumber
+		s.tsOffset = s.lastTS + opusFrameTicks - pkt.Timestamp
+	}
+
+	pkt.SequenceNumber += s.seqOffset
+	pkt.Timestamp += s.tsOffset
+
+	s.lastSeq = pkt.SequenceNumber
+	s.lastTS = pkt.Timestamp
+}
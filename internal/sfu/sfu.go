@@ -0,0 +1,311 @@
+package sfu
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/webrtc/v4"
+)
+
+// gatherTimeout bounds how long we wait for ICE candidates before answering.
+// Signaling goes over the hub in a single offer/answer round trip, so all
+// candidates have to be in the SDP we send back.
+const gatherTimeout = 5 * time.Second
+
+type Config struct {
+	// ICEServers are STUN/TURN URLs handed to the server's peer connections.
+	ICEServers []string
+	// PublicIP is advertised in place of the server's own host candidates,
+	// e.g. when running behind a 1:1 NAT or in a container.
+	PublicIP string
+}
+
+// SFU forwards the live Opus audio a single publisher (the GM's browser)
+// sends over WebRTC to any number of subscribers (the players), without
+// decoding or re-encoding it.
+type SFU struct {
+	logger *slog.Logger
+	api    *webrtc.API
+	config webrtc.Configuration
+
+	// track is shared by every subscriber and outlives publishers, so a GM
+	// can reconnect without players having to renegotiate.
+	track *webrtc.TrackLocalStaticRTP
+
+	mu          sync.Mutex
+	publisher   *peer
+	subscribers map[string]*peer
+	sequencer   sequencer
+
+	// publisherLost is told when the publisher's connection fails.
+	publisherLost func(peerID string)
+}
+
+type peer struct {
+	id string
+	pc *webrtc.PeerConnection
+}
+
+func New(cfg Config, logger *slog.Logger) (*SFU, error) {
+	media := &webrtc.MediaEngine{}
+	if err := media.RegisterDefaultCodecs(); err != nil {
+		return nil, fmt.Errorf("couldn't register codecs: %w", err)
+	}
+
+	interceptors := &interceptor.Registry{}
+	if err := webrtc.RegisterDefaultInterceptors(media, interceptors); err != nil {
+		return nil, fmt.Errorf("couldn't register interceptors: %w", err)
+	}
+
+	settings := webrtc.SettingEngine{}
+	if cfg.PublicIP != "" {
+		settings.SetNAT1To1IPs([]string{cfg.PublicIP}, webrtc.ICECandidateTypeHost)
+	}
+
+	track, err := webrtc.NewTrackLocalStaticRTP(webrtc.RTPCodecCapability{
+		MimeType:  webrtc.MimeTypeOpus,
+		ClockRate: 48000,
+		Channels:  2,
+	}, "audio", "table-mix")
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create forwarding track: %w", err)
+	}
+
+	var config webrtc.Configuration
+	if len(cfg.ICEServers) > 0 {
+		config.ICEServers = []webrtc.ICEServer{{URLs: cfg.ICEServers}}
+	}
+
+	return &SFU{
+		logger: logger,
+		api: webrtc.NewAPI(
+			webrtc.WithMediaEngine(media),
+			webrtc.WithInterceptorRegistry(interceptors),
+			webrtc.WithSettingEngine(settings),
+		),
+		config:      config,
+		track:       track,
+		subscribers: make(map[string]*peer),
+	}, nil
+}
+
+// OnPublisherLost registers a function to call with the publisher's peer ID
+// when its connection fails, rather than being removed. It must be called
+// before anything is published.
+func (s *SFU) OnPublisherLost(f func(peerID string)) {
+	s.publisherLost = f
+}
+
+// Publish accepts an SDP offer carrying the publisher's audio and returns the
+// SDP answer. A new publisher replaces the previous one.
+func (s *SFU) Publish(peerID, offer string) (string, error) {
+	pc, err := s.api.NewPeerConnection(s.config)
+	if err != nil {
+		return "", fmt.Errorf("couldn't create peer connection: %w", err)
+	}
+
+	pc.OnTrack(func(remote *webrtc.TrackRemote, _ *webrtc.RTPReceiver) {
+		if remote.Codec().MimeType != webrtc.MimeTypeOpus {
+			s.logger.Warn("ignoring non-Opus track from publisher",
+				"peerId", peerID,
+				"mimeType", remote.Codec().MimeType,
+			)
+			return
+		}
+
+		s.logger.Info("forwarding live audio", "peerId", peerID)
+		s.forward(pc, remote)
+	})
+	s.closeOnFailure(peerID, pc)
+
+	answer, err := s.negotiate(pc, offer)
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	previous := s.publisher
+	s.publisher = &peer{id: peerID, pc: pc}
+	s.mu.Unlock()
+
+	if previous != nil {
+		s.closePeer(previous)
+	}
+
+	return answer, nil
+}
+
+// Subscribe accepts an SDP offer from a listener and returns an answer that
+// sends it the forwarded audio.
+func (s *SFU) Subscribe(peerID, offer string) (string, error) {
+	pc, err := s.api.NewPeerConnection(s.config)
+	if err != nil {
+		return "", fmt.Errorf("couldn't create peer connection: %w", err)
+	}
+
+	sender, err := pc.AddTrack(s.track)
+	if err != nil {
+		pc.Close()
+		return "", fmt.Errorf("couldn't add forwarding track: %w", err)
+	}
+
+	// RTCP has to be read for interceptors such as NACK to work.
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			if _, _, err := sender.Read(buf); err != nil {
+				return
+			}
+		}
+	}()
+	s.closeOnFailure(peerID, pc)
+
+	answer, err := s.negotiate(pc, offer)
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	previous := s.subscribers[peerID]
+	s.subscribers[peerID] = &peer{id: peerID, pc: pc}
+	s.mu.Unlock()
+
+	if previous != nil {
+		s.closePeer(previous)
+	}
+
+	return answer, nil
+}
+
+// Remove closes any connections belonging to the peer.
+func (s *SFU) Remove(peerID string) {
+	var closing []*peer
+
+	s.mu.Lock()
+	if s.publisher != nil && s.publisher.id == peerID {
+		closing = append(closing, s.publisher)
+		s.publisher = nil
+	}
+	if sub, ok := s.subscribers[peerID]; ok {
+		closing = append(closing, sub)
+		delete(s.subscribers, peerID)
+	}
+	s.mu.Unlock()
+
+	for _, p := range closing {
+		s.closePeer(p)
+	}
+}
+
+// Close shuts down every peer connection.
+func (s *SFU) Close() {
+	s.mu.Lock()
+	closing := make([]*peer, 0, len(s.subscribers)+1)
+	if s.publisher != nil {
+		closing = append(closing, s.publisher)
+		s.publisher = nil
+	}
+	for id, sub := range s.subscribers {
+		closing = append(closing, sub)
+		delete(s.subscribers, id)
+	}
+	s.mu.Unlock()
+
+	for _, p := range closing {
+		s.closePeer(p)
+	}
+}
+
+func (s *SFU) negotiate(pc *webrtc.PeerConnection, offer string) (string, error) {
+	if err := pc.SetRemoteDescription(webrtc.SessionDescription{
+		Type: webrtc.SDPTypeOffer,
+		SDP:  offer,
+	}); err != nil {
+		pc.Close()
+		return "", fmt.Errorf("couldn't set remote description: %w", err)
+	}
+
+	answer, err := pc.CreateAnswer(nil)
+	if err != nil {
+		pc.Close()
+		return "", fmt.Errorf("couldn't create answer: %w", err)
+	}
+
+	gathered := webrtc.GatheringCompletePromise(pc)
+	if err := pc.SetLocalDescription(answer); err != nil {
+		pc.Close()
+		return "", fmt.Errorf("couldn't set local description: %w", err)
+	}
+
+	select {
+	case <-gathered:
+	case <-time.After(gatherTimeout):
+		s.logger.Warn("ICE gathering timed out; answering with the candidates found so far")
+	}
+
+	return pc.LocalDescription().SDP, nil
+}
+
+// forward copies RTP from the publisher onto the shared track for as long as
+// the connection remains the current publisher.
+func (s *SFU) forward(pc *webrtc.PeerConnection, remote *webrtc.TrackRemote) {
+	for {
+		pkt, _, err := remote.ReadRTP()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		current := s.publisher != nil && s.publisher.pc == pc
+		if current {
+			s.sequencer.rewrite(pkt)
+		}
+		s.mu.Unlock()
+
+		if !current {
+			return
+		}
+
+		if err := s.track.WriteRTP(pkt); err != nil && !errors.Is(err, io.ErrClosedPipe) {
+			s.logger.Error("failed to forward RTP packet", "error", err)
+			return
+		}
+	}
+}
+
+// closeOnFailure drops a peer once its connection fails, e.g. when a browser
+// goes away without saying goodbye over the hub.
+func (s *SFU) closeOnFailure(peerID string, pc *webrtc.PeerConnection) {
+	pc.OnConnectionStateChange(func(state webrtc.PeerConnectionState) {
+		s.logger.Debug("peer connection state changed", "peerId", peerID, "state", state.String())
+		if state != webrtc.PeerConnectionStateFailed {
+			return
+		}
+
+		s.mu.Lock()
+		wasPublisher := s.publisher != nil && s.publisher.pc == pc
+		if wasPublisher {
+			s.publisher = nil
+		}
+		if sub, ok := s.subscribers[peerID]; ok && sub.pc == pc {
+			delete(s.subscribers, peerID)
+		}
+		s.mu.Unlock()
+
+		go pc.Close()
+		if wasPublisher && s.publisherLost != nil {
+			s.publisherLost(peerID)
+		}
+	})
+}
+
+func (s *SFU) closePeer(p *peer) {
+	if err := p.pc.Close(); err != nil {
+		s.logger.Warn("failed to close peer connection", "peerId", p.id, "error", err)
+	}
+}
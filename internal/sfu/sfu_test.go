@@ -0,0 +1,218 @@
+package sfu
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v4"
+	"github.com/pion/webrtc/v4/pkg/media"
+)
+
+// offer creates a complete (non-trickle) SDP offer, the same as the browser
+// sends over the hub.
+func offer(t *testing.T, pc *webrtc.PeerConnection) string {
+	t.Helper()
+
+	sdp, err := pc.CreateOffer(nil)
+	if err != nil {
+		t.Fatalf("failed to create offer: %v", err)
+	}
+
+	gathered := webrtc.GatheringCompletePromise(pc)
+	if err := pc.SetLocalDescription(sdp); err != nil {
+		t.Fatalf("failed to set local description: %v", err)
+	}
+	<-gathered
+
+	return pc.LocalDescription().SDP
+}
+
+func accept(t *testing.T, pc *webrtc.PeerConnection, answer string) {
+	t.Helper()
+
+	if err := pc.SetRemoteDescription(webrtc.SessionDescription{
+		Type: webrtc.SDPTypeAnswer,
+		SDP:  answer,
+	}); err != nil {
+		t.Fatalf("failed to set remote description: %v", err)
+	}
+}
+
+func newPublisher(t *testing.T, s *SFU, peerID string) *webrtc.TrackLocalStaticSample {
+	t.Helper()
+
+	pc, err := webrtc.NewPeerConnection(webrtc.Configuration{})
+	if err != nil {
+		t.Fatalf("failed to create publisher: %v", err)
+	}
+	t.Cleanup(func() { pc.Close() })
+
+	track, err := webrtc.NewTrackLocalStaticSample(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeOpus}, "audio", peerID)
+	if err != nil {
+		t.Fatalf("failed to create track: %v", err)
+	}
+	if _, err := pc.AddTrack(track); err != nil {
+		t.Fatalf("failed to add track: %v", err)
+	}
+
+	answer, err := s.Publish(peerID, offer(t, pc))
+	if err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	accept(t, pc, answer)
+
+	return track
+}
+
+func newSubscriber(t *testing.T, s *SFU, peerID string) <-chan *rtp.Packet {
+	t.Helper()
+
+	pc, err := webrtc.NewPeerConnection(webrtc.Configuration{})
+	if err != nil {
+		t.Fatalf("failed to create subscriber: %v", err)
+	}
+	t.Cleanup(func() { pc.Close() })
+
+	if _, err := pc.AddTransceiverFromKind(webrtc.RTPCodecTypeAudio, webrtc.RTPTransceiverInit{
+		Direction: webrtc.RTPTransceiverDirectionRecvonly,
+	}); err != nil {
+		t.Fatalf("failed to add transceiver: %v", err)
+	}
+
+	packets := make(chan *rtp.Packet, 256)
+	pc.OnTrack(func(remote *webrtc.TrackRemote, _ *webrtc.RTPReceiver) {
+		for {
+			pkt, _, err := remote.ReadRTP()
+			if err != nil {
+				return
+			}
+			select {
+			case packets <- pkt:
+			default:
+			}
+		}
+	})
+
+	answer, err := s.Subscribe(peerID, offer(t, pc))
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	accept(t, pc, answer)
+
+	return packets
+}
+
+// sendAudio writes fake Opus frames until the test ends.
+func sendAudio(t *testing.T, track *webrtc.TrackLocalStaticSample) {
+	t.Helper()
+
+	done := make(chan struct{})
+	t.Cleanup(func() { close(done) })
+
+	go func() {
+		ticker := time.NewTicker(20 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := track.WriteSample(media.Sample{Data: []byte{0xf8, 0xff, 0xfe}, Duration: 20 * time.Millisecond}); err != nil && err != io.ErrClosedPipe {
+					return
+				}
+			}
+		}
+	}()
+}
+
+func receive(t *testing.T, packets <-chan *rtp.Packet) *rtp.Packet {
+	t.Helper()
+
+	select {
+	case pkt := <-packets:
+		return pkt
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for forwarded audio")
+		return nil
+	}
+}
+
+func TestForwardsPublisherToSubscribers(t *testing.T) {
+	s, err := New(Config{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer s.Close()
+
+	// Players may subscribe before the GM starts publishing.
+	early := newSubscriber(t, s, "player-1")
+
+	sendAudio(t, newPublisher(t, s, "gm"))
+
+	late := newSubscriber(t, s, "player-2")
+
+	for name, packets := range map[string]<-chan *rtp.Packet{"early": early, "late": late} {
+		pkt := receive(t, packets)
+		if len(pkt.Payload) == 0 {
+			t.Errorf("%s subscriber: expected an audio payload", name)
+		}
+	}
+}
+
+func TestRemove(t *testing.T) {
+	s, err := New(Config{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer s.Close()
+
+	newPublisher(t, s, "gm")
+	newSubscriber(t, s, "player-1")
+
+	s.Remove("gm")
+	s.Remove("player-1")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.publisher != nil {
+		t.Error("expected publisher to be removed")
+	}
+	if len(s.subscribers) != 0 {
+		t.Errorf("expected no subscribers; got %d", len(s.subscribers))
+	}
+}
+
+func TestSequencer(t *testing.T) {
+	var seq sequencer
+
+	first := []*rtp.Packet{
+		{Header: rtp.Header{SSRC: 1, SequenceNumber: 100, Timestamp: 5000}},
+		{Header: rtp.Header{SSRC: 1, SequenceNumber: 101, Timestamp: 5960}},
+	}
+	for _, pkt := range first {
+		seq.rewrite(pkt)
+	}
+	if first[1].SequenceNumber != 101 || first[1].Timestamp != 5960 {
+		t.Errorf("expected the first publisher's packets to pass through unchanged; got %+v", first[1].Header)
+	}
+
+	// A new publisher starts from unrelated random values, including ones that
+	// wrap around.
+	second := []*rtp.Packet{
+		{Header: rtp.Header{SSRC: 2, SequenceNumber: 65535, Timestamp: 4294967000}},
+		{Header: rtp.Header{SSRC: 2, SequenceNumber: 0, Timestamp: 664}},
+	}
+	for _, pkt := range second {
+		seq.rewrite(pkt)
+	}
+
+	if second[0].SequenceNumber != 102 || second[0].Timestamp != 5960+opusFrameTicks {
+		t.Errorf("expected the stream to continue at 102/%d; got %d/%d", 5960+opusFrameTicks, second[0].SequenceNumber, second[0].Timestamp)
+	}
+	if second[1].SequenceNumber != 103 || second[1].Timestamp != 5960+2*opusFrameTicks {
+		t.Errorf("expected the stream to continue at 103/%d; got %d/%d", 5960+2*opusFrameTicks, second[1].SequenceNumber, second[1].Timestamp)
+	}
+}
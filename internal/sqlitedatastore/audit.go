@@ -0,0 +1,92 @@
+package sqlitedatastore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/terrabitz/rpg-audio-streamer/internal/server"
+	"github.com/terrabitz/rpg-audio-streamer/internal/sqlitedatastore/sqlitedb"
+)
+
+func (db *SQLiteDatastore) SaveAuditEntry(ctx context.Context, entry server.AuditEntry) error {
+	params := sqlitedb.SaveAuditEntryParams{
+		CreatedAt:   entry.CreatedAt.UTC().Format(time.RFC3339),
+		Actor:       entry.Actor,
+		Action:      string(entry.Action),
+		TargetID:    entry.TargetID,
+		BeforeState: nullableJSON(entry.Before),
+		AfterState:  nullableJSON(entry.After),
+		Ip:          entry.IP,
+	}
+
+	if err := sqlitedb.New(db.DB).SaveAuditEntry(ctx, params); err != nil {
+		return fmt.Errorf("couldn't save audit entry to SQLite: %w", err)
+	}
+
+	return nil
+}
+
+func (db *SQLiteDatastore) GetAuditEntries(ctx context.Context, filter server.AuditFilter, page server.AuditPage) ([]server.AuditEntry, error) {
+	params := sqlitedb.GetAuditEntriesParams{
+		Actor:         nullableString(filter.Actor),
+		Action:        nullableString(string(filter.Action)),
+		TargetID:      nullableString(filter.TargetID),
+		CreatedAfter:  nullableTime(filter.From),
+		CreatedBefore: nullableTime(filter.To),
+		Limit:         int64(page.Limit),
+	}
+	if page.BeforeID != 0 {
+		params.BeforeID = sql.NullInt64{Int64: page.BeforeID, Valid: true}
+	}
+
+	dbEntries, err := sqlitedb.New(db.DB).GetAuditEntries(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get audit entries: %w", err)
+	}
+
+	entries := make([]server.AuditEntry, 0, len(dbEntries))
+	for _, dbEntry := range dbEntries {
+		createdAt, err := time.Parse(time.RFC3339, dbEntry.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid audit entry time: %w", err)
+		}
+
+		entries = append(entries, server.AuditEntry{
+			ID:        dbEntry.ID,
+			CreatedAt: createdAt,
+			Actor:     dbEntry.Actor,
+			Action:    server.AuditAction(dbEntry.Action),
+			TargetID:  dbEntry.TargetID,
+			Before:    rawJSON(dbEntry.BeforeState),
+			After:     rawJSON(dbEntry.AfterState),
+			IP:        dbEntry.Ip,
+		})
+	}
+
+	return entries, nil
+}
+
+func nullableString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func nullableTime(t *time.Time) sql.NullString {
+	if t == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: t.UTC().Format(time.RFC3339), Valid: true}
+}
+
+func nullableJSON(data json.RawMessage) sql.NullString {
+	return sql.NullString{String: string(data), Valid: len(data) > 0}
+}
+
+func rawJSON(s sql.NullString) json.RawMessage {
+	if !s.Valid {
+		return nil
+	}
+	return json.RawMessage(s.String)
+}
@@ -0,0 +1,130 @@
+package sqlitedatastore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/terrabitz/rpg-audio-streamer/internal/server"
+	"github.com/terrabitz/rpg-audio-streamer/internal/sqlitedatastore/sqlitedb"
+)
+
+func (db *SQLiteDatastore) GetCollections(ctx context.Context) ([]server.Collection, error) {
+	dbCollections, err := sqlitedb.New(db.DB).GetCollections(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get collections: %w", err)
+	}
+
+	var result []server.Collection
+	for _, dbCollection := range dbCollections {
+		collection, err := convertDBCollection(dbCollection)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, collection)
+	}
+	return result, nil
+}
+
+func (db *SQLiteDatastore) SaveCollection(ctx context.Context, collection *server.Collection) error {
+	if err := sqlitedb.New(db.DB).SaveCollection(ctx, sqlitedb.SaveCollectionParams{
+		ID:        collection.ID[:],
+		ParentID:  nullableUUID(collection.ParentID),
+		Name:      collection.Name,
+		CreatedAt: collection.CreatedAt.Format(time.RFC3339),
+	}); err != nil {
+		return fmt.Errorf("couldn't save collection to SQLite: %w", wrapError(err))
+	}
+
+	return nil
+}
+
+func (db *SQLiteDatastore) UpdateCollection(ctx context.Context, collection server.Collection) error {
+	if err := sqlitedb.New(db.DB).UpdateCollection(ctx, sqlitedb.UpdateCollectionParams{
+		Name:     collection.Name,
+		ParentID: nullableUUID(collection.ParentID),
+		ID:       collection.ID[:],
+	}); err != nil {
+		return fmt.Errorf("couldn't update collection in SQLite: %w", wrapError(err))
+	}
+
+	return nil
+}
+
+func (db *SQLiteDatastore) DeleteCollection(ctx context.Context, id uuid.UUID) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("couldn't start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	queries := sqlitedb.New(db.DB).WithTx(tx)
+	if err := queries.DeleteCollectionTracks(ctx, id[:]); err != nil {
+		return fmt.Errorf("couldn't remove tracks from collection: %w", err)
+	}
+
+	if err := queries.DeleteCollection(ctx, id[:]); err != nil {
+		return fmt.Errorf("couldn't delete collection: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("couldn't commit collection deletion: %w", err)
+	}
+
+	return nil
+}
+
+// setTrackCollections replaces the collections a track is in.
+func setTrackCollections(ctx context.Context, queries *sqlitedb.Queries, trackID uuid.UUID, collectionIDs []uuid.UUID) error {
+	if err := queries.DeleteTrackCollections(ctx, trackID[:]); err != nil {
+		return fmt.Errorf("couldn't clear track collections: %w", err)
+	}
+
+	for _, collectionID := range collectionIDs {
+		if err := queries.AddCollectionTrack(ctx, sqlitedb.AddCollectionTrackParams{
+			CollectionID: collectionID[:],
+			TrackID:      trackID[:],
+		}); err != nil {
+			return fmt.Errorf("couldn't add track to collection %s: %w", collectionID, wrapError(err))
+		}
+	}
+
+	return nil
+}
+
+func nullableUUID(id *uuid.UUID) []byte {
+	if id == nil {
+		return nil
+	}
+	return id[:]
+}
+
+func convertDBCollection(dbCollection sqlitedb.GetCollectionsRow) (server.Collection, error) {
+	id, err := uuid.FromBytes(dbCollection.ID)
+	if err != nil {
+		return server.Collection{}, fmt.Errorf("invalid ID: %w", err)
+	}
+
+	var parentID *uuid.UUID
+	if dbCollection.ParentID != nil {
+		parent, err := uuid.FromBytes(dbCollection.ParentID)
+		if err != nil {
+			return server.Collection{}, fmt.Errorf("invalid ParentID: %w", err)
+		}
+		parentID = &parent
+	}
+
+	createdAt, err := time.Parse(time.RFC3339, dbCollection.CreatedAt)
+	if err != nil {
+		return server.Collection{}, fmt.Errorf("invalid CreatedAt: %w", err)
+	}
+
+	return server.Collection{
+		ID:         id,
+		ParentID:   parentID,
+		Name:       dbCollection.Name,
+		CreatedAt:  createdAt,
+		TrackCount: int(dbCollection.TrackCount),
+	}, nil
+}
@@ -2,18 +2,88 @@ package sqlitedatastore
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/terrabitz/rpg-audio-streamer/internal/server"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
 )
 
 type SQLiteDatastore struct {
 	*sql.DB
 }
 
-func New(path string) (*SQLiteDatastore, error) {
-	db, err := sql.Open("sqlite", path)
+// Config sets up the connections to the database. Foreign keys are always
+// enforced.
+type Config struct {
+	Path string
+	// JournalMode is the journal_mode pragma. WAL lets requests read while
+	// another writes.
+	JournalMode string
+	// Synchronous is the synchronous pragma. NORMAL is safe with WAL, and
+	// saves a sync to disk on every commit.
+	Synchronous string
+	// BusyTimeout is how long a connection waits for another to finish
+	// writing before failing with SQLITE_BUSY.
+	BusyTimeout time.Duration
+	// MaxOpenConns limits the connections to the database. Zero is no limit.
+	MaxOpenConns int
+}
+
+func New(cfg Config) (*SQLiteDatastore, error) {
+	db, err := sql.Open("sqlite", dsn(cfg))
 	if err != nil {
-		return nil, fmt.Errorf("couldn't open sqlite DB at '%s': %v", path, err)
+		return nil, fmt.Errorf("couldn't open sqlite DB at '%s': %v", cfg.Path, err)
+	}
+
+	if cfg.MaxOpenConns > 0 {
+		// Keep every connection open, as each new one sets its pragmas again.
+		db.SetMaxOpenConns(cfg.MaxOpenConns)
+		db.SetMaxIdleConns(cfg.MaxOpenConns)
 	}
 
 	return &SQLiteDatastore{DB: db}, nil
 }
+
+// dsn adds the pragmas to the database path. The driver runs them on every
+// new connection, as they only last as long as the connection does.
+func dsn(cfg Config) string {
+	params := url.Values{}
+	params.Add("_pragma", "foreign_keys(1)")
+	if cfg.BusyTimeout > 0 {
+		params.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", cfg.BusyTimeout.Milliseconds()))
+	}
+	if cfg.JournalMode != "" {
+		params.Add("_pragma", fmt.Sprintf("journal_mode(%s)", cfg.JournalMode))
+	}
+	if cfg.Synchronous != "" {
+		params.Add("_pragma", fmt.Sprintf("synchronous(%s)", cfg.Synchronous))
+	}
+	// Take the write lock as a transaction begins. A transaction that only
+	// asks for it on its first write can't wait out the busy timeout, and
+	// fails straight away if another connection wrote in the meantime.
+	params.Set("_txlock", "immediate")
+
+	return cfg.Path + "?" + params.Encode()
+}
+
+// wrapError wraps a missing row in server.ErrNotFound and a violated unique or
+// foreign key in server.ErrConflict. Other errors are returned as they are.
+func wrapError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %w", server.ErrNotFound, err)
+	}
+
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.Code() {
+		case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY, sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+			return fmt.Errorf("%w: %w", server.ErrConflict, err)
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,144 @@
+package sqlitedatastore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/terrabitz/rpg-audio-streamer/internal/server"
+	"github.com/terrabitz/rpg-audio-streamer/internal/sqlitedatastore/sqlitedb"
+)
+
+func (db *SQLiteDatastore) SaveJoinToken(ctx context.Context, token server.JoinToken) error {
+	if err := sqlitedb.New(db.DB).SaveJoinToken(ctx, sqlitedb.SaveJoinTokenParams{
+		ID:         token.ID[:],
+		Label:      token.Label,
+		SecretHash: token.SecretHash,
+		CreatedAt:  token.CreatedAt.UTC().Format(time.RFC3339),
+		ExpiresAt:  nullableTime(token.ExpiresAt),
+		MaxUses:    int64(token.MaxUses),
+		Uses:       int64(token.Uses),
+		RevokedAt:  nullableTime(token.RevokedAt),
+	}); err != nil {
+		return fmt.Errorf("couldn't save join token to SQLite: %w", wrapError(err))
+	}
+
+	return nil
+}
+
+func (db *SQLiteDatastore) GetJoinTokens(ctx context.Context) ([]server.JoinToken, error) {
+	dbTokens, err := sqlitedb.New(db.DB).GetJoinTokens(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get join tokens: %w", err)
+	}
+
+	tokens := make([]server.JoinToken, 0, len(dbTokens))
+	for _, dbToken := range dbTokens {
+		token, err := convertDBJoinToken(dbToken)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens, nil
+}
+
+func (db *SQLiteDatastore) GetJoinTokenByID(ctx context.Context, id uuid.UUID) (server.JoinToken, error) {
+	dbToken, err := sqlitedb.New(db.DB).GetJoinTokenByID(ctx, id[:])
+	if err != nil {
+		return server.JoinToken{}, fmt.Errorf("couldn't get join token by ID: %w", wrapError(err))
+	}
+
+	return convertDBJoinToken(dbToken)
+}
+
+func (db *SQLiteDatastore) RotateJoinToken(ctx context.Context, id uuid.UUID, secretHash []byte) error {
+	rows, err := sqlitedb.New(db.DB).RotateJoinToken(ctx, sqlitedb.RotateJoinTokenParams{
+		SecretHash: secretHash,
+		ID:         id[:],
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't rotate join token: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("couldn't rotate join token: %w", server.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (db *SQLiteDatastore) RevokeJoinToken(ctx context.Context, id uuid.UUID, revokedAt time.Time) error {
+	rows, err := sqlitedb.New(db.DB).RevokeJoinToken(ctx, sqlitedb.RevokeJoinTokenParams{
+		RevokedAt: nullableTime(&revokedAt),
+		ID:        id[:],
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't revoke join token: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("couldn't revoke join token: %w", server.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (db *SQLiteDatastore) UseJoinToken(ctx context.Context, id uuid.UUID, secretHash []byte, now time.Time) (server.JoinToken, error) {
+	dbToken, err := sqlitedb.New(db.DB).UseJoinToken(ctx, sqlitedb.UseJoinTokenParams{
+		ID:         id[:],
+		SecretHash: secretHash,
+		Now:        nullableTime(&now),
+	})
+	if err != nil {
+		return server.JoinToken{}, fmt.Errorf("couldn't use join token: %w", wrapError(err))
+	}
+
+	return convertDBJoinToken(dbToken)
+}
+
+func convertDBJoinToken(dbToken sqlitedb.JoinToken) (server.JoinToken, error) {
+	id, err := uuid.FromBytes(dbToken.ID)
+	if err != nil {
+		return server.JoinToken{}, fmt.Errorf("invalid ID: %w", err)
+	}
+
+	createdAt, err := time.Parse(time.RFC3339, dbToken.CreatedAt)
+	if err != nil {
+		return server.JoinToken{}, fmt.Errorf("invalid CreatedAt: %w", err)
+	}
+
+	expiresAt, err := parseNullableTime(dbToken.ExpiresAt)
+	if err != nil {
+		return server.JoinToken{}, fmt.Errorf("invalid ExpiresAt: %w", err)
+	}
+
+	revokedAt, err := parseNullableTime(dbToken.RevokedAt)
+	if err != nil {
+		return server.JoinToken{}, fmt.Errorf("invalid RevokedAt: %w", err)
+	}
+
+	return server.JoinToken{
+		ID:         id,
+		Label:      dbToken.Label,
+		SecretHash: dbToken.SecretHash,
+		CreatedAt:  createdAt,
+		ExpiresAt:  expiresAt,
+		MaxUses:    int(dbToken.MaxUses),
+		Uses:       int(dbToken.Uses),
+		RevokedAt:  revokedAt,
+	}, nil
+}
+
+func parseNullableTime(s sql.NullString) (*time.Time, error) {
+	if !s.Valid {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, s.String)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
@@ -0,0 +1,73 @@
+package sqlitedatastore
+
+import (
+	"context"
+	"fmt"
+)
+
+// Optimize refreshes the statistics the query planner uses and moves the
+// WAL's contents into the database file. Both are safe while the server is
+// running.
+func (db *SQLiteDatastore) Optimize(ctx context.Context) error {
+	// 0x10002 analyzes every table that needs it, not just those queried on
+	// this connection.
+	if _, err := db.ExecContext(ctx, "PRAGMA optimize = 0x10002"); err != nil {
+		return fmt.Errorf("couldn't optimize database: %w", err)
+	}
+
+	if _, err := db.ExecContext(ctx, "PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+		return fmt.Errorf("couldn't checkpoint WAL: %w", err)
+	}
+
+	return nil
+}
+
+// CheckIntegrity runs SQLite's integrity and foreign key checks, returning
+// the problems they find.
+func (db *SQLiteDatastore) CheckIntegrity(ctx context.Context) ([]string, error) {
+	rows, err := db.QueryContext(ctx, "PRAGMA integrity_check")
+	if err != nil {
+		return nil, fmt.Errorf("couldn't check integrity: %w", err)
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var problem string
+		if err := rows.Scan(&problem); err != nil {
+			return nil, fmt.Errorf("couldn't read integrity check: %w", err)
+		}
+		if problem != "ok" {
+			problems = append(problems, problem)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("couldn't read integrity check: %w", err)
+	}
+
+	fkRows, err := db.QueryContext(ctx, "PRAGMA foreign_key_check")
+	if err != nil {
+		return nil, fmt.Errorf("couldn't check foreign keys: %w", err)
+	}
+	defer fkRows.Close()
+
+	for fkRows.Next() {
+		var table, parent string
+		var rowID *int64
+		var fkID int64
+		if err := fkRows.Scan(&table, &rowID, &parent, &fkID); err != nil {
+			return nil, fmt.Errorf("couldn't read foreign key check: %w", err)
+		}
+
+		row := "a row"
+		if rowID != nil {
+			row = fmt.Sprintf("row %d", *rowID)
+		}
+		problems = append(problems, fmt.Sprintf("%s of %s refers to a missing row of %s", row, table, parent))
+	}
+	if err := fkRows.Err(); err != nil {
+		return nil, fmt.Errorf("couldn't read foreign key check: %w", err)
+	}
+
+	return problems, nil
+}
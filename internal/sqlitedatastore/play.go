@@ -0,0 +1,120 @@
+package sqlitedatastore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/terrabitz/rpg-audio-streamer/internal/server"
+	"github.com/terrabitz/rpg-audio-streamer/internal/sqlitedatastore/sqlitedb"
+)
+
+func (db *SQLiteDatastore) RecordPlay(ctx context.Context, trackID uuid.UUID, startedAt time.Time) error {
+	params := sqlitedb.SaveTrackPlayParams{
+		TrackID:   trackID[:],
+		StartedAt: startedAt.UTC().Format(time.RFC3339),
+	}
+
+	if err := sqlitedb.New(db.DB).SaveTrackPlay(ctx, params); err != nil {
+		return fmt.Errorf("couldn't save track play to SQLite: %w", wrapError(err))
+	}
+
+	return nil
+}
+
+func (db *SQLiteDatastore) GetPlayedTrackIDs(ctx context.Context, from, to time.Time) ([]uuid.UUID, error) {
+	params := sqlitedb.GetPlayedTrackIDsParams{
+		StartedAfter:  from.UTC().Format(time.RFC3339),
+		StartedBefore: to.UTC().Format(time.RFC3339),
+	}
+
+	dbIDs, err := sqlitedb.New(db.DB).GetPlayedTrackIDs(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get played tracks: %w", err)
+	}
+
+	trackIDs := make([]uuid.UUID, 0, len(dbIDs))
+	for _, dbID := range dbIDs {
+		id, err := uuid.FromBytes(dbID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid track ID: %w", err)
+		}
+		trackIDs = append(trackIDs, id)
+	}
+
+	return trackIDs, nil
+}
+
+func (db *SQLiteDatastore) FinishPlay(ctx context.Context, trackID uuid.UUID, startedAt time.Time, duration time.Duration) error {
+	params := sqlitedb.FinishTrackPlayParams{
+		Duration:  sql.NullFloat64{Float64: duration.Seconds(), Valid: true},
+		TrackID:   trackID[:],
+		StartedAt: startedAt.UTC().Format(time.RFC3339),
+	}
+
+	if err := sqlitedb.New(db.DB).FinishTrackPlay(ctx, params); err != nil {
+		return fmt.Errorf("couldn't save track play duration to SQLite: %w", err)
+	}
+
+	return nil
+}
+
+func (db *SQLiteDatastore) GetRecentTracks(ctx context.Context, limit int) ([]server.TrackStats, error) {
+	rows, err := sqlitedb.New(db.DB).GetRecentTrackStats(ctx, int64(limit))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get recent tracks: %w", err)
+	}
+
+	stats := make([]server.TrackStats, 0, len(rows))
+	for _, row := range rows {
+		s, err := convertDBTrackStats(sqlitedb.GetPopularTrackStatsRow(row))
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+
+	return stats, nil
+}
+
+func (db *SQLiteDatastore) GetPopularTracks(ctx context.Context, since time.Time, limit int) ([]server.TrackStats, error) {
+	rows, err := sqlitedb.New(db.DB).GetPopularTrackStats(ctx, sqlitedb.GetPopularTrackStatsParams{
+		StartedAfter: since.UTC().Format(time.RFC3339),
+		Limit:        int64(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get popular tracks: %w", err)
+	}
+
+	stats := make([]server.TrackStats, 0, len(rows))
+	for _, row := range rows {
+		s, err := convertDBTrackStats(row)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+
+	return stats, nil
+}
+
+func convertDBTrackStats(row sqlitedb.GetPopularTrackStatsRow) (server.TrackStats, error) {
+	trackID, err := uuid.FromBytes(row.TrackID)
+	if err != nil {
+		return server.TrackStats{}, fmt.Errorf("invalid track ID: %w", err)
+	}
+
+	lastPlayedAt, err := time.Parse(time.RFC3339, row.LastPlayedAt)
+	if err != nil {
+		return server.TrackStats{}, fmt.Errorf("invalid LastPlayedAt: %w", err)
+	}
+
+	return server.TrackStats{
+		TrackID:      trackID,
+		PlayCount:    int(row.PlayCount),
+		TotalPlayed:  row.TotalDuration,
+		LastPlayedAt: lastPlayedAt,
+	}, nil
+}
@@ -0,0 +1,96 @@
+package sqlitedatastore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/terrabitz/rpg-audio-streamer/internal/server"
+	"github.com/terrabitz/rpg-audio-streamer/internal/sqlitedatastore/sqlitedb"
+)
+
+// sessionTimeFormat keeps event times to the millisecond, so a session can be
+// replayed with the gaps between its events.
+const sessionTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+func (db *SQLiteDatastore) SaveSessionEvent(ctx context.Context, event server.SessionEvent) error {
+	if err := sqlitedb.New(db.DB).SaveSessionEvent(ctx, sqlitedb.SaveSessionEventParams{
+		SessionID: event.SessionID[:],
+		TableID:   event.TableID,
+		Seq:       event.Seq,
+		CreatedAt: event.CreatedAt.UTC().Format(sessionTimeFormat),
+		Method:    event.Method,
+		SenderID:  event.SenderID,
+		Payload:   string(event.Payload),
+	}); err != nil {
+		return fmt.Errorf("couldn't save session event to SQLite: %w", wrapError(err))
+	}
+
+	return nil
+}
+
+func (db *SQLiteDatastore) GetSessionEvents(ctx context.Context, sessionID uuid.UUID, page server.SessionEventPage) ([]server.SessionEvent, error) {
+	dbEvents, err := sqlitedb.New(db.DB).GetSessionEvents(ctx, sqlitedb.GetSessionEventsParams{
+		SessionID: sessionID[:],
+		AfterSeq:  page.AfterSeq,
+		Limit:     int64(page.Limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get session events: %w", err)
+	}
+
+	events := make([]server.SessionEvent, 0, len(dbEvents))
+	for _, dbEvent := range dbEvents {
+		createdAt, err := time.Parse(sessionTimeFormat, dbEvent.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid session event time: %w", err)
+		}
+
+		events = append(events, server.SessionEvent{
+			SessionID: sessionID,
+			TableID:   dbEvent.TableID,
+			Seq:       dbEvent.Seq,
+			CreatedAt: createdAt,
+			Method:    dbEvent.Method,
+			SenderID:  dbEvent.SenderID,
+			Payload:   json.RawMessage(dbEvent.Payload),
+		})
+	}
+
+	return events, nil
+}
+
+func (db *SQLiteDatastore) GetSessions(ctx context.Context, tableID string) ([]server.Session, error) {
+	rows, err := sqlitedb.New(db.DB).GetSessions(ctx, tableID)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get sessions: %w", err)
+	}
+
+	sessions := make([]server.Session, 0, len(rows))
+	for _, row := range rows {
+		id, err := uuid.FromBytes(row.SessionID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid session ID: %w", err)
+		}
+		startedAt, err := time.Parse(sessionTimeFormat, row.StartedAt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid session start time: %w", err)
+		}
+		lastEventAt, err := time.Parse(sessionTimeFormat, row.LastEventAt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid session event time: %w", err)
+		}
+
+		sessions = append(sessions, server.Session{
+			ID:          id,
+			TableID:     tableID,
+			StartedAt:   startedAt,
+			LastEventAt: lastEventAt,
+			EventCount:  row.EventCount,
+		})
+	}
+
+	return sessions, nil
+}
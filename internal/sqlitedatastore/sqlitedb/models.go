@@ -4,19 +4,133 @@
 
 package sqlitedb
 
-type Track struct {
+import (
+	"database/sql"
+)
+
+type AuditLog struct {
+	ID          int64
+	CreatedAt   string
+	Actor       string
+	Action      string
+	TargetID    string
+	BeforeState sql.NullString
+	AfterState  sql.NullString
+	Ip          string
+}
+
+type Collection struct {
 	ID        []byte
-	CreatedAt string
+	ParentID  []byte
 	Name      string
-	Path      string
-	TypeID    []byte
+	CreatedAt string
+}
+
+type CollectionTrack struct {
+	CollectionID []byte
+	TrackID      []byte
+}
+
+type JoinToken struct {
+	ID         []byte
+	Label      string
+	SecretHash []byte
+	CreatedAt  string
+	ExpiresAt  sql.NullString
+	MaxUses    int64
+	Uses       int64
+	RevokedAt  sql.NullString
+}
+
+type SessionEvent struct {
+	SessionID []byte
+	Seq       int64
+	CreatedAt string
+	Method    string
+	SenderID  string
+	Payload   string
+	TableID   string
+}
+
+type Tag struct {
+	ID   []byte
+	Name string
+}
+
+type Track struct {
+	ID          []byte
+	CreatedAt   string
+	Name        string
+	Path        string
+	TypeID      []byte
+	Duration    float64
+	Metadata    string
+	Position    int64
+	Description string
+	Artist      string
+	SourceUrl   string
+	License     string
+	Attribution string
+	Favorite    bool
+}
+
+type TrackDetail struct {
+	ID          []byte
+	CreatedAt   string
+	Name        string
+	Path        string
+	TypeID      []byte
+	Duration    float64
+	Metadata    string
+	Position    int64
+	Description string
+	Artist      string
+	SourceUrl   string
+	License     string
+	Attribution string
+	Favorite    bool
+	Tags        string
+	Collections string
+}
+
+type TrackPlay struct {
+	ID        int64
+	TrackID   []byte
+	StartedAt string
+	Duration  sql.NullFloat64
+}
+
+type TrackTag struct {
+	TrackID []byte
+	TagID   []byte
 }
 
 type TrackType struct {
-	ID                    []byte
-	Name                  string
-	Color                 string
-	IsRepeating           bool
-	AllowSimultaneousPlay bool
-	CreatedAt             string
+	ID                       []byte
+	Name                     string
+	Color                    string
+	IsRepeating              bool
+	AllowSimultaneousPlay    bool
+	CreatedAt                string
+	DefaultVolume            int64
+	FadeInMs                 int64
+	FadeOutMs                int64
+	AlwaysStartFromBeginning bool
+	MaxConcurrent            int64
+	AutoStopOthers           bool
+}
+
+type TracksFt struct {
+	TrackID  string
+	Name     string
+	Tags     string
+	Metadata string
+}
+
+type User struct {
+	ID           []byte
+	Username     string
+	PasswordHash string
+	Admin        bool
+	CreatedAt    string
 }
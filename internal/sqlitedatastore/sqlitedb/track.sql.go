@@ -10,6 +10,56 @@ import (
 	"database/sql"
 )
 
+const countTracks = `-- name: CountTracks :one
+select count(*) from tracks
+where
+  (?1 is null or id in (select track_id from tracks_fts where tracks_fts match ?1))
+  and (?2 is null or type_id = ?2)
+  and (?3 is null or id in (
+    select track_tags.track_id from track_tags
+    join tags on tags.id = track_tags.tag_id
+    where tags.name = ?3
+  ))
+  and (?4 is null or id >= ?4)
+  and (?5 is null or id < ?5)
+  and (?6 is null or id in (
+    select track_id from collection_tracks where collection_id in (
+      with recursive subtree(id) as (
+        select ?6
+        union
+        select collections.id from collections join subtree on collections.parent_id = subtree.id
+      )
+      select id from subtree
+    )
+  ))
+  and (?7 is null or favorite = ?7)
+`
+
+type CountTracksParams struct {
+	Query        sql.NullString
+	TypeID       []byte
+	Tag          sql.NullString
+	MinID        []byte
+	MaxID        []byte
+	CollectionID []byte
+	Favorite     sql.NullBool
+}
+
+func (q *Queries) CountTracks(ctx context.Context, arg CountTracksParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countTracks,
+		arg.Query,
+		arg.TypeID,
+		arg.Tag,
+		arg.MinID,
+		arg.MaxID,
+		arg.CollectionID,
+		arg.Favorite,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const deleteTrackByID = `-- name: DeleteTrackByID :exec
 delete from tracks where id = ?1
 `
@@ -20,41 +70,179 @@ func (q *Queries) DeleteTrackByID(ctx context.Context, id []byte) error {
 }
 
 const getTrackByID = `-- name: GetTrackByID :one
-select id, created_at, name, path, type_id from tracks where id = ?1
+select id, created_at, name, path, type_id, duration, metadata, position, description, artist, source_url, license, attribution, favorite, tags, collections from track_details where id = ?1
 `
 
-func (q *Queries) GetTrackByID(ctx context.Context, id []byte) (Track, error) {
+func (q *Queries) GetTrackByID(ctx context.Context, id []byte) (TrackDetail, error) {
 	row := q.db.QueryRowContext(ctx, getTrackByID, id)
-	var i Track
+	var i TrackDetail
 	err := row.Scan(
 		&i.ID,
 		&i.CreatedAt,
 		&i.Name,
 		&i.Path,
 		&i.TypeID,
+		&i.Duration,
+		&i.Metadata,
+		&i.Position,
+		&i.Description,
+		&i.Artist,
+		&i.SourceUrl,
+		&i.License,
+		&i.Attribution,
+		&i.Favorite,
+		&i.Tags,
+		&i.Collections,
 	)
 	return i, err
 }
 
+const getTrackIDsByType = `-- name: GetTrackIDsByType :many
+select id from tracks where type_id = ?1 order by position
+`
+
+func (q *Queries) GetTrackIDsByType(ctx context.Context, typeID []byte) ([][]byte, error) {
+	rows, err := q.db.QueryContext(ctx, getTrackIDsByType, typeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items [][]byte
+	for rows.Next() {
+		var id []byte
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getTracks = `-- name: GetTracks :many
-select id, created_at, name, path, type_id from tracks
+select id, created_at, name, path, type_id, duration, metadata, position, description, artist, source_url, license, attribution, favorite, tags, collections from track_details
+where
+  (?1 is null or id in (select track_id from tracks_fts where tracks_fts match ?1))
+  and (?2 is null or type_id = ?2)
+  and (?3 is null or id in (
+    select track_tags.track_id from track_tags
+    join tags on tags.id = track_tags.tag_id
+    where tags.name = ?3
+  ))
+  and (?4 is null or id >= ?4)
+  and (?5 is null or id < ?5)
+  and (?6 is null or id in (
+    select track_id from collection_tracks where collection_id in (
+      with recursive subtree(id) as (
+        select ?6
+        union
+        select collections.id from collections join subtree on collections.parent_id = subtree.id
+      )
+      select id from subtree
+    )
+  ))
+  and (?7 is null or favorite = ?7)
+  and (
+    ?8 is null
+    or case ?9
+      when 'name' then
+        case when ?10
+          then name collate nocase < ?11 or (name collate nocase = ?11 and id < ?8)
+          else name collate nocase > ?11 or (name collate nocase = ?11 and id > ?8)
+        end
+      when 'duration' then
+        case when ?10
+          then duration < ?12 or (duration = ?12 and id < ?8)
+          else duration > ?12 or (duration = ?12 and id > ?8)
+        end
+      when 'position' then
+        case when ?10
+          then (type_id, position, id) < (?13, ?14, ?8)
+          else (type_id, position, id) > (?13, ?14, ?8)
+        end
+      else
+        case when ?10 then id < ?8 else id > ?8 end
+    end
+  )
+order by
+  case when ?9 = 'name' and not ?10 then name end collate nocase asc,
+  case when ?9 = 'name' and ?10 then name end collate nocase desc,
+  case when ?9 = 'duration' and not ?10 then duration end asc,
+  case when ?9 = 'duration' and ?10 then duration end desc,
+  case when ?9 = 'position' and not ?10 then type_id end asc,
+  case when ?9 = 'position' and ?10 then type_id end desc,
+  case when ?9 = 'position' and not ?10 then position end asc,
+  case when ?9 = 'position' and ?10 then position end desc,
+  case when not ?10 then id end asc,
+  case when ?10 then id end desc
+limit ?15
 `
 
-func (q *Queries) GetTracks(ctx context.Context) ([]Track, error) {
-	rows, err := q.db.QueryContext(ctx, getTracks)
+type GetTracksParams struct {
+	Query          sql.NullString
+	TypeID         []byte
+	Tag            sql.NullString
+	MinID          []byte
+	MaxID          []byte
+	CollectionID   []byte
+	Favorite       sql.NullBool
+	CursorID       []byte
+	SortBy         string
+	Descending     bool
+	CursorName     string
+	CursorDuration float64
+	CursorTypeID   []byte
+	CursorPosition int64
+	Limit          int64
+}
+
+func (q *Queries) GetTracks(ctx context.Context, arg GetTracksParams) ([]TrackDetail, error) {
+	rows, err := q.db.QueryContext(ctx, getTracks,
+		arg.Query,
+		arg.TypeID,
+		arg.Tag,
+		arg.MinID,
+		arg.MaxID,
+		arg.CollectionID,
+		arg.Favorite,
+		arg.CursorID,
+		arg.SortBy,
+		arg.Descending,
+		arg.CursorName,
+		arg.CursorDuration,
+		arg.CursorTypeID,
+		arg.CursorPosition,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Track
+	var items []TrackDetail
 	for rows.Next() {
-		var i Track
+		var i TrackDetail
 		if err := rows.Scan(
 			&i.ID,
 			&i.CreatedAt,
 			&i.Name,
 			&i.Path,
 			&i.TypeID,
+			&i.Duration,
+			&i.Metadata,
+			&i.Position,
+			&i.Description,
+			&i.Artist,
+			&i.SourceUrl,
+			&i.License,
+			&i.Attribution,
+			&i.Favorite,
+			&i.Tags,
+			&i.Collections,
 		); err != nil {
 			return nil, err
 		}
@@ -69,8 +257,31 @@ func (q *Queries) GetTracks(ctx context.Context) ([]Track, error) {
 	return items, nil
 }
 
+const moveTracksToType = `-- name: MoveTracksToType :exec
+update tracks
+set
+  type_id = ?1,
+  position = position + (select coalesce(max(position) + 1, 0) from tracks as others where others.type_id = ?1)
+where type_id = ?2
+`
+
+type MoveTracksToTypeParams struct {
+	NewTypeID []byte
+	OldTypeID []byte
+}
+
+// The moved tracks keep their order and go after the new type's own tracks.
+func (q *Queries) MoveTracksToType(ctx context.Context, arg MoveTracksToTypeParams) error {
+	_, err := q.db.ExecContext(ctx, moveTracksToType, arg.NewTypeID, arg.OldTypeID)
+	return err
+}
+
 const saveTrack = `-- name: SaveTrack :exec
-insert into tracks (id, created_at, name, path, type_id) values (?1, ?2, ?3, ?4, ?5)
+insert into tracks (id, created_at, name, path, type_id, duration, metadata, position)
+values (
+  ?1, ?2, ?3, ?4, ?5, ?6, ?7,
+  (select coalesce(max(position) + 1, 0) from tracks where type_id = ?5)
+)
 `
 
 type SaveTrackParams struct {
@@ -79,6 +290,8 @@ type SaveTrackParams struct {
 	Name      string
 	Path      string
 	TypeID    []byte
+	Duration  float64
+	Metadata  string
 }
 
 func (q *Queries) SaveTrack(ctx context.Context, arg SaveTrackParams) error {
@@ -88,27 +301,85 @@ func (q *Queries) SaveTrack(ctx context.Context, arg SaveTrackParams) error {
 		arg.Name,
 		arg.Path,
 		arg.TypeID,
+		arg.Duration,
+		arg.Metadata,
 	)
 	return err
 }
 
+const setTrackMedia = `-- name: SetTrackMedia :exec
+update tracks set duration = ?1, metadata = ?2 where id = ?3
+`
+
+type SetTrackMediaParams struct {
+	Duration float64
+	Metadata string
+	ID       []byte
+}
+
+func (q *Queries) SetTrackMedia(ctx context.Context, arg SetTrackMediaParams) error {
+	_, err := q.db.ExecContext(ctx, setTrackMedia, arg.Duration, arg.Metadata, arg.ID)
+	return err
+}
+
+const setTrackPosition = `-- name: SetTrackPosition :exec
+update tracks set position = ?1 where id = ?2
+`
+
+type SetTrackPositionParams struct {
+	Position int64
+	ID       []byte
+}
+
+func (q *Queries) SetTrackPosition(ctx context.Context, arg SetTrackPositionParams) error {
+	_, err := q.db.ExecContext(ctx, setTrackPosition, arg.Position, arg.ID)
+	return err
+}
+
 const updateTrack = `-- name: UpdateTrack :one
 update tracks
 set
   name = coalesce(?1, name),
-  type_id = coalesce(?2, type_id)
-where id = ?3
-returning id, created_at, name, path, type_id
+  position = case
+    when ?2 is null or ?2 = type_id then position
+    else (select coalesce(max(position) + 1, 0) from tracks as others where others.type_id = ?2)
+  end,
+  type_id = coalesce(?2, type_id),
+  description = coalesce(?3, description),
+  artist = coalesce(?4, artist),
+  source_url = coalesce(?5, source_url),
+  license = coalesce(?6, license),
+  attribution = coalesce(?7, attribution),
+  favorite = coalesce(?8, favorite)
+where id = ?9
+returning id, created_at, name, path, type_id, duration, metadata, position, description, artist, source_url, license, attribution, favorite
 `
 
 type UpdateTrackParams struct {
-	Name   sql.NullString
-	TypeID []byte
-	ID     []byte
+	Name        sql.NullString
+	TypeID      []byte
+	Description sql.NullString
+	Artist      sql.NullString
+	SourceUrl   sql.NullString
+	License     sql.NullString
+	Attribution sql.NullString
+	Favorite    sql.NullBool
+	ID          []byte
 }
 
+// Tracks moved to another type go to the end of it.
 func (q *Queries) UpdateTrack(ctx context.Context, arg UpdateTrackParams) (Track, error) {
-	row := q.db.QueryRowContext(ctx, updateTrack, arg.Name, arg.TypeID, arg.ID)
+	row := q.db.QueryRowContext(ctx, updateTrack,
+		arg.Name,
+		arg.TypeID,
+		arg.Description,
+		arg.Artist,
+		arg.SourceUrl,
+		arg.License,
+		arg.Attribution,
+		arg.Favorite,
+		arg.ID,
+	)
 	var i Track
 	err := row.Scan(
 		&i.ID,
@@ -116,6 +387,15 @@ func (q *Queries) UpdateTrack(ctx context.Context, arg UpdateTrackParams) (Track
 		&i.Name,
 		&i.Path,
 		&i.TypeID,
+		&i.Duration,
+		&i.Metadata,
+		&i.Position,
+		&i.Description,
+		&i.Artist,
+		&i.SourceUrl,
+		&i.License,
+		&i.Attribution,
+		&i.Favorite,
 	)
 	return i, err
 }
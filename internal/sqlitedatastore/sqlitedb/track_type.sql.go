@@ -9,8 +9,17 @@ import (
 	"context"
 )
 
+const deleteTrackType = `-- name: DeleteTrackType :exec
+delete from track_types where id = ?1
+`
+
+func (q *Queries) DeleteTrackType(ctx context.Context, id []byte) error {
+	_, err := q.db.ExecContext(ctx, deleteTrackType, id)
+	return err
+}
+
 const getTrackTypeByID = `-- name: GetTrackTypeByID :one
-select id, name, color, is_repeating, allow_simultaneous_play, created_at from track_types where id = ?1
+select id, name, color, is_repeating, allow_simultaneous_play, created_at, default_volume, fade_in_ms, fade_out_ms, always_start_from_beginning, max_concurrent, auto_stop_others from track_types where id = ?1
 `
 
 func (q *Queries) GetTrackTypeByID(ctx context.Context, id []byte) (TrackType, error) {
@@ -23,12 +32,18 @@ func (q *Queries) GetTrackTypeByID(ctx context.Context, id []byte) (TrackType, e
 		&i.IsRepeating,
 		&i.AllowSimultaneousPlay,
 		&i.CreatedAt,
+		&i.DefaultVolume,
+		&i.FadeInMs,
+		&i.FadeOutMs,
+		&i.AlwaysStartFromBeginning,
+		&i.MaxConcurrent,
+		&i.AutoStopOthers,
 	)
 	return i, err
 }
 
 const getTrackTypes = `-- name: GetTrackTypes :many
-select id, name, color, is_repeating, allow_simultaneous_play, created_at from track_types
+select id, name, color, is_repeating, allow_simultaneous_play, created_at, default_volume, fade_in_ms, fade_out_ms, always_start_from_beginning, max_concurrent, auto_stop_others from track_types
 `
 
 func (q *Queries) GetTrackTypes(ctx context.Context) ([]TrackType, error) {
@@ -47,6 +62,12 @@ func (q *Queries) GetTrackTypes(ctx context.Context) ([]TrackType, error) {
 			&i.IsRepeating,
 			&i.AllowSimultaneousPlay,
 			&i.CreatedAt,
+			&i.DefaultVolume,
+			&i.FadeInMs,
+			&i.FadeOutMs,
+			&i.AlwaysStartFromBeginning,
+			&i.MaxConcurrent,
+			&i.AutoStopOthers,
 		); err != nil {
 			return nil, err
 		}
@@ -60,3 +81,92 @@ func (q *Queries) GetTrackTypes(ctx context.Context) ([]TrackType, error) {
 	}
 	return items, nil
 }
+
+const saveTrackType = `-- name: SaveTrackType :exec
+insert into track_types (
+  id, name, color, is_repeating, allow_simultaneous_play, default_volume,
+  fade_in_ms, fade_out_ms, always_start_from_beginning, max_concurrent, auto_stop_others
+)
+values (
+  ?1, ?2, ?3, ?4, ?5, ?6,
+  ?7, ?8, ?9, ?10, ?11
+)
+`
+
+type SaveTrackTypeParams struct {
+	ID                       []byte
+	Name                     string
+	Color                    string
+	IsRepeating              bool
+	AllowSimultaneousPlay    bool
+	DefaultVolume            int64
+	FadeInMs                 int64
+	FadeOutMs                int64
+	AlwaysStartFromBeginning bool
+	MaxConcurrent            int64
+	AutoStopOthers           bool
+}
+
+func (q *Queries) SaveTrackType(ctx context.Context, arg SaveTrackTypeParams) error {
+	_, err := q.db.ExecContext(ctx, saveTrackType,
+		arg.ID,
+		arg.Name,
+		arg.Color,
+		arg.IsRepeating,
+		arg.AllowSimultaneousPlay,
+		arg.DefaultVolume,
+		arg.FadeInMs,
+		arg.FadeOutMs,
+		arg.AlwaysStartFromBeginning,
+		arg.MaxConcurrent,
+		arg.AutoStopOthers,
+	)
+	return err
+}
+
+const updateTrackType = `-- name: UpdateTrackType :exec
+update track_types
+set
+  name = ?1,
+  color = ?2,
+  is_repeating = ?3,
+  allow_simultaneous_play = ?4,
+  default_volume = ?5,
+  fade_in_ms = ?6,
+  fade_out_ms = ?7,
+  always_start_from_beginning = ?8,
+  max_concurrent = ?9,
+  auto_stop_others = ?10
+where id = ?11
+`
+
+type UpdateTrackTypeParams struct {
+	Name                     string
+	Color                    string
+	IsRepeating              bool
+	AllowSimultaneousPlay    bool
+	DefaultVolume            int64
+	FadeInMs                 int64
+	FadeOutMs                int64
+	AlwaysStartFromBeginning bool
+	MaxConcurrent            int64
+	AutoStopOthers           bool
+	ID                       []byte
+}
+
+func (q *Queries) UpdateTrackType(ctx context.Context, arg UpdateTrackTypeParams) error {
+	_, err := q.db.ExecContext(ctx, updateTrackType,
+		arg.Name,
+		arg.Color,
+		arg.IsRepeating,
+		arg.AllowSimultaneousPlay,
+		arg.DefaultVolume,
+		arg.FadeInMs,
+		arg.FadeOutMs,
+		arg.AlwaysStartFromBeginning,
+		arg.MaxConcurrent,
+		arg.AutoStopOthers,
+		arg.ID,
+	)
+	return err
+}
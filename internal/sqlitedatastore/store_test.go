@@ -0,0 +1,100 @@
+package sqlitedatastore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/terrabitz/rpg-audio-streamer/internal/server"
+	"github.com/terrabitz/rpg-audio-streamer/internal/server/storetest"
+)
+
+func newTestDB(t *testing.T) *SQLiteDatastore {
+	t.Helper()
+
+	db, err := New(Config{
+		Path:         filepath.Join(t.TempDir(), "skaldbot.db"),
+		JournalMode:  "wal",
+		Synchronous:  "normal",
+		BusyTimeout:  5 * time.Second,
+		MaxOpenConns: 4,
+	})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrations, err := NewMigration(os.DirFS("../../sql/migrations"), db)
+	if err != nil {
+		t.Fatalf("failed to initialize migrations: %v", err)
+	}
+	if err := migrations.Up(); err != nil {
+		t.Fatalf("failed to apply migrations: %v", err)
+	}
+
+	return db
+}
+
+func TestStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) server.Store {
+		return newTestDB(t)
+	})
+}
+
+func TestPragmas(t *testing.T) {
+	db := newTestDB(t)
+
+	for pragma, expected := range map[string]string{
+		"journal_mode": "wal",
+		"foreign_keys": "1",
+		"busy_timeout": "5000",
+		"synchronous":  "1",
+	} {
+		var value string
+		if err := db.QueryRowContext(t.Context(), "PRAGMA "+pragma).Scan(&value); err != nil {
+			t.Fatalf("failed to read %s: %v", pragma, err)
+		}
+		if value != expected {
+			t.Errorf("expected %s to be %s; got %s", pragma, expected, value)
+		}
+	}
+}
+
+func TestMaintenance(t *testing.T) {
+	db := newTestDB(t)
+
+	if err := db.Optimize(t.Context()); err != nil {
+		t.Fatalf("failed to optimize: %v", err)
+	}
+
+	problems, err := db.CheckIntegrity(t.Context())
+	if err != nil {
+		t.Fatalf("failed to check integrity: %v", err)
+	}
+	if len(problems) > 0 {
+		t.Fatalf("expected no problems; got %v", problems)
+	}
+
+	// Break a reference behind the foreign key's back, on a connection of its
+	// own so the others still enforce them.
+	conn, err := db.Conn(t.Context())
+	if err != nil {
+		t.Fatalf("failed to get connection: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(t.Context(), "PRAGMA foreign_keys = OFF"); err != nil {
+		t.Fatalf("failed to turn off foreign keys: %v", err)
+	}
+	if _, err := conn.ExecContext(t.Context(), "INSERT INTO track_plays (track_id, started_at) VALUES (x'00', '2024-05-01T12:00:00Z')"); err != nil {
+		t.Fatalf("failed to insert orphan: %v", err)
+	}
+
+	problems, err = db.CheckIntegrity(t.Context())
+	if err != nil {
+		t.Fatalf("failed to check integrity: %v", err)
+	}
+	if len(problems) != 1 {
+		t.Errorf("expected the orphaned play; got %v", problems)
+	}
+}
@@ -2,8 +2,14 @@ package sqlitedatastore
 
 import (
 	"context"
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/google/uuid"
 	"github.com/terrabitz/rpg-audio-streamer/internal/server"
@@ -11,23 +17,82 @@ import (
 )
 
 func (db *SQLiteDatastore) SaveTrack(ctx context.Context, track *server.Track) error {
+	metadata, err := encodeMetadata(track.Metadata)
+	if err != nil {
+		return err
+	}
+
 	dbTrack := sqlitedb.SaveTrackParams{
 		ID:        track.ID[:],
 		CreatedAt: track.CreatedAt.Format(time.RFC3339),
 		Name:      track.Name,
 		Path:      track.Path,
 		TypeID:    track.TypeID[:],
+		Duration:  track.Duration,
+		Metadata:  metadata,
 	}
 
 	if err := sqlitedb.New(db.DB).SaveTrack(ctx, dbTrack); err != nil {
-		return fmt.Errorf("couldn't save track to SQLite: %w", err)
+		return fmt.Errorf("couldn't save track to SQLite: %w", wrapError(err))
 	}
 
 	return nil
 }
 
-func (db *SQLiteDatastore) GetTracks(ctx context.Context) ([]server.Track, error) {
-	dbTracks, err := sqlitedb.New(db.DB).GetTracks(ctx)
+func (db *SQLiteDatastore) SetTrackMedia(ctx context.Context, trackID uuid.UUID, duration float64, metadata map[string]string) error {
+	encoded, err := encodeMetadata(metadata)
+	if err != nil {
+		return err
+	}
+
+	params := sqlitedb.SetTrackMediaParams{
+		Duration: duration,
+		Metadata: encoded,
+		ID:       trackID[:],
+	}
+	if err := sqlitedb.New(db.DB).SetTrackMedia(ctx, params); err != nil {
+		return fmt.Errorf("couldn't update track media in SQLite: %w", wrapError(err))
+	}
+
+	return nil
+}
+
+func encodeMetadata(metadata map[string]string) (string, error) {
+	if metadata == nil {
+		return "{}", nil
+	}
+
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return "", fmt.Errorf("couldn't encode track metadata: %w", err)
+	}
+	return string(encoded), nil
+}
+
+func (db *SQLiteDatastore) GetTracks(ctx context.Context, filter server.TrackFilter, page server.TrackPage) ([]server.Track, error) {
+	query, typeID, tag, minID, maxID := trackFilterParams(filter)
+	params := sqlitedb.GetTracksParams{
+		Query:        query,
+		TypeID:       typeID,
+		Tag:          tag,
+		MinID:        minID,
+		MaxID:        maxID,
+		CollectionID: nullableUUID(filter.CollectionID),
+		Favorite:     nullableBool(filter.Favorite),
+		SortBy:       string(page.Sort),
+		Descending:   page.Descending,
+		Limit:        int64(page.Limit),
+	}
+
+	if page.After != nil {
+		params.CursorID = page.After.ID[:]
+		params.CursorName = page.After.Name
+		params.CursorDuration = page.After.Duration
+		params.CursorTypeID = page.After.TypeID[:]
+		params.CursorPosition = int64(page.After.Position)
+	}
+
+	dbTracks, err := sqlitedb.New(db.DB).GetTracks(ctx, params)
 	if err != nil {
 		return nil, err
 	}
@@ -43,10 +108,72 @@ func (db *SQLiteDatastore) GetTracks(ctx context.Context) ([]server.Track, error
 	return result, nil
 }
 
+func (db *SQLiteDatastore) CountTracks(ctx context.Context, filter server.TrackFilter) (int, error) {
+	query, typeID, tag, minID, maxID := trackFilterParams(filter)
+	count, err := sqlitedb.New(db.DB).CountTracks(ctx, sqlitedb.CountTracksParams{
+		Query:        query,
+		TypeID:       typeID,
+		Tag:          tag,
+		MinID:        minID,
+		MaxID:        maxID,
+		CollectionID: nullableUUID(filter.CollectionID),
+		Favorite:     nullableBool(filter.Favorite),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("couldn't count tracks: %w", err)
+	}
+
+	return int(count), nil
+}
+
+// trackFilterParams converts a filter into query parameters. The creation
+// date range is matched against track IDs rather than created_at: IDs are
+// UUIDv7, whose leading bytes are the big-endian creation time in
+// milliseconds, so they compare chronologically and are indexed.
+func trackFilterParams(filter server.TrackFilter) (query sql.NullString, typeID []byte, tag sql.NullString, minID, maxID []byte) {
+	if q := ftsQuery(filter.Query); q != "" {
+		query = sql.NullString{String: q, Valid: true}
+	}
+
+	if filter.Tag != "" {
+		tag = sql.NullString{String: filter.Tag, Valid: true}
+	}
+
+	if filter.TypeID != nil {
+		typeID = filter.TypeID[:]
+	}
+
+	if !filter.CreatedAfter.IsZero() {
+		minID = uuidV7Floor(filter.CreatedAfter)
+	}
+
+	if !filter.CreatedBefore.IsZero() {
+		maxID = uuidV7Floor(filter.CreatedBefore)
+	}
+
+	return query, typeID, tag, minID, maxID
+}
+
+func nullableBool(b *bool) sql.NullBool {
+	if b == nil {
+		return sql.NullBool{}
+	}
+	return sql.NullBool{Bool: *b, Valid: true}
+}
+
+// uuidV7Floor returns the smallest possible UUIDv7 created at t.
+func uuidV7Floor(t time.Time) []byte {
+	var id [16]byte
+	var ms [8]byte
+	binary.BigEndian.PutUint64(ms[:], uint64(t.UnixMilli()))
+	copy(id[:6], ms[2:])
+	return id[:]
+}
+
 func (db *SQLiteDatastore) GetTrackByID(ctx context.Context, trackID uuid.UUID) (server.Track, error) {
 	dbTrack, err := sqlitedb.New(db.DB).GetTrackByID(ctx, trackID[:])
 	if err != nil {
-		return server.Track{}, fmt.Errorf("couldn't get track by ID: %w", err)
+		return server.Track{}, fmt.Errorf("couldn't get track by ID: %w", wrapError(err))
 	}
 
 	return convertDBTrack(dbTrack)
@@ -57,6 +184,111 @@ func (db *SQLiteDatastore) DeleteTrack(ctx context.Context, trackID uuid.UUID) e
 }
 
 func (db *SQLiteDatastore) UpdateTrack(ctx context.Context, trackID uuid.UUID, update server.UpdateTrackRequest) (server.Track, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return server.Track{}, fmt.Errorf("couldn't start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	track, err := updateTrack(ctx, sqlitedb.New(db.DB).WithTx(tx), trackID, update)
+	if err != nil {
+		return server.Track{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return server.Track{}, fmt.Errorf("couldn't commit track update: %w", err)
+	}
+
+	return track, nil
+}
+
+func (db *SQLiteDatastore) ModifyTracks(ctx context.Context, trackIDs []uuid.UUID, modify func(server.Track) server.UpdateTrackRequest) ([]server.Track, []server.Track, error) {
+	// Transactions take the write lock as they start, so nothing can change
+	// the tracks between reading and writing them.
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("couldn't start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	queries := sqlitedb.New(db.DB).WithTx(tx)
+	before, err := getTracks(ctx, queries, trackIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	after := make([]server.Track, 0, len(before))
+	for _, track := range before {
+		updated, err := updateTrack(ctx, queries, track.ID, modify(track))
+		if err != nil {
+			return nil, nil, fmt.Errorf("couldn't update track %s: %w", track.ID, err)
+		}
+		after = append(after, updated)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("couldn't commit track updates: %w", err)
+	}
+
+	return before, after, nil
+}
+
+func (db *SQLiteDatastore) DeleteTracks(ctx context.Context, trackIDs []uuid.UUID) ([]server.Track, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	queries := sqlitedb.New(db.DB).WithTx(tx)
+	tracks, err := getTracks(ctx, queries, trackIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, trackID := range trackIDs {
+		if err := queries.DeleteTrackByID(ctx, trackID[:]); err != nil {
+			return nil, fmt.Errorf("couldn't delete track %s: %w", trackID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("couldn't commit track deletion: %w", err)
+	}
+
+	return tracks, nil
+}
+
+// getTracks reads several tracks within a transaction. It fails with a
+// *server.MissingTracksError if any don't exist.
+func getTracks(ctx context.Context, queries *sqlitedb.Queries, trackIDs []uuid.UUID) ([]server.Track, error) {
+	tracks := make([]server.Track, 0, len(trackIDs))
+	var missing []uuid.UUID
+	for _, trackID := range trackIDs {
+		dbTrack, err := queries.GetTrackByID(ctx, trackID[:])
+		if errors.Is(err, sql.ErrNoRows) {
+			missing = append(missing, trackID)
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("couldn't get track %s: %w", trackID, err)
+		}
+
+		track, err := convertDBTrack(dbTrack)
+		if err != nil {
+			return nil, err
+		}
+		tracks = append(tracks, track)
+	}
+
+	if len(missing) > 0 {
+		return nil, &server.MissingTracksError{TrackIDs: missing}
+	}
+	return tracks, nil
+}
+
+// updateTrack applies an update to a track within a transaction and returns
+// the updated track.
+func updateTrack(ctx context.Context, queries *sqlitedb.Queries, trackID uuid.UUID, update server.UpdateTrackRequest) (server.Track, error) {
 	params := sqlitedb.UpdateTrackParams{
 		ID: trackID[:],
 	}
@@ -70,15 +302,146 @@ func (db *SQLiteDatastore) UpdateTrack(ctx context.Context, trackID uuid.UUID, u
 		params.TypeID = update.TypeID[:]
 	}
 
-	dbTrack, err := sqlitedb.New(db.DB).UpdateTrack(ctx, params)
+	params.Favorite = nullableBool(update.Favorite)
+
+	for _, field := range []struct {
+		value *string
+		param *sql.NullString
+	}{
+		{update.Description, &params.Description},
+		{update.Artist, &params.Artist},
+		{update.SourceURL, &params.SourceUrl},
+		{update.License, &params.License},
+		{update.Attribution, &params.Attribution},
+	} {
+		if field.value != nil {
+			*field.param = sql.NullString{String: *field.value, Valid: true}
+		}
+	}
+
+	if _, err := queries.UpdateTrack(ctx, params); err != nil {
+		return server.Track{}, fmt.Errorf("couldn't update track in SQLite: %w", wrapError(err))
+	}
+
+	if update.Tags != nil {
+		if err := setTrackTags(ctx, queries, trackID, *update.Tags); err != nil {
+			return server.Track{}, err
+		}
+	}
+
+	if update.Collections != nil {
+		if err := setTrackCollections(ctx, queries, trackID, *update.Collections); err != nil {
+			return server.Track{}, err
+		}
+	}
+
+	dbTrack, err := queries.GetTrackByID(ctx, trackID[:])
 	if err != nil {
-		return server.Track{}, fmt.Errorf("couldn't update track in SQLite: %w", err)
+		return server.Track{}, fmt.Errorf("couldn't get updated track: %w", wrapError(err))
 	}
 
 	return convertDBTrack(dbTrack)
 }
 
-func convertDBTrack(dbTrack sqlitedb.Track) (server.Track, error) {
+func (db *SQLiteDatastore) ReorderTracks(ctx context.Context, typeID uuid.UUID, trackIDs []uuid.UUID) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("couldn't start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	queries := sqlitedb.New(db.DB).WithTx(tx)
+	current, err := queries.GetTrackIDsByType(ctx, typeID[:])
+	if err != nil {
+		return fmt.Errorf("couldn't get tracks of type: %w", err)
+	}
+
+	remaining := make(map[uuid.UUID]bool, len(current))
+	for _, id := range current {
+		trackID, err := uuid.FromBytes(id)
+		if err != nil {
+			return fmt.Errorf("invalid track ID: %w", err)
+		}
+		remaining[trackID] = true
+	}
+
+	if len(trackIDs) != len(remaining) {
+		return server.ErrTrackOrderMismatch
+	}
+
+	for position, trackID := range trackIDs {
+		if !remaining[trackID] {
+			return server.ErrTrackOrderMismatch
+		}
+		delete(remaining, trackID)
+
+		if err := queries.SetTrackPosition(ctx, sqlitedb.SetTrackPositionParams{
+			Position: int64(position),
+			ID:       trackID[:],
+		}); err != nil {
+			return fmt.Errorf("couldn't set track position: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("couldn't commit track order: %w", err)
+	}
+
+	return nil
+}
+
+func (db *SQLiteDatastore) GetTags(ctx context.Context) ([]string, error) {
+	return sqlitedb.New(db.DB).GetTags(ctx)
+}
+
+// setTrackTags replaces a track's tags, creating any new ones and dropping
+// tags no track uses anymore.
+func setTrackTags(ctx context.Context, queries *sqlitedb.Queries, trackID uuid.UUID, tags []string) error {
+	if err := queries.DeleteTrackTags(ctx, trackID[:]); err != nil {
+		return fmt.Errorf("couldn't clear track tags: %w", err)
+	}
+
+	for _, tag := range tags {
+		tagID := uuid.New()
+		id, err := queries.UpsertTag(ctx, sqlitedb.UpsertTagParams{
+			ID:   tagID[:],
+			Name: tag,
+		})
+		if err != nil {
+			return fmt.Errorf("couldn't save tag '%s': %w", tag, err)
+		}
+
+		if err := queries.AddTrackTag(ctx, sqlitedb.AddTrackTagParams{
+			TrackID: trackID[:],
+			TagID:   id,
+		}); err != nil {
+			return fmt.Errorf("couldn't tag track with '%s': %w", tag, wrapError(err))
+		}
+	}
+
+	if err := queries.DeleteUnusedTags(ctx); err != nil {
+		return fmt.Errorf("couldn't remove unused tags: %w", err)
+	}
+
+	return nil
+}
+
+// ftsQuery turns free text into an FTS5 query matching every word as a
+// prefix, so half-typed words still find tracks and user input can never be
+// an FTS syntax error.
+func ftsQuery(text string) string {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	for i, word := range words {
+		words[i] = `"` + word + `"*`
+	}
+
+	return strings.Join(words, " ")
+}
+
+func convertDBTrack(dbTrack sqlitedb.TrackDetail) (server.Track, error) {
 	id, err := uuid.FromBytes(dbTrack.ID)
 	if err != nil {
 		return server.Track{}, fmt.Errorf("invalid ID: %w", err)
@@ -94,11 +457,38 @@ func convertDBTrack(dbTrack sqlitedb.Track) (server.Track, error) {
 		return server.Track{}, fmt.Errorf("error converting track type ID to UUID: %w", err)
 	}
 
+	var metadata map[string]string
+	if err := json.Unmarshal([]byte(dbTrack.Metadata), &metadata); err != nil {
+		return server.Track{}, fmt.Errorf("invalid Metadata: %w", err)
+	}
+
+	var tags []string
+	if err := json.Unmarshal([]byte(dbTrack.Tags), &tags); err != nil {
+		return server.Track{}, fmt.Errorf("invalid Tags: %w", err)
+	}
+
+	// Collection IDs come out of the view hex encoded, as JSON has no blobs
+	var collections []uuid.UUID
+	if err := json.Unmarshal([]byte(dbTrack.Collections), &collections); err != nil {
+		return server.Track{}, fmt.Errorf("invalid Collections: %w", err)
+	}
+
 	return server.Track{
-		ID:        id,
-		CreatedAt: createdAt,
-		Name:      dbTrack.Name,
-		Path:      dbTrack.Path,
-		TypeID:    typeID,
+		ID:          id,
+		CreatedAt:   createdAt,
+		Name:        dbTrack.Name,
+		Path:        dbTrack.Path,
+		TypeID:      typeID,
+		Duration:    dbTrack.Duration,
+		Metadata:    metadata,
+		Tags:        tags,
+		Position:    int(dbTrack.Position),
+		Collections: collections,
+		Description: dbTrack.Description,
+		Artist:      dbTrack.Artist,
+		SourceURL:   dbTrack.SourceUrl,
+		License:     dbTrack.License,
+		Attribution: dbTrack.Attribution,
+		Favorite:    dbTrack.Favorite,
 	}, nil
 }
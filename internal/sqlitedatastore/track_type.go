@@ -2,6 +2,7 @@ package sqlitedatastore
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/google/uuid"
 	"github.com/terrabitz/rpg-audio-streamer/internal/server"
@@ -28,12 +29,90 @@ func (db *SQLiteDatastore) GetTrackTypes(ctx context.Context) ([]server.TrackTyp
 func (db *SQLiteDatastore) GetTrackTypeByID(ctx context.Context, id uuid.UUID) (server.TrackType, error) {
 	dbTrackType, err := sqlitedb.New(db.DB).GetTrackTypeByID(ctx, id[:])
 	if err != nil {
-		return server.TrackType{}, err
+		return server.TrackType{}, wrapError(err)
 	}
 
 	return convertDBTrackType(dbTrackType)
 }
 
+func (db *SQLiteDatastore) SaveTrackType(ctx context.Context, trackType server.TrackType) error {
+	if err := sqlitedb.New(db.DB).SaveTrackType(ctx, sqlitedb.SaveTrackTypeParams{
+		ID:                       trackType.ID[:],
+		Name:                     trackType.Name,
+		Color:                    trackType.Color,
+		IsRepeating:              trackType.IsRepeating,
+		AllowSimultaneousPlay:    trackType.AllowSimultaneousPlay,
+		DefaultVolume:            int64(trackType.DefaultVolume),
+		FadeInMs:                 int64(trackType.FadeInMs),
+		FadeOutMs:                int64(trackType.FadeOutMs),
+		AlwaysStartFromBeginning: trackType.AlwaysStartFromBeginning,
+		MaxConcurrent:            int64(trackType.MaxConcurrent),
+		AutoStopOthers:           trackType.AutoStopOthers,
+	}); err != nil {
+		return fmt.Errorf("couldn't save track type to SQLite: %w", wrapError(err))
+	}
+
+	return nil
+}
+
+func (db *SQLiteDatastore) UpdateTrackType(ctx context.Context, trackType server.TrackType) error {
+	if err := sqlitedb.New(db.DB).UpdateTrackType(ctx, sqlitedb.UpdateTrackTypeParams{
+		Name:                     trackType.Name,
+		Color:                    trackType.Color,
+		IsRepeating:              trackType.IsRepeating,
+		AllowSimultaneousPlay:    trackType.AllowSimultaneousPlay,
+		DefaultVolume:            int64(trackType.DefaultVolume),
+		FadeInMs:                 int64(trackType.FadeInMs),
+		FadeOutMs:                int64(trackType.FadeOutMs),
+		AlwaysStartFromBeginning: trackType.AlwaysStartFromBeginning,
+		MaxConcurrent:            int64(trackType.MaxConcurrent),
+		AutoStopOthers:           trackType.AutoStopOthers,
+		ID:                       trackType.ID[:],
+	}); err != nil {
+		return fmt.Errorf("couldn't update track type in SQLite: %w", wrapError(err))
+	}
+
+	return nil
+}
+
+func (db *SQLiteDatastore) DeleteTrackType(ctx context.Context, id uuid.UUID, reassignTo *uuid.UUID) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("couldn't start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	queries := sqlitedb.New(db.DB).WithTx(tx)
+	if reassignTo != nil {
+		if err := queries.MoveTracksToType(ctx, sqlitedb.MoveTracksToTypeParams{
+			NewTypeID: reassignTo[:],
+			OldTypeID: id[:],
+		}); err != nil {
+			return fmt.Errorf("couldn't reassign tracks: %w", wrapError(err))
+		}
+	}
+
+	// The foreign key would refuse the delete anyway, but checking first says
+	// why.
+	remaining, err := queries.GetTrackIDsByType(ctx, id[:])
+	if err != nil {
+		return fmt.Errorf("couldn't check for remaining tracks: %w", err)
+	}
+	if len(remaining) > 0 {
+		return server.ErrTrackTypeInUse
+	}
+
+	if err := queries.DeleteTrackType(ctx, id[:]); err != nil {
+		return fmt.Errorf("couldn't delete track type: %w", wrapError(err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("couldn't commit track type deletion: %w", err)
+	}
+
+	return nil
+}
+
 func convertDBTrackType(dbTrackType sqlitedb.TrackType) (server.TrackType, error) {
 	id, err := uuid.FromBytes(dbTrackType.ID)
 	if err != nil {
@@ -41,10 +120,16 @@ func convertDBTrackType(dbTrackType sqlitedb.TrackType) (server.TrackType, error
 	}
 
 	return server.TrackType{
-		ID:                    id,
-		Name:                  dbTrackType.Name,
-		Color:                 dbTrackType.Color,
-		IsRepeating:           dbTrackType.IsRepeating,
-		AllowSimultaneousPlay: dbTrackType.AllowSimultaneousPlay,
+		ID:                       id,
+		Name:                     dbTrackType.Name,
+		Color:                    dbTrackType.Color,
+		IsRepeating:              dbTrackType.IsRepeating,
+		AllowSimultaneousPlay:    dbTrackType.AllowSimultaneousPlay,
+		DefaultVolume:            int(dbTrackType.DefaultVolume),
+		FadeInMs:                 int(dbTrackType.FadeInMs),
+		FadeOutMs:                int(dbTrackType.FadeOutMs),
+		AlwaysStartFromBeginning: dbTrackType.AlwaysStartFromBeginning,
+		MaxConcurrent:            int(dbTrackType.MaxConcurrent),
+		AutoStopOthers:           dbTrackType.AutoStopOthers,
 	}, nil
 }
@@ -0,0 +1,102 @@
+package sqlitedatastore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/terrabitz/rpg-audio-streamer/internal/server"
+	"github.com/terrabitz/rpg-audio-streamer/internal/sqlitedatastore/sqlitedb"
+)
+
+func (db *SQLiteDatastore) SaveUser(ctx context.Context, user server.User) error {
+	if err := sqlitedb.New(db.DB).SaveUser(ctx, sqlitedb.SaveUserParams{
+		ID:           user.ID[:],
+		Username:     user.Username,
+		PasswordHash: user.PasswordHash,
+		Admin:        user.Admin,
+		CreatedAt:    user.CreatedAt.UTC().Format(time.RFC3339),
+	}); err != nil {
+		return fmt.Errorf("couldn't save user to SQLite: %w", wrapError(err))
+	}
+
+	return nil
+}
+
+func (db *SQLiteDatastore) GetUsers(ctx context.Context) ([]server.User, error) {
+	dbUsers, err := sqlitedb.New(db.DB).GetUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get users: %w", err)
+	}
+
+	users := make([]server.User, 0, len(dbUsers))
+	for _, dbUser := range dbUsers {
+		user, err := convertDBUser(dbUser)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+func (db *SQLiteDatastore) GetUserByID(ctx context.Context, id uuid.UUID) (server.User, error) {
+	dbUser, err := sqlitedb.New(db.DB).GetUserByID(ctx, id[:])
+	if err != nil {
+		return server.User{}, fmt.Errorf("couldn't get user by ID: %w", wrapError(err))
+	}
+
+	return convertDBUser(dbUser)
+}
+
+func (db *SQLiteDatastore) GetUserByUsername(ctx context.Context, username string) (server.User, error) {
+	dbUser, err := sqlitedb.New(db.DB).GetUserByUsername(ctx, username)
+	if err != nil {
+		return server.User{}, fmt.Errorf("couldn't get user by username: %w", wrapError(err))
+	}
+
+	return convertDBUser(dbUser)
+}
+
+func (db *SQLiteDatastore) UpdateUser(ctx context.Context, user server.User) error {
+	if err := sqlitedb.New(db.DB).UpdateUser(ctx, sqlitedb.UpdateUserParams{
+		Username:     user.Username,
+		PasswordHash: user.PasswordHash,
+		Admin:        user.Admin,
+		ID:           user.ID[:],
+	}); err != nil {
+		return fmt.Errorf("couldn't update user in SQLite: %w", wrapError(err))
+	}
+
+	return nil
+}
+
+func (db *SQLiteDatastore) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	if err := sqlitedb.New(db.DB).DeleteUser(ctx, id[:]); err != nil {
+		return fmt.Errorf("couldn't delete user: %w", err)
+	}
+
+	return nil
+}
+
+func convertDBUser(dbUser sqlitedb.User) (server.User, error) {
+	id, err := uuid.FromBytes(dbUser.ID)
+	if err != nil {
+		return server.User{}, fmt.Errorf("invalid ID: %w", err)
+	}
+
+	createdAt, err := time.Parse(time.RFC3339, dbUser.CreatedAt)
+	if err != nil {
+		return server.User{}, fmt.Errorf("invalid CreatedAt: %w", err)
+	}
+
+	return server.User{
+		ID:           id,
+		Username:     dbUser.Username,
+		PasswordHash: dbUser.PasswordHash,
+		Admin:        dbUser.Admin,
+		CreatedAt:    createdAt,
+	}, nil
+}
@@ -17,24 +17,53 @@ const (
 )
 
 type Client struct {
+	// ID identifies the connection. A player with several tabs open has a
+	// client for each, all sharing the same PlayerID.
 	ID    string
 	hub   *Hub
 	conn  *websocket.Conn
 	send  chan []byte
 	Token *auth.Token
+
+	// PlayerID, Name and Color identify the player the client belongs to, and
+	// are empty for GMs.
+	PlayerID string
+	Name     string
+	Color    string
 }
 
 func NewClient(hub *Hub, conn *websocket.Conn, token *auth.Token) *Client {
-	return &Client{
+	client := &Client{
 		ID:    uuid.New().String(),
 		hub:   hub,
 		conn:  conn,
 		send:  make(chan []byte, 256),
 		Token: token,
 	}
+	if token.Role == auth.RolePlayer {
+		client.PlayerID = token.Subject
+		client.Name = token.Name
+		client.Color = token.Color
+	}
+
+	return client
+}
+
+// message builds a message sent on behalf of the client, naming the player
+// who sent it.
+func (c *Client) message(method string, payload json.RawMessage) Message {
+	return Message{
+		Method:         method,
+		Payload:        payload,
+		SenderID:       c.ID,
+		SenderPlayerID: c.PlayerID,
+		SenderName:     c.Name,
+		SenderColor:    c.Color,
+	}
 }
 
 func (c *Client) Send(msg Message) error {
+	msg.ServerTime = serverTime(time.Now())
 	j, err := json.Marshal(msg)
 	if err != nil {
 		return fmt.Errorf("couldn't marshal input: %w", err)
@@ -0,0 +1,99 @@
+package websocket
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// serverTime is the hub's clock in fractional milliseconds since the Unix
+// epoch, matching what browsers use for Date.now() and performance.timeOrigin.
+func serverTime(t time.Time) float64 {
+	return float64(t.UnixMicro()) / 1000
+}
+
+// clockSyncPayload stamps a ping with the server's receive and send times.
+// Together with the client's own send and receive times this gives an
+// NTP-style estimate of the client's clock offset:
+//
+//	offset = ((serverReceivedAt - clientSentAt) + (serverSentAt - clientReceivedAt)) / 2
+//
+// Payloads that aren't JSON objects are echoed back unchanged, so older
+// clients that use ping as a plain keepalive keep working.
+func clockSyncPayload(payload json.RawMessage, receivedAt time.Time) json.RawMessage {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &fields); err != nil || fields == nil {
+		return payload
+	}
+
+	fields["serverReceivedAt"] = mustMarshal(serverTime(receivedAt))
+	fields["serverSentAt"] = mustMarshal(serverTime(time.Now()))
+
+	stamped, err := json.Marshal(fields)
+	if err != nil {
+		return payload
+	}
+	return stamped
+}
+
+// withPlaybackStart adds a startedAt timestamp (in server time) to a track
+// state that carries a currentTime, i.e. the moment the track would have been
+// at position zero. Every client can then work out the same position
+// regardless of how long the message took to reach them.
+func withPlaybackStart(track map[string]json.RawMessage, now time.Time) {
+	rawTime, ok := track["currentTime"]
+	if !ok {
+		return
+	}
+
+	var currentTime float64
+	if err := json.Unmarshal(rawTime, &currentTime); err != nil {
+		return
+	}
+
+	track["startedAt"] = mustMarshal(serverTime(now) - currentTime*1000)
+}
+
+func stampSyncTrack(payload json.RawMessage, now time.Time) json.RawMessage {
+	var track map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &track); err != nil || track == nil {
+		return payload
+	}
+
+	withPlaybackStart(track, now)
+
+	stamped, err := json.Marshal(track)
+	if err != nil {
+		return payload
+	}
+	return stamped
+}
+
+func stampSyncAll(payload json.RawMessage, now time.Time) json.RawMessage {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &fields); err != nil || fields == nil {
+		return payload
+	}
+
+	var tracks []map[string]json.RawMessage
+	if err := json.Unmarshal(fields["tracks"], &tracks); err != nil {
+		return payload
+	}
+	for _, track := range tracks {
+		withPlaybackStart(track, now)
+	}
+	fields["tracks"] = mustMarshal(tracks)
+
+	stamped, err := json.Marshal(fields)
+	if err != nil {
+		return payload
+	}
+	return stamped
+}
+
+func mustMarshal(v any) json.RawMessage {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
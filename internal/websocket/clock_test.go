@@ -0,0 +1,106 @@
+package websocket
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestClockSyncPayload(t *testing.T) {
+	receivedAt := time.UnixMilli(1_700_000_000_000)
+
+	t.Run("stamps object payloads", func(t *testing.T) {
+		stamped := clockSyncPayload(json.RawMessage(`{"clientSentAt":1699999999990}`), receivedAt)
+
+		var fields struct {
+			ClientSentAt     float64 `json:"clientSentAt"`
+			ServerReceivedAt float64 `json:"serverReceivedAt"`
+			ServerSentAt     float64 `json:"serverSentAt"`
+		}
+		if err := json.Unmarshal(stamped, &fields); err != nil {
+			t.Fatalf("failed to decode stamped payload: %v", err)
+		}
+
+		if fields.ClientSentAt != 1699999999990 {
+			t.Errorf("expected clientSentAt to be preserved; got %v", fields.ClientSentAt)
+		}
+		if fields.ServerReceivedAt != 1_700_000_000_000 {
+			t.Errorf("expected serverReceivedAt %v; got %v", 1_700_000_000_000, fields.ServerReceivedAt)
+		}
+		if fields.ServerSentAt < fields.ServerReceivedAt {
+			t.Errorf("expected serverSentAt (%v) to be after serverReceivedAt (%v)", fields.ServerSentAt, fields.ServerReceivedAt)
+		}
+	})
+
+	t.Run("echoes other payloads unchanged", func(t *testing.T) {
+		for _, payload := range []string{`"keepalive"`, `null`, `42`} {
+			if got := clockSyncPayload(json.RawMessage(payload), receivedAt); string(got) != payload {
+				t.Errorf("expected %s to be echoed unchanged; got %s", payload, got)
+			}
+		}
+	})
+}
+
+func TestStampSyncTrack(t *testing.T) {
+	now := time.UnixMilli(1_700_000_000_000)
+
+	t.Run("adds playback start for seeks", func(t *testing.T) {
+		stamped := stampSyncTrack(json.RawMessage(`{"fileID":"abc","isPlaying":true,"currentTime":12.5}`), now)
+
+		var track struct {
+			FileID    string  `json:"fileID"`
+			StartedAt float64 `json:"startedAt"`
+		}
+		if err := json.Unmarshal(stamped, &track); err != nil {
+			t.Fatalf("failed to decode stamped payload: %v", err)
+		}
+
+		if track.FileID != "abc" {
+			t.Errorf("expected fileID to be preserved; got %q", track.FileID)
+		}
+		if want := float64(1_700_000_000_000 - 12_500); track.StartedAt != want {
+			t.Errorf("expected startedAt %v; got %v", want, track.StartedAt)
+		}
+	})
+
+	t.Run("leaves other updates alone", func(t *testing.T) {
+		payload := `{"fileID":"abc","volume":50}`
+		var before, after map[string]any
+		json.Unmarshal([]byte(payload), &before)
+		json.Unmarshal(stampSyncTrack(json.RawMessage(payload), now), &after)
+
+		if _, ok := after["startedAt"]; ok {
+			t.Error("expected no startedAt on a volume-only update")
+		}
+		if len(after) != len(before) {
+			t.Errorf("expected %d fields; got %d", len(before), len(after))
+		}
+	})
+}
+
+func TestStampSyncAll(t *testing.T) {
+	now := time.UnixMilli(1_700_000_000_000)
+
+	stamped := stampSyncAll(json.RawMessage(`{"to":"client-1","tracks":[{"fileID":"a","currentTime":1},{"fileID":"b","currentTime":2}]}`), now)
+
+	var payload struct {
+		To     string `json:"to"`
+		Tracks []struct {
+			FileID    string  `json:"fileID"`
+			StartedAt float64 `json:"startedAt"`
+		} `json:"tracks"`
+	}
+	if err := json.Unmarshal(stamped, &payload); err != nil {
+		t.Fatalf("failed to decode stamped payload: %v", err)
+	}
+
+	if payload.To != "client-1" {
+		t.Errorf("expected target to be preserved; got %q", payload.To)
+	}
+	if len(payload.Tracks) != 2 {
+		t.Fatalf("expected 2 tracks; got %d", len(payload.Tracks))
+	}
+	if payload.Tracks[0].StartedAt != 1_700_000_000_000-1_000 || payload.Tracks[1].StartedAt != 1_700_000_000_000-2_000 {
+		t.Errorf("unexpected startedAt values: %+v", payload.Tracks)
+	}
+}
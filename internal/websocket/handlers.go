@@ -2,6 +2,7 @@ package websocket
 
 import (
 	"encoding/json"
+	"time"
 
 	"github.com/terrabitz/rpg-audio-streamer/internal/auth"
 )
@@ -10,12 +11,19 @@ type Message struct {
 	Method   string          `json:"method"`
 	Payload  json.RawMessage `json:"payload"`
 	SenderID string          `json:"senderId"`
+	// SenderPlayerID, SenderName and SenderColor identify the player who sent
+	// the message, and are left out for messages from GMs.
+	SenderPlayerID string  `json:"senderPlayerId,omitempty"`
+	SenderName     string  `json:"senderName,omitempty"`
+	SenderColor    string  `json:"senderColor,omitempty"`
+	ServerTime     float64 `json:"serverTime,omitempty"`
 }
 
 func (h *Hub) handlePing(payload json.RawMessage, c *Client) {
+	receivedAt := time.Now()
 	if err := c.Send(Message{
 		Method:  "pong",
-		Payload: payload,
+		Payload: clockSyncPayload(payload, receivedAt),
 	}); err != nil {
 		h.logger.Error("failed to send pong message", "error", err)
 	}
@@ -27,20 +35,12 @@ func (h *Hub) handleBroadcast(payload json.RawMessage, c *Client) {
 		return
 	}
 
-	h.Broadcast(Message{
-		Method:   "broadcast",
-		SenderID: c.ID,
-		Payload:  payload,
-	}, ExceptClient(c)) // Don't send back to sender
+	h.Broadcast(c.message("broadcast", payload), ExceptClient(c)) // Don't send back to sender
 }
 
 func (h *Hub) handleSyncRequest(payload json.RawMessage, c *Client) {
 	// Only forward to GM clients
-	h.Broadcast(Message{
-		Method:   "syncRequest",
-		SenderID: c.ID,
-		Payload:  payload,
-	}, ToGMOnly())
+	h.Broadcast(c.message("syncRequest", payload), ToGMOnly())
 }
 
 func (h *Hub) handleSyncAll(payload json.RawMessage, c *Client) {
@@ -51,28 +51,33 @@ func (h *Hub) handleSyncAll(payload json.RawMessage, c *Client) {
 
 	// Extract target client ID from payload
 	var syncPayload struct {
-		Tracks []any  `json:"tracks"`
-		To     string `json:"to"`
+		Tracks []struct {
+			FileID    string `json:"fileID"`
+			IsPlaying bool   `json:"isPlaying"`
+		} `json:"tracks"`
+		To string `json:"to"`
 	}
 	if err := json.Unmarshal(payload, &syncPayload); err != nil {
 		h.logger.Error("failed to unmarshal sync payload", "error", err)
 		return
 	}
 
+	// Paused and stopped tracks are in the GM's state too, but players only
+	// get to hear the ones that are playing.
+	for _, track := range syncPayload.Tracks {
+		if track.IsPlaying {
+			h.releaseTrack(track.FileID)
+		}
+	}
+
+	payload = stampSyncAll(payload, time.Now())
+
 	// If a target client is specified, only send to them
 	if syncPayload.To != "" {
-		h.Broadcast(Message{
-			Method:   "syncAll",
-			SenderID: c.ID,
-			Payload:  payload,
-		}, ToClientID(syncPayload.To))
+		h.Broadcast(c.message("syncAll", payload), ToClientID(syncPayload.To))
 	} else {
 		// Otherwise broadcast to all players
-		h.Broadcast(Message{
-			Method:   "syncAll",
-			SenderID: c.ID,
-			Payload:  payload,
-		}, ToPlayersOnly())
+		h.Broadcast(c.message("syncAll", payload), ToPlayersOnly())
 	}
 }
 
@@ -81,9 +86,42 @@ func (h *Hub) handleSyncTrack(payload json.RawMessage, c *Client) {
 		h.logger.Warn("unauthorized syncTrack command", "role", c.Token.Role)
 		return
 	}
-	h.Broadcast(Message{
-		Method:   "syncTrack",
-		SenderID: c.ID,
-		Payload:  payload,
-	}, ToPlayersOnly())
+
+	var syncPayload struct {
+		FileID    string `json:"fileID"`
+		IsPlaying *bool  `json:"isPlaying"`
+	}
+	if err := json.Unmarshal(payload, &syncPayload); err != nil {
+		h.logger.Error("failed to unmarshal syncTrack payload", "error", err)
+		return
+	}
+	if syncPayload.IsPlaying != nil && *syncPayload.IsPlaying {
+		h.releaseTrack(syncPayload.FileID)
+	}
+
+	h.Broadcast(c.message("syncTrack", stampSyncTrack(payload, time.Now())), ToPlayersOnly())
+}
+
+// handlePrefetch forwards a GM's hint that the listed tracks are about to be
+// played, so players can start buffering them ahead of time.
+func (h *Hub) handlePrefetch(payload json.RawMessage, c *Client) {
+	if c.Token.Role != auth.RoleGM {
+		h.logger.Warn("unauthorized prefetch command", "role", c.Token.Role)
+		return
+	}
+
+	var prefetchPayload struct {
+		TrackIDs []string `json:"trackIDs"`
+	}
+	if err := json.Unmarshal(payload, &prefetchPayload); err != nil {
+		h.logger.Error("failed to unmarshal prefetch payload", "error", err)
+		return
+	}
+
+	// Players need to be allowed to fetch the tracks to prefetch them.
+	for _, trackID := range prefetchPayload.TrackIDs {
+		h.releaseTrack(trackID)
+	}
+
+	h.Broadcast(c.message("prefetch", payload), ToPlayersOnly())
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log/slog"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
@@ -19,6 +20,20 @@ type Hub struct {
 	unregister chan *Client
 	logger     *slog.Logger
 	handlers   map[string]HandlerFunc
+	listeners  []CommandListener
+
+	// released holds the tracks players may stream: the ones the GM has
+	// started or prefetched. It's kept in memory for the life of the
+	// process, and entries aren't removed when a track stops, since players
+	// fetch heard tracks again to resume or seek them. After a restart it
+	// fills up again as reconnecting players ask for a sync and the GM
+	// answers with what's playing.
+	releasedMu sync.RWMutex
+	released   map[uuid.UUID]bool
+
+	rtc          RTCForwarder
+	rtcMu        sync.Mutex
+	rtcPublisher string
 }
 
 func NewHub(logger *slog.Logger) *Hub {
@@ -29,6 +44,7 @@ func NewHub(logger *slog.Logger) *Hub {
 		unregister: make(chan *Client),
 		logger:     logger,
 		handlers:   make(map[string]HandlerFunc),
+		released:   make(map[uuid.UUID]bool),
 	}
 
 	hub.HandleFunc("ping", hub.handlePing)
@@ -36,6 +52,10 @@ func NewHub(logger *slog.Logger) *Hub {
 	hub.HandleFunc("syncRequest", hub.handleSyncRequest)
 	hub.HandleFunc("syncAll", hub.handleSyncAll)
 	hub.HandleFunc("syncTrack", hub.handleSyncTrack)
+	hub.HandleFunc("prefetch", hub.handlePrefetch)
+	hub.HandleFunc("rtcPublish", hub.handleRTCPublish)
+	hub.HandleFunc("rtcSubscribe", hub.handleRTCSubscribe)
+	hub.HandleFunc("rtcUnpublish", hub.handleRTCUnpublish)
 
 	return hub
 }
@@ -54,6 +74,7 @@ func (h *Hub) Run() {
 				close(client.send)
 			}
 			h.clientsMu.Unlock()
+			go h.rtcDisconnect(client)
 		case message := <-h.broadcast:
 			h.clientsMu.RLock()
 			for client := range h.clients {
@@ -70,20 +91,23 @@ func (h *Hub) Run() {
 }
 
 func (h *Hub) Register(conn *websocket.Conn, token *auth.Token) {
-	client := &Client{
-		ID:    uuid.New().String(),
-		hub:   h,
-		conn:  conn,
-		send:  make(chan []byte, 256),
-		Token: token,
-	}
+	client := NewClient(h, conn, token)
 
 	h.logger.Debug("new websocket connection",
 		"clientId", client.ID,
 		"role", token.Role,
+		"playerId", client.PlayerID,
+		"name", client.Name,
 	)
 	h.register <- client
 
+	// Players joining mid-broadcast need to know to subscribe.
+	if token.Role == auth.RolePlayer && h.isRTCLive() {
+		if err := client.Send(Message{Method: "rtcPublished", Payload: json.RawMessage(`{}`)}); err != nil {
+			h.logger.Error("failed to send rtcPublished message", "error", err)
+		}
+	}
+
 	go client.WritePump()
 	go client.ReadPump()
 }
@@ -120,6 +144,7 @@ func ToClientID(clientID string) BroadcastOption {
 }
 
 func (h *Hub) Broadcast(msg Message, opts ...BroadcastOption) error {
+	msg.ServerTime = serverTime(time.Now())
 	jsonMsg, err := json.Marshal(msg)
 	if err != nil {
 		return fmt.Errorf("couldn't marshal JSON: %w", err)
@@ -156,6 +181,25 @@ func (h *Hub) Broadcast(msg Message, opts ...BroadcastOption) error {
 	return nil
 }
 
+// NotifyGMs sends a server-originated message to every connected GM.
+func (h *Hub) NotifyGMs(method string, payload any) error {
+	return h.notify(method, payload, ToGMOnly())
+}
+
+// NotifyAll sends a server-originated message to every connected client.
+func (h *Hub) NotifyAll(method string, payload any) error {
+	return h.notify(method, payload, ToAll())
+}
+
+func (h *Hub) notify(method string, payload any, opts ...BroadcastOption) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("couldn't marshal payload: %w", err)
+	}
+
+	return h.Broadcast(Message{Method: method, Payload: data}, opts...)
+}
+
 // ForEachClient allows iterating over clients with a filter
 func (h *Hub) ForEachClient(fn func(*Client), opts ...BroadcastOption) {
 	filter := func(c *Client) bool {
@@ -180,8 +224,47 @@ func (h *Hub) ForEachClient(fn func(*Client), opts ...BroadcastOption) {
 	h.clientsMu.RUnlock()
 }
 
+// DisconnectJoinToken closes the connections of players who joined with the
+// given join token. Their clients are unregistered as the connections fail.
+func (h *Hub) DisconnectJoinToken(joinTokenID string) {
+	h.ForEachClient(func(c *Client) {
+		h.logger.Debug("disconnecting player", "clientId", c.ID, "joinTokenId", joinTokenID)
+		c.conn.Close()
+	}, func(c *Client) bool {
+		return c.Token.JoinTokenID == joinTokenID
+	})
+}
+
+// releaseTrack marks a track as having been played by the GM, which allows
+// players to stream it until the server restarts.
+func (h *Hub) releaseTrack(fileID string) {
+	trackID, err := uuid.Parse(fileID)
+	if err != nil {
+		return
+	}
+
+	h.releasedMu.Lock()
+	h.released[trackID] = true
+	h.releasedMu.Unlock()
+}
+
+func (h *Hub) IsTrackReleased(trackID uuid.UUID) bool {
+	h.releasedMu.RLock()
+	defer h.releasedMu.RUnlock()
+	return h.released[trackID]
+}
+
 type HandlerFunc func(payload json.RawMessage, c *Client)
 
+type CommandListener func(method string, payload json.RawMessage, c *Client)
+
+// OnGMCommand registers a listener that observes every command a GM sends
+// through the hub, so server-side state can follow along with the table.
+// Listeners must be registered before the hub starts serving clients.
+func (h *Hub) OnGMCommand(fn CommandListener) {
+	h.listeners = append(h.listeners, fn)
+}
+
 func (h *Hub) HandleFunc(name string, fn func(payload json.RawMessage, c *Client)) {
 	h.handlers[name] = fn
 }
@@ -212,4 +295,10 @@ func (h *Hub) route(message []byte, c *Client) {
 	)
 
 	fn(msg.Payload, c)
+
+	if c.Token.Role == auth.RoleGM {
+		for _, listener := range h.listeners {
+			listener(msg.Method, msg.Payload, c)
+		}
+	}
 }
@@ -0,0 +1,210 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/terrabitz/rpg-audio-streamer/internal/auth"
+)
+
+func TestNotifyGMs(t *testing.T) {
+	h := NewHub(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	gm := newTestClient(h, "gm", auth.RoleGM)
+	player := newTestClient(h, "player", auth.RolePlayer)
+
+	if err := h.NotifyGMs("trackOrder", map[string]any{"trackIDs": []string{"a", "b"}}); err != nil {
+		t.Fatalf("NotifyGMs failed: %v", err)
+	}
+
+	msg := nextMessage(t, gm)
+	if msg.Method != "trackOrder" || string(msg.Payload) != `{"trackIDs":["a","b"]}` {
+		t.Errorf("unexpected GM message %+v", msg)
+	}
+
+	if msg := nextMessage(t, player); msg.Method != "" {
+		t.Errorf("expected players not to be notified; got %s", msg.Method)
+	}
+}
+
+func TestNotifyAll(t *testing.T) {
+	h := NewHub(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	gm := newTestClient(h, "gm", auth.RoleGM)
+	player := newTestClient(h, "player", auth.RolePlayer)
+
+	if err := h.NotifyAll("trackTypes", []string{"Music"}); err != nil {
+		t.Fatalf("NotifyAll failed: %v", err)
+	}
+
+	for _, c := range []*Client{gm, player} {
+		if msg := nextMessage(t, c); msg.Method != "trackTypes" || string(msg.Payload) != `["Music"]` {
+			t.Errorf("unexpected message for %s: %+v", c.ID, msg)
+		}
+	}
+}
+
+func TestSenderIdentity(t *testing.T) {
+	h := NewHub(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	gm := newTestClient(h, "gm", auth.RoleGM)
+	player := NewClient(h, nil, &auth.Token{
+		Role:    auth.RolePlayer,
+		Subject: "player-id",
+		Name:    "Alice",
+		Color:   "#FF8A80",
+	})
+	h.clients[player] = true
+
+	h.route(mustMarshal(Message{Method: "syncRequest", Payload: json.RawMessage(`{}`)}), player)
+	msg := nextMessage(t, gm)
+	if msg.SenderID != player.ID || msg.SenderPlayerID != "player-id" || msg.SenderName != "Alice" || msg.SenderColor != "#FF8A80" {
+		t.Errorf("expected the GM to see who asked to sync; got %+v", msg)
+	}
+
+	h.route(mustMarshal(Message{Method: "syncAll", Payload: mustMarshal(map[string]any{"to": player.ID})}), gm)
+	msg = nextMessage(t, player)
+	if msg.Method != "syncAll" || msg.SenderID != "gm" || msg.SenderPlayerID != "" || msg.SenderName != "" {
+		t.Errorf("expected GM messages not to name a player; got %+v", msg)
+	}
+}
+
+func TestDisconnectJoinToken(t *testing.T) {
+	h := NewHub(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	go h.Run()
+
+	tokens := make(chan *auth.Token, 1)
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		h.Register(conn, <-tokens)
+	}))
+	defer srv.Close()
+
+	dial := func(joinTokenID string) *websocket.Conn {
+		t.Helper()
+		tokens <- &auth.Token{Role: auth.RolePlayer, JoinTokenID: joinTokenID}
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+		if err != nil {
+			t.Fatalf("failed to connect: %v", err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		return conn
+	}
+	revoked := dial("revoked")
+	kept := dial("kept")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		h.clientsMu.RLock()
+		registered := len(h.clients)
+		h.clientsMu.RUnlock()
+		if registered == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected 2 clients to register; got %d", registered)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	h.DisconnectJoinToken("revoked")
+	if err := h.NotifyAll("trackTypes", []string{"Music"}); err != nil {
+		t.Fatalf("NotifyAll failed: %v", err)
+	}
+
+	revoked.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		_, _, err := revoked.ReadMessage()
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			t.Fatal("expected the revoked player to be disconnected")
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	kept.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, msg, err := kept.ReadMessage(); err != nil || !strings.Contains(string(msg), "trackTypes") {
+		t.Errorf("expected the other player to stay connected; got %q, %v", msg, err)
+	}
+}
+
+func TestPrefetch(t *testing.T) {
+	h := NewHub(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	gm := newTestClient(h, "gm", auth.RoleGM)
+	player := newTestClient(h, "player", auth.RolePlayer)
+	trackID := uuid.New()
+
+	t.Run("players can't send prefetch hints", func(t *testing.T) {
+		h.route(mustMarshal(Message{Method: "prefetch", Payload: mustMarshal(map[string]any{"trackIDs": []uuid.UUID{trackID}})}), player)
+
+		if msg := nextMessage(t, player); msg.Method != "" {
+			t.Errorf("expected player's prefetch to be dropped; got %+v", msg)
+		}
+		if h.IsTrackReleased(trackID) {
+			t.Error("expected player's prefetch not to release the track")
+		}
+	})
+
+	t.Run("GM prefetch reaches players", func(t *testing.T) {
+		h.route(mustMarshal(Message{Method: "prefetch", Payload: mustMarshal(map[string]any{"trackIDs": []uuid.UUID{trackID}})}), gm)
+
+		msg := nextMessage(t, player)
+		var payload struct {
+			TrackIDs []uuid.UUID `json:"trackIDs"`
+		}
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			t.Fatalf("failed to decode prefetch payload: %v", err)
+		}
+		if msg.Method != "prefetch" || len(payload.TrackIDs) != 1 || payload.TrackIDs[0] != trackID {
+			t.Errorf("expected player to get the prefetch hint; got %+v", msg)
+		}
+		if msg := nextMessage(t, gm); msg.Method != "" {
+			t.Errorf("expected the GM not to get their own hint; got %s", msg.Method)
+		}
+		if !h.IsTrackReleased(trackID) {
+			t.Error("expected prefetched track to be released to players")
+		}
+	})
+}
+
+func TestReleaseTracks(t *testing.T) {
+	h := NewHub(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	gm := newTestClient(h, "gm", auth.RoleGM)
+	playing, paused, updated, stopped := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	h.route(mustMarshal(Message{Method: "syncAll", Payload: mustMarshal(map[string]any{
+		"tracks": []map[string]any{
+			{"fileID": playing, "isPlaying": true},
+			{"fileID": paused, "isPlaying": false},
+		},
+	})}), gm)
+	h.route(mustMarshal(Message{Method: "syncTrack", Payload: mustMarshal(map[string]any{"fileID": updated, "volume": 50})}), gm)
+	h.route(mustMarshal(Message{Method: "syncTrack", Payload: mustMarshal(map[string]any{"fileID": stopped, "isPlaying": false})}), gm)
+
+	if !h.IsTrackReleased(playing) {
+		t.Error("expected playing track to be released")
+	}
+	for name, id := range map[string]uuid.UUID{"paused": paused, "updated": updated, "stopped": stopped} {
+		if h.IsTrackReleased(id) {
+			t.Errorf("expected %s track not to be released", name)
+		}
+	}
+
+	h.route(mustMarshal(Message{Method: "syncTrack", Payload: mustMarshal(map[string]any{"fileID": stopped, "isPlaying": true})}), gm)
+	h.route(mustMarshal(Message{Method: "syncTrack", Payload: mustMarshal(map[string]any{"fileID": stopped, "isPlaying": false})}), gm)
+	if !h.IsTrackReleased(stopped) {
+		t.Error("expected a track to stay released once it has been played")
+	}
+}
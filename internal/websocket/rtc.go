@@ -0,0 +1,193 @@
+package websocket
+
+import (
+	"encoding/json"
+
+	"github.com/terrabitz/rpg-audio-streamer/internal/auth"
+)
+
+// RTCForwarder relays live WebRTC audio from a publishing GM to subscribing
+// players. The hub carries the SDP offers and answers between them.
+type RTCForwarder interface {
+	Publish(peerID, offer string) (answer string, err error)
+	Subscribe(peerID, offer string) (answer string, err error)
+	Remove(peerID string)
+	// OnPublisherLost registers a function to call when the publisher's
+	// connection fails.
+	OnPublisherLost(f func(peerID string))
+}
+
+// SetRTCForwarder enables live WebRTC audio. It must be called before the hub
+// starts serving clients.
+func (h *Hub) SetRTCForwarder(f RTCForwarder) {
+	h.rtc = f
+	f.OnPublisherLost(h.rtcPublisherLost)
+}
+
+type rtcSessionPayload struct {
+	SDP string `json:"sdp"`
+}
+
+type rtcErrorPayload struct {
+	Error string `json:"error"`
+}
+
+func (h *Hub) handleRTCPublish(payload json.RawMessage, c *Client) {
+	if c.Token.Role != auth.RoleGM {
+		h.logger.Warn("unauthorized rtcPublish command", "role", c.Token.Role)
+		return
+	}
+
+	if !h.rtcEnabled(c) {
+		return
+	}
+
+	answer, ok := h.negotiateRTC(payload, c, h.rtc.Publish)
+	if !ok {
+		return
+	}
+
+	h.rtcMu.Lock()
+	h.rtcPublisher = c.ID
+	h.rtcMu.Unlock()
+
+	h.sendRTCAnswer(c, answer)
+	h.Broadcast(c.message("rtcPublished", json.RawMessage(`{}`)), ToPlayersOnly())
+}
+
+func (h *Hub) handleRTCSubscribe(payload json.RawMessage, c *Client) {
+	if !h.rtcEnabled(c) {
+		return
+	}
+
+	answer, ok := h.negotiateRTC(payload, c, h.rtc.Subscribe)
+	if !ok {
+		return
+	}
+
+	h.sendRTCAnswer(c, answer)
+}
+
+func (h *Hub) handleRTCUnpublish(_ json.RawMessage, c *Client) {
+	if c.Token.Role != auth.RoleGM {
+		h.logger.Warn("unauthorized rtcUnpublish command", "role", c.Token.Role)
+		return
+	}
+
+	if !h.rtcEnabled(c) {
+		return
+	}
+
+	h.stopRTCPublisher()
+}
+
+func (h *Hub) rtcEnabled(c *Client) bool {
+	if h.rtc == nil {
+		h.sendRTCError(c, "live audio is not enabled on this server")
+		return false
+	}
+	return true
+}
+
+// negotiateRTC runs an offer from the client through the forwarder, replying
+// with an rtcError if it can't be answered.
+func (h *Hub) negotiateRTC(payload json.RawMessage, c *Client, negotiate func(peerID, offer string) (string, error)) (string, bool) {
+	var session rtcSessionPayload
+	if err := json.Unmarshal(payload, &session); err != nil || session.SDP == "" {
+		h.sendRTCError(c, "invalid SDP offer")
+		return "", false
+	}
+
+	answer, err := negotiate(c.ID, session.SDP)
+	if err != nil {
+		h.logger.Error("failed to negotiate WebRTC session", "clientId", c.ID, "error", err)
+		h.sendRTCError(c, "couldn't negotiate WebRTC session")
+		return "", false
+	}
+
+	return answer, true
+}
+
+func (h *Hub) sendRTCAnswer(c *Client, answer string) {
+	if err := c.Send(Message{
+		Method:  "rtcAnswer",
+		Payload: mustMarshal(rtcSessionPayload{SDP: answer}),
+	}); err != nil {
+		h.logger.Error("failed to send rtcAnswer message", "error", err)
+	}
+}
+
+func (h *Hub) sendRTCError(c *Client, msg string) {
+	if err := c.Send(Message{
+		Method:  "rtcError",
+		Payload: mustMarshal(rtcErrorPayload{Error: msg}),
+	}); err != nil {
+		h.logger.Error("failed to send rtcError message", "error", err)
+	}
+}
+
+// stopRTCPublisher ends the live broadcast and tells players to fall back to
+// streaming the tracks themselves.
+func (h *Hub) stopRTCPublisher() {
+	h.rtcMu.Lock()
+	publisher := h.rtcPublisher
+	h.rtcPublisher = ""
+	h.rtcMu.Unlock()
+
+	if publisher == "" {
+		return
+	}
+
+	h.rtc.Remove(publisher)
+	h.broadcastRTCUnpublished(publisher)
+}
+
+// rtcPublisherLost ends the live broadcast when the forwarder loses the
+// publisher's connection, so players joining later aren't told it's live and
+// the GM can publish again.
+func (h *Hub) rtcPublisherLost(peerID string) {
+	h.rtcMu.Lock()
+	if h.rtcPublisher != peerID {
+		h.rtcMu.Unlock()
+		return
+	}
+	h.rtcPublisher = ""
+	h.rtcMu.Unlock()
+
+	h.logger.Warn("lost the live audio publisher's connection", "clientId", peerID)
+	h.ForEachClient(func(c *Client) {
+		h.sendRTCError(c, "live audio connection lost")
+	}, ToClientID(peerID))
+	h.broadcastRTCUnpublished(peerID)
+}
+
+func (h *Hub) broadcastRTCUnpublished(publisher string) {
+	h.Broadcast(Message{
+		Method:   "rtcUnpublished",
+		SenderID: publisher,
+		Payload:  json.RawMessage(`{}`),
+	}, ToPlayersOnly())
+}
+
+// rtcDisconnect cleans up after a client that has left the hub.
+func (h *Hub) rtcDisconnect(c *Client) {
+	if h.rtc == nil {
+		return
+	}
+
+	h.rtcMu.Lock()
+	isPublisher := h.rtcPublisher == c.ID
+	h.rtcMu.Unlock()
+
+	if isPublisher {
+		h.stopRTCPublisher()
+	}
+	h.rtc.Remove(c.ID)
+}
+
+// isRTCLive reports whether a GM is currently broadcasting live audio.
+func (h *Hub) isRTCLive() bool {
+	h.rtcMu.Lock()
+	defer h.rtcMu.Unlock()
+	return h.rtcPublisher != ""
+}
@@ -0,0 +1,183 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"github.com/terrabitz/rpg-audio-streamer/internal/auth"
+)
+
+type mockForwarder struct {
+	mu            sync.Mutex
+	removed       []string
+	publisherLost func(peerID string)
+}
+
+func (m *mockForwarder) Publish(peerID, offer string) (string, error) {
+	if offer == "bad" {
+		return "", errors.New("bad offer")
+	}
+	return "publish-answer:" + peerID, nil
+}
+
+func (m *mockForwarder) Subscribe(peerID, offer string) (string, error) {
+	return "subscribe-answer:" + peerID, nil
+}
+
+func (m *mockForwarder) Remove(peerID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.removed = append(m.removed, peerID)
+}
+
+func (m *mockForwarder) OnPublisherLost(f func(peerID string)) {
+	m.publisherLost = f
+}
+
+func newTestClient(h *Hub, id string, role auth.Role) *Client {
+	c := &Client{
+		ID:    id,
+		hub:   h,
+		send:  make(chan []byte, 16),
+		Token: &auth.Token{Role: role},
+	}
+	h.clients[c] = true
+	return c
+}
+
+// nextMessage returns the next queued message for the client, or an empty
+// message if there is none.
+func nextMessage(t *testing.T, c *Client) Message {
+	t.Helper()
+
+	select {
+	case raw := <-c.send:
+		var msg Message
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			t.Fatalf("failed to decode message: %v", err)
+		}
+		return msg
+	default:
+		return Message{}
+	}
+}
+
+func rtcCommand(method, sdp string) []byte {
+	return mustMarshal(Message{Method: method, Payload: mustMarshal(rtcSessionPayload{SDP: sdp})})
+}
+
+func TestRTCSignaling(t *testing.T) {
+	hub := NewHub(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	forwarder := &mockForwarder{}
+	hub.SetRTCForwarder(forwarder)
+
+	gm := newTestClient(hub, "gm", auth.RoleGM)
+	player := newTestClient(hub, "player", auth.RolePlayer)
+
+	t.Run("players can't publish", func(t *testing.T) {
+		hub.route(rtcCommand("rtcPublish", "offer"), player)
+
+		if msg := nextMessage(t, player); msg.Method != "" {
+			t.Errorf("expected no reply; got %q", msg.Method)
+		}
+		if hub.isRTCLive() {
+			t.Error("expected broadcast not to be live")
+		}
+	})
+
+	t.Run("failed negotiation reports an error", func(t *testing.T) {
+		hub.route(rtcCommand("rtcPublish", "bad"), gm)
+
+		if msg := nextMessage(t, gm); msg.Method != "rtcError" {
+			t.Errorf("expected rtcError; got %q", msg.Method)
+		}
+		if msg := nextMessage(t, player); msg.Method != "" {
+			t.Errorf("expected players not to be notified; got %q", msg.Method)
+		}
+	})
+
+	t.Run("GM publishes", func(t *testing.T) {
+		hub.route(rtcCommand("rtcPublish", "offer"), gm)
+
+		msg := nextMessage(t, gm)
+		var answer rtcSessionPayload
+		json.Unmarshal(msg.Payload, &answer)
+		if msg.Method != "rtcAnswer" || answer.SDP != "publish-answer:gm" {
+			t.Errorf("expected GM to get the publish answer; got %q %+v", msg.Method, answer)
+		}
+
+		if msg := nextMessage(t, player); msg.Method != "rtcPublished" {
+			t.Errorf("expected players to be told to subscribe; got %q", msg.Method)
+		}
+	})
+
+	t.Run("player subscribes", func(t *testing.T) {
+		hub.route(rtcCommand("rtcSubscribe", "offer"), player)
+
+		msg := nextMessage(t, player)
+		var answer rtcSessionPayload
+		json.Unmarshal(msg.Payload, &answer)
+		if msg.Method != "rtcAnswer" || answer.SDP != "subscribe-answer:player" {
+			t.Errorf("expected player to get the subscribe answer; got %q %+v", msg.Method, answer)
+		}
+	})
+
+	t.Run("publisher's connection fails", func(t *testing.T) {
+		forwarder.publisherLost("gm")
+
+		if hub.isRTCLive() {
+			t.Error("expected broadcast to stop")
+		}
+		if msg := nextMessage(t, gm); msg.Method != "rtcError" {
+			t.Errorf("expected the GM to be told the broadcast failed; got %q", msg.Method)
+		}
+		if msg := nextMessage(t, player); msg.Method != "rtcUnpublished" {
+			t.Errorf("expected players to be told the broadcast stopped; got %q", msg.Method)
+		}
+
+		forwarder.publisherLost("gm")
+		if msg := nextMessage(t, player); msg.Method != "" {
+			t.Errorf("expected a stale failure to be ignored; got %q", msg.Method)
+		}
+
+		hub.route(rtcCommand("rtcPublish", "offer"), gm)
+		if msg := nextMessage(t, gm); msg.Method != "rtcAnswer" {
+			t.Errorf("expected the GM to publish again; got %q", msg.Method)
+		}
+		if msg := nextMessage(t, player); msg.Method != "rtcPublished" {
+			t.Errorf("expected players to be told to subscribe again; got %q", msg.Method)
+		}
+	})
+
+	t.Run("publisher disconnects", func(t *testing.T) {
+		hub.rtcDisconnect(gm)
+
+		if hub.isRTCLive() {
+			t.Error("expected broadcast to stop")
+		}
+		if msg := nextMessage(t, player); msg.Method != "rtcUnpublished" {
+			t.Errorf("expected players to be told the broadcast stopped; got %q", msg.Method)
+		}
+
+		forwarder.mu.Lock()
+		defer forwarder.mu.Unlock()
+		if len(forwarder.removed) == 0 || forwarder.removed[0] != "gm" {
+			t.Errorf("expected the publisher to be removed; got %v", forwarder.removed)
+		}
+	})
+}
+
+func TestRTCDisabled(t *testing.T) {
+	hub := NewHub(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	gm := newTestClient(hub, "gm", auth.RoleGM)
+
+	hub.route(rtcCommand("rtcPublish", "offer"), gm)
+
+	if msg := nextMessage(t, gm); msg.Method != "rtcError" {
+		t.Errorf("expected rtcError; got %q", msg.Method)
+	}
+}
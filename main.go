@@ -1,41 +1,59 @@
 package main
 
 import (
+	"bufio"
+	"context"
 	"embed"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"log"
 	"log/slog"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/joho/godotenv"
 	"github.com/urfave/cli/v2"
+	"golang.org/x/term"
 
 	"github.com/terrabitz/rpg-audio-streamer/internal/auth"
+	"github.com/terrabitz/rpg-audio-streamer/internal/mixer"
+	"github.com/terrabitz/rpg-audio-streamer/internal/pgdatastore"
 	"github.com/terrabitz/rpg-audio-streamer/internal/server"
+	"github.com/terrabitz/rpg-audio-streamer/internal/sfu"
 	"github.com/terrabitz/rpg-audio-streamer/internal/sqlitedatastore"
 	ws "github.com/terrabitz/rpg-audio-streamer/internal/websocket"
 )
 
-//go:embed sql/migrations/*
+//go:embed sql/migrations/* sql/postgres/migrations/*
 var migrations embed.FS
 
-const migrationsPath = "sql/migrations"
+const (
+	migrationsPath         = "sql/migrations"
+	postgresMigrationsPath = "sql/postgres/migrations"
+)
 
 type Config struct {
 	Server server.Config
 	Log    LogConfig
 	Auth   auth.Config
 	DB     DBConfig
+	Mix    mixer.Config
+	RTC    sfu.Config
 }
 
 type DBConfig struct {
-	Path string
+	// Driver is the database to store the library in, either "sqlite" or
+	// "postgres".
+	Driver string
+	SQLite sqlitedatastore.Config
+	URL    string
 }
 
 type LogConfig struct {
@@ -55,7 +73,7 @@ func main() {
 				Name:    "serve",
 				Aliases: []string{"s"},
 				Usage:   "Start the audio streaming server",
-				Flags: []cli.Flag{
+				Flags: append([]cli.Flag{
 					&cli.IntFlag{
 						Name:        "port",
 						EnvVars:     []string{"PORT"},
@@ -132,11 +150,19 @@ func main() {
 						Usage:       "Audience for JWT tokens",
 						Destination: &cfg.Auth.TokenAudience,
 					},
+					&cli.DurationFlag{
+						Name:        "stream-url-duration",
+						EnvVars:     []string{"STREAM_URL_DURATION"},
+						Value:       6 * time.Hour,
+						Usage:       "How long signed stream URLs remain valid",
+						Destination: &cfg.Auth.StreamURLDuration,
+					},
 					&cli.StringFlag{
-						Name:        "join-token",
-						EnvVars:     []string{"JOIN_TOKEN"},
-						Usage:       "The static join token to use",
-						Destination: &cfg.Auth.JoinToken,
+						Name:        "table-id",
+						EnvVars:     []string{"TABLE_ID"},
+						Value:       "default",
+						Usage:       "Name of the table in the session log",
+						Destination: &cfg.Server.TableID,
 					},
 					&cli.BoolFlag{
 						Name:        "dev-mode",
@@ -145,29 +171,40 @@ func main() {
 						Destination: &cfg.Server.DevMode,
 					},
 					&cli.StringFlag{
-						Name:        "db-path",
-						EnvVars:     []string{"DB_PATH"},
-						Value:       "skaldbot.db",
-						Usage:       "Path to SQLite database file",
-						Destination: &cfg.DB.Path,
+						Name:        "mix-format",
+						EnvVars:     []string{"MIX_FORMAT"},
+						Value:       "mp3",
+						Usage:       "Encoding of the server-side mix stream (mp3 or opus)",
+						Destination: &cfg.Mix.Format,
 					},
-				},
+					&cli.StringFlag{
+						Name:        "mix-bitrate",
+						EnvVars:     []string{"MIX_BITRATE"},
+						Value:       "128k",
+						Usage:       "Bitrate of the server-side mix stream",
+						Destination: &cfg.Mix.Bitrate,
+					},
+					&cli.StringSliceFlag{
+						Name:    "webrtc-ice-servers",
+						EnvVars: []string{"WEBRTC_ICE_SERVERS"},
+						Usage:   "STUN/TURN server URLs used for live WebRTC audio",
+					},
+					&cli.StringFlag{
+						Name:        "webrtc-public-ip",
+						EnvVars:     []string{"WEBRTC_PUBLIC_IP"},
+						Usage:       "Public IP to advertise for live WebRTC audio when behind NAT",
+						Destination: &cfg.RTC.PublicIP,
+					},
+				}, dbFlags(&cfg.DB)...),
 				Action: func(cCtx *cli.Context) error {
+					cfg.RTC.ICEServers = cCtx.StringSlice("webrtc-ice-servers")
 					return startServer(cfg)
 				},
 			},
 			{
 				Name:  "migrate",
 				Usage: "Run database migrations",
-				Flags: []cli.Flag{
-					&cli.StringFlag{
-						Name:        "db-path",
-						EnvVars:     []string{"DB_PATH"},
-						Value:       "skaldbot.db",
-						Usage:       "Path to SQLite database file",
-						Destination: &cfg.DB.Path,
-					},
-				},
+				Flags: dbFlags(&cfg.DB),
 				Subcommands: []*cli.Command{
 					{
 						Name:  "up",
@@ -180,19 +217,9 @@ func main() {
 							},
 						},
 						Action: func(cCtx *cli.Context) error {
-							migrationsSub, err := fs.Sub(migrations, migrationsPath)
-							if err != nil {
-								log.Fatalf("couldn't find database migrations: %v", err)
-							}
-
-							db, err := sqlitedatastore.New(cfg.DB.Path)
-							if err != nil {
-								return fmt.Errorf("couldn't initialize SQLite DB: %w", err)
-							}
-
-							migrations, err := sqlitedatastore.NewMigration(migrationsSub, db)
+							_, migrations, err := openDB(cfg.DB)
 							if err != nil {
-								return fmt.Errorf("couldn't initialize migrations: %w", err)
+								return err
 							}
 
 							steps := cCtx.Int("steps")
@@ -219,19 +246,9 @@ func main() {
 							},
 						},
 						Action: func(cCtx *cli.Context) error {
-							migrationsSub, err := fs.Sub(migrations, migrationsPath)
-							if err != nil {
-								log.Fatalf("couldn't find database migrations: %v", err)
-							}
-
-							db, err := sqlitedatastore.New(cfg.DB.Path)
+							_, migrations, err := openDB(cfg.DB)
 							if err != nil {
-								return fmt.Errorf("couldn't initialize SQLite DB: %w", err)
-							}
-
-							migrations, err := sqlitedatastore.NewMigration(migrationsSub, db)
-							if err != nil {
-								return fmt.Errorf("couldn't initialize migrations: %w", err)
+								return err
 							}
 
 							steps := cCtx.Int("steps")
@@ -245,28 +262,120 @@ func main() {
 						Name:  "version",
 						Usage: "Print the current migration version",
 						Action: func(cCtx *cli.Context) error {
-							migrationsSub, err := fs.Sub(migrations, migrationsPath)
+							_, migrations, err := openDB(cfg.DB)
 							if err != nil {
-								log.Fatalf("couldn't find database migrations: %v", err)
+								return err
+							}
+
+							version, dirty, err := migrations.Version()
+							if err != nil {
+								return fmt.Errorf("couldn't get migration version: %w", err)
 							}
 
-							db, err := sqlitedatastore.New(cfg.DB.Path)
+							versionJSON, _ := json.MarshalIndent(migrateVersion{
+								Version: version,
+								Dirty:   dirty,
+							}, "", "  ")
+							fmt.Println(string(versionJSON))
+							return nil
+						},
+					},
+				},
+			},
+			{
+				Name:  "users",
+				Usage: "Manage GM accounts",
+				Flags: dbFlags(&cfg.DB),
+				Subcommands: []*cli.Command{
+					{
+						Name:  "add",
+						Usage: "Add a GM account, prompting for its password",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:     "username",
+								Required: true,
+								Usage:    "Username to sign in with",
+							},
+							&cli.BoolFlag{
+								Name:  "admin",
+								Usage: "Let the account manage other accounts",
+							},
+							&cli.StringFlag{
+								Name:    "root-username",
+								EnvVars: []string{"ROOT_USERNAME"},
+								Value:   "admin",
+								Usage:   "Root username, which accounts can't use",
+							},
+						},
+						Action: func(cCtx *cli.Context) error {
+							password, err := readPassword()
 							if err != nil {
-								return fmt.Errorf("couldn't initialize SQLite DB: %w", err)
+								return err
 							}
 
-							migrations, err := sqlitedatastore.NewMigration(migrationsSub, db)
+							user, err := server.NewUser(cCtx.String("username"), password, cCtx.Bool("admin"), cCtx.String("root-username"))
 							if err != nil {
-								return fmt.Errorf("couldn't initialize migrations: %w", err)
+								return err
 							}
 
-							version, err := migrations.Version()
+							db, migrations, err := openDB(cfg.DB)
 							if err != nil {
-								return fmt.Errorf("couldn't get migration version: %w", err)
+								return err
+							}
+							if err := migrations.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+								return fmt.Errorf("couldn't run migrations: %w", err)
 							}
 
-							versionJSON, _ := json.MarshalIndent(version, "", "  ")
-							fmt.Println(string(versionJSON))
+							if err := db.SaveUser(cCtx.Context, user); err != nil {
+								return fmt.Errorf("couldn't add user: %w", err)
+							}
+
+							fmt.Println(user.ID)
+							return nil
+						},
+					},
+				},
+			},
+			{
+				Name:  "db",
+				Usage: "Maintain the SQLite database while the server is running",
+				Flags: dbFlags(&cfg.DB),
+				Subcommands: []*cli.Command{
+					{
+						Name:  "optimize",
+						Usage: "Refresh query planner statistics and checkpoint the WAL",
+						Action: func(cCtx *cli.Context) error {
+							db, err := openSQLite(cfg.DB)
+							if err != nil {
+								return err
+							}
+							defer db.Close()
+
+							return db.Optimize(cCtx.Context)
+						},
+					},
+					{
+						Name:  "integrity-check",
+						Usage: "Check the database for corruption and broken references",
+						Action: func(cCtx *cli.Context) error {
+							db, err := openSQLite(cfg.DB)
+							if err != nil {
+								return err
+							}
+							defer db.Close()
+
+							problems, err := db.CheckIntegrity(cCtx.Context)
+							if err != nil {
+								return err
+							}
+
+							for _, problem := range problems {
+								fmt.Println(problem)
+							}
+							if len(problems) > 0 {
+								return fmt.Errorf("found %d problems with the database", len(problems))
+							}
+							fmt.Println("ok")
 							return nil
 						},
 					},
@@ -280,6 +389,147 @@ func main() {
 	}
 }
 
+type migrateVersion struct {
+	Version uint
+	Dirty   bool
+}
+
+// readPassword prompts for a password without echoing it, or reads a line
+// from stdin when it isn't a terminal.
+func readPassword() (string, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return "", fmt.Errorf("couldn't read password: %w", err)
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+
+	fmt.Fprint(os.Stderr, "Enter password: ")
+	password, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("couldn't read password: %w", err)
+	}
+	return string(password), nil
+}
+
+// dbFlags configures the database for any command that opens it.
+func dbFlags(cfg *DBConfig) []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:        "db-driver",
+			EnvVars:     []string{"DB_DRIVER"},
+			Value:       "sqlite",
+			Usage:       "Database to use, either sqlite or postgres",
+			Destination: &cfg.Driver,
+		},
+		&cli.StringFlag{
+			Name:        "db-path",
+			EnvVars:     []string{"DB_PATH"},
+			Value:       "skaldbot.db",
+			Usage:       "Path to SQLite database file",
+			Destination: &cfg.SQLite.Path,
+		},
+		&cli.StringFlag{
+			Name:        "db-journal-mode",
+			EnvVars:     []string{"DB_JOURNAL_MODE"},
+			Value:       "wal",
+			Usage:       "SQLite journal mode",
+			Destination: &cfg.SQLite.JournalMode,
+		},
+		&cli.StringFlag{
+			Name:        "db-synchronous",
+			EnvVars:     []string{"DB_SYNCHRONOUS"},
+			Value:       "normal",
+			Usage:       "How often SQLite syncs to disk (off, normal, full or extra)",
+			Destination: &cfg.SQLite.Synchronous,
+		},
+		&cli.DurationFlag{
+			Name:        "db-busy-timeout",
+			EnvVars:     []string{"DB_BUSY_TIMEOUT"},
+			Value:       5 * time.Second,
+			Usage:       "How long SQLite waits for another connection to finish writing",
+			Destination: &cfg.SQLite.BusyTimeout,
+		},
+		&cli.IntFlag{
+			Name:        "db-max-open-conns",
+			EnvVars:     []string{"DB_MAX_OPEN_CONNS"},
+			Value:       4,
+			Usage:       "Most connections to open to the SQLite database (0 for no limit)",
+			Destination: &cfg.SQLite.MaxOpenConns,
+		},
+		&cli.StringFlag{
+			Name:        "db-url",
+			EnvVars:     []string{"DB_URL"},
+			Usage:       "Postgres connection URL, when using the postgres driver",
+			Destination: &cfg.URL,
+		},
+	}
+}
+
+// openSQLite opens the configured database for maintenance, which only
+// SQLite needs.
+func openSQLite(cfg DBConfig) (*sqlitedatastore.SQLiteDatastore, error) {
+	if cfg.Driver != "sqlite" {
+		return nil, fmt.Errorf("database maintenance isn't supported for the %s driver", cfg.Driver)
+	}
+
+	db, err := sqlitedatastore.New(cfg.SQLite)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't initialize SQLite DB: %w", err)
+	}
+
+	return db, nil
+}
+
+// openDB opens the configured database, along with the migrations for its
+// schema.
+func openDB(cfg DBConfig) (server.Store, *migrate.Migrate, error) {
+	switch cfg.Driver {
+	case "sqlite":
+		migrationsSub, err := fs.Sub(migrations, migrationsPath)
+		if err != nil {
+			log.Fatalf("couldn't find database migrations: %v", err)
+		}
+
+		db, err := sqlitedatastore.New(cfg.SQLite)
+		if err != nil {
+			return nil, nil, fmt.Errorf("couldn't initialize SQLite DB: %w", err)
+		}
+
+		migrations, err := sqlitedatastore.NewMigration(migrationsSub, db)
+		if err != nil {
+			return nil, nil, fmt.Errorf("couldn't initialize migrations: %w", err)
+		}
+
+		return db, migrations.Migrate, nil
+	case "postgres":
+		migrationsSub, err := fs.Sub(migrations, postgresMigrationsPath)
+		if err != nil {
+			log.Fatalf("couldn't find database migrations: %v", err)
+		}
+
+		if cfg.URL == "" {
+			return nil, nil, errors.New("a database URL is required for the postgres driver")
+		}
+
+		db, err := pgdatastore.New(cfg.URL)
+		if err != nil {
+			return nil, nil, fmt.Errorf("couldn't initialize Postgres DB: %w", err)
+		}
+
+		migrations, err := pgdatastore.NewMigration(migrationsSub, db)
+		if err != nil {
+			return nil, nil, fmt.Errorf("couldn't initialize migrations: %w", err)
+		}
+
+		return db, migrations.Migrate, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown database driver '%s'", cfg.Driver)
+	}
+}
+
 func setupLogger(cfg Config) (*slog.Logger, error) {
 	level := new(slog.Level)
 	if err := level.UnmarshalText([]byte(strings.ToLower(cfg.Log.Level))); err != nil {
@@ -306,19 +556,9 @@ func startServer(cfg Config) error {
 		return fmt.Errorf("couldn't initialize logger: %w", err)
 	}
 
-	migrationsSub, err := fs.Sub(migrations, migrationsPath)
-	if err != nil {
-		log.Fatalf("couldn't find database migrations: %v", err)
-	}
-
-	db, err := sqlitedatastore.New(cfg.DB.Path)
-	if err != nil {
-		return fmt.Errorf("couldn't initialize SQLite DB: %w", err)
-	}
-
-	migrations, err := sqlitedatastore.NewMigration(migrationsSub, db)
+	db, migrations, err := openDB(cfg.DB)
 	if err != nil {
-		return fmt.Errorf("couldn't initialize migrations: %w", err)
+		return err
 	}
 
 	if err := migrations.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
@@ -329,13 +569,41 @@ func startServer(cfg Config) error {
 
 	hub := ws.NewHub(logger)
 
-	srv, err := server.New(cfg.Server, logger, authService, db, hub)
+	mix, err := mixer.New(cfg.Mix, logger, db)
+	if err != nil {
+		return fmt.Errorf("couldn't create mixer: %w", err)
+	}
+	hub.OnGMCommand(func(method string, payload json.RawMessage, _ *ws.Client) {
+		mix.HandleCommand(method, payload)
+	})
+
+	rtc, err := sfu.New(cfg.RTC, logger)
+	if err != nil {
+		return fmt.Errorf("couldn't create WebRTC forwarder: %w", err)
+	}
+	defer rtc.Close()
+	hub.SetRTCForwarder(rtc)
+
+	srv, err := server.New(cfg.Server, logger, authService, db, hub, mix)
 	if err != nil {
 		return fmt.Errorf("couldn't create server: %w", err)
 	}
+	defer srv.Close()
+	hub.OnGMCommand(func(method string, payload json.RawMessage, _ *ws.Client) {
+		srv.HandleCommand(method, payload)
+	})
+	hub.OnGMCommand(func(method string, payload json.RawMessage, c *ws.Client) {
+		srv.RecordSessionEvent(method, payload, c.ID)
+	})
+
+	// Stopping the server returns from here, so the deferred Close calls
+	// save the rest of the session log before the process exits.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	// FIXME use cleaner shutdown handling
 	go hub.Run()
+	go mix.Run(ctx)
 
-	return srv.Start()
+	return srv.Start(ctx)
 }
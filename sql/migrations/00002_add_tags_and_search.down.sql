@@ -0,0 +1,11 @@
+DROP TRIGGER IF EXISTS track_tags_fts_delete;
+DROP TRIGGER IF EXISTS track_tags_fts_insert;
+DROP TRIGGER IF EXISTS tracks_fts_delete;
+DROP TRIGGER IF EXISTS tracks_fts_update;
+DROP TRIGGER IF EXISTS tracks_fts_insert;
+DROP TABLE IF EXISTS tracks_fts;
+DROP VIEW IF EXISTS track_details;
+DROP TABLE IF EXISTS track_tags;
+DROP TABLE IF EXISTS tags;
+ALTER TABLE tracks DROP COLUMN metadata;
+ALTER TABLE tracks DROP COLUMN duration;
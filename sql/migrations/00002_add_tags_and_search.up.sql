@@ -0,0 +1,96 @@
+ALTER TABLE tracks ADD COLUMN duration REAL NOT NULL DEFAULT 0;
+ALTER TABLE tracks ADD COLUMN metadata TEXT NOT NULL DEFAULT '{}';
+
+CREATE TABLE tags (
+    id BLOB PRIMARY KEY NOT NULL,
+    name TEXT NOT NULL UNIQUE COLLATE NOCASE
+);
+
+CREATE TABLE track_tags (
+    track_id BLOB NOT NULL,
+    tag_id BLOB NOT NULL,
+    PRIMARY KEY (track_id, tag_id),
+    FOREIGN KEY (track_id) REFERENCES tracks(id) ON DELETE CASCADE,
+    FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE
+);
+
+CREATE INDEX track_tags_tag_id ON track_tags(tag_id);
+
+CREATE VIEW track_details AS
+SELECT
+    tracks.id,
+    tracks.created_at,
+    tracks.name,
+    tracks.path,
+    tracks.type_id,
+    tracks.duration,
+    tracks.metadata,
+    CAST((
+        SELECT json_group_array(name) FROM (
+            SELECT tags.name FROM track_tags
+            JOIN tags ON tags.id = track_tags.tag_id
+            WHERE track_tags.track_id = tracks.id
+            ORDER BY tags.name
+        )
+    ) AS TEXT) AS tags
+FROM tracks;
+
+-- Full-text index over each track's name, tags and probed metadata, kept up
+-- to date by the triggers below.
+CREATE VIRTUAL TABLE tracks_fts USING fts5(
+    track_id UNINDEXED,
+    name,
+    tags,
+    metadata,
+    prefix = '2 3',
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+CREATE TRIGGER tracks_fts_insert AFTER INSERT ON tracks BEGIN
+    INSERT INTO tracks_fts (track_id, name, tags, metadata)
+    VALUES (
+        new.id,
+        new.name,
+        '',
+        (SELECT coalesce(group_concat(value, ' '), '') FROM json_each(new.metadata))
+    );
+END;
+
+CREATE TRIGGER tracks_fts_update AFTER UPDATE OF name, metadata ON tracks BEGIN
+    UPDATE tracks_fts
+    SET
+        name = new.name,
+        metadata = (SELECT coalesce(group_concat(value, ' '), '') FROM json_each(new.metadata))
+    WHERE track_id = new.id;
+END;
+
+-- Foreign keys aren't enforced on every connection, so clean up tag links
+-- here rather than relying on the cascade.
+CREATE TRIGGER tracks_fts_delete AFTER DELETE ON tracks BEGIN
+    DELETE FROM tracks_fts WHERE track_id = old.id;
+    DELETE FROM track_tags WHERE track_id = old.id;
+END;
+
+CREATE TRIGGER track_tags_fts_insert AFTER INSERT ON track_tags BEGIN
+    UPDATE tracks_fts
+    SET tags = (
+        SELECT coalesce(group_concat(tags.name, ' '), '') FROM track_tags
+        JOIN tags ON tags.id = track_tags.tag_id
+        WHERE track_tags.track_id = new.track_id
+    )
+    WHERE track_id = new.track_id;
+END;
+
+CREATE TRIGGER track_tags_fts_delete AFTER DELETE ON track_tags BEGIN
+    UPDATE tracks_fts
+    SET tags = (
+        SELECT coalesce(group_concat(tags.name, ' '), '') FROM track_tags
+        JOIN tags ON tags.id = track_tags.tag_id
+        WHERE track_tags.track_id = old.track_id
+    )
+    WHERE track_id = old.track_id;
+END;
+
+-- Index the tracks that already exist
+INSERT INTO tracks_fts (track_id, name, tags, metadata)
+SELECT id, name, '', '' FROM tracks;
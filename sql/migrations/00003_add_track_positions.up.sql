@@ -0,0 +1,32 @@
+ALTER TABLE tracks ADD COLUMN position INTEGER NOT NULL DEFAULT 0;
+
+-- Start each track type off in alphabetical order.
+UPDATE tracks SET position = (
+    SELECT count(*) FROM tracks AS earlier
+    WHERE earlier.type_id = tracks.type_id
+      AND (earlier.name < tracks.name OR (earlier.name = tracks.name AND earlier.id < tracks.id))
+);
+
+CREATE INDEX tracks_type_position ON tracks(type_id, position);
+
+DROP VIEW track_details;
+
+CREATE VIEW track_details AS
+SELECT
+    tracks.id,
+    tracks.created_at,
+    tracks.name,
+    tracks.path,
+    tracks.type_id,
+    tracks.duration,
+    tracks.metadata,
+    tracks.position,
+    CAST((
+        SELECT json_group_array(name) FROM (
+            SELECT tags.name FROM track_tags
+            JOIN tags ON tags.id = track_tags.tag_id
+            WHERE track_tags.track_id = tracks.id
+            ORDER BY tags.name
+        )
+    ) AS TEXT) AS tags
+FROM tracks;
@@ -0,0 +1,25 @@
+DROP VIEW IF EXISTS track_details;
+
+CREATE VIEW track_details AS
+SELECT
+    tracks.id,
+    tracks.created_at,
+    tracks.name,
+    tracks.path,
+    tracks.type_id,
+    tracks.duration,
+    tracks.metadata,
+    tracks.position,
+    CAST((
+        SELECT json_group_array(name) FROM (
+            SELECT tags.name FROM track_tags
+            JOIN tags ON tags.id = track_tags.tag_id
+            WHERE track_tags.track_id = tracks.id
+            ORDER BY tags.name
+        )
+    ) AS TEXT) AS tags
+FROM tracks;
+
+DROP TRIGGER IF EXISTS tracks_collections_delete;
+DROP TABLE IF EXISTS collection_tracks;
+DROP TABLE IF EXISTS collections;
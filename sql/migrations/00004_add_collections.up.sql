@@ -0,0 +1,50 @@
+CREATE TABLE collections (
+    id BLOB PRIMARY KEY NOT NULL,
+    parent_id BLOB,
+    name TEXT NOT NULL,
+    created_at TEXT NOT NULL,
+    FOREIGN KEY (parent_id) REFERENCES collections(id) ON DELETE CASCADE
+);
+
+CREATE INDEX collections_parent_id ON collections(parent_id);
+
+CREATE TABLE collection_tracks (
+    collection_id BLOB NOT NULL,
+    track_id BLOB NOT NULL,
+    PRIMARY KEY (collection_id, track_id),
+    FOREIGN KEY (collection_id) REFERENCES collections(id) ON DELETE CASCADE,
+    FOREIGN KEY (track_id) REFERENCES tracks(id) ON DELETE CASCADE
+);
+
+CREATE INDEX collection_tracks_track_id ON collection_tracks(track_id);
+
+-- As with tags, don't rely on the cascade to clean up after deleted tracks.
+CREATE TRIGGER tracks_collections_delete AFTER DELETE ON tracks BEGIN
+    DELETE FROM collection_tracks WHERE track_id = old.id;
+END;
+
+DROP VIEW track_details;
+
+CREATE VIEW track_details AS
+SELECT
+    tracks.id,
+    tracks.created_at,
+    tracks.name,
+    tracks.path,
+    tracks.type_id,
+    tracks.duration,
+    tracks.metadata,
+    tracks.position,
+    CAST((
+        SELECT json_group_array(name) FROM (
+            SELECT tags.name FROM track_tags
+            JOIN tags ON tags.id = track_tags.tag_id
+            WHERE track_tags.track_id = tracks.id
+            ORDER BY tags.name
+        )
+    ) AS TEXT) AS tags,
+    CAST((
+        SELECT json_group_array(lower(hex(collection_id))) FROM collection_tracks
+        WHERE collection_tracks.track_id = tracks.id
+    ) AS TEXT) AS collections
+FROM tracks;
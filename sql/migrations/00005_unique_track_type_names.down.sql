@@ -0,0 +1 @@
+DROP INDEX IF EXISTS track_types_name;
@@ -0,0 +1 @@
+CREATE UNIQUE INDEX track_types_name ON track_types(name COLLATE NOCASE);
@@ -0,0 +1,6 @@
+ALTER TABLE track_types DROP COLUMN auto_stop_others;
+ALTER TABLE track_types DROP COLUMN max_concurrent;
+ALTER TABLE track_types DROP COLUMN always_start_from_beginning;
+ALTER TABLE track_types DROP COLUMN fade_out_ms;
+ALTER TABLE track_types DROP COLUMN fade_in_ms;
+ALTER TABLE track_types DROP COLUMN default_volume;
@@ -0,0 +1,12 @@
+ALTER TABLE track_types ADD COLUMN default_volume INTEGER NOT NULL DEFAULT 100;
+ALTER TABLE track_types ADD COLUMN fade_in_ms INTEGER NOT NULL DEFAULT 2000;
+ALTER TABLE track_types ADD COLUMN fade_out_ms INTEGER NOT NULL DEFAULT 2000;
+ALTER TABLE track_types ADD COLUMN always_start_from_beginning BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE track_types ADD COLUMN max_concurrent INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE track_types ADD COLUMN auto_stop_others BOOLEAN NOT NULL DEFAULT 0;
+
+-- Types that didn't allow simultaneous play only ever had one track playing.
+UPDATE track_types SET max_concurrent = 1 WHERE allow_simultaneous_play = 0;
+
+-- Only repeating tracks used to fade.
+UPDATE track_types SET fade_in_ms = 0, fade_out_ms = 0 WHERE is_repeating = 0;
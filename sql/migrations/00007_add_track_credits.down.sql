@@ -0,0 +1,34 @@
+DROP VIEW IF EXISTS track_details;
+
+CREATE VIEW track_details AS
+SELECT
+    tracks.id,
+    tracks.created_at,
+    tracks.name,
+    tracks.path,
+    tracks.type_id,
+    tracks.duration,
+    tracks.metadata,
+    tracks.position,
+    CAST((
+        SELECT json_group_array(name) FROM (
+            SELECT tags.name FROM track_tags
+            JOIN tags ON tags.id = track_tags.tag_id
+            WHERE track_tags.track_id = tracks.id
+            ORDER BY tags.name
+        )
+    ) AS TEXT) AS tags,
+    CAST((
+        SELECT json_group_array(lower(hex(collection_id))) FROM collection_tracks
+        WHERE collection_tracks.track_id = tracks.id
+    ) AS TEXT) AS collections
+FROM tracks;
+
+DROP TRIGGER IF EXISTS tracks_plays_delete;
+DROP TABLE IF EXISTS track_plays;
+
+ALTER TABLE tracks DROP COLUMN attribution;
+ALTER TABLE tracks DROP COLUMN license;
+ALTER TABLE tracks DROP COLUMN source_url;
+ALTER TABLE tracks DROP COLUMN artist;
+ALTER TABLE tracks DROP COLUMN description;
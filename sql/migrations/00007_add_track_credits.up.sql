@@ -0,0 +1,51 @@
+ALTER TABLE tracks ADD COLUMN description TEXT NOT NULL DEFAULT '';
+ALTER TABLE tracks ADD COLUMN artist TEXT NOT NULL DEFAULT '';
+ALTER TABLE tracks ADD COLUMN source_url TEXT NOT NULL DEFAULT '';
+ALTER TABLE tracks ADD COLUMN license TEXT NOT NULL DEFAULT '';
+ALTER TABLE tracks ADD COLUMN attribution TEXT NOT NULL DEFAULT '';
+
+-- started_at is always UTC, so times compare correctly as text.
+CREATE TABLE track_plays (
+    id INTEGER PRIMARY KEY,
+    track_id BLOB NOT NULL,
+    started_at TEXT NOT NULL,
+    FOREIGN KEY (track_id) REFERENCES tracks(id) ON DELETE CASCADE
+);
+
+CREATE INDEX track_plays_started_at ON track_plays(started_at);
+CREATE INDEX track_plays_track_id ON track_plays(track_id);
+
+CREATE TRIGGER tracks_plays_delete AFTER DELETE ON tracks BEGIN
+    DELETE FROM track_plays WHERE track_id = old.id;
+END;
+
+DROP VIEW track_details;
+
+CREATE VIEW track_details AS
+SELECT
+    tracks.id,
+    tracks.created_at,
+    tracks.name,
+    tracks.path,
+    tracks.type_id,
+    tracks.duration,
+    tracks.metadata,
+    tracks.position,
+    tracks.description,
+    tracks.artist,
+    tracks.source_url,
+    tracks.license,
+    tracks.attribution,
+    CAST((
+        SELECT json_group_array(name) FROM (
+            SELECT tags.name FROM track_tags
+            JOIN tags ON tags.id = track_tags.tag_id
+            WHERE track_tags.track_id = tracks.id
+            ORDER BY tags.name
+        )
+    ) AS TEXT) AS tags,
+    CAST((
+        SELECT json_group_array(lower(hex(collection_id))) FROM collection_tracks
+        WHERE collection_tracks.track_id = tracks.id
+    ) AS TEXT) AS collections
+FROM tracks;
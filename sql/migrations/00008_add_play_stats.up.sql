@@ -0,0 +1,37 @@
+ALTER TABLE tracks ADD COLUMN favorite BOOLEAN NOT NULL DEFAULT false;
+
+-- duration is how many seconds the track played for. It stays null until the
+-- track is stopped.
+ALTER TABLE track_plays ADD COLUMN duration REAL;
+
+DROP VIEW track_details;
+
+CREATE VIEW track_details AS
+SELECT
+    tracks.id,
+    tracks.created_at,
+    tracks.name,
+    tracks.path,
+    tracks.type_id,
+    tracks.duration,
+    tracks.metadata,
+    tracks.position,
+    tracks.description,
+    tracks.artist,
+    tracks.source_url,
+    tracks.license,
+    tracks.attribution,
+    tracks.favorite,
+    CAST((
+        SELECT json_group_array(name) FROM (
+            SELECT tags.name FROM track_tags
+            JOIN tags ON tags.id = track_tags.tag_id
+            WHERE track_tags.track_id = tracks.id
+            ORDER BY tags.name
+        )
+    ) AS TEXT) AS tags,
+    CAST((
+        SELECT json_group_array(lower(hex(collection_id))) FROM collection_tracks
+        WHERE collection_tracks.track_id = tracks.id
+    ) AS TEXT) AS collections
+FROM tracks;
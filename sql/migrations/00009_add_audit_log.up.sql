@@ -0,0 +1,25 @@
+-- audit_log records who changed the library and who signed in. Entries are
+-- never changed once written, which the triggers below enforce. created_at
+-- is always UTC, so times compare correctly as text.
+CREATE TABLE audit_log (
+    id INTEGER PRIMARY KEY,
+    created_at TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    action TEXT NOT NULL,
+    target_id TEXT NOT NULL DEFAULT '',
+    -- JSON objects holding only the fields the action changed
+    before_state TEXT,
+    after_state TEXT,
+    ip TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX audit_log_created_at ON audit_log(created_at);
+CREATE INDEX audit_log_target_id ON audit_log(target_id);
+
+CREATE TRIGGER audit_log_no_update BEFORE UPDATE ON audit_log BEGIN
+    SELECT RAISE(ABORT, 'audit log entries cannot be changed');
+END;
+
+CREATE TRIGGER audit_log_no_delete BEFORE DELETE ON audit_log BEGIN
+    SELECT RAISE(ABORT, 'audit log entries cannot be deleted');
+END;
@@ -0,0 +1,3 @@
+-- The deleted orphans can't be brought back, and the rest of the repair
+-- leaves the schema as it was.
+SELECT 1;
@@ -0,0 +1,26 @@
+-- Foreign keys are now enforced, so clear out the rows that were left pointing
+-- at deleted ones while they weren't.
+
+-- Tracks can't be left without a type, so move them to the oldest one.
+UPDATE tracks
+SET type_id = (SELECT id FROM track_types ORDER BY created_at, id LIMIT 1)
+WHERE type_id NOT IN (SELECT id FROM track_types)
+AND EXISTS (SELECT 1 FROM track_types);
+
+-- Collections whose parent is gone move to the top level rather than being
+-- deleted along with everything in them.
+UPDATE collections
+SET parent_id = NULL
+WHERE parent_id IS NOT NULL
+AND parent_id NOT IN (SELECT id FROM collections);
+
+DELETE FROM track_tags
+WHERE track_id NOT IN (SELECT id FROM tracks)
+OR tag_id NOT IN (SELECT id FROM tags);
+
+DELETE FROM collection_tracks
+WHERE collection_id NOT IN (SELECT id FROM collections)
+OR track_id NOT IN (SELECT id FROM tracks);
+
+DELETE FROM track_plays
+WHERE track_id NOT IN (SELECT id FROM tracks);
@@ -0,0 +1,3 @@
+DROP TRIGGER session_events_no_delete;
+DROP TRIGGER session_events_no_update;
+DROP TABLE session_events;
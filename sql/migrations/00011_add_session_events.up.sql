@@ -0,0 +1,21 @@
+-- session_events logs the commands the GM sent to the table, in order, so a
+-- session can be recapped or replayed. Like the audit log, events are never
+-- changed once written.
+CREATE TABLE session_events (
+    session_id BLOB NOT NULL,
+    seq INTEGER NOT NULL,
+    -- UTC, to the millisecond
+    created_at TEXT NOT NULL,
+    method TEXT NOT NULL,
+    sender_id TEXT NOT NULL DEFAULT '',
+    payload TEXT NOT NULL,
+    PRIMARY KEY (session_id, seq)
+);
+
+CREATE TRIGGER session_events_no_update BEFORE UPDATE ON session_events BEGIN
+    SELECT RAISE(ABORT, 'session events cannot be changed');
+END;
+
+CREATE TRIGGER session_events_no_delete BEFORE DELETE ON session_events BEGIN
+    SELECT RAISE(ABORT, 'session events cannot be deleted');
+END;
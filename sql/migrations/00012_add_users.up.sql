@@ -0,0 +1,11 @@
+-- users are the GM accounts, alongside the root account from the config.
+-- password_hash is an argon2id hash in the PHC string format.
+CREATE TABLE users (
+    id BLOB PRIMARY KEY,
+    username TEXT NOT NULL,
+    password_hash TEXT NOT NULL,
+    admin BOOLEAN NOT NULL DEFAULT false,
+    created_at TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX users_username ON users(username COLLATE NOCASE);
@@ -0,0 +1,12 @@
+-- join_tokens let players join the table. Players present "<id>.<secret>",
+-- and only a SHA-256 hash of the secret is kept. A max_uses of 0 is no limit.
+CREATE TABLE join_tokens (
+    id BLOB PRIMARY KEY,
+    label TEXT NOT NULL,
+    secret_hash BLOB NOT NULL,
+    created_at TEXT NOT NULL,
+    expires_at TEXT,
+    max_uses INTEGER NOT NULL DEFAULT 0,
+    uses INTEGER NOT NULL DEFAULT 0,
+    revoked_at TEXT
+);
@@ -0,0 +1,13 @@
+DROP TABLE IF EXISTS audit_log;
+DROP FUNCTION IF EXISTS audit_log_append_only;
+DROP TABLE IF EXISTS track_plays;
+DROP VIEW IF EXISTS track_details;
+DROP TABLE IF EXISTS collection_tracks;
+DROP TABLE IF EXISTS collections;
+DROP TABLE IF EXISTS track_tags;
+DROP FUNCTION IF EXISTS track_tags_search_update;
+DROP TABLE IF EXISTS tags;
+DROP TABLE IF EXISTS tracks;
+DROP FUNCTION IF EXISTS tracks_search_update;
+DROP FUNCTION IF EXISTS track_search;
+DROP TABLE IF EXISTS track_types;
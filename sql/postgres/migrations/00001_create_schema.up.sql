@@ -0,0 +1,195 @@
+-- The Postgres schema starts out matching the SQLite one as of its audit log
+-- migration. Text is sorted by code point rather than by locale, as SQLite
+-- does, and the names SQLite compares without case are lowercased.
+CREATE TABLE track_types (
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL,
+    color TEXT NOT NULL,
+    is_repeating BOOLEAN NOT NULL DEFAULT false,
+    allow_simultaneous_play BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    default_volume INTEGER NOT NULL DEFAULT 100,
+    fade_in_ms INTEGER NOT NULL DEFAULT 2000,
+    fade_out_ms INTEGER NOT NULL DEFAULT 2000,
+    always_start_from_beginning BOOLEAN NOT NULL DEFAULT false,
+    max_concurrent INTEGER NOT NULL DEFAULT 0,
+    auto_stop_others BOOLEAN NOT NULL DEFAULT false
+);
+
+CREATE UNIQUE INDEX track_types_name ON track_types (lower(name));
+
+INSERT INTO track_types (id, name, color, is_repeating, allow_simultaneous_play, fade_in_ms, fade_out_ms, max_concurrent)
+VALUES
+    ('1ec000a2-a7c9-11ee-a0e5-0242ac120002', 'Ambiance', '#B39DDB', true, true, 2000, 2000, 0),
+    ('1ec000a2-a7c9-11ee-a0e5-0242ac120003', 'Music', '#80DEEA', true, false, 2000, 2000, 1),
+    ('1ec000a2-a7c9-11ee-a0e5-0242ac120004', 'One-Shot', '#A5D6A7', false, true, 0, 0, 0);
+
+CREATE TABLE tracks (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    name TEXT NOT NULL,
+    path TEXT NOT NULL,
+    type_id UUID NOT NULL REFERENCES track_types (id),
+    duration DOUBLE PRECISION NOT NULL DEFAULT 0,
+    metadata JSONB NOT NULL DEFAULT '{}',
+    position INTEGER NOT NULL DEFAULT 0,
+    description TEXT NOT NULL DEFAULT '',
+    artist TEXT NOT NULL DEFAULT '',
+    source_url TEXT NOT NULL DEFAULT '',
+    license TEXT NOT NULL DEFAULT '',
+    attribution TEXT NOT NULL DEFAULT '',
+    favorite BOOLEAN NOT NULL DEFAULT false,
+    -- Full-text document over the name, tags and probed metadata, kept up to
+    -- date by the triggers below. Unlike SQLite's index it doesn't fold
+    -- accents, which would need the unaccent extension.
+    search TSVECTOR NOT NULL DEFAULT ''
+);
+
+CREATE INDEX tracks_type_position ON tracks (type_id, position);
+CREATE INDEX tracks_search ON tracks USING gin (search);
+
+CREATE TABLE tags (
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX tags_name ON tags (lower(name));
+
+CREATE TABLE track_tags (
+    track_id UUID NOT NULL REFERENCES tracks (id) ON DELETE CASCADE,
+    tag_id UUID NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    PRIMARY KEY (track_id, tag_id)
+);
+
+CREATE INDEX track_tags_tag_id ON track_tags (tag_id);
+
+CREATE FUNCTION track_search(search_track_id UUID, search_name TEXT, search_metadata JSONB) RETURNS TSVECTOR
+LANGUAGE sql STABLE AS $$
+    SELECT to_tsvector('simple', concat_ws(' ',
+        search_name,
+        (SELECT string_agg(tags.name, ' ') FROM track_tags
+         JOIN tags ON tags.id = track_tags.tag_id
+         WHERE track_tags.track_id = search_track_id),
+        (SELECT string_agg(value, ' ') FROM jsonb_each_text(search_metadata))
+    ))
+$$;
+
+CREATE FUNCTION tracks_search_update() RETURNS TRIGGER
+LANGUAGE plpgsql AS $$
+BEGIN
+    NEW.search := track_search(NEW.id, NEW.name, NEW.metadata);
+    RETURN NEW;
+END;
+$$;
+
+CREATE TRIGGER tracks_search BEFORE INSERT OR UPDATE OF name, metadata ON tracks
+FOR EACH ROW EXECUTE FUNCTION tracks_search_update();
+
+CREATE FUNCTION track_tags_search_update() RETURNS TRIGGER
+LANGUAGE plpgsql AS $$
+DECLARE
+    changed_track_id UUID;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        changed_track_id := OLD.track_id;
+    ELSE
+        changed_track_id := NEW.track_id;
+    END IF;
+
+    UPDATE tracks SET search = track_search(id, name, metadata) WHERE id = changed_track_id;
+    RETURN NULL;
+END;
+$$;
+
+CREATE TRIGGER track_tags_search AFTER INSERT OR DELETE ON track_tags
+FOR EACH ROW EXECUTE FUNCTION track_tags_search_update();
+
+CREATE TABLE collections (
+    id UUID PRIMARY KEY,
+    parent_id UUID REFERENCES collections (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX collections_parent_id ON collections (parent_id);
+
+CREATE TABLE collection_tracks (
+    collection_id UUID NOT NULL REFERENCES collections (id) ON DELETE CASCADE,
+    track_id UUID NOT NULL REFERENCES tracks (id) ON DELETE CASCADE,
+    PRIMARY KEY (collection_id, track_id)
+);
+
+CREATE INDEX collection_tracks_track_id ON collection_tracks (track_id);
+
+-- Tags and collections come out as JSON arrays, as they do from SQLite.
+CREATE VIEW track_details AS
+SELECT
+    tracks.id,
+    tracks.created_at,
+    tracks.name,
+    tracks.path,
+    tracks.type_id,
+    tracks.duration,
+    tracks.metadata,
+    tracks.position,
+    tracks.description,
+    tracks.artist,
+    tracks.source_url,
+    tracks.license,
+    tracks.attribution,
+    tracks.favorite,
+    coalesce((
+        SELECT jsonb_agg(tags.name ORDER BY lower(tags.name) COLLATE "C") FROM track_tags
+        JOIN tags ON tags.id = track_tags.tag_id
+        WHERE track_tags.track_id = tracks.id
+    ), '[]')::JSONB AS tags,
+    coalesce((
+        SELECT jsonb_agg(collection_id ORDER BY collection_id) FROM collection_tracks
+        WHERE collection_tracks.track_id = tracks.id
+    ), '[]')::JSONB AS collections
+FROM tracks;
+
+CREATE TABLE track_plays (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    track_id UUID NOT NULL REFERENCES tracks (id) ON DELETE CASCADE,
+    -- Times are kept to the second, as in SQLite, so the start time a play is
+    -- finished with always matches the one it was recorded with.
+    started_at TIMESTAMPTZ NOT NULL,
+    -- duration is how many seconds the track played for. It stays null until
+    -- the track is stopped.
+    duration DOUBLE PRECISION
+);
+
+CREATE INDEX track_plays_started_at ON track_plays (started_at);
+CREATE INDEX track_plays_track_id ON track_plays (track_id);
+
+-- audit_log records who changed the library and who signed in. Entries are
+-- never changed once written, which the triggers below enforce.
+CREATE TABLE audit_log (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL,
+    actor TEXT NOT NULL,
+    action TEXT NOT NULL,
+    target_id TEXT NOT NULL DEFAULT '',
+    -- JSON objects holding only the fields the action changed. They're json
+    -- rather than jsonb so they read back exactly as written.
+    before_state JSON,
+    after_state JSON,
+    ip TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX audit_log_created_at ON audit_log (created_at);
+CREATE INDEX audit_log_target_id ON audit_log (target_id);
+
+CREATE FUNCTION audit_log_append_only() RETURNS TRIGGER
+LANGUAGE plpgsql AS $$
+BEGIN
+    RAISE EXCEPTION 'audit log entries cannot be changed or deleted';
+END;
+$$;
+
+CREATE TRIGGER audit_log_no_change BEFORE UPDATE OR DELETE ON audit_log
+FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+
+CREATE TRIGGER audit_log_no_truncate BEFORE TRUNCATE ON audit_log
+FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
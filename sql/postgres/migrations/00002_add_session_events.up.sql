@@ -0,0 +1,26 @@
+-- session_events logs the commands the GM sent to the table, in order, so a
+-- session can be recapped or replayed. Like the audit log, events are never
+-- changed once written.
+CREATE TABLE session_events (
+    session_id UUID NOT NULL,
+    seq BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    method TEXT NOT NULL,
+    sender_id TEXT NOT NULL DEFAULT '',
+    -- json rather than jsonb, so payloads read back exactly as sent
+    payload JSON NOT NULL,
+    PRIMARY KEY (session_id, seq)
+);
+
+CREATE FUNCTION session_events_append_only() RETURNS TRIGGER
+LANGUAGE plpgsql AS $$
+BEGIN
+    RAISE EXCEPTION 'session events cannot be changed or deleted';
+END;
+$$;
+
+CREATE TRIGGER session_events_no_change BEFORE UPDATE OR DELETE ON session_events
+FOR EACH ROW EXECUTE FUNCTION session_events_append_only();
+
+CREATE TRIGGER session_events_no_truncate BEFORE TRUNCATE ON session_events
+FOR EACH STATEMENT EXECUTE FUNCTION session_events_append_only();
@@ -0,0 +1,2 @@
+DROP INDEX session_events_table_id;
+ALTER TABLE session_events DROP COLUMN table_id;
@@ -0,0 +1,6 @@
+-- table_id says which table a session was played at, so sessions can be
+-- listed and found again after the server restarts. Earlier sessions were all
+-- played at the default table.
+ALTER TABLE session_events ADD COLUMN table_id TEXT NOT NULL DEFAULT 'default';
+
+CREATE INDEX session_events_table_id ON session_events (table_id, session_id);
@@ -0,0 +1,36 @@
+-- name: SaveTrackPlay :exec
+insert into track_plays (track_id, started_at) values (@track_id, @started_at);
+
+-- name: GetPlayedTrackIDs :many
+-- Tracks are listed in the order they were first played.
+select track_id from track_plays
+where started_at >= @started_after and started_at < @started_before
+group by track_id
+order by min(started_at);
+
+-- name: FinishTrackPlay :exec
+update track_plays set duration = @duration
+where track_id = @track_id and started_at = @started_at and duration is null;
+
+-- name: GetRecentTrackStats :many
+select
+  track_id,
+  count(*) as play_count,
+  coalesce(sum(duration), 0)::double precision as total_duration,
+  max(started_at)::timestamptz as last_played_at
+from track_plays
+group by track_id
+order by last_played_at desc
+limit sqlc.arg('limit');
+
+-- name: GetPopularTrackStats :many
+select
+  track_id,
+  count(*) as play_count,
+  coalesce(sum(duration), 0)::double precision as total_duration,
+  max(started_at)::timestamptz as last_played_at
+from track_plays
+where started_at >= @started_after
+group by track_id
+order by play_count desc, total_duration desc, last_played_at desc
+limit sqlc.arg('limit');
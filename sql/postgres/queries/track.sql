@@ -0,0 +1,130 @@
+-- name: GetTracks :many
+with recursive subtree(id) as (
+  select sqlc.narg('collection_id')::uuid
+  union
+  select collections.id from collections join subtree on collections.parent_id = subtree.id
+)
+select * from track_details
+where
+  (sqlc.narg('query')::text is null or id in (select id from tracks where search @@ to_tsquery('simple', sqlc.narg('query'))))
+  and (sqlc.narg('type_id')::uuid is null or type_id = sqlc.narg('type_id'))
+  and (sqlc.narg('tag')::text is null or id in (
+    select track_tags.track_id from track_tags
+    join tags on tags.id = track_tags.tag_id
+    where lower(tags.name) = lower(sqlc.narg('tag'))
+  ))
+  and (sqlc.narg('min_id')::uuid is null or id >= sqlc.narg('min_id'))
+  and (sqlc.narg('max_id')::uuid is null or id < sqlc.narg('max_id'))
+  and (sqlc.narg('collection_id')::uuid is null or id in (
+    select track_id from collection_tracks where collection_id in (select id from subtree)
+  ))
+  and (sqlc.narg('favorite')::boolean is null or favorite = sqlc.narg('favorite'))
+  and (
+    sqlc.narg('cursor_id')::uuid is null
+    or case @sort_by::text
+      when 'name' then
+        case when @descending::boolean
+          then lower(name) collate "C" < lower(@cursor_name::text) or (lower(name) = lower(@cursor_name::text) and id < sqlc.narg('cursor_id'))
+          else lower(name) collate "C" > lower(@cursor_name::text) or (lower(name) = lower(@cursor_name::text) and id > sqlc.narg('cursor_id'))
+        end
+      when 'duration' then
+        case when @descending::boolean
+          then duration < @cursor_duration::double precision or (duration = @cursor_duration::double precision and id < sqlc.narg('cursor_id'))
+          else duration > @cursor_duration::double precision or (duration = @cursor_duration::double precision and id > sqlc.narg('cursor_id'))
+        end
+      when 'position' then
+        case when @descending::boolean
+          then (type_id, position, id) < (@cursor_type_id::uuid, @cursor_position::integer, sqlc.narg('cursor_id'))
+          else (type_id, position, id) > (@cursor_type_id::uuid, @cursor_position::integer, sqlc.narg('cursor_id'))
+        end
+      else
+        case when @descending::boolean then id < sqlc.narg('cursor_id') else id > sqlc.narg('cursor_id') end
+    end
+  )
+order by
+  case when @sort_by::text = 'name' and not @descending::boolean then lower(name) end collate "C" asc,
+  case when @sort_by::text = 'name' and @descending::boolean then lower(name) end collate "C" desc,
+  case when @sort_by::text = 'duration' and not @descending::boolean then duration end asc,
+  case when @sort_by::text = 'duration' and @descending::boolean then duration end desc,
+  case when @sort_by::text = 'position' and not @descending::boolean then type_id end asc,
+  case when @sort_by::text = 'position' and @descending::boolean then type_id end desc,
+  case when @sort_by::text = 'position' and not @descending::boolean then position end asc,
+  case when @sort_by::text = 'position' and @descending::boolean then position end desc,
+  case when not @descending::boolean then id end asc,
+  case when @descending::boolean then id end desc
+limit sqlc.arg('limit');
+
+-- name: CountTracks :one
+with recursive subtree(id) as (
+  select sqlc.narg('collection_id')::uuid
+  union
+  select collections.id from collections join subtree on collections.parent_id = subtree.id
+)
+select count(*) from tracks
+where
+  (sqlc.narg('query')::text is null or search @@ to_tsquery('simple', sqlc.narg('query')))
+  and (sqlc.narg('type_id')::uuid is null or type_id = sqlc.narg('type_id'))
+  and (sqlc.narg('tag')::text is null or id in (
+    select track_tags.track_id from track_tags
+    join tags on tags.id = track_tags.tag_id
+    where lower(tags.name) = lower(sqlc.narg('tag'))
+  ))
+  and (sqlc.narg('min_id')::uuid is null or id >= sqlc.narg('min_id'))
+  and (sqlc.narg('max_id')::uuid is null or id < sqlc.narg('max_id'))
+  and (sqlc.narg('collection_id')::uuid is null or id in (
+    select track_id from collection_tracks where collection_id in (select id from subtree)
+  ))
+  and (sqlc.narg('favorite')::boolean is null or favorite = sqlc.narg('favorite'));
+
+-- name: GetTrackByID :one
+select * from track_details where id = @id;
+
+-- name: LockTrack :one
+-- Locks a track until the end of the transaction.
+select id from tracks where id = @id for update;
+
+-- name: DeleteTrackByID :exec
+delete from tracks where id = @id;
+
+-- name: SaveTrack :exec
+insert into tracks (id, created_at, name, path, type_id, duration, metadata, position)
+values (
+  @id, @created_at, @name, @path, @type_id, @duration, @metadata,
+  (select coalesce(max(position) + 1, 0) from tracks where type_id = @type_id)
+);
+
+-- name: SetTrackMedia :exec
+update tracks set duration = @duration, metadata = @metadata where id = @id;
+
+-- name: UpdateTrack :one
+-- Tracks moved to another type go to the end of it.
+update tracks
+set
+  name = coalesce(sqlc.narg('name'), name),
+  position = case
+    when sqlc.narg('type_id')::uuid is null or sqlc.narg('type_id') = tracks.type_id then tracks.position
+    else (select coalesce(max(position) + 1, 0) from tracks as others where others.type_id = sqlc.narg('type_id'))
+  end,
+  type_id = coalesce(sqlc.narg('type_id'), type_id),
+  description = coalesce(sqlc.narg('description'), description),
+  artist = coalesce(sqlc.narg('artist'), artist),
+  source_url = coalesce(sqlc.narg('source_url'), source_url),
+  license = coalesce(sqlc.narg('license'), license),
+  attribution = coalesce(sqlc.narg('attribution'), attribution),
+  favorite = coalesce(sqlc.narg('favorite'), favorite)
+where tracks.id = @id
+returning id;
+
+-- name: GetTrackIDsByType :many
+select id from tracks where type_id = @type_id order by position;
+
+-- name: SetTrackPosition :exec
+update tracks set position = @position where id = @id;
+
+-- name: MoveTracksToType :exec
+-- The moved tracks keep their order and go after the new type's own tracks.
+update tracks
+set
+  type_id = @new_type_id,
+  position = tracks.position + (select coalesce(max(position) + 1, 0) from tracks as others where others.type_id = @new_type_id)
+where tracks.type_id = @old_type_id;
@@ -0,0 +1,33 @@
+-- name: GetTrackTypes :many
+select * from track_types order by created_at, id;
+
+-- name: GetTrackTypeByID :one
+select * from track_types where id = @id;
+
+-- name: SaveTrackType :exec
+insert into track_types (
+  id, name, color, is_repeating, allow_simultaneous_play, default_volume,
+  fade_in_ms, fade_out_ms, always_start_from_beginning, max_concurrent, auto_stop_others
+)
+values (
+  @id, @name, @color, @is_repeating, @allow_simultaneous_play, @default_volume,
+  @fade_in_ms, @fade_out_ms, @always_start_from_beginning, @max_concurrent, @auto_stop_others
+);
+
+-- name: UpdateTrackType :exec
+update track_types
+set
+  name = @name,
+  color = @color,
+  is_repeating = @is_repeating,
+  allow_simultaneous_play = @allow_simultaneous_play,
+  default_volume = @default_volume,
+  fade_in_ms = @fade_in_ms,
+  fade_out_ms = @fade_out_ms,
+  always_start_from_beginning = @always_start_from_beginning,
+  max_concurrent = @max_concurrent,
+  auto_stop_others = @auto_stop_others
+where id = @id;
+
+-- name: DeleteTrackType :exec
+delete from track_types where id = @id;
@@ -0,0 +1,23 @@
+-- name: SaveUser :exec
+insert into users (id, username, password_hash, admin, created_at)
+values (@id, @username, @password_hash, @admin, @created_at);
+
+-- name: GetUsers :many
+select * from users order by lower(username) collate "C";
+
+-- name: GetUserByID :one
+select * from users where id = @id;
+
+-- name: GetUserByUsername :one
+select * from users where lower(username) = lower(@username);
+
+-- name: UpdateUser :exec
+update users
+set
+  username = @username,
+  password_hash = @password_hash,
+  admin = @admin
+where id = @id;
+
+-- name: DeleteUser :exec
+delete from users where id = @id;
@@ -0,0 +1,16 @@
+-- name: SaveAuditEntry :exec
+insert into audit_log (created_at, actor, action, target_id, before_state, after_state, ip)
+values (@created_at, @actor, @action, @target_id, @before_state, @after_state, @ip);
+
+-- name: GetAuditEntries :many
+-- Entries are listed newest first. before_id continues from the last entry of
+-- the previous page.
+select * from audit_log
+where (sqlc.narg('actor') is null or actor = sqlc.narg('actor'))
+  and (sqlc.narg('action') is null or action = sqlc.narg('action'))
+  and (sqlc.narg('target_id') is null or target_id = sqlc.narg('target_id'))
+  and (sqlc.narg('created_after') is null or created_at >= sqlc.narg('created_after'))
+  and (sqlc.narg('created_before') is null or created_at < sqlc.narg('created_before'))
+  and (sqlc.narg('before_id') is null or id < sqlc.narg('before_id'))
+order by id desc
+limit @limit;
@@ -0,0 +1,41 @@
+-- name: GetCollections :many
+select
+  collections.*,
+  (select count(*) from collection_tracks where collection_id = collections.id) as track_count
+from collections
+order by name;
+
+-- name: SaveCollection :exec
+insert into collections (id, parent_id, name, created_at) values (@id, @parent_id, @name, @created_at);
+
+-- name: UpdateCollection :exec
+update collections set name = @name, parent_id = @parent_id where id = @id;
+
+-- name: DeleteCollectionTracks :exec
+-- Removes track links from a collection and everything nested in it.
+delete from collection_tracks where collection_id in (
+  with recursive subtree(id) as (
+    select @id
+    union
+    select collections.id from collections join subtree on collections.parent_id = subtree.id
+  )
+  select id from subtree
+);
+
+-- name: DeleteCollection :exec
+-- Deletes a collection and everything nested in it.
+delete from collections where id in (
+  with recursive subtree(id) as (
+    select @id
+    union
+    select collections.id from collections join subtree on collections.parent_id = subtree.id
+  )
+  select id from subtree
+);
+
+-- name: AddCollectionTrack :exec
+insert into collection_tracks (collection_id, track_id) values (@collection_id, @track_id)
+on conflict do nothing;
+
+-- name: DeleteTrackCollections :exec
+delete from collection_tracks where track_id = @track_id;
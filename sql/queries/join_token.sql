@@ -0,0 +1,31 @@
+-- name: SaveJoinToken :exec
+insert into join_tokens (id, label, secret_hash, created_at, expires_at, max_uses, uses, revoked_at)
+values (@id, @label, @secret_hash, @created_at, @expires_at, @max_uses, @uses, @revoked_at);
+
+-- name: GetJoinTokens :many
+select * from join_tokens order by created_at desc, id desc;
+
+-- name: GetJoinTokenByID :one
+select * from join_tokens where id = @id;
+
+-- name: RotateJoinToken :execrows
+update join_tokens
+set
+  secret_hash = @secret_hash,
+  uses = 0
+where id = @id and revoked_at is null;
+
+-- name: RevokeJoinToken :execrows
+update join_tokens
+set revoked_at = coalesce(revoked_at, @revoked_at)
+where id = @id;
+
+-- name: UseJoinToken :one
+update join_tokens
+set uses = uses + 1
+where id = @id
+  and secret_hash = @secret_hash
+  and revoked_at is null
+  and (expires_at is null or expires_at > @now)
+  and (max_uses = 0 or uses < max_uses)
+returning *;
@@ -0,0 +1,23 @@
+-- name: SaveSessionEvent :exec
+insert into session_events (session_id, table_id, seq, created_at, method, sender_id, payload)
+values (@session_id, @table_id, @seq, @created_at, @method, @sender_id, @payload);
+
+-- name: GetSessionEvents :many
+-- after_seq continues from the last event of the previous page.
+select * from session_events
+where session_id = @session_id
+  and seq > @after_seq
+order by seq
+limit @limit;
+
+-- name: GetSessions :many
+-- Sessions are only logged once the GM sends a command, so every session
+-- has at least one event.
+select session_id,
+       min(created_at) as started_at,
+       max(created_at) as last_event_at,
+       count(*) as event_count
+from session_events
+where table_id = @table_id
+group by session_id
+order by started_at desc, session_id desc;
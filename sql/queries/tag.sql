@@ -0,0 +1,17 @@
+-- name: GetTags :many
+select name from tags order by name;
+
+-- name: UpsertTag :one
+insert into tags (id, name) values (@id, @name)
+on conflict (name) do update set name = tags.name
+returning id;
+
+-- name: AddTrackTag :exec
+insert into track_tags (track_id, tag_id) values (@track_id, @tag_id)
+on conflict do nothing;
+
+-- name: DeleteTrackTags :exec
+delete from track_tags where track_id = @track_id;
+
+-- name: DeleteUnusedTags :exec
+delete from tags where id not in (select tag_id from track_tags);
@@ -1,19 +1,130 @@
 -- name: GetTracks :many
-select * from tracks;
+select * from track_details
+where
+  (sqlc.narg('query') is null or id in (select track_id from tracks_fts where tracks_fts match sqlc.narg('query')))
+  and (sqlc.narg('type_id') is null or type_id = sqlc.narg('type_id'))
+  and (sqlc.narg('tag') is null or id in (
+    select track_tags.track_id from track_tags
+    join tags on tags.id = track_tags.tag_id
+    where tags.name = sqlc.narg('tag')
+  ))
+  and (sqlc.narg('min_id') is null or id >= sqlc.narg('min_id'))
+  and (sqlc.narg('max_id') is null or id < sqlc.narg('max_id'))
+  and (sqlc.narg('collection_id') is null or id in (
+    select track_id from collection_tracks where collection_id in (
+      with recursive subtree(id) as (
+        select sqlc.narg('collection_id')
+        union
+        select collections.id from collections join subtree on collections.parent_id = subtree.id
+      )
+      select id from subtree
+    )
+  ))
+  and (sqlc.narg('favorite') is null or favorite = sqlc.narg('favorite'))
+  and (
+    sqlc.narg('cursor_id') is null
+    or case @sort_by
+      when 'name' then
+        case when @descending
+          then name collate nocase < @cursor_name or (name collate nocase = @cursor_name and id < sqlc.narg('cursor_id'))
+          else name collate nocase > @cursor_name or (name collate nocase = @cursor_name and id > sqlc.narg('cursor_id'))
+        end
+      when 'duration' then
+        case when @descending
+          then duration < @cursor_duration or (duration = @cursor_duration and id < sqlc.narg('cursor_id'))
+          else duration > @cursor_duration or (duration = @cursor_duration and id > sqlc.narg('cursor_id'))
+        end
+      when 'position' then
+        case when @descending
+          then (type_id, position, id) < (@cursor_type_id, @cursor_position, sqlc.narg('cursor_id'))
+          else (type_id, position, id) > (@cursor_type_id, @cursor_position, sqlc.narg('cursor_id'))
+        end
+      else
+        case when @descending then id < sqlc.narg('cursor_id') else id > sqlc.narg('cursor_id') end
+    end
+  )
+order by
+  case when @sort_by = 'name' and not @descending then name end collate nocase asc,
+  case when @sort_by = 'name' and @descending then name end collate nocase desc,
+  case when @sort_by = 'duration' and not @descending then duration end asc,
+  case when @sort_by = 'duration' and @descending then duration end desc,
+  case when @sort_by = 'position' and not @descending then type_id end asc,
+  case when @sort_by = 'position' and @descending then type_id end desc,
+  case when @sort_by = 'position' and not @descending then position end asc,
+  case when @sort_by = 'position' and @descending then position end desc,
+  case when not @descending then id end asc,
+  case when @descending then id end desc
+limit @limit;
+
+-- name: CountTracks :one
+select count(*) from tracks
+where
+  (sqlc.narg('query') is null or id in (select track_id from tracks_fts where tracks_fts match sqlc.narg('query')))
+  and (sqlc.narg('type_id') is null or type_id = sqlc.narg('type_id'))
+  and (sqlc.narg('tag') is null or id in (
+    select track_tags.track_id from track_tags
+    join tags on tags.id = track_tags.tag_id
+    where tags.name = sqlc.narg('tag')
+  ))
+  and (sqlc.narg('min_id') is null or id >= sqlc.narg('min_id'))
+  and (sqlc.narg('max_id') is null or id < sqlc.narg('max_id'))
+  and (sqlc.narg('collection_id') is null or id in (
+    select track_id from collection_tracks where collection_id in (
+      with recursive subtree(id) as (
+        select sqlc.narg('collection_id')
+        union
+        select collections.id from collections join subtree on collections.parent_id = subtree.id
+      )
+      select id from subtree
+    )
+  ))
+  and (sqlc.narg('favorite') is null or favorite = sqlc.narg('favorite'));
 
 -- name: GetTrackByID :one
-select * from tracks where id = @id;
+select * from track_details where id = @id;
 
 -- name: DeleteTrackByID :exec
 delete from tracks where id = @id;
 
 -- name: SaveTrack :exec
-insert into tracks (id, created_at, name, path, type_id) values (@id, @created_at, @name, @path, @type_id);
+insert into tracks (id, created_at, name, path, type_id, duration, metadata, position)
+values (
+  @id, @created_at, @name, @path, @type_id, @duration, @metadata,
+  (select coalesce(max(position) + 1, 0) from tracks where type_id = @type_id)
+);
+
+-- name: SetTrackMedia :exec
+update tracks set duration = @duration, metadata = @metadata where id = @id;
 
 -- name: UpdateTrack :one
+-- Tracks moved to another type go to the end of it.
 update tracks
 set
   name = coalesce(sqlc.narg('name'), name),
-  type_id = coalesce(sqlc.narg('type_id'), type_id)
+  position = case
+    when sqlc.narg('type_id') is null or sqlc.narg('type_id') = type_id then position
+    else (select coalesce(max(position) + 1, 0) from tracks as others where others.type_id = sqlc.narg('type_id'))
+  end,
+  type_id = coalesce(sqlc.narg('type_id'), type_id),
+  description = coalesce(sqlc.narg('description'), description),
+  artist = coalesce(sqlc.narg('artist'), artist),
+  source_url = coalesce(sqlc.narg('source_url'), source_url),
+  license = coalesce(sqlc.narg('license'), license),
+  attribution = coalesce(sqlc.narg('attribution'), attribution),
+  favorite = coalesce(sqlc.narg('favorite'), favorite)
 where id = @id
-returning *
\ No newline at end of file
+returning *;
+
+-- name: GetTrackIDsByType :many
+select id from tracks where type_id = @type_id order by position;
+
+-- name: SetTrackPosition :exec
+update tracks set position = @position where id = @id;
+
+-- name: MoveTracksToType :exec
+-- The moved tracks keep their order and go after the new type's own tracks.
+update tracks
+set
+  type_id = @new_type_id,
+  position = position + (select coalesce(max(position) + 1, 0) from tracks as others where others.type_id = @new_type_id)
+where type_id = @old_type_id;
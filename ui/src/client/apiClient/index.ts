@@ -1,4 +1,4 @@
 // This file is auto-generated by @hey-api/openapi-ts
 
-export { deleteApiV1FilesByTrackId, getApiV1AuthStatus, getApiV1Files, getApiV1JoinToken, getApiV1StreamByPath, getApiV1TrackTypes, getApiV1Ws, type Options, postApiV1AuthLogout, postApiV1Files, postApiV1Login, putApiV1FilesByTrackId } from './sdk.gen';
-export type { AuthStatusResponse, ClientOptions, DeleteApiV1FilesByTrackIdData, DeleteApiV1FilesByTrackIdErrors, DeleteApiV1FilesByTrackIdResponses, GetApiV1AuthStatusData, GetApiV1AuthStatusResponse, GetApiV1AuthStatusResponses, GetApiV1FilesData, GetApiV1FilesErrors, GetApiV1FilesResponse, GetApiV1FilesResponses, GetApiV1JoinTokenData, GetApiV1JoinTokenErrors, GetApiV1JoinTokenResponse, GetApiV1JoinTokenResponses, GetApiV1StreamByPathData, GetApiV1StreamByPathErrors, GetApiV1StreamByPathResponse, GetApiV1StreamByPathResponses, GetApiV1TrackTypesData, GetApiV1TrackTypesErrors, GetApiV1TrackTypesResponse, GetApiV1TrackTypesResponses, GetApiV1WsData, GetApiV1WsErrors, JoinRequest, JoinTokenResponse, LoginRequest, LoginResponse, PostApiV1AuthLogoutData, PostApiV1AuthLogoutResponses, PostApiV1FilesData, PostApiV1FilesErrors, PostApiV1FilesResponses, PostApiV1LoginData, PostApiV1LoginError, PostApiV1LoginErrors, PostApiV1LoginResponse, PostApiV1LoginResponses, PutApiV1FilesByTrackIdData, PutApiV1FilesByTrackIdErrors, PutApiV1FilesByTrackIdResponse, PutApiV1FilesByTrackIdResponses, Track, TrackType, UpdateTrackRequest } from './types.gen';
+export { deleteApiV1CollectionsByCollectionId, deleteApiV1FilesByTrackId, deleteApiV1TrackTypesByTypeId, deleteApiV1UsersByUserId, getApiV1Audit, getApiV1AuthStatus, getApiV1Collections, getApiV1CollectionsByCollectionId, getApiV1Credits, getApiV1Files, getApiV1FilesFavorites, getApiV1FilesPopular, getApiV1FilesRecent, getApiV1JoinTokens, getApiV1Sessions, getApiV1SessionsBySessionIdEvents, getApiV1StreamByPath, getApiV1Tags, getApiV1TrackTypes, getApiV1Users, getApiV1UsersByUserId, getApiV1Ws, type Options, postApiV1AuthLogout, postApiV1Collections, postApiV1Files, postApiV1FilesBulk, postApiV1FilesByTrackIdMediaRollback, postApiV1Join, postApiV1JoinTokens, postApiV1JoinTokensByJoinTokenIdRevoke, postApiV1JoinTokensByJoinTokenIdRotate, postApiV1Login, postApiV1TrackTypes, postApiV1Users, putApiV1CollectionsByCollectionId, putApiV1FilesByTrackId, putApiV1FilesByTrackIdMedia, putApiV1FilesOrder, putApiV1TrackTypesByTypeId, putApiV1UsersByUserId } from './sdk.gen';
+export type { AuditEntry, AuditLog, AuthStatusResponse, BulkTrackRequest, BulkTrackResponse, BulkTrackResult, ClientOptions, Collection, CollectionRequest, DeleteApiV1CollectionsByCollectionIdData, DeleteApiV1CollectionsByCollectionIdErrors, DeleteApiV1CollectionsByCollectionIdResponses, DeleteApiV1FilesByTrackIdData, DeleteApiV1FilesByTrackIdErrors, DeleteApiV1FilesByTrackIdResponses, DeleteApiV1TrackTypesByTypeIdData, DeleteApiV1TrackTypesByTypeIdErrors, DeleteApiV1TrackTypesByTypeIdResponses, DeleteApiV1UsersByUserIdData, DeleteApiV1UsersByUserIdErrors, DeleteApiV1UsersByUserIdResponse, DeleteApiV1UsersByUserIdResponses, GetApiV1AuditData, GetApiV1AuditErrors, GetApiV1AuditResponse, GetApiV1AuditResponses, GetApiV1AuthStatusData, GetApiV1AuthStatusResponse, GetApiV1AuthStatusResponses, GetApiV1CollectionsByCollectionIdData, GetApiV1CollectionsByCollectionIdErrors, GetApiV1CollectionsByCollectionIdResponse, GetApiV1CollectionsByCollectionIdResponses, GetApiV1CollectionsData, GetApiV1CollectionsErrors, GetApiV1CollectionsResponse, GetApiV1CollectionsResponses, GetApiV1CreditsData, GetApiV1CreditsErrors, GetApiV1CreditsResponse, GetApiV1CreditsResponses, GetApiV1FilesData, GetApiV1FilesErrors, GetApiV1FilesFavoritesData, GetApiV1FilesFavoritesErrors, GetApiV1FilesFavoritesResponse, GetApiV1FilesFavoritesResponses, GetApiV1FilesPopularData, GetApiV1FilesPopularErrors, GetApiV1FilesPopularResponse, GetApiV1FilesPopularResponses, GetApiV1FilesRecentData, GetApiV1FilesRecentErrors, GetApiV1FilesRecentResponse, GetApiV1FilesRecentResponses, GetApiV1FilesResponse, GetApiV1FilesResponses, GetApiV1JoinTokensData, GetApiV1JoinTokensErrors, GetApiV1JoinTokensResponse, GetApiV1JoinTokensResponses, GetApiV1SessionsBySessionIdEventsData, GetApiV1SessionsBySessionIdEventsErrors, GetApiV1SessionsBySessionIdEventsResponse, GetApiV1SessionsBySessionIdEventsResponses, GetApiV1SessionsData, GetApiV1SessionsErrors, GetApiV1SessionsResponse, GetApiV1SessionsResponses, GetApiV1StreamByPathData, GetApiV1StreamByPathErrors, GetApiV1StreamByPathResponse, GetApiV1StreamByPathResponses, GetApiV1TagsData, GetApiV1TagsErrors, GetApiV1TagsResponse, GetApiV1TagsResponses, GetApiV1TrackTypesData, GetApiV1TrackTypesErrors, GetApiV1TrackTypesResponse, GetApiV1TrackTypesResponses, GetApiV1UsersByUserIdData, GetApiV1UsersByUserIdErrors, GetApiV1UsersByUserIdResponse, GetApiV1UsersByUserIdResponses, GetApiV1UsersData, GetApiV1UsersErrors, GetApiV1UsersResponse, GetApiV1UsersResponses, GetApiV1WsData, GetApiV1WsErrors, IssuedJoinToken, JoinRequest, JoinResponse, JoinToken, JoinTokenRequest, LoginRequest, LoginResponse, PlayedTrack, PostApiV1AuthLogoutData, PostApiV1AuthLogoutResponses, PostApiV1CollectionsData, PostApiV1CollectionsErrors, PostApiV1CollectionsResponse, PostApiV1CollectionsResponses, PostApiV1FilesBulkData, PostApiV1FilesBulkErrors, PostApiV1FilesBulkResponse, PostApiV1FilesBulkResponses, PostApiV1FilesByTrackIdMediaRollbackData, PostApiV1FilesByTrackIdMediaRollbackErrors, PostApiV1FilesByTrackIdMediaRollbackResponse, PostApiV1FilesByTrackIdMediaRollbackResponses, PostApiV1FilesData, PostApiV1FilesErrors, PostApiV1FilesResponses, PostApiV1JoinData, PostApiV1JoinErrors, PostApiV1JoinResponse, PostApiV1JoinResponses, PostApiV1JoinTokensByJoinTokenIdRevokeData, PostApiV1JoinTokensByJoinTokenIdRevokeErrors, PostApiV1JoinTokensByJoinTokenIdRevokeResponse, PostApiV1JoinTokensByJoinTokenIdRevokeResponses, PostApiV1JoinTokensByJoinTokenIdRotateData, PostApiV1JoinTokensByJoinTokenIdRotateErrors, PostApiV1JoinTokensByJoinTokenIdRotateResponse, PostApiV1JoinTokensByJoinTokenIdRotateResponses, PostApiV1JoinTokensData, PostApiV1JoinTokensErrors, PostApiV1JoinTokensResponse, PostApiV1JoinTokensResponses, PostApiV1LoginData, PostApiV1LoginError, PostApiV1LoginErrors, PostApiV1LoginResponse, PostApiV1LoginResponses, PostApiV1TrackTypesData, PostApiV1TrackTypesErrors, PostApiV1TrackTypesResponse, PostApiV1TrackTypesResponses, PostApiV1UsersData, PostApiV1UsersErrors, PostApiV1UsersResponse, PostApiV1UsersResponses, PutApiV1CollectionsByCollectionIdData, PutApiV1CollectionsByCollectionIdErrors, PutApiV1CollectionsByCollectionIdResponse, PutApiV1CollectionsByCollectionIdResponses, PutApiV1FilesByTrackIdData, PutApiV1FilesByTrackIdErrors, PutApiV1FilesByTrackIdMediaData, PutApiV1FilesByTrackIdMediaErrors, PutApiV1FilesByTrackIdMediaResponse, PutApiV1FilesByTrackIdMediaResponses, PutApiV1FilesByTrackIdResponse, PutApiV1FilesByTrackIdResponses, PutApiV1FilesOrderData, PutApiV1FilesOrderErrors, PutApiV1FilesOrderResponse, PutApiV1FilesOrderResponses, PutApiV1TrackTypesByTypeIdData, PutApiV1TrackTypesByTypeIdErrors, PutApiV1TrackTypesByTypeIdResponse, PutApiV1TrackTypesByTypeIdResponses, PutApiV1UsersByUserIdData, PutApiV1UsersByUserIdErrors, PutApiV1UsersByUserIdResponse, PutApiV1UsersByUserIdResponses, Session, SessionEvent, SessionList, SessionLog, Track, TrackList, TrackOrder, TrackStats, TrackType, TrackTypeRequest, UpdateTrackRequest, User, UserRequest } from './types.gen';
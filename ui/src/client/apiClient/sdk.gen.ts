@@ -2,7 +2,7 @@
 
 import { type Client, formDataBodySerializer, type Options as Options2, type TDataShape } from './client';
 import { client } from './client.gen';
-import type { DeleteApiV1FilesByTrackIdData, DeleteApiV1FilesByTrackIdErrors, DeleteApiV1FilesByTrackIdResponses, GetApiV1AuthStatusData, GetApiV1AuthStatusResponses, GetApiV1FilesData, GetApiV1FilesErrors, GetApiV1FilesResponses, GetApiV1JoinTokenData, GetApiV1JoinTokenErrors, GetApiV1JoinTokenResponses, GetApiV1StreamByPathData, GetApiV1StreamByPathErrors, GetApiV1StreamByPathResponses, GetApiV1TrackTypesData, GetApiV1TrackTypesErrors, GetApiV1TrackTypesResponses, GetApiV1WsData, GetApiV1WsErrors, PostApiV1AuthLogoutData, PostApiV1AuthLogoutResponses, PostApiV1FilesData, PostApiV1FilesErrors, PostApiV1FilesResponses, PostApiV1LoginData, PostApiV1LoginErrors, PostApiV1LoginResponses, PutApiV1FilesByTrackIdData, PutApiV1FilesByTrackIdErrors, PutApiV1FilesByTrackIdResponses } from './types.gen';
+import type { DeleteApiV1CollectionsByCollectionIdData, DeleteApiV1CollectionsByCollectionIdErrors, DeleteApiV1CollectionsByCollectionIdResponses, DeleteApiV1FilesByTrackIdData, DeleteApiV1FilesByTrackIdErrors, DeleteApiV1FilesByTrackIdResponses, DeleteApiV1TrackTypesByTypeIdData, DeleteApiV1TrackTypesByTypeIdErrors, DeleteApiV1TrackTypesByTypeIdResponses, DeleteApiV1UsersByUserIdData, DeleteApiV1UsersByUserIdErrors, DeleteApiV1UsersByUserIdResponses, GetApiV1AuditData, GetApiV1AuditErrors, GetApiV1AuditResponses, GetApiV1AuthStatusData, GetApiV1AuthStatusResponses, GetApiV1CollectionsByCollectionIdData, GetApiV1CollectionsByCollectionIdErrors, GetApiV1CollectionsByCollectionIdResponses, GetApiV1CollectionsData, GetApiV1CollectionsErrors, GetApiV1CollectionsResponses, GetApiV1CreditsData, GetApiV1CreditsErrors, GetApiV1CreditsResponses, GetApiV1FilesData, GetApiV1FilesErrors, GetApiV1FilesFavoritesData, GetApiV1FilesFavoritesErrors, GetApiV1FilesFavoritesResponses, GetApiV1FilesPopularData, GetApiV1FilesPopularErrors, GetApiV1FilesPopularResponses, GetApiV1FilesRecentData, GetApiV1FilesRecentErrors, GetApiV1FilesRecentResponses, GetApiV1FilesResponses, GetApiV1JoinTokensData, GetApiV1JoinTokensErrors, GetApiV1JoinTokensResponses, GetApiV1SessionsBySessionIdEventsData, GetApiV1SessionsBySessionIdEventsErrors, GetApiV1SessionsBySessionIdEventsResponses, GetApiV1SessionsData, GetApiV1SessionsErrors, GetApiV1SessionsResponses, GetApiV1StreamByPathData, GetApiV1StreamByPathErrors, GetApiV1StreamByPathResponses, GetApiV1TagsData, GetApiV1TagsErrors, GetApiV1TagsResponses, GetApiV1TrackTypesData, GetApiV1TrackTypesErrors, GetApiV1TrackTypesResponses, GetApiV1UsersByUserIdData, GetApiV1UsersByUserIdErrors, GetApiV1UsersByUserIdResponses, GetApiV1UsersData, GetApiV1UsersErrors, GetApiV1UsersResponses, GetApiV1WsData, GetApiV1WsErrors, PostApiV1AuthLogoutData, PostApiV1AuthLogoutResponses, PostApiV1CollectionsData, PostApiV1CollectionsErrors, PostApiV1CollectionsResponses, PostApiV1FilesBulkData, PostApiV1FilesBulkErrors, PostApiV1FilesBulkResponses, PostApiV1FilesByTrackIdMediaRollbackData, PostApiV1FilesByTrackIdMediaRollbackErrors, PostApiV1FilesByTrackIdMediaRollbackResponses, PostApiV1FilesData, PostApiV1FilesErrors, PostApiV1FilesResponses, PostApiV1JoinData, PostApiV1JoinErrors, PostApiV1JoinResponses, PostApiV1JoinTokensByJoinTokenIdRevokeData, PostApiV1JoinTokensByJoinTokenIdRevokeErrors, PostApiV1JoinTokensByJoinTokenIdRevokeResponses, PostApiV1JoinTokensByJoinTokenIdRotateData, PostApiV1JoinTokensByJoinTokenIdRotateErrors, PostApiV1JoinTokensByJoinTokenIdRotateResponses, PostApiV1JoinTokensData, PostApiV1JoinTokensErrors, PostApiV1JoinTokensResponses, PostApiV1LoginData, PostApiV1LoginErrors, PostApiV1LoginResponses, PostApiV1TrackTypesData, PostApiV1TrackTypesErrors, PostApiV1TrackTypesResponses, PostApiV1UsersData, PostApiV1UsersErrors, PostApiV1UsersResponses, PutApiV1CollectionsByCollectionIdData, PutApiV1CollectionsByCollectionIdErrors, PutApiV1CollectionsByCollectionIdResponses, PutApiV1FilesByTrackIdData, PutApiV1FilesByTrackIdErrors, PutApiV1FilesByTrackIdMediaData, PutApiV1FilesByTrackIdMediaErrors, PutApiV1FilesByTrackIdMediaResponses, PutApiV1FilesByTrackIdResponses, PutApiV1FilesOrderData, PutApiV1FilesOrderErrors, PutApiV1FilesOrderResponses, PutApiV1TrackTypesByTypeIdData, PutApiV1TrackTypesByTypeIdErrors, PutApiV1TrackTypesByTypeIdResponses, PutApiV1UsersByUserIdData, PutApiV1UsersByUserIdErrors, PutApiV1UsersByUserIdResponses } from './types.gen';
 
 export type Options<TData extends TDataShape = TDataShape, ThrowOnError extends boolean = boolean> = Options2<TData, ThrowOnError> & {
     /**
@@ -30,6 +30,19 @@ export const postApiV1Login = <ThrowOnError extends boolean = false>(options: Op
     }
 });
 
+/**
+ * Redeem a join token for a player token
+ * Each successful join counts as a use of the join token.
+ */
+export const postApiV1Join = <ThrowOnError extends boolean = false>(options: Options<PostApiV1JoinData, ThrowOnError>) => (options.client ?? client).post<PostApiV1JoinResponses, PostApiV1JoinErrors, ThrowOnError>({
+    url: '/api/v1/join',
+    ...options,
+    headers: {
+        'Content-Type': 'application/json',
+        ...options.headers
+    }
+});
+
 /**
  * Get current authentication status
  */
@@ -57,7 +70,7 @@ export const postApiV1AuthLogout = <ThrowOnError extends boolean = false>(option
 });
 
 /**
- * List all audio tracks
+ * List audio tracks, one page at a time
  */
 export const getApiV1Files = <ThrowOnError extends boolean = false>(options?: Options<GetApiV1FilesData, ThrowOnError>) => (options?.client ?? client).get<GetApiV1FilesResponses, GetApiV1FilesErrors, ThrowOnError>({
     security: [{
@@ -87,6 +100,84 @@ export const postApiV1Files = <ThrowOnError extends boolean = false>(options: Op
     }
 });
 
+/**
+ * Set the order of a track type's tracks
+ * Replaces the order of every track of a type in one step. Connected GM clients are sent a trackOrder message with the new order. Tracks are only ordered within their type; collections list their tracks in the type order.
+ *
+ */
+export const putApiV1FilesOrder = <ThrowOnError extends boolean = false>(options: Options<PutApiV1FilesOrderData, ThrowOnError>) => (options.client ?? client).put<PutApiV1FilesOrderResponses, PutApiV1FilesOrderErrors, ThrowOnError>({
+    security: [{
+            in: 'cookie',
+            name: 'auth_token',
+            type: 'apiKey'
+        }],
+    url: '/api/v1/files/order',
+    ...options,
+    headers: {
+        'Content-Type': 'application/json',
+        ...options.headers
+    }
+});
+
+/**
+ * Apply one action to several tracks
+ * Changes the type, tags or collections of a list of tracks, or deletes them, in one transaction. If any track can't be changed, none are and the results say which tracks failed.
+ *
+ */
+export const postApiV1FilesBulk = <ThrowOnError extends boolean = false>(options: Options<PostApiV1FilesBulkData, ThrowOnError>) => (options.client ?? client).post<PostApiV1FilesBulkResponses, PostApiV1FilesBulkErrors, ThrowOnError>({
+    security: [{
+            in: 'cookie',
+            name: 'auth_token',
+            type: 'apiKey'
+        }],
+    url: '/api/v1/files/bulk',
+    ...options,
+    headers: {
+        'Content-Type': 'application/json',
+        ...options.headers
+    }
+});
+
+/**
+ * List the most recently played tracks
+ */
+export const getApiV1FilesRecent = <ThrowOnError extends boolean = false>(options?: Options<GetApiV1FilesRecentData, ThrowOnError>) => (options?.client ?? client).get<GetApiV1FilesRecentResponses, GetApiV1FilesRecentErrors, ThrowOnError>({
+    security: [{
+            in: 'cookie',
+            name: 'auth_token',
+            type: 'apiKey'
+        }],
+    url: '/api/v1/files/recent',
+    ...options
+});
+
+/**
+ * List the most played tracks
+ */
+export const getApiV1FilesPopular = <ThrowOnError extends boolean = false>(options?: Options<GetApiV1FilesPopularData, ThrowOnError>) => (options?.client ?? client).get<GetApiV1FilesPopularResponses, GetApiV1FilesPopularErrors, ThrowOnError>({
+    security: [{
+            in: 'cookie',
+            name: 'auth_token',
+            type: 'apiKey'
+        }],
+    url: '/api/v1/files/popular',
+    ...options
+});
+
+/**
+ * List favourite tracks, one page at a time
+ * Takes the same search and pagination parameters as GET /api/v1/files.
+ */
+export const getApiV1FilesFavorites = <ThrowOnError extends boolean = false>(options?: Options<GetApiV1FilesFavoritesData, ThrowOnError>) => (options?.client ?? client).get<GetApiV1FilesFavoritesResponses, GetApiV1FilesFavoritesErrors, ThrowOnError>({
+    security: [{
+            in: 'cookie',
+            name: 'auth_token',
+            type: 'apiKey'
+        }],
+    url: '/api/v1/files/favorites',
+    ...options
+});
+
 /**
  * Delete an audio track
  */
@@ -118,15 +209,99 @@ export const putApiV1FilesByTrackId = <ThrowOnError extends boolean = false>(opt
 });
 
 /**
- * Get a new join token for players
+ * Replace a track's audio
+ * Converts the uploaded file and swaps it in as the track's audio, keeping the track's ID, tags, collections and other details. The audio it replaces is kept so it can be restored. Every connected client is sent a trackMedia message so it reloads the track.
+ *
+ */
+export const putApiV1FilesByTrackIdMedia = <ThrowOnError extends boolean = false>(options: Options<PutApiV1FilesByTrackIdMediaData, ThrowOnError>) => (options.client ?? client).put<PutApiV1FilesByTrackIdMediaResponses, PutApiV1FilesByTrackIdMediaErrors, ThrowOnError>({
+    ...formDataBodySerializer,
+    security: [{
+            in: 'cookie',
+            name: 'auth_token',
+            type: 'apiKey'
+        }],
+    url: '/api/v1/files/{trackID}/media',
+    ...options,
+    headers: {
+        'Content-Type': null,
+        ...options.headers
+    }
+});
+
+/**
+ * Restore a track's previous audio
+ * Swaps the track's audio back to the version it had before it was last replaced. Rolling back again restores the newer version. Every connected client is sent a trackMedia message so it reloads the track.
+ *
+ */
+export const postApiV1FilesByTrackIdMediaRollback = <ThrowOnError extends boolean = false>(options: Options<PostApiV1FilesByTrackIdMediaRollbackData, ThrowOnError>) => (options.client ?? client).post<PostApiV1FilesByTrackIdMediaRollbackResponses, PostApiV1FilesByTrackIdMediaRollbackErrors, ThrowOnError>({
+    security: [{
+            in: 'cookie',
+            name: 'auth_token',
+            type: 'apiKey'
+        }],
+    url: '/api/v1/files/{trackID}/media/rollback',
+    ...options
+});
+
+/**
+ * List join tokens
+ * Lists every join token, newest first, including revoked and expired ones. The tokens themselves are only shown when they're created or rotated.
+ *
+ */
+export const getApiV1JoinTokens = <ThrowOnError extends boolean = false>(options?: Options<GetApiV1JoinTokensData, ThrowOnError>) => (options?.client ?? client).get<GetApiV1JoinTokensResponses, GetApiV1JoinTokensErrors, ThrowOnError>({
+    security: [{
+            in: 'cookie',
+            name: 'auth_token',
+            type: 'apiKey'
+        }],
+    url: '/api/v1/joinTokens',
+    ...options
+});
+
+/**
+ * Create a join token for players
+ */
+export const postApiV1JoinTokens = <ThrowOnError extends boolean = false>(options: Options<PostApiV1JoinTokensData, ThrowOnError>) => (options.client ?? client).post<PostApiV1JoinTokensResponses, PostApiV1JoinTokensErrors, ThrowOnError>({
+    security: [{
+            in: 'cookie',
+            name: 'auth_token',
+            type: 'apiKey'
+        }],
+    url: '/api/v1/joinTokens',
+    ...options,
+    headers: {
+        'Content-Type': 'application/json',
+        ...options.headers
+    }
+});
+
+/**
+ * Revoke a join token
+ * Stops the token from working, and signs out and disconnects the players who joined with it. Revoking a revoked token changes nothing.
+ *
  */
-export const getApiV1JoinToken = <ThrowOnError extends boolean = false>(options?: Options<GetApiV1JoinTokenData, ThrowOnError>) => (options?.client ?? client).get<GetApiV1JoinTokenResponses, GetApiV1JoinTokenErrors, ThrowOnError>({
+export const postApiV1JoinTokensByJoinTokenIdRevoke = <ThrowOnError extends boolean = false>(options: Options<PostApiV1JoinTokensByJoinTokenIdRevokeData, ThrowOnError>) => (options.client ?? client).post<PostApiV1JoinTokensByJoinTokenIdRevokeResponses, PostApiV1JoinTokensByJoinTokenIdRevokeErrors, ThrowOnError>({
     security: [{
             in: 'cookie',
             name: 'auth_token',
             type: 'apiKey'
         }],
-    url: '/api/v1/joinToken',
+    url: '/api/v1/joinTokens/{joinTokenID}/revoke',
+    ...options
+});
+
+/**
+ * Replace a join token
+ * Issues a new token in place of the old one, which stops working, and resets the uses. Players who already joined stay connected.
+ *
+ */
+export const postApiV1JoinTokensByJoinTokenIdRotate = <ThrowOnError extends boolean = false>(options: Options<PostApiV1JoinTokensByJoinTokenIdRotateData, ThrowOnError>) => (options.client ?? client).post<PostApiV1JoinTokensByJoinTokenIdRotateResponses, PostApiV1JoinTokensByJoinTokenIdRotateErrors, ThrowOnError>({
+    security: [{
+            in: 'cookie',
+            name: 'auth_token',
+            type: 'apiKey'
+        }],
+    url: '/api/v1/joinTokens/{joinTokenID}/rotate',
     ...options
 });
 
@@ -143,6 +318,151 @@ export const getApiV1StreamByPath = <ThrowOnError extends boolean = false>(optio
     ...options
 });
 
+/**
+ * List all tags in use
+ */
+export const getApiV1Tags = <ThrowOnError extends boolean = false>(options?: Options<GetApiV1TagsData, ThrowOnError>) => (options?.client ?? client).get<GetApiV1TagsResponses, GetApiV1TagsErrors, ThrowOnError>({
+    security: [{
+            in: 'cookie',
+            name: 'auth_token',
+            type: 'apiKey'
+        }],
+    url: '/api/v1/tags',
+    ...options
+});
+
+/**
+ * List all collections as a tree
+ */
+export const getApiV1Collections = <ThrowOnError extends boolean = false>(options?: Options<GetApiV1CollectionsData, ThrowOnError>) => (options?.client ?? client).get<GetApiV1CollectionsResponses, GetApiV1CollectionsErrors, ThrowOnError>({
+    security: [{
+            in: 'cookie',
+            name: 'auth_token',
+            type: 'apiKey'
+        }],
+    url: '/api/v1/collections',
+    ...options
+});
+
+/**
+ * Create a collection
+ */
+export const postApiV1Collections = <ThrowOnError extends boolean = false>(options: Options<PostApiV1CollectionsData, ThrowOnError>) => (options.client ?? client).post<PostApiV1CollectionsResponses, PostApiV1CollectionsErrors, ThrowOnError>({
+    security: [{
+            in: 'cookie',
+            name: 'auth_token',
+            type: 'apiKey'
+        }],
+    url: '/api/v1/collections',
+    ...options,
+    headers: {
+        'Content-Type': 'application/json',
+        ...options.headers
+    }
+});
+
+/**
+ * Delete a collection and everything nested in it
+ * Tracks in the deleted collections are kept.
+ */
+export const deleteApiV1CollectionsByCollectionId = <ThrowOnError extends boolean = false>(options: Options<DeleteApiV1CollectionsByCollectionIdData, ThrowOnError>) => (options.client ?? client).delete<DeleteApiV1CollectionsByCollectionIdResponses, DeleteApiV1CollectionsByCollectionIdErrors, ThrowOnError>({
+    security: [{
+            in: 'cookie',
+            name: 'auth_token',
+            type: 'apiKey'
+        }],
+    url: '/api/v1/collections/{collectionID}',
+    ...options
+});
+
+/**
+ * Get a collection and everything nested in it
+ */
+export const getApiV1CollectionsByCollectionId = <ThrowOnError extends boolean = false>(options: Options<GetApiV1CollectionsByCollectionIdData, ThrowOnError>) => (options.client ?? client).get<GetApiV1CollectionsByCollectionIdResponses, GetApiV1CollectionsByCollectionIdErrors, ThrowOnError>({
+    security: [{
+            in: 'cookie',
+            name: 'auth_token',
+            type: 'apiKey'
+        }],
+    url: '/api/v1/collections/{collectionID}',
+    ...options
+});
+
+/**
+ * Rename or move a collection
+ */
+export const putApiV1CollectionsByCollectionId = <ThrowOnError extends boolean = false>(options: Options<PutApiV1CollectionsByCollectionIdData, ThrowOnError>) => (options.client ?? client).put<PutApiV1CollectionsByCollectionIdResponses, PutApiV1CollectionsByCollectionIdErrors, ThrowOnError>({
+    security: [{
+            in: 'cookie',
+            name: 'auth_token',
+            type: 'apiKey'
+        }],
+    url: '/api/v1/collections/{collectionID}',
+    ...options,
+    headers: {
+        'Content-Type': 'application/json',
+        ...options.headers
+    }
+});
+
+/**
+ * Generate credits for a collection or a session
+ * Lists a credit line for each track in a collection (including nested
+ * collections), or for each track played between from and to. Exactly one
+ * of collection and from must be given.
+ */
+export const getApiV1Credits = <ThrowOnError extends boolean = false>(options?: Options<GetApiV1CreditsData, ThrowOnError>) => (options?.client ?? client).get<GetApiV1CreditsResponses, GetApiV1CreditsErrors, ThrowOnError>({
+    security: [{
+            in: 'cookie',
+            name: 'auth_token',
+            type: 'apiKey'
+        }],
+    url: '/api/v1/credits',
+    ...options
+});
+
+/**
+ * List the audit log
+ * Entries are listed newest first.
+ */
+export const getApiV1Audit = <ThrowOnError extends boolean = false>(options?: Options<GetApiV1AuditData, ThrowOnError>) => (options?.client ?? client).get<GetApiV1AuditResponses, GetApiV1AuditErrors, ThrowOnError>({
+    security: [{
+            in: 'cookie',
+            name: 'auth_token',
+            type: 'apiKey'
+        }],
+    url: '/api/v1/audit',
+    ...options
+});
+
+/**
+ * List sessions
+ * The sessions played at this server's table, set by TABLE_ID, so an earlier session can be found again after the server restarts.
+ */
+export const getApiV1Sessions = <ThrowOnError extends boolean = false>(options?: Options<GetApiV1SessionsData, ThrowOnError>) => (options?.client ?? client).get<GetApiV1SessionsResponses, GetApiV1SessionsErrors, ThrowOnError>({
+    security: [{
+            in: 'cookie',
+            name: 'auth_token',
+            type: 'apiKey'
+        }],
+    url: '/api/v1/sessions',
+    ...options
+});
+
+/**
+ * List a session's events
+ * Every command the GM sent to the table in a session, in the order they were sent. Clock pings and WebRTC signaling aren't logged. A session lasts as long as the server runs; use "current" for the running one. If commands couldn't be logged, a sessionGap event takes their place.
+ */
+export const getApiV1SessionsBySessionIdEvents = <ThrowOnError extends boolean = false>(options: Options<GetApiV1SessionsBySessionIdEventsData, ThrowOnError>) => (options.client ?? client).get<GetApiV1SessionsBySessionIdEventsResponses, GetApiV1SessionsBySessionIdEventsErrors, ThrowOnError>({
+    security: [{
+            in: 'cookie',
+            name: 'auth_token',
+            type: 'apiKey'
+        }],
+    url: '/api/v1/sessions/{sessionID}/events',
+    ...options
+});
+
 /**
  * Get available track types
  */
@@ -156,6 +476,131 @@ export const getApiV1TrackTypes = <ThrowOnError extends boolean = false>(options
     ...options
 });
 
+/**
+ * Create a track type
+ * Creating, updating or deleting a track type sends every connected client a trackTypes message with the full list of track types.
+ *
+ */
+export const postApiV1TrackTypes = <ThrowOnError extends boolean = false>(options: Options<PostApiV1TrackTypesData, ThrowOnError>) => (options.client ?? client).post<PostApiV1TrackTypesResponses, PostApiV1TrackTypesErrors, ThrowOnError>({
+    security: [{
+            in: 'cookie',
+            name: 'auth_token',
+            type: 'apiKey'
+        }],
+    url: '/api/v1/trackTypes',
+    ...options,
+    headers: {
+        'Content-Type': 'application/json',
+        ...options.headers
+    }
+});
+
+/**
+ * Delete a track type
+ */
+export const deleteApiV1TrackTypesByTypeId = <ThrowOnError extends boolean = false>(options: Options<DeleteApiV1TrackTypesByTypeIdData, ThrowOnError>) => (options.client ?? client).delete<DeleteApiV1TrackTypesByTypeIdResponses, DeleteApiV1TrackTypesByTypeIdErrors, ThrowOnError>({
+    security: [{
+            in: 'cookie',
+            name: 'auth_token',
+            type: 'apiKey'
+        }],
+    url: '/api/v1/trackTypes/{typeID}',
+    ...options
+});
+
+/**
+ * Update a track type
+ */
+export const putApiV1TrackTypesByTypeId = <ThrowOnError extends boolean = false>(options: Options<PutApiV1TrackTypesByTypeIdData, ThrowOnError>) => (options.client ?? client).put<PutApiV1TrackTypesByTypeIdResponses, PutApiV1TrackTypesByTypeIdErrors, ThrowOnError>({
+    security: [{
+            in: 'cookie',
+            name: 'auth_token',
+            type: 'apiKey'
+        }],
+    url: '/api/v1/trackTypes/{typeID}',
+    ...options,
+    headers: {
+        'Content-Type': 'application/json',
+        ...options.headers
+    }
+});
+
+/**
+ * List GM accounts
+ * Only admins can manage accounts. The root account from the server's config is always an admin and isn't listed.
+ *
+ */
+export const getApiV1Users = <ThrowOnError extends boolean = false>(options?: Options<GetApiV1UsersData, ThrowOnError>) => (options?.client ?? client).get<GetApiV1UsersResponses, GetApiV1UsersErrors, ThrowOnError>({
+    security: [{
+            in: 'cookie',
+            name: 'auth_token',
+            type: 'apiKey'
+        }],
+    url: '/api/v1/users',
+    ...options
+});
+
+/**
+ * Create a GM account
+ */
+export const postApiV1Users = <ThrowOnError extends boolean = false>(options: Options<PostApiV1UsersData, ThrowOnError>) => (options.client ?? client).post<PostApiV1UsersResponses, PostApiV1UsersErrors, ThrowOnError>({
+    security: [{
+            in: 'cookie',
+            name: 'auth_token',
+            type: 'apiKey'
+        }],
+    url: '/api/v1/users',
+    ...options,
+    headers: {
+        'Content-Type': 'application/json',
+        ...options.headers
+    }
+});
+
+/**
+ * Delete a GM account
+ * Tokens already issued to the account stay valid until they expire.
+ */
+export const deleteApiV1UsersByUserId = <ThrowOnError extends boolean = false>(options: Options<DeleteApiV1UsersByUserIdData, ThrowOnError>) => (options.client ?? client).delete<DeleteApiV1UsersByUserIdResponses, DeleteApiV1UsersByUserIdErrors, ThrowOnError>({
+    security: [{
+            in: 'cookie',
+            name: 'auth_token',
+            type: 'apiKey'
+        }],
+    url: '/api/v1/users/{userID}',
+    ...options
+});
+
+/**
+ * Get a GM account
+ */
+export const getApiV1UsersByUserId = <ThrowOnError extends boolean = false>(options: Options<GetApiV1UsersByUserIdData, ThrowOnError>) => (options.client ?? client).get<GetApiV1UsersByUserIdResponses, GetApiV1UsersByUserIdErrors, ThrowOnError>({
+    security: [{
+            in: 'cookie',
+            name: 'auth_token',
+            type: 'apiKey'
+        }],
+    url: '/api/v1/users/{userID}',
+    ...options
+});
+
+/**
+ * Update a GM account
+ */
+export const putApiV1UsersByUserId = <ThrowOnError extends boolean = false>(options: Options<PutApiV1UsersByUserIdData, ThrowOnError>) => (options.client ?? client).put<PutApiV1UsersByUserIdResponses, PutApiV1UsersByUserIdErrors, ThrowOnError>({
+    security: [{
+            in: 'cookie',
+            name: 'auth_token',
+            type: 'apiKey'
+        }],
+    url: '/api/v1/users/{userID}',
+    ...options,
+    headers: {
+        'Content-Type': 'application/json',
+        ...options.headers
+    }
+});
+
 /**
  * WebSocket connection for real-time updates
  */
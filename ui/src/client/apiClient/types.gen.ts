@@ -16,15 +16,78 @@ export type LoginResponse = {
 
 export type JoinRequest = {
     token: string;
+    /**
+     * The player's display name, shown to the GM
+     */
+    name: string;
+    /**
+     * An optional avatar color for the player
+     */
+    color?: string;
+};
+
+/**
+ * The player token a join token was redeemed for. Send it as a bearer token, or in the token query parameter for the WebSocket.
+ *
+ */
+export type JoinResponse = {
+    token: string;
+    expiresAt: string;
+    /**
+     * Identifies the player for as long as the token lasts
+     */
+    playerID: string;
+};
+
+export type JoinToken = {
+    id: string;
+    label: string;
+    createdAt: string;
+    /**
+     * When the token stops working for new players. Absent if it never expires.
+     */
+    expiresAt?: string;
+    /**
+     * How many times the token can be redeemed, or 0 for no limit
+     */
+    maxUses: number;
+    /**
+     * How many times the token has been redeemed since it was created or last rotated
+     */
+    uses: number;
+    /**
+     * When the token was revoked. Absent if it hasn't been.
+     */
+    revokedAt?: string;
+};
+
+export type JoinTokenRequest = {
+    label: string;
+    /**
+     * When the token stops working for new players. Omit for no expiry.
+     */
+    expiresAt?: string;
+    /**
+     * How many times the token can be redeemed, or 0 for no limit
+     */
+    maxUses?: number;
 };
 
-export type JoinTokenResponse = {
+/**
+ * A join token along with the token players join with. Only its hash is kept, so it can't be retrieved again.
+ *
+ */
+export type IssuedJoinToken = JoinToken & {
     token: string;
 };
 
 export type AuthStatusResponse = {
     authenticated: boolean;
     role: 'gm' | 'player';
+    /**
+     * Whether the GM can manage user accounts
+     */
+    admin?: boolean;
 };
 
 export type Track = {
@@ -33,12 +96,272 @@ export type Track = {
     name: string;
     path: string;
     typeID: string;
+    /**
+     * Length of the track in seconds
+     */
+    duration?: number;
+    /**
+     * Descriptive tags read from the uploaded file (e.g. title, artist)
+     */
+    metadata?: {
+        [key: string]: string;
+    };
+    tags?: Array<string>;
+    /**
+     * Position of the track among the others of its type
+     */
+    position?: number;
+    /**
+     * IDs of the collections the track is in
+     */
+    collections?: Array<string>;
+    description?: string;
+    artist?: string;
+    /**
+     * Where the track was downloaded from
+     */
+    sourceURL?: string;
+    /**
+     * SPDX license identifier, e.g. CC-BY-4.0
+     */
+    license?: string;
+    /**
+     * Credit line to use as is, when the license asks for specific wording
+     */
+    attribution?: string;
+    favorite?: boolean;
+};
+
+export type TrackOrder = {
+    typeID: string;
+    /**
+     * Every track of the type, in order
+     */
+    trackIDs: Array<string>;
+};
+
+export type TrackList = {
+    tracks: Array<Track>;
+    /**
+     * Number of tracks matching the filter across all pages
+     */
+    total: number;
+    /**
+     * Cursor for the next page. Absent on the last page.
+     */
+    nextCursor?: string;
+};
+
+export type SessionEvent = {
+    sessionID: string;
+    /**
+     * The table the session was played at
+     */
+    tableID: string;
+    /**
+     * Counts up from 1 within the session
+     */
+    seq: number;
+    /**
+     * When the server received the command, to the millisecond
+     */
+    createdAt: string;
+    /**
+     * WebSocket method of the command, e.g. syncAll or syncTrack, or sessionGap where commands are missing from the log. A sessionGap's payload is {"missed": <number of commands>}.
+     */
+    method: string;
+    /**
+     * ID of the GM's WebSocket client
+     */
+    senderID: string;
+    /**
+     * The command's payload as the GM sent it
+     */
+    payload: unknown;
+};
+
+export type Session = {
+    id: string;
+    tableID: string;
+    /**
+     * When the session's first event was logged
+     */
+    startedAt: string;
+    lastEventAt: string;
+    eventCount: number;
+};
+
+export type SessionList = {
+    /**
+     * The session being played now. It's only listed once the GM has sent a command.
+     *
+     */
+    current: string;
+    /**
+     * Sessions with any events, the most recently started first
+     */
+    sessions: Array<Session>;
+};
+
+export type SessionLog = {
+    sessionID: string;
+    events: Array<SessionEvent>;
+    /**
+     * Cursor for later events. Absent on the last page.
+     */
+    nextCursor?: string;
+};
+
+export type TrackStats = {
+    trackID: string;
+    playCount: number;
+    /**
+     * Seconds the track has played for, not counting plays still going
+     */
+    totalPlayed: number;
+    lastPlayedAt: string;
+};
+
+export type PlayedTrack = {
+    track: Track;
+    stats: TrackStats;
 };
 
 export type UpdateTrackRequest = {
     id: string;
     name?: string;
     typeID?: string;
+    /**
+     * Replaces the track's tags when set. Tags are lowercased.
+     */
+    tags?: Array<string>;
+    /**
+     * Replaces the collections the track is in when set
+     */
+    collections?: Array<string> | null;
+    description?: string | null;
+    artist?: string | null;
+    /**
+     * An http or https URL, or empty to clear it
+     */
+    sourceURL?: string | null;
+    /**
+     * An SPDX license identifier, or empty to clear it
+     */
+    license?: string | null;
+    attribution?: string | null;
+    favorite?: boolean | null;
+};
+
+export type BulkTrackRequest = {
+    trackIDs: Array<string>;
+    action: 'setType' | 'addTags' | 'removeTags' | 'addToCollection' | 'removeFromCollection' | 'delete';
+    /**
+     * The type to give the tracks; required for setType
+     */
+    typeID?: string;
+    /**
+     * Tags to add or remove; required for addTags and removeTags
+     */
+    tags?: Array<string>;
+    /**
+     * Required for addToCollection and removeFromCollection
+     */
+    collectionID?: string;
+};
+
+export type BulkTrackResult = {
+    trackID: string;
+    /**
+     * Why the action can't be applied to the track
+     */
+    error?: string;
+    track?: Track;
+};
+
+export type BulkTrackResponse = {
+    /**
+     * False if any track failed, in which case none were changed
+     */
+    applied: boolean;
+    /**
+     * A result for each requested track, in order
+     */
+    results: Array<BulkTrackResult>;
+};
+
+export type Collection = {
+    id: string;
+    parentID: string | null;
+    name: string;
+    createdAt: string;
+    /**
+     * Number of tracks directly in the collection, not counting nested collections
+     */
+    trackCount: number;
+    children: Array<Collection>;
+};
+
+export type CollectionRequest = {
+    name: string;
+    /**
+     * Collection to nest this one in. Null or absent puts it at the top level.
+     */
+    parentID?: string | null;
+};
+
+export type AuditEntry = {
+    id: number;
+    createdAt: string;
+    /**
+     * Subject of the token used (a user ID, or the root username), or the username given for a failed login
+     */
+    actor: string;
+    action: 'track.create' | 'track.update' | 'track.delete' | 'track.replaceMedia' | 'track.rollbackMedia' | 'trackType.create' | 'trackType.update' | 'trackType.delete' | 'auth.login' | 'auth.loginFailed' | 'auth.logout' | 'auth.joinTokenRetrieved' | 'auth.join' | 'user.create' | 'user.update' | 'user.delete' | 'joinToken.create' | 'joinToken.rotate' | 'joinToken.revoke';
+    /**
+     * ID of the track or track type acted on
+     */
+    targetID?: string;
+    /**
+     * Fields the action changed, as they were. Absent for created targets.
+     */
+    before?: {
+        [key: string]: unknown;
+    };
+    /**
+     * Fields the action changed, as they are now. Absent for deleted targets.
+     */
+    after?: {
+        [key: string]: unknown;
+    };
+    ip?: string;
+};
+
+export type AuditLog = {
+    entries: Array<AuditEntry>;
+    /**
+     * Cursor for older entries. Absent on the last page.
+     */
+    nextCursor?: string;
+};
+
+export type User = {
+    id: string;
+    username: string;
+    /**
+     * Whether the GM can manage user accounts
+     */
+    admin: boolean;
+    createdAt: string;
+};
+
+/**
+ * Omitted fields are left unchanged on update. Creating a user needs a username and password.
+ */
+export type UserRequest = {
+    username?: string;
+    password?: string;
+    admin?: boolean;
 };
 
 export type TrackType = {
@@ -47,6 +370,58 @@ export type TrackType = {
     color: string;
     isRepeating: boolean;
     allowSimultaneousPlay: boolean;
+    /**
+     * Volume new tracks of the type start at
+     */
+    defaultVolume: number;
+    fadeInMs: number;
+    fadeOutMs: number;
+    /**
+     * Rewind tracks whenever they're played rather than resuming
+     */
+    alwaysStartFromBeginning: boolean;
+    /**
+     * How many tracks of the type can play at once. Starting another stops the one playing longest. Zero is no limit; always 1 when allowSimultaneousPlay is off.
+     *
+     */
+    maxConcurrent: number;
+    /**
+     * Starting a track of this type stops tracks of every other type
+     */
+    autoStopOthers: boolean;
+};
+
+/**
+ * The behaviour settings are optional. Omitted ones take their defaults when creating a type and are left unchanged when updating one.
+ *
+ */
+export type TrackTypeRequest = {
+    /**
+     * Must be unique, ignoring case
+     */
+    name: string;
+    color: string;
+    isRepeating?: boolean;
+    allowSimultaneousPlay?: boolean;
+    /**
+     * Volume new tracks of the type start at
+     */
+    defaultVolume?: number;
+    fadeInMs?: number;
+    fadeOutMs?: number;
+    /**
+     * Rewind tracks whenever they're played rather than resuming
+     */
+    alwaysStartFromBeginning?: boolean;
+    /**
+     * How many tracks of the type can play at once. Starting another stops the one playing longest. Zero is no limit; always 1 when allowSimultaneousPlay is off. Turning allowSimultaneousPlay on without setting this removes the limit.
+     *
+     */
+    maxConcurrent?: number;
+    /**
+     * Starting a track of this type stops tracks of every other type
+     */
+    autoStopOthers?: boolean;
 };
 
 export type PostApiV1LoginData = {
@@ -74,6 +449,33 @@ export type PostApiV1LoginResponses = {
 
 export type PostApiV1LoginResponse = PostApiV1LoginResponses[keyof PostApiV1LoginResponses];
 
+export type PostApiV1JoinData = {
+    body: JoinRequest;
+    path?: never;
+    query?: never;
+    url: '/api/v1/join';
+};
+
+export type PostApiV1JoinErrors = {
+    /**
+     * Invalid request body, name or color
+     */
+    400: unknown;
+    /**
+     * The join token is invalid, revoked, expired or used up
+     */
+    401: unknown;
+};
+
+export type PostApiV1JoinResponses = {
+    /**
+     * Joined
+     */
+    200: JoinResponse;
+};
+
+export type PostApiV1JoinResponse = PostApiV1JoinResponses[keyof PostApiV1JoinResponses];
+
 export type GetApiV1AuthStatusData = {
     body?: never;
     path?: never;
@@ -107,11 +509,54 @@ export type PostApiV1AuthLogoutResponses = {
 export type GetApiV1FilesData = {
     body?: never;
     path?: never;
-    query?: never;
+    query?: {
+        /**
+         * Free-text search over track names, tags and file metadata. Words match as prefixes.
+         */
+        q?: string;
+        /**
+         * Only return tracks with this tag
+         */
+        tag?: string;
+        /**
+         * Only return tracks of this track type
+         */
+        type?: string;
+        /**
+         * Only return tracks in this collection or any collection nested in it
+         */
+        collection?: string;
+        /**
+         * Only return tracks uploaded at or after this time. Plain dates are taken as midnight UTC.
+         */
+        createdAfter?: string;
+        /**
+         * Only return tracks uploaded before this time. Plain dates are taken as midnight UTC.
+         */
+        createdBefore?: string;
+        /**
+         * Field to order tracks by. Ties are broken by track ID. Sorting by position groups tracks by type, in their custom order.
+         *
+         */
+        sort?: 'created' | 'name' | 'duration' | 'position';
+        order?: 'asc' | 'desc';
+        /**
+         * Maximum number of tracks to return
+         */
+        limit?: number;
+        /**
+         * The nextCursor from the previous page. Must be used with the same sort and order.
+         */
+        cursor?: string;
+    };
     url: '/api/v1/files';
 };
 
 export type GetApiV1FilesErrors = {
+    /**
+     * Invalid filter or pagination parameters
+     */
+    400: unknown;
     /**
      * Not authorized
      */
@@ -120,9 +565,9 @@ export type GetApiV1FilesErrors = {
 
 export type GetApiV1FilesResponses = {
     /**
-     * List of tracks
+     * A page of tracks
      */
-    200: Array<Track>;
+    200: TrackList;
 };
 
 export type GetApiV1FilesResponse = GetApiV1FilesResponses[keyof GetApiV1FilesResponses];
@@ -152,121 +597,767 @@ export type PostApiV1FilesResponses = {
     200: unknown;
 };
 
-export type DeleteApiV1FilesByTrackIdData = {
-    body?: never;
-    path: {
-        trackID: string;
-    };
+export type PutApiV1FilesOrderData = {
+    body: TrackOrder;
+    path?: never;
     query?: never;
-    url: '/api/v1/files/{trackID}';
+    url: '/api/v1/files/order';
 };
 
-export type DeleteApiV1FilesByTrackIdErrors = {
+export type PutApiV1FilesOrderErrors = {
+    /**
+     * Invalid request body
+     */
+    400: unknown;
     /**
      * Not authorized
      */
     403: unknown;
     /**
-     * Track not found
+     * The list doesn't contain every track of the type exactly once
      */
-    404: unknown;
+    409: unknown;
 };
 
-export type DeleteApiV1FilesByTrackIdResponses = {
+export type PutApiV1FilesOrderResponses = {
     /**
-     * Track deleted successfully
+     * Tracks reordered
      */
-    200: unknown;
+    200: TrackOrder;
 };
 
-export type PutApiV1FilesByTrackIdData = {
-    body: UpdateTrackRequest;
-    path: {
-        trackID: string;
-    };
+export type PutApiV1FilesOrderResponse = PutApiV1FilesOrderResponses[keyof PutApiV1FilesOrderResponses];
+
+export type PostApiV1FilesBulkData = {
+    body: BulkTrackRequest;
+    path?: never;
     query?: never;
-    url: '/api/v1/files/{trackID}';
+    url: '/api/v1/files/bulk';
 };
 
-export type PutApiV1FilesByTrackIdErrors = {
+export type PostApiV1FilesBulkErrors = {
     /**
-     * Invalid request body
+     * Invalid request, or some tracks failed and nothing was changed. In the latter case the body is a BulkTrackResponse.
+     *
      */
-    400: unknown;
+    400: BulkTrackResponse;
     /**
      * Not authorized
      */
     403: unknown;
-    /**
-     * Track not found
-     */
-    404: unknown;
-    /**
-     * Internal server error
-     */
-    500: unknown;
 };
 
-export type PutApiV1FilesByTrackIdResponses = {
+export type PostApiV1FilesBulkResponses = {
     /**
-     * Track updated successfully
+     * The action was applied to every track
      */
-    200: Track;
+    200: BulkTrackResponse;
 };
 
-export type PutApiV1FilesByTrackIdResponse = PutApiV1FilesByTrackIdResponses[keyof PutApiV1FilesByTrackIdResponses];
+export type PostApiV1FilesBulkResponse = PostApiV1FilesBulkResponses[keyof PostApiV1FilesBulkResponses];
 
-export type GetApiV1JoinTokenData = {
+export type GetApiV1FilesRecentData = {
     body?: never;
     path?: never;
-    query?: never;
-    url: '/api/v1/joinToken';
+    query?: {
+        limit?: number;
+    };
+    url: '/api/v1/files/recent';
 };
 
-export type GetApiV1JoinTokenErrors = {
+export type GetApiV1FilesRecentErrors = {
+    /**
+     * Invalid parameters
+     */
+    400: unknown;
     /**
      * Not authorized
      */
     403: unknown;
 };
 
-export type GetApiV1JoinTokenResponses = {
+export type GetApiV1FilesRecentResponses = {
     /**
-     * Join token
+     * Tracks with their play statistics
      */
-    200: JoinTokenResponse;
+    200: Array<PlayedTrack>;
 };
 
-export type GetApiV1JoinTokenResponse = GetApiV1JoinTokenResponses[keyof GetApiV1JoinTokenResponses];
+export type GetApiV1FilesRecentResponse = GetApiV1FilesRecentResponses[keyof GetApiV1FilesRecentResponses];
 
-export type GetApiV1StreamByPathData = {
+export type GetApiV1FilesPopularData = {
     body?: never;
-    path: {
-        path: string;
+    path?: never;
+    query?: {
+        /**
+         * Only count plays at or after this time. Plain dates are taken as midnight UTC.
+         */
+        since?: string;
+        limit?: number;
     };
-    query?: never;
-    url: '/api/v1/stream/{path}';
+    url: '/api/v1/files/popular';
 };
 
-export type GetApiV1StreamByPathErrors = {
+export type GetApiV1FilesPopularErrors = {
+    /**
+     * Invalid parameters
+     */
+    400: unknown;
     /**
      * Not authorized
      */
     403: unknown;
-    /**
+};
+
+export type GetApiV1FilesPopularResponses = {
+    /**
+     * Tracks with their play statistics
+     */
+    200: Array<PlayedTrack>;
+};
+
+export type GetApiV1FilesPopularResponse = GetApiV1FilesPopularResponses[keyof GetApiV1FilesPopularResponses];
+
+export type GetApiV1FilesFavoritesData = {
+    body?: never;
+    path?: never;
+    query?: {
+        q?: string;
+        tag?: string;
+        type?: string;
+        collection?: string;
+        sort?: 'created' | 'name' | 'duration' | 'position';
+        order?: 'asc' | 'desc';
+        limit?: number;
+        cursor?: string;
+    };
+    url: '/api/v1/files/favorites';
+};
+
+export type GetApiV1FilesFavoritesErrors = {
+    /**
+     * Invalid pagination parameters
+     */
+    400: unknown;
+    /**
+     * Not authorized
+     */
+    403: unknown;
+};
+
+export type GetApiV1FilesFavoritesResponses = {
+    /**
+     * A page of favourite tracks
+     */
+    200: TrackList;
+};
+
+export type GetApiV1FilesFavoritesResponse = GetApiV1FilesFavoritesResponses[keyof GetApiV1FilesFavoritesResponses];
+
+export type DeleteApiV1FilesByTrackIdData = {
+    body?: never;
+    path: {
+        trackID: string;
+    };
+    query?: never;
+    url: '/api/v1/files/{trackID}';
+};
+
+export type DeleteApiV1FilesByTrackIdErrors = {
+    /**
+     * Not authorized
+     */
+    403: unknown;
+    /**
+     * Track not found
+     */
+    404: unknown;
+    /**
+     * Internal server error
+     */
+    500: unknown;
+};
+
+export type DeleteApiV1FilesByTrackIdResponses = {
+    /**
+     * Track deleted successfully
+     */
+    200: unknown;
+};
+
+export type PutApiV1FilesByTrackIdData = {
+    body: UpdateTrackRequest;
+    path: {
+        trackID: string;
+    };
+    query?: never;
+    url: '/api/v1/files/{trackID}';
+};
+
+export type PutApiV1FilesByTrackIdErrors = {
+    /**
+     * Invalid request body
+     */
+    400: unknown;
+    /**
+     * Not authorized
+     */
+    403: unknown;
+    /**
+     * Track not found
+     */
+    404: unknown;
+    /**
+     * The update conflicts with existing data, e.g. a track type that doesn't exist
+     */
+    409: unknown;
+    /**
+     * Internal server error
+     */
+    500: unknown;
+};
+
+export type PutApiV1FilesByTrackIdResponses = {
+    /**
+     * Track updated successfully
+     */
+    200: Track;
+};
+
+export type PutApiV1FilesByTrackIdResponse = PutApiV1FilesByTrackIdResponses[keyof PutApiV1FilesByTrackIdResponses];
+
+export type PutApiV1FilesByTrackIdMediaData = {
+    body: {
+        files?: Blob | File;
+    };
+    path: {
+        trackID: string;
+    };
+    query?: never;
+    url: '/api/v1/files/{trackID}/media';
+};
+
+export type PutApiV1FilesByTrackIdMediaErrors = {
+    /**
+     * Invalid form data
+     */
+    400: unknown;
+    /**
+     * Not authorized
+     */
+    403: unknown;
+    /**
+     * Track not found
+     */
+    404: unknown;
+    /**
+     * The track's audio is already being replaced or rolled back
+     */
+    409: unknown;
+    /**
+     * The file couldn't be converted
+     */
+    500: unknown;
+};
+
+export type PutApiV1FilesByTrackIdMediaResponses = {
+    /**
+     * Audio replaced
+     */
+    200: Track;
+};
+
+export type PutApiV1FilesByTrackIdMediaResponse = PutApiV1FilesByTrackIdMediaResponses[keyof PutApiV1FilesByTrackIdMediaResponses];
+
+export type PostApiV1FilesByTrackIdMediaRollbackData = {
+    body?: never;
+    path: {
+        trackID: string;
+    };
+    query?: never;
+    url: '/api/v1/files/{trackID}/media/rollback';
+};
+
+export type PostApiV1FilesByTrackIdMediaRollbackErrors = {
+    /**
+     * Not authorized
+     */
+    403: unknown;
+    /**
+     * Track not found, or it has no previous audio
+     */
+    404: unknown;
+    /**
+     * The track's audio is already being replaced or rolled back
+     */
+    409: unknown;
+};
+
+export type PostApiV1FilesByTrackIdMediaRollbackResponses = {
+    /**
+     * Previous audio restored
+     */
+    200: Track;
+};
+
+export type PostApiV1FilesByTrackIdMediaRollbackResponse = PostApiV1FilesByTrackIdMediaRollbackResponses[keyof PostApiV1FilesByTrackIdMediaRollbackResponses];
+
+export type GetApiV1JoinTokensData = {
+    body?: never;
+    path?: never;
+    query?: never;
+    url: '/api/v1/joinTokens';
+};
+
+export type GetApiV1JoinTokensErrors = {
+    /**
+     * Not authorized
+     */
+    403: unknown;
+};
+
+export type GetApiV1JoinTokensResponses = {
+    /**
+     * Join tokens
+     */
+    200: Array<JoinToken>;
+};
+
+export type GetApiV1JoinTokensResponse = GetApiV1JoinTokensResponses[keyof GetApiV1JoinTokensResponses];
+
+export type PostApiV1JoinTokensData = {
+    body: JoinTokenRequest;
+    path?: never;
+    query?: never;
+    url: '/api/v1/joinTokens';
+};
+
+export type PostApiV1JoinTokensErrors = {
+    /**
+     * Invalid label, expiry or max uses
+     */
+    400: unknown;
+    /**
+     * Not authorized
+     */
+    403: unknown;
+};
+
+export type PostApiV1JoinTokensResponses = {
+    /**
+     * Join token created
+     */
+    200: IssuedJoinToken;
+};
+
+export type PostApiV1JoinTokensResponse = PostApiV1JoinTokensResponses[keyof PostApiV1JoinTokensResponses];
+
+export type PostApiV1JoinTokensByJoinTokenIdRotateData = {
+    body?: never;
+    path: {
+        joinTokenID: string;
+    };
+    query?: never;
+    url: '/api/v1/joinTokens/{joinTokenID}/rotate';
+};
+
+export type PostApiV1JoinTokensByJoinTokenIdRotateErrors = {
+    /**
+     * Not authorized
+     */
+    403: unknown;
+    /**
+     * Join token not found or revoked
+     */
+    404: unknown;
+};
+
+export type PostApiV1JoinTokensByJoinTokenIdRotateResponses = {
+    /**
+     * Join token rotated
+     */
+    200: IssuedJoinToken;
+};
+
+export type PostApiV1JoinTokensByJoinTokenIdRotateResponse = PostApiV1JoinTokensByJoinTokenIdRotateResponses[keyof PostApiV1JoinTokensByJoinTokenIdRotateResponses];
+
+export type PostApiV1JoinTokensByJoinTokenIdRevokeData = {
+    body?: never;
+    path: {
+        joinTokenID: string;
+    };
+    query?: never;
+    url: '/api/v1/joinTokens/{joinTokenID}/revoke';
+};
+
+export type PostApiV1JoinTokensByJoinTokenIdRevokeErrors = {
+    /**
+     * Not authorized
+     */
+    403: unknown;
+    /**
+     * Join token not found
+     */
+    404: unknown;
+};
+
+export type PostApiV1JoinTokensByJoinTokenIdRevokeResponses = {
+    /**
+     * Join token revoked
+     */
+    200: JoinToken;
+};
+
+export type PostApiV1JoinTokensByJoinTokenIdRevokeResponse = PostApiV1JoinTokensByJoinTokenIdRevokeResponses[keyof PostApiV1JoinTokensByJoinTokenIdRevokeResponses];
+
+export type GetApiV1StreamByPathData = {
+    body?: never;
+    path: {
+        path: string;
+    };
+    query?: never;
+    url: '/api/v1/stream/{path}';
+};
+
+export type GetApiV1StreamByPathErrors = {
+    /**
+     * Not authorized
+     */
+    403: unknown;
+    /**
      * File not found
      */
     404: unknown;
 };
 
-export type GetApiV1StreamByPathResponses = {
+export type GetApiV1StreamByPathResponses = {
+    /**
+     * Audio stream
+     */
+    200: string;
+};
+
+export type GetApiV1StreamByPathResponse = GetApiV1StreamByPathResponses[keyof GetApiV1StreamByPathResponses];
+
+export type GetApiV1TagsData = {
+    body?: never;
+    path?: never;
+    query?: never;
+    url: '/api/v1/tags';
+};
+
+export type GetApiV1TagsErrors = {
+    /**
+     * Not authorized
+     */
+    403: unknown;
+};
+
+export type GetApiV1TagsResponses = {
+    /**
+     * Tag names in alphabetical order
+     */
+    200: Array<string>;
+};
+
+export type GetApiV1TagsResponse = GetApiV1TagsResponses[keyof GetApiV1TagsResponses];
+
+export type GetApiV1CollectionsData = {
+    body?: never;
+    path?: never;
+    query?: never;
+    url: '/api/v1/collections';
+};
+
+export type GetApiV1CollectionsErrors = {
+    /**
+     * Not authorized
+     */
+    403: unknown;
+};
+
+export type GetApiV1CollectionsResponses = {
+    /**
+     * Top-level collections, with nested collections under children
+     */
+    200: Array<Collection>;
+};
+
+export type GetApiV1CollectionsResponse = GetApiV1CollectionsResponses[keyof GetApiV1CollectionsResponses];
+
+export type PostApiV1CollectionsData = {
+    body: CollectionRequest;
+    path?: never;
+    query?: never;
+    url: '/api/v1/collections';
+};
+
+export type PostApiV1CollectionsErrors = {
+    /**
+     * Invalid name or parent collection
+     */
+    400: unknown;
+    /**
+     * Not authorized
+     */
+    403: unknown;
+    /**
+     * The parent already has a collection with that name
+     */
+    409: unknown;
+};
+
+export type PostApiV1CollectionsResponses = {
+    /**
+     * Collection created
+     */
+    200: Collection;
+};
+
+export type PostApiV1CollectionsResponse = PostApiV1CollectionsResponses[keyof PostApiV1CollectionsResponses];
+
+export type DeleteApiV1CollectionsByCollectionIdData = {
+    body?: never;
+    path: {
+        collectionID: string;
+    };
+    query?: never;
+    url: '/api/v1/collections/{collectionID}';
+};
+
+export type DeleteApiV1CollectionsByCollectionIdErrors = {
+    /**
+     * Not authorized
+     */
+    403: unknown;
+    /**
+     * Collection not found
+     */
+    404: unknown;
+};
+
+export type DeleteApiV1CollectionsByCollectionIdResponses = {
+    /**
+     * Collection deleted
+     */
+    200: unknown;
+};
+
+export type GetApiV1CollectionsByCollectionIdData = {
+    body?: never;
+    path: {
+        collectionID: string;
+    };
+    query?: never;
+    url: '/api/v1/collections/{collectionID}';
+};
+
+export type GetApiV1CollectionsByCollectionIdErrors = {
+    /**
+     * Not authorized
+     */
+    403: unknown;
+    /**
+     * Collection not found
+     */
+    404: unknown;
+};
+
+export type GetApiV1CollectionsByCollectionIdResponses = {
+    /**
+     * The collection
+     */
+    200: Collection;
+};
+
+export type GetApiV1CollectionsByCollectionIdResponse = GetApiV1CollectionsByCollectionIdResponses[keyof GetApiV1CollectionsByCollectionIdResponses];
+
+export type PutApiV1CollectionsByCollectionIdData = {
+    body: CollectionRequest;
+    path: {
+        collectionID: string;
+    };
+    query?: never;
+    url: '/api/v1/collections/{collectionID}';
+};
+
+export type PutApiV1CollectionsByCollectionIdErrors = {
+    /**
+     * Invalid name or parent collection, or the collection would be nested inside itself
+     */
+    400: unknown;
+    /**
+     * Not authorized
+     */
+    403: unknown;
+    /**
+     * Collection not found
+     */
+    404: unknown;
+    /**
+     * The parent already has a collection with that name
+     */
+    409: unknown;
+};
+
+export type PutApiV1CollectionsByCollectionIdResponses = {
     /**
-     * Audio stream
+     * Collection updated
+     */
+    200: Collection;
+};
+
+export type PutApiV1CollectionsByCollectionIdResponse = PutApiV1CollectionsByCollectionIdResponses[keyof PutApiV1CollectionsByCollectionIdResponses];
+
+export type GetApiV1CreditsData = {
+    body?: never;
+    path?: never;
+    query?: {
+        collection?: string;
+        /**
+         * Start of the session, as an RFC 3339 timestamp or a date
+         */
+        from?: string;
+        /**
+         * End of the session, as an RFC 3339 timestamp or a date. Defaults to now.
+         */
+        to?: string;
+        format?: 'markdown' | 'text';
+    };
+    url: '/api/v1/credits';
+};
+
+export type GetApiV1CreditsErrors = {
+    /**
+     * Invalid parameters
+     */
+    400: unknown;
+    /**
+     * Not authorized
+     */
+    403: unknown;
+    /**
+     * Collection not found
+     */
+    404: unknown;
+};
+
+export type GetApiV1CreditsResponses = {
+    /**
+     * One credit line per track
      */
     200: string;
 };
 
-export type GetApiV1StreamByPathResponse = GetApiV1StreamByPathResponses[keyof GetApiV1StreamByPathResponses];
+export type GetApiV1CreditsResponse = GetApiV1CreditsResponses[keyof GetApiV1CreditsResponses];
+
+export type GetApiV1AuditData = {
+    body?: never;
+    path?: never;
+    query?: {
+        actor?: string;
+        action?: string;
+        /**
+         * ID of the track or track type acted on
+         */
+        target?: string;
+        /**
+         * Earliest entry time, as an RFC 3339 timestamp or a date
+         */
+        from?: string;
+        /**
+         * Entries before this time, as an RFC 3339 timestamp or a date
+         */
+        to?: string;
+        limit?: number;
+        /**
+         * nextCursor from the previous page
+         */
+        cursor?: string;
+    };
+    url: '/api/v1/audit';
+};
+
+export type GetApiV1AuditErrors = {
+    /**
+     * Invalid filter or pagination parameters
+     */
+    400: unknown;
+    /**
+     * Not authorized
+     */
+    403: unknown;
+};
+
+export type GetApiV1AuditResponses = {
+    /**
+     * A page of audit entries
+     */
+    200: AuditLog;
+};
+
+export type GetApiV1AuditResponse = GetApiV1AuditResponses[keyof GetApiV1AuditResponses];
+
+export type GetApiV1SessionsData = {
+    body?: never;
+    path?: never;
+    query?: never;
+    url: '/api/v1/sessions';
+};
+
+export type GetApiV1SessionsErrors = {
+    /**
+     * Not authorized
+     */
+    403: unknown;
+};
+
+export type GetApiV1SessionsResponses = {
+    /**
+     * The table's sessions
+     */
+    200: SessionList;
+};
+
+export type GetApiV1SessionsResponse = GetApiV1SessionsResponses[keyof GetApiV1SessionsResponses];
+
+export type GetApiV1SessionsBySessionIdEventsData = {
+    body?: never;
+    path: {
+        /**
+         * ID of the session, or "current"
+         */
+        sessionID: string;
+    };
+    query?: {
+        limit?: number;
+        /**
+         * nextCursor from the previous page
+         */
+        cursor?: string;
+    };
+    url: '/api/v1/sessions/{sessionID}/events';
+};
+
+export type GetApiV1SessionsBySessionIdEventsErrors = {
+    /**
+     * Invalid session ID or pagination parameters
+     */
+    400: unknown;
+    /**
+     * Not authorized
+     */
+    403: unknown;
+};
+
+export type GetApiV1SessionsBySessionIdEventsResponses = {
+    /**
+     * A page of session events
+     */
+    200: SessionLog;
+};
+
+export type GetApiV1SessionsBySessionIdEventsResponse = GetApiV1SessionsBySessionIdEventsResponses[keyof GetApiV1SessionsBySessionIdEventsResponses];
 
 export type GetApiV1TrackTypesData = {
     body?: never;
@@ -291,6 +1382,263 @@ export type GetApiV1TrackTypesResponses = {
 
 export type GetApiV1TrackTypesResponse = GetApiV1TrackTypesResponses[keyof GetApiV1TrackTypesResponses];
 
+export type PostApiV1TrackTypesData = {
+    body: TrackTypeRequest;
+    path?: never;
+    query?: never;
+    url: '/api/v1/trackTypes';
+};
+
+export type PostApiV1TrackTypesErrors = {
+    /**
+     * Invalid name or color
+     */
+    400: unknown;
+    /**
+     * Not authorized
+     */
+    403: unknown;
+    /**
+     * A track type with that name already exists
+     */
+    409: unknown;
+};
+
+export type PostApiV1TrackTypesResponses = {
+    /**
+     * Track type created
+     */
+    200: TrackType;
+};
+
+export type PostApiV1TrackTypesResponse = PostApiV1TrackTypesResponses[keyof PostApiV1TrackTypesResponses];
+
+export type DeleteApiV1TrackTypesByTypeIdData = {
+    body?: never;
+    path: {
+        typeID: string;
+    };
+    query: {
+        /**
+         * Track type to move the deleted type's tracks to. Required if any tracks use the type.
+         */
+        reassignTo?: string;
+    };
+    url: '/api/v1/trackTypes/{typeID}';
+};
+
+export type DeleteApiV1TrackTypesByTypeIdErrors = {
+    /**
+     * Invalid reassignTo track type
+     */
+    400: unknown;
+    /**
+     * Not authorized
+     */
+    403: unknown;
+    /**
+     * Track type not found
+     */
+    404: unknown;
+    /**
+     * Tracks still use the type and no reassignTo was given
+     */
+    409: unknown;
+};
+
+export type DeleteApiV1TrackTypesByTypeIdResponses = {
+    /**
+     * Track type deleted
+     */
+    200: unknown;
+};
+
+export type PutApiV1TrackTypesByTypeIdData = {
+    body: TrackTypeRequest;
+    path: {
+        typeID: string;
+    };
+    query?: never;
+    url: '/api/v1/trackTypes/{typeID}';
+};
+
+export type PutApiV1TrackTypesByTypeIdErrors = {
+    /**
+     * Invalid name or color
+     */
+    400: unknown;
+    /**
+     * Not authorized
+     */
+    403: unknown;
+    /**
+     * Track type not found
+     */
+    404: unknown;
+    /**
+     * A track type with that name already exists
+     */
+    409: unknown;
+};
+
+export type PutApiV1TrackTypesByTypeIdResponses = {
+    /**
+     * Track type updated
+     */
+    200: TrackType;
+};
+
+export type PutApiV1TrackTypesByTypeIdResponse = PutApiV1TrackTypesByTypeIdResponses[keyof PutApiV1TrackTypesByTypeIdResponses];
+
+export type GetApiV1UsersData = {
+    body?: never;
+    path?: never;
+    query?: never;
+    url: '/api/v1/users';
+};
+
+export type GetApiV1UsersErrors = {
+    /**
+     * Not an admin
+     */
+    403: unknown;
+};
+
+export type GetApiV1UsersResponses = {
+    /**
+     * Every account, ordered by username
+     */
+    200: Array<User>;
+};
+
+export type GetApiV1UsersResponse = GetApiV1UsersResponses[keyof GetApiV1UsersResponses];
+
+export type PostApiV1UsersData = {
+    body: UserRequest;
+    path?: never;
+    query?: never;
+    url: '/api/v1/users';
+};
+
+export type PostApiV1UsersErrors = {
+    /**
+     * Missing or invalid username or password, or the root account's username
+     */
+    400: unknown;
+    /**
+     * Not an admin
+     */
+    403: unknown;
+    /**
+     * The username is taken
+     */
+    409: unknown;
+};
+
+export type PostApiV1UsersResponses = {
+    /**
+     * Account created
+     */
+    200: User;
+};
+
+export type PostApiV1UsersResponse = PostApiV1UsersResponses[keyof PostApiV1UsersResponses];
+
+export type DeleteApiV1UsersByUserIdData = {
+    body?: never;
+    path: {
+        userID: string;
+    };
+    query?: never;
+    url: '/api/v1/users/{userID}';
+};
+
+export type DeleteApiV1UsersByUserIdErrors = {
+    /**
+     * Not an admin
+     */
+    403: unknown;
+    /**
+     * User not found
+     */
+    404: unknown;
+};
+
+export type DeleteApiV1UsersByUserIdResponses = {
+    /**
+     * Account deleted
+     */
+    204: void;
+};
+
+export type DeleteApiV1UsersByUserIdResponse = DeleteApiV1UsersByUserIdResponses[keyof DeleteApiV1UsersByUserIdResponses];
+
+export type GetApiV1UsersByUserIdData = {
+    body?: never;
+    path: {
+        userID: string;
+    };
+    query?: never;
+    url: '/api/v1/users/{userID}';
+};
+
+export type GetApiV1UsersByUserIdErrors = {
+    /**
+     * Not an admin
+     */
+    403: unknown;
+    /**
+     * User not found
+     */
+    404: unknown;
+};
+
+export type GetApiV1UsersByUserIdResponses = {
+    /**
+     * The account
+     */
+    200: User;
+};
+
+export type GetApiV1UsersByUserIdResponse = GetApiV1UsersByUserIdResponses[keyof GetApiV1UsersByUserIdResponses];
+
+export type PutApiV1UsersByUserIdData = {
+    body: UserRequest;
+    path: {
+        userID: string;
+    };
+    query?: never;
+    url: '/api/v1/users/{userID}';
+};
+
+export type PutApiV1UsersByUserIdErrors = {
+    /**
+     * Invalid username or password, or the root account's username
+     */
+    400: unknown;
+    /**
+     * Not an admin
+     */
+    403: unknown;
+    /**
+     * User not found
+     */
+    404: unknown;
+    /**
+     * The username is taken
+     */
+    409: unknown;
+};
+
+export type PutApiV1UsersByUserIdResponses = {
+    /**
+     * Account updated
+     */
+    200: User;
+};
+
+export type PutApiV1UsersByUserIdResponse = PutApiV1UsersByUserIdResponses[keyof PutApiV1UsersByUserIdResponses];
+
 export type GetApiV1WsData = {
     body?: never;
     path?: never;
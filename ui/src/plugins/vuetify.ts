@@ -1,5 +1,5 @@
 import IconLute from '@/components/icons/IconLute.vue';
-import { mdiAccountMusic, mdiBug, mdiCircle, mdiContentCopy, mdiContentSave, mdiDelete, mdiDotsVertical, mdiHeadphones, mdiHome, mdiLoading, mdiLogin, mdiMusic, mdiPause, mdiPlay, mdiRefresh, mdiRepeat, mdiRepeatOff, mdiUpload, mdiVolumeHigh, mdiVolumeLow, mdiVolumeMedium, mdiVolumeOff } from '@mdi/js';
+import { mdiAccountMusic, mdiBug, mdiCertificate, mdiCircle, mdiContentCopy, mdiContentSave, mdiDelete, mdiDotsVertical, mdiDownload, mdiFileEditOutline, mdiFolderPlus, mdiHeadphones, mdiHistory, mdiHome, mdiLoading, mdiLogin, mdiMusic, mdiPause, mdiPlay, mdiRefresh, mdiRepeat, mdiRepeatOff, mdiMagnify, mdiStar, mdiStarOutline, mdiTune, mdiUpload, mdiVolumeHigh, mdiVolumeLow, mdiVolumeMedium, mdiVolumeOff } from '@mdi/js';
 import { h, type Component } from 'vue';
 import { createVuetify, type IconProps, type IconSet } from 'vuetify';
 import { aliases, mdi } from 'vuetify/iconsets/mdi-svg';
@@ -47,7 +47,16 @@ export default createVuetify({
       login: mdiLogin,
       accountMusic: mdiAccountMusic,
       headphones: mdiHeadphones,
-      save: mdiContentSave
+      save: mdiContentSave,
+      search: mdiMagnify,
+      folderPlus: mdiFolderPlus,
+      tune: mdiTune,
+      credits: mdiCertificate,
+      star: mdiStar,
+      starOutline: mdiStarOutline,
+      editMultiple: mdiFileEditOutline,
+      history: mdiHistory,
+      prefetch: mdiDownload
     },
     sets: {
       mdi,
@@ -16,12 +16,12 @@ interface FadeStatus {
   inProgress: boolean
 }
 
-function newAudioTrack(fileID: string, name: string, typeID: string = ""): AudioTrack {
+function newAudioTrack(fileID: string, name: string, typeID: string = "", volume: number = 100): AudioTrack {
   return {
     fileID,
     name,
     isPlaying: false,
-    volume: 100,
+    volume,
     isRepeating: false,
     currentTime: 0,
     duration: 0,
@@ -36,15 +36,17 @@ export const useAudioStore = defineStore('audio', {
     masterVolume: 100,
     fadeStates: {} as Record<string, FadeStatus>,
     typeVolumes: {} as Record<string, number>,
+    // Bumped when a track's audio is replaced, so its player reloads
+    mediaVersions: {} as Record<string, number>,
   }),
   persist: true,
   getters: {
     availableTracks: (state) => Object.values(state.tracks)
   },
   actions: {
-    initTrack(fileID: string, name: string, typeID: string = "") {
+    initTrack(fileID: string, name: string, typeID: string = "", volume: number = 100) {
       if (!this.tracks[fileID]) {
-        this.tracks[fileID] = newAudioTrack(fileID, name, typeID)
+        this.tracks[fileID] = newAudioTrack(fileID, name, typeID, volume)
       }
     },
     updateTrackState(fileID: string, updates: Partial<AudioTrack>) {
@@ -52,6 +54,9 @@ export const useAudioStore = defineStore('audio', {
 
       this.tracks[fileID] = patchObject(track, updates)
     },
+    reloadMedia(fileID: string) {
+      this.mediaVersions[fileID] = (this.mediaVersions[fileID] ?? 0) + 1
+    },
     removeTrack(fildID: string) {
       delete this.tracks[fildID]
     },
@@ -1,4 +1,4 @@
-import { getApiV1AuthStatus, type LoginRequest, postApiV1AuthLogout, postApiV1Login } from '@/client/apiClient'
+import { getApiV1AuthStatus, type JoinResponse, type LoginRequest, postApiV1AuthLogout, postApiV1Join, postApiV1Login } from '@/client/apiClient'
 import type { Role } from '@/types/auth'
 import { defineStore } from 'pinia'
 import { ref } from 'vue'
@@ -7,6 +7,8 @@ export const useAuthStore = defineStore('auth', () => {
   const authenticated = ref(false)
   const loading = ref(false)
   const role = ref<Role | null>(null)
+  const playerToken = ref<string | null>(null)
+  const joinError = ref<string | null>(null)
 
   async function checkAuthStatus(token?: string) {
     try {
@@ -29,6 +31,46 @@ export const useAuthStore = defineStore('auth', () => {
     }
   }
 
+  // resumeJoin restores the player token a join token was redeemed for in this
+  // session, so reloading the page doesn't use up the join token. It returns
+  // false if the player still has to join.
+  async function resumeJoin(joinToken: string): Promise<boolean> {
+    const storageKey = `playerToken:${joinToken}`
+    const saved = sessionStorage.getItem(storageKey)
+    if (saved) {
+      const { token, expiresAt } = JSON.parse(saved) as JoinResponse
+      if (new Date(expiresAt) > new Date()) {
+        await checkAuthStatus(token)
+        if (authenticated.value) {
+          playerToken.value = token
+          return true
+        }
+      }
+      sessionStorage.removeItem(storageKey)
+    }
+    return false
+  }
+
+  // join redeems a join token for a player token under the player's chosen
+  // name and color, which are remembered for the next invite link.
+  async function join(joinToken: string, name: string, color?: string) {
+    joinError.value = null
+    try {
+      const { data } = await postApiV1Join<true>({ body: { token: joinToken, name, color } })
+      sessionStorage.setItem(`playerToken:${joinToken}`, JSON.stringify(data))
+      localStorage.setItem('playerName', name)
+      if (color) {
+        localStorage.setItem('playerColor', color)
+      }
+      playerToken.value = data.token
+      await checkAuthStatus(data.token)
+    } catch (error) {
+      console.error('Failed to join:', error)
+      joinError.value = 'This invite link is no longer valid. Ask your GM for a new one.'
+      playerToken.value = null
+    }
+  }
+
   async function login(username: string, password: string) {
     loading.value = true
     try {
@@ -63,7 +105,11 @@ export const useAuthStore = defineStore('auth', () => {
     authenticated,
     loading,
     role,
+    playerToken,
+    joinError,
     checkAuthStatus,
+    resumeJoin,
+    join,
     logout,
     login
   }
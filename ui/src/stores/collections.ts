@@ -0,0 +1,45 @@
+import { getApiV1Collections, postApiV1Collections, type Collection, type CollectionRequest } from '@/client/apiClient'
+import { defineStore } from 'pinia'
+
+export interface CollectionOption {
+  id: string
+  // Full path to the collection, e.g. "Waterdeep > Yawning Portal"
+  title: string
+}
+
+function flatten(collections: Collection[], prefix = ''): CollectionOption[] {
+  return collections.flatMap(collection => {
+    const title = prefix + collection.name
+    return [{ id: collection.id, title }, ...flatten(collection.children, `${title} > `)]
+  })
+}
+
+export const useCollectionStore = defineStore('collections', {
+  state: () => ({
+    collections: [] as Collection[]
+  }),
+  getters: {
+    options: (state) => flatten(state.collections)
+  },
+  actions: {
+    async fetchCollections() {
+      try {
+        const { data } = await getApiV1Collections<true>()
+        this.collections = data
+      } catch (error) {
+        console.error('Error fetching collections:', error)
+        throw error
+      }
+    },
+    async createCollection(request: CollectionRequest) {
+      try {
+        const { data } = await postApiV1Collections<true>({ body: request })
+        await this.fetchCollections()
+        return data
+      } catch (error) {
+        console.error('Error creating collection:', error)
+        throw error
+      }
+    }
+  }
+})
@@ -1,25 +1,134 @@
-import { deleteApiV1FilesByTrackId, getApiV1Files, postApiV1Files, putApiV1FilesByTrackId, type Track, type UpdateTrackRequest } from '@/client/apiClient'
+import { deleteApiV1FilesByTrackId, getApiV1Files, getApiV1FilesFavorites, getApiV1FilesPopular, getApiV1FilesRecent, getApiV1Tags, postApiV1Files, postApiV1FilesBulk, postApiV1FilesByTrackIdMediaRollback, putApiV1FilesByTrackId, putApiV1FilesByTrackIdMedia, putApiV1FilesOrder, type BulkTrackRequest, type GetApiV1FilesData, type Track, type TrackOrder, type UpdateTrackRequest } from '@/client/apiClient'
 import { defineStore } from 'pinia'
 
+type TrackQuery = NonNullable<GetApiV1FilesData['query']>
+
+// TrackView picks which tracks the board shows before any search filters.
+// Recent and popular tracks are shown in their ranked order.
+export type TrackView = 'all' | 'favorites' | 'recent' | 'popular'
+
+// fetchAllTracks follows the listing's cursors until every matching track has
+// been loaded.
+async function fetchAllTracks(query: TrackQuery = {}, favoritesOnly = false): Promise<Track[]> {
+  const tracks: Track[] = []
+  const list = favoritesOnly ? getApiV1FilesFavorites : getApiV1Files
+  let cursor: string | undefined
+
+  do {
+    const { data } = await list<true>({ query: { ...query, limit: 500, cursor } })
+    tracks.push(...data.tracks)
+    cursor = data.nextCursor
+  } while (cursor)
+
+  return tracks
+}
+
 export const useFileStore = defineStore('files', {
   state: () => ({
-    tracks: [] as Track[]
+    tracks: [] as Track[],
+    tags: [] as string[],
+    // IDs of the tracks matching the current search, or null when not searching
+    searchResults: null as string[] | null,
+    // Whether searchResults are ranked, rather than following the board order
+    searchRanked: false,
   }),
-  persist: true,
+  persist: {
+    pick: ['tracks'],
+  },
   getters: {
     getTrackById: (state) => {
       return (id: string) => state.tracks.find(track => track.id === id)
-    }
+    },
+    visibleTracks: (state) => {
+      if (state.searchResults === null) {
+        return state.tracks
+      }
+      if (state.searchRanked) {
+        return state.searchResults
+          .map(id => state.tracks.find(track => track.id === id))
+          .filter(track => track !== undefined)
+      }
+      const matches = new Set(state.searchResults)
+      return state.tracks.filter(track => matches.has(track.id))
+    },
   },
   actions: {
     async fetchFiles() {
       try {
-        const { data } = await getApiV1Files<true>()
-        this.tracks = data
+        this.tracks = await fetchAllTracks({ sort: 'position' })
       } catch (error) {
         console.error('Error fetching files:', error)
       }
     },
+    async fetchTags() {
+      try {
+        const { data } = await getApiV1Tags<true>()
+        this.tags = data
+      } catch (error) {
+        console.error('Error fetching tags:', error)
+      }
+    },
+    // search narrows the visible tracks without dropping the rest of the
+    // library, since tracks that are playing still need to be looked up.
+    async search(filter: Pick<TrackQuery, 'q' | 'tag' | 'type' | 'collection'>, view: TrackView = 'all') {
+      const filtered = !!(filter.q || filter.tag || filter.type || filter.collection)
+      if (view === 'all' && !filtered) {
+        this.searchResults = null
+        this.searchRanked = false
+        return
+      }
+
+      try {
+        if (view === 'recent' || view === 'popular') {
+          const listPlayed = view === 'recent' ? getApiV1FilesRecent : getApiV1FilesPopular
+          const { data } = await listPlayed<true>({ query: { limit: 50 } })
+          let ids = data.map(played => played.track.id)
+          if (filtered) {
+            const matches = new Set((await fetchAllTracks(filter)).map(track => track.id))
+            ids = ids.filter(id => matches.has(id))
+          }
+          this.searchResults = ids
+          this.searchRanked = true
+        } else {
+          const tracks = await fetchAllTracks(filter, view === 'favorites')
+          this.searchResults = tracks.map(track => track.id)
+          this.searchRanked = false
+        }
+      } catch (error) {
+        console.error('Error searching files:', error)
+      }
+    },
+    // reorderTracks moves a track type's tracks into the given order. The
+    // change shows immediately and is rolled back if the server rejects it.
+    async reorderTracks(order: TrackOrder) {
+      const previous = [...this.tracks]
+      this.applyOrder(order)
+
+      try {
+        await putApiV1FilesOrder<true>({ body: order })
+      } catch (error) {
+        console.error('Error reordering tracks:', error)
+        this.tracks = previous
+        throw error
+      }
+    },
+    // applyOrder updates track positions from a new order, whether made
+    // locally or by another GM.
+    applyOrder(order: TrackOrder) {
+      const positions = new Map(order.trackIDs.map((id, position) => [id, position]))
+      this.tracks = this.tracks.map(track => {
+        const position = positions.get(track.id)
+        return track.typeID === order.typeID && position !== undefined ? { ...track, position } : track
+      })
+
+      // Keep the grouping by type and only shuffle this type's tracks
+      const slots = this.tracks.flatMap((track, index) => track.typeID === order.typeID ? [index] : [])
+      const reordered = slots.map(index => this.tracks[index]!)
+        .sort((a, b) => (a.position ?? 0) - (b.position ?? 0))
+      const tracks = [...this.tracks]
+      slots.forEach((slot, i) => { tracks[slot] = reordered[i]! })
+      this.tracks = tracks
+    },
     async deleteFile(trackId: string) {
       try {
         await deleteApiV1FilesByTrackId<true>({ path: { trackID: trackId } })
@@ -29,6 +138,27 @@ export const useFileStore = defineStore('files', {
         throw error
       }
     },
+    // bulkUpdate applies one action to several tracks. If any of them can't be
+    // changed, none are and the failures are returned instead.
+    async bulkUpdate(request: BulkTrackRequest) {
+      const { data, error } = await postApiV1FilesBulk({ body: request })
+      if (error) {
+        // Rejected tracks come back as results; anything else is plain text
+        if (typeof error === 'object' && 'results' in error) {
+          return error.results.filter(result => result.error)
+        }
+        throw new Error(String(error))
+      }
+      if (!data?.applied) {
+        throw new Error('Bulk update was not applied')
+      }
+
+      await this.fetchFiles()
+      if (request.action === 'addTags' || request.action === 'removeTags') {
+        await this.fetchTags()
+      }
+      return []
+    },
     async uploadFile(formData: FormData) {
       try {
         const files = formData.get('files') as Blob | null
@@ -52,20 +182,36 @@ export const useFileStore = defineStore('files', {
         throw new Error('Failed to upload file')
       }
     },
-    async updateTrack(trackId: string, update: { name?: string; typeID?: string }) {
+    // replaceMedia swaps in new audio for a track, keeping everything else
+    // about it. The audio it replaces can be restored with rollbackMedia.
+    async replaceMedia(trackId: string, file: File) {
+      const { data } = await putApiV1FilesByTrackIdMedia<true>({
+        path: { trackID: trackId },
+        body: { files: file },
+      })
+      this.setTrack(data)
+      return data
+    },
+    async rollbackMedia(trackId: string) {
+      const { data } = await postApiV1FilesByTrackIdMediaRollback<true>({ path: { trackID: trackId } })
+      this.setTrack(data)
+      return data
+    },
+    setTrack(track: Track) {
+      const index = this.tracks.findIndex(t => t.id === track.id)
+      if (index !== -1) {
+        this.tracks[index] = track
+      }
+    },
+    async updateTrack(trackId: string, update: Omit<UpdateTrackRequest, 'id'>) {
       try {
+        // Fields left undefined are dropped from the request, which leaves
+        // them unchanged
         const trackRequest: UpdateTrackRequest = {
+          ...update,
           id: trackId
         }
 
-        if (update.name !== undefined) {
-          trackRequest.name = update.name
-        }
-
-        if (update.typeID !== undefined) {
-          trackRequest.typeID = update.typeID
-        }
-
         const { data } = await putApiV1FilesByTrackId<true>({
           path: { trackID: trackId },
           body: trackRequest
@@ -77,6 +223,10 @@ export const useFileStore = defineStore('files', {
           this.tracks[index] = data
         }
 
+        if (update.tags !== undefined) {
+          await this.fetchTags()
+        }
+
         return data
       } catch (error) {
         console.error('Error updating track:', error)
@@ -1,18 +1,30 @@
-import { getApiV1JoinToken } from '@/client/apiClient'
+import { getApiV1JoinTokens, postApiV1JoinTokens } from '@/client/apiClient'
 import { defineStore } from 'pinia'
 import { ref } from 'vue'
 
+const inviteStorageKey = 'inviteToken'
+
 export const useJoinStore = defineStore('join', () => {
   const token = ref<string | null>(null)
   const loading = ref(false)
   const error = ref<string | null>(null)
 
+  // Join tokens can only be read when they're created, so the GM's invite
+  // token is kept in local storage to share the same link again. A new one is
+  // created once it's revoked, expired or used up.
   async function fetchToken() {
     loading.value = true
     error.value = null
 
     try {
-      const { data } = await getApiV1JoinToken<true>()
+      const saved = localStorage.getItem(inviteStorageKey)
+      if (saved && await isUsable(saved)) {
+        token.value = saved
+        return
+      }
+
+      const { data } = await postApiV1JoinTokens<true>({ body: { label: 'Invite link' } })
+      localStorage.setItem(inviteStorageKey, data.token)
       token.value = data.token
     } catch (err) {
       console.error('Failed to fetch join token:', err)
@@ -23,6 +35,16 @@ export const useJoinStore = defineStore('join', () => {
     }
   }
 
+  async function isUsable(saved: string) {
+    const id = saved.split('.')[0]
+    const { data } = await getApiV1JoinTokens<true>()
+    const joinToken = data.find(t => t.id === id)
+
+    return !!joinToken && !joinToken.revokedAt &&
+      (!joinToken.expiresAt || new Date(joinToken.expiresAt) > new Date()) &&
+      (joinToken.maxUses === 0 || joinToken.uses < joinToken.maxUses)
+  }
+
   function clearToken() {
     token.value = null
     error.value = null
@@ -0,0 +1,189 @@
+import { defineStore } from 'pinia'
+import { ref } from 'vue'
+import { useWebSocketStore, type WebSocketMessage } from './websocket'
+
+const iceGatheringTimeoutMs = 5000
+
+interface SessionPayload {
+  sdp: string
+}
+
+interface ErrorPayload {
+  error: string
+}
+
+// waitForIceGathering resolves once all local candidates are in the SDP, since
+// the server expects a single offer/answer round trip over the websocket.
+function waitForIceGathering(pc: RTCPeerConnection): Promise<void> {
+  if (pc.iceGatheringState === 'complete') {
+    return Promise.resolve()
+  }
+
+  return new Promise(resolve => {
+    const timeout = setTimeout(resolve, iceGatheringTimeoutMs)
+    pc.addEventListener('icegatheringstatechange', () => {
+      if (pc.iceGatheringState === 'complete') {
+        clearTimeout(timeout)
+        resolve()
+      }
+    })
+  })
+}
+
+async function createOffer(pc: RTCPeerConnection): Promise<string> {
+  const offer = await pc.createOffer()
+  await pc.setLocalDescription(offer)
+  await waitForIceGathering(pc)
+  return pc.localDescription?.sdp ?? ''
+}
+
+export const useRtcStore = defineStore('rtc', () => {
+  const wsStore = useWebSocketStore()
+
+  // For the GM, whether we're broadcasting; for players, whether we're
+  // listening to the GM's broadcast instead of streaming tracks ourselves.
+  const isLive = ref(false)
+  const error = ref<string | null>(null)
+
+  let pc: RTCPeerConnection | null = null
+
+  // The GM's track players are routed through one audio context, so we can
+  // tap the combined output for the broadcast.
+  let audioContext: AudioContext | null = null
+  let mixDestination: MediaStreamAudioDestinationNode | null = null
+  const elements = new Set<HTMLMediaElement>()
+  const sources = new WeakMap<HTMLMediaElement, MediaElementAudioSourceNode>()
+
+  let listenerAudio: HTMLAudioElement | null = null
+
+  function registerElement(el: HTMLMediaElement) {
+    elements.add(el)
+    if (mixDestination) {
+      routeElement(el)
+    }
+  }
+
+  function unregisterElement(el: HTMLMediaElement) {
+    elements.delete(el)
+  }
+
+  function routeElement(el: HTMLMediaElement) {
+    if (!audioContext || !mixDestination) return
+
+    let source = sources.get(el)
+    if (!source) {
+      // Once captured, an element only plays through the audio context, so
+      // keep it connected to the speakers as well.
+      source = audioContext.createMediaElementSource(el)
+      source.connect(audioContext.destination)
+      sources.set(el, source)
+    }
+    source.connect(mixDestination)
+  }
+
+  function handleMessage(message: WebSocketMessage) {
+    switch (message.method) {
+      case 'rtcAnswer':
+        pc?.setRemoteDescription({ type: 'answer', sdp: (message.payload as SessionPayload).sdp })
+          .catch(err => console.error('Failed to apply WebRTC answer:', err))
+        break
+      case 'rtcError':
+        error.value = (message.payload as ErrorPayload).error
+        console.error('Live audio failed:', error.value)
+        closeConnection()
+        break
+      case 'rtcPublished':
+        subscribe()
+        break
+      case 'rtcUnpublished':
+        closeConnection()
+        // Pick the tracks back up where the GM is now
+        wsStore.sendMessage('syncRequest', {})
+        break
+    }
+  }
+
+  async function startBroadcast() {
+    error.value = null
+    audioContext ??= new AudioContext()
+    mixDestination ??= audioContext.createMediaStreamDestination()
+    elements.forEach(routeElement)
+
+    closeConnection()
+    pc = new RTCPeerConnection()
+    mixDestination.stream.getAudioTracks().forEach(track => {
+      pc?.addTrack(track, mixDestination!.stream)
+    })
+
+    wsStore.addMessageHandler(handleMessage)
+    const sdp = await createOffer(pc)
+    wsStore.sendMessage('rtcPublish', { sdp })
+    isLive.value = true
+  }
+
+  function stopBroadcast() {
+    wsStore.sendMessage('rtcUnpublish', {})
+    wsStore.removeMessageHandler(handleMessage)
+    closeConnection()
+
+    if (mixDestination) {
+      elements.forEach(el => sources.get(el)?.disconnect(mixDestination!))
+    }
+  }
+
+  // listen waits for the GM to go live and plays the broadcast when they do.
+  function listen() {
+    wsStore.addMessageHandler(handleMessage)
+  }
+
+  function stopListening() {
+    wsStore.removeMessageHandler(handleMessage)
+    closeConnection()
+  }
+
+  async function subscribe() {
+    error.value = null
+    closeConnection()
+
+    pc = new RTCPeerConnection()
+    pc.addTransceiver('audio', { direction: 'recvonly' })
+    pc.ontrack = (event) => {
+      listenerAudio ??= new Audio()
+      listenerAudio.srcObject = event.streams[0] ?? new MediaStream([event.track])
+      listenerAudio.play().catch(err => console.error('Failed to play live audio:', err))
+    }
+
+    const sdp = await createOffer(pc)
+    wsStore.sendMessage('rtcSubscribe', { sdp })
+    isLive.value = true
+  }
+
+  function setListenerVolume(volume: number) {
+    if (listenerAudio) {
+      listenerAudio.volume = Math.min(Math.max(volume, 0), 1)
+    }
+  }
+
+  function closeConnection() {
+    pc?.close()
+    pc = null
+    isLive.value = false
+
+    if (listenerAudio) {
+      listenerAudio.pause()
+      listenerAudio.srcObject = null
+    }
+  }
+
+  return {
+    isLive,
+    error,
+    registerElement,
+    unregisterElement,
+    startBroadcast,
+    stopBroadcast,
+    listen,
+    stopListening,
+    setListenerVolume,
+  }
+})
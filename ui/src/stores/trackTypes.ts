@@ -1,4 +1,4 @@
-import { getApiV1TrackTypes, type TrackType } from '@/client/apiClient'
+import { deleteApiV1TrackTypesByTypeId, getApiV1TrackTypes, postApiV1TrackTypes, putApiV1TrackTypesByTypeId, type TrackType, type TrackTypeRequest } from '@/client/apiClient'
 import { defineStore } from 'pinia'
 
 export const useTrackTypeStore = defineStore('trackTypes', {
@@ -9,6 +9,10 @@ export const useTrackTypeStore = defineStore('trackTypes', {
   getters: {
     getTypeById: (state) => (id: string) => {
       return state.trackTypes.find(type => type.id === id)
+    },
+    // Audio state refers to track types by name
+    getTypeByName: (state) => (name: string) => {
+      return state.trackTypes.find(type => type.name === name)
     }
   },
   actions: {
@@ -26,6 +30,30 @@ export const useTrackTypeStore = defineStore('trackTypes', {
         console.error('Error fetching track types:', error)
         throw error
       }
+    },
+    async createTrackType(request: TrackTypeRequest) {
+      const { data } = await postApiV1TrackTypes<true>({ body: request })
+      this.trackTypes.push(data)
+      return data
+    },
+    async updateTrackType(id: string, request: TrackTypeRequest) {
+      const { data } = await putApiV1TrackTypesByTypeId<true>({
+        path: { typeID: id },
+        body: request
+      })
+      const index = this.trackTypes.findIndex(type => type.id === id)
+      if (index !== -1) {
+        this.trackTypes[index] = data
+      }
+      return data
+    },
+    // deleteTrackType removes a type, moving any tracks using it to reassignTo
+    async deleteTrackType(id: string, reassignTo?: string) {
+      await deleteApiV1TrackTypesByTypeId<true>({
+        path: { typeID: id },
+        query: reassignTo ? { reassignTo } : undefined
+      })
+      this.trackTypes = this.trackTypes.filter(type => type.id !== id)
     }
   }
 })
@@ -2,13 +2,25 @@ import { defineStore } from 'pinia'
 import { ref } from 'vue'
 
 const reconnectIntervalMs = 3000
+const clockSyncSamples = 5
 
 export interface WebSocketMessage<T = unknown> {
   method: string
   senderId?: string
+  // Set when a player sent the message
+  senderPlayerId?: string
+  senderName?: string
+  senderColor?: string
+  serverTime?: number
   payload: T
 }
 
+interface ClockSyncPayload {
+  clientSentAt: number
+  serverReceivedAt?: number
+  serverSentAt?: number
+}
+
 interface StoredMessage<T = unknown> extends WebSocketMessage<T> {
   timestamp: number
   direction: 'sent' | 'received'
@@ -34,6 +46,9 @@ export const useWebSocketStore = defineStore('websocket', () => {
   const isConnected = ref(false)
   const messageHandlers = ref<MessageHandler[]>([])
   const messageHistory = ref<StoredMessage[]>([])
+  // Offset (ms) to add to the local clock to get the server's clock
+  const clockOffset = ref(0)
+  let bestRoundTrip = Infinity
   let socket: WebSocket | null = null
 
   async function connect(token?: string) {
@@ -54,6 +69,7 @@ export const useWebSocketStore = defineStore('websocket', () => {
         isConnected.value = true
         resolve(s)
         console.log('WebSocket connected')
+        syncClock(s)
       }
 
       s.onmessage = receiveMessage
@@ -76,9 +92,42 @@ export const useWebSocketStore = defineStore('websocket', () => {
     })
   }
 
+  function syncClock(s: WebSocket) {
+    bestRoundTrip = Infinity
+    for (let i = 0; i < clockSyncSamples; i++) {
+      setTimeout(() => {
+        if (s.readyState !== WebSocket.OPEN) return
+        const payload: ClockSyncPayload = { clientSentAt: Date.now() }
+        s.send(JSON.stringify({ method: 'ping', payload }))
+      }, i * 200)
+    }
+  }
+
+  function handleClockSync(payload: ClockSyncPayload) {
+    const clientReceivedAt = Date.now()
+    if (payload?.clientSentAt === undefined ||
+      payload.serverReceivedAt === undefined ||
+      payload.serverSentAt === undefined) return
+
+    // Keep the sample with the shortest round trip, since it has the least
+    // room for asymmetric network delay.
+    const roundTrip = (clientReceivedAt - payload.clientSentAt) - (payload.serverSentAt - payload.serverReceivedAt)
+    if (roundTrip >= bestRoundTrip) return
+
+    bestRoundTrip = roundTrip
+    clockOffset.value = ((payload.serverReceivedAt - payload.clientSentAt) + (payload.serverSentAt - clientReceivedAt)) / 2
+  }
+
+  function serverNow(): number {
+    return Date.now() + clockOffset.value
+  }
+
   function receiveMessage<T>(event: MessageEvent) {
     try {
       const message = JSON.parse(event.data) as WebSocketMessage<T>
+      if (message.method === 'pong') {
+        handleClockSync(message.payload as ClockSyncPayload)
+      }
       console.log('Received WebSocket message:', message)
       const storedMessage: StoredMessage = {
         ...message,
@@ -138,6 +187,8 @@ export const useWebSocketStore = defineStore('websocket', () => {
     addMessageHandler,
     removeMessageHandler,
     messageHistory,
-    clearMessageHistory
+    clearMessageHistory,
+    clockOffset,
+    serverNow,
   }
 })